@@ -73,17 +73,40 @@ var (
 				"$aconfig",
 			},
 		}, "gendir", "mode")
+
+	// For python_aconfig_library: Generate Python module
+	pythonRule = pctx.AndroidStaticRule("python_aconfig_library",
+		blueprint.RuleParams{
+			Command: `rm -f ${out} ${out}.flags` +
+				` && ${aconfig} dump-cache --dedup` +
+				`    --cache ${in}` +
+				`    ${filter}` +
+				`    --format='{package} {name} {state:bool} {permission}'` +
+				`    --out ${out}.flags` +
+				` && ${aconfig_python_codegen}` +
+				`    -mode ${mode}` +
+				`    -package ${package}` +
+				`    -o ${out}` +
+				`    ${out}.flags` +
+				` && rm -f ${out}.flags`,
+			CommandDeps: []string{
+				"$aconfig",
+				"$aconfig_python_codegen",
+			},
+		}, "mode", "package", "filter")
 )
 
 func init() {
 	RegisterBuildComponents(android.InitRegistrationContext)
 	pctx.HostBinToolVariable("aconfig", "aconfig")
 	pctx.HostBinToolVariable("soong_zip", "soong_zip")
+	pctx.HostBinToolVariable("aconfig_python_codegen", "aconfig_python_codegen")
 }
 
 func RegisterBuildComponents(ctx android.RegistrationContext) {
 	ctx.RegisterModuleType("aconfig_declarations_group", AconfigDeclarationsGroupFactory)
 	ctx.RegisterModuleType("cc_aconfig_library", CcAconfigLibraryFactory)
 	ctx.RegisterModuleType("java_aconfig_library", JavaDeclarationsLibraryFactory)
+	ctx.RegisterModuleType("python_aconfig_library", PythonAconfigLibraryFactory)
 	ctx.RegisterModuleType("rust_aconfig_library", RustAconfigLibraryFactory)
 }
@@ -0,0 +1,114 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"strings"
+
+	"android/soong/android"
+	"android/soong/python"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+)
+
+type pythonDeclarationsTagType struct {
+	blueprint.BaseDependencyTag
+}
+
+var pythonDeclarationsTag = pythonDeclarationsTagType{}
+
+type PythonAconfigLibraryProperties struct {
+	// name of the aconfig_declarations module to generate a library for
+	Aconfig_declarations string
+
+	// default mode is "production", the other accepted modes are:
+	// "test": to generate test mode version of the library, which allows overriding flag values
+	// "exported": to generate exported mode version of the library
+	// "force-read-only": to generate force-read-only mode version of the library
+	// an error will be thrown if the mode is not supported
+	Mode *string
+}
+
+type PythonAconfigLibraryCallbacks struct {
+	properties PythonAconfigLibraryProperties
+}
+
+// python_aconfig_library generates a python module of accessors for the flags of an
+// aconfig_declarations module.  The module is named after the aconfig package with dots replaced
+// by underscores, e.g. com_android_foo for the com.android.foo package, and can be imported by
+// python modules that list the python_aconfig_library in their libs.
+func PythonAconfigLibraryFactory() android.Module {
+	callbacks := &PythonAconfigLibraryCallbacks{}
+	return python.GeneratedPythonLibraryModuleFactory("python_aconfig_library", callbacks, &callbacks.properties)
+}
+
+func (callbacks *PythonAconfigLibraryCallbacks) DepsMutator(module *python.GeneratedPythonLibraryModule, ctx android.BottomUpMutatorContext) {
+	declarations := callbacks.properties.Aconfig_declarations
+	if len(declarations) == 0 {
+		ctx.PropertyErrorf("aconfig_declarations", "aconfig_declarations property required")
+	} else {
+		ctx.AddDependency(ctx.Module(), pythonDeclarationsTag, declarations)
+	}
+}
+
+func (callbacks *PythonAconfigLibraryCallbacks) GenerateSourceBuildActions(module *python.GeneratedPythonLibraryModule, ctx android.ModuleContext) android.Paths {
+	declarationsModules := ctx.GetDirectDepsWithTag(pythonDeclarationsTag)
+	if len(declarationsModules) != 1 {
+		panic("Exactly one aconfig_declarations property required")
+	}
+	declarations, _ := android.OtherModuleProvider(ctx, declarationsModules[0], android.AconfigDeclarationsProviderKey)
+
+	mode := proptools.StringDefault(callbacks.properties.Mode, "production")
+	if !isModeSupported(mode) {
+		ctx.PropertyErrorf("mode", "%q is not a supported mode", mode)
+	}
+
+	filter := ""
+	if mode == "exported" {
+		if !declarations.Exportable {
+			// if mode is exported, the corresponding aconfig_declaration must mark its
+			// exportable property true
+			ctx.PropertyErrorf("mode", "exported mode requires its aconfig_declaration has exportable prop true")
+		}
+		filter = "--filter=is_exported:true"
+	}
+
+	generatedSource := android.PathForModuleGen(ctx, strings.ReplaceAll(declarations.Package, ".", "_")+".py")
+
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        pythonRule,
+		Input:       declarations.IntermediateCacheOutputPath,
+		Output:      generatedSource,
+		Description: "python_aconfig_library",
+		Args: map[string]string{
+			"mode":    mode,
+			"package": declarations.Package,
+			"filter":  filter,
+		},
+	})
+
+	android.SetProvider(ctx, android.CodegenInfoProvider, android.CodegenInfo{
+		AconfigDeclarations:          []string{declarationsModules[0].Name()},
+		IntermediateCacheOutputPaths: android.Paths{declarations.IntermediateCacheOutputPath},
+		ModeInfos: map[string]android.ModeInfo{
+			ctx.ModuleName(): {
+				Container: declarations.Container,
+				Mode:      mode,
+			}},
+	})
+
+	return android.Paths{generatedSource}
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"testing"
+
+	"android/soong/android"
+	"android/soong/python"
+)
+
+var prepareForPythonAconfigLibraryTest = android.GroupFixturePreparers(
+	PrepareForTestWithAconfigBuildComponents,
+	python.PrepareForTestWithPythonBuildComponents,
+	android.PrepareForTestWithArchMutator,
+	android.PrepareForTestWithDefaults,
+	android.PrepareForTestWithAllowMissingDependencies,
+)
+
+func TestPythonAconfigLibrary(t *testing.T) {
+	result := prepareForPythonAconfigLibraryTest.RunTestWithBp(t, `
+		aconfig_declarations {
+			name: "my_aconfig_declarations",
+			package: "com.example.package",
+			container: "com.android.foo",
+			srcs: ["foo.aconfig"],
+		}
+
+		python_aconfig_library {
+			name: "my_python_aconfig_library",
+			aconfig_declarations: "my_aconfig_declarations",
+			mode: "test",
+		}
+
+		python_test_host {
+			name: "my_python_test",
+			srcs: ["my_test.py"],
+			libs: ["my_python_aconfig_library"],
+		}
+	`)
+
+	library := result.ModuleForTests(t, "my_python_aconfig_library", "linux_glibc_x86_64")
+	rule := library.Rule("python_aconfig_library")
+	android.AssertStringEquals(t, "mode", "test", rule.Args["mode"])
+	android.AssertStringEquals(t, "package", "com.example.package", rule.Args["package"])
+	android.AssertStringEquals(t, "filter", "", rule.Args["filter"])
+	android.AssertPathRelativeToTopEquals(t, "generated module",
+		"out/soong/.intermediates/my_python_aconfig_library/linux_glibc_x86_64/gen/com_example_package.py",
+		rule.Output)
+
+	info, ok := android.OtherModuleProvider(result.TestContext.OtherModuleProviderAdaptor(), library.Module(), python.PythonLibraryInfoProvider)
+	if !ok {
+		t.Fatalf("expected python_aconfig_library to provide PythonLibraryInfo")
+	}
+	android.AssertPathRelativeToTopEquals(t, "srcs zip",
+		"out/soong/.intermediates/my_python_aconfig_library/linux_glibc_x86_64/my_python_aconfig_library.py.srcszip",
+		info.SrcsZip)
+	srcsZip := library.Output("my_python_aconfig_library.py.srcszip")
+	android.AssertStringListContains(t, "srcs zip inputs", srcsZip.Implicits.Strings(),
+		"out/soong/.intermediates/my_python_aconfig_library/linux_glibc_x86_64/gen/com_example_package.py")
+}
+
+func TestPythonAconfigLibraryErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		bp    string
+		error string
+	}{
+		{
+			name: "srcs not allowed",
+			bp: `
+				python_aconfig_library {
+					name: "my_python_aconfig_library",
+					aconfig_declarations: "my_aconfig_declarations",
+					srcs: ["foo.py"],
+				}
+			`,
+			error: "srcs not allowed on python_aconfig_library",
+		},
+		{
+			name: "unsupported mode",
+			bp: `
+				python_aconfig_library {
+					name: "my_python_aconfig_library",
+					aconfig_declarations: "my_aconfig_declarations",
+					mode: "fast",
+				}
+			`,
+			error: `"fast" is not a supported mode`,
+		},
+		{
+			name: "exported mode requires exportable declarations",
+			bp: `
+				python_aconfig_library {
+					name: "my_python_aconfig_library",
+					aconfig_declarations: "my_aconfig_declarations",
+					mode: "exported",
+				}
+			`,
+			error: "exported mode requires its aconfig_declaration has exportable prop true",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prepareForPythonAconfigLibraryTest.
+				ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(tc.error)).
+				RunTestWithBp(t, `
+					aconfig_declarations {
+						name: "my_aconfig_declarations",
+						package: "com.example.package",
+						container: "com.android.foo",
+						srcs: ["foo.aconfig"],
+					}
+				`+tc.bp)
+		})
+	}
+}
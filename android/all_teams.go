@@ -12,6 +12,11 @@ import (
 const ownershipDirectory = "ownership"
 const allTeamsFile = "all_teams.pb"
 
+// AllTeamsFilePath returns the path to the all_teams.pb file that lists the team of every module.
+func AllTeamsFilePath(ctx PathContext) OutputPath {
+	return PathForOutput(ctx, ownershipDirectory, allTeamsFile)
+}
+
 func AllTeamsFactory() Singleton {
 	return &allTeamsSingleton{}
 }
@@ -126,7 +131,7 @@ func (t *allTeamsSingleton) GenerateBuildActions(ctx SingletonContext) {
 	// isn't assignged at the module level.
 	allTeams := t.lookupTeamForAllModules()
 
-	t.outputPath = PathForOutput(ctx, ownershipDirectory, allTeamsFile)
+	t.outputPath = AllTeamsFilePath(ctx)
 	data, err := proto.Marshal(allTeams)
 	if err != nil {
 		ctx.Errorf("Unable to marshal team data. %s", err)
@@ -15,6 +15,7 @@
 package android
 
 import (
+	"encoding/json"
 	"fmt"
 	"slices"
 	"strconv"
@@ -614,8 +615,8 @@ func UpdateUniqueApexVariationsForDeps(mctx BottomUpMutatorContext, am ApexModul
 //Below are routines for extra safety checks.
 //
 // BuildDepsInfoLists is to flatten the dependency graph for an apexBundle into a text file
-// (actually two in slightly different formats). The files are mostly for debugging, for example to
-// see why a certain module is included in an APEX via which dependency path.
+// (actually two in slightly different formats, and a JSON file). The files are mostly for debugging,
+// for example to see why a certain module is included in an APEX via which dependency path.
 //
 // CheckMinSdkVersion is to make sure that all modules in an apexBundle satisfy the min_sdk_version
 // requirement of the apexBundle.
@@ -635,20 +636,72 @@ type ApexModuleDepInfo struct {
 // A map of a dependency name to its ApexModuleDepInfo
 type DepNameToDepInfoMap map[string]ApexModuleDepInfo
 
+// ApexDepGraph records the dependency edges walked from an APEX or APK, including the edges of
+// modules that are not in its DepNameToDepInfoMap, to find how each dependency is included.
+type ApexDepGraph map[string][]string
+
+func (g ApexDepGraph) AddEdge(from, to string) {
+	if !InList(to, g[from]) {
+		g[from] = append(g[from], to)
+	}
+}
+
+// ShortestPaths returns the shortest dependency path from root to each module reachable from it,
+// including both root and the module.
+func (g ApexDepGraph) ShortestPaths(root string) map[string][]string {
+	paths := map[string][]string{root: {root}}
+	queue := []string{root}
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		for _, to := range g[from] {
+			if _, visited := paths[to]; visited {
+				continue
+			}
+			path := make([]string, len(paths[from]), len(paths[from])+1)
+			copy(path, paths[from])
+			paths[to] = append(path, to)
+			queue = append(queue, to)
+		}
+	}
+	return paths
+}
+
+// The dependency info of an APEX or APK in depsinfo/deps.json.
+type apexDepsInfoJson struct {
+	Name          string
+	MinSdkVersion string
+	Dependencies  []apexDepInfoJson
+}
+
+type apexDepInfoJson struct {
+	Name          string
+	MinSdkVersion string
+	External      bool
+	// The modules that depend on this one.
+	From []string
+	// The shortest dependency path from the APEX or APK to this module.
+	Path []string
+}
+
 type ApexBundleDepsInfo struct {
 	flatListPath Path
 	fullListPath Path
+	jsonPath     Path
 }
 
 type ApexBundleDepsInfoIntf interface {
 	Updatable() bool
 	FlatListPath() Path
 	FullListPath() Path
+	DepsInfoJsonPath() Path
 }
 
 type ApexBundleDepsData struct {
 	Updatable    bool
 	FlatListPath Path
+	// depsinfo/deps.json, used to explain changes to allowed_deps.txt.
+	JsonPath Path
 }
 
 var ApexBundleDepsDataProvider = blueprint.NewProvider[ApexBundleDepsData]()
@@ -661,15 +714,28 @@ func (d *ApexBundleDepsInfo) FullListPath() Path {
 	return d.fullListPath
 }
 
-// Generate two module out files:
+func (d *ApexBundleDepsInfo) DepsInfoJsonPath() Path {
+	return d.jsonPath
+}
+
+// Generate three module out files:
 // 1. FullList with transitive deps and their parents in the dep graph
 // 2. FlatList with a flat list of transitive deps
-// In both cases transitive deps of external deps are not included. Neither are deps that are only
+// 3. deps.json with the transitive deps, their parents and the shortest dependency path to them
+// In all cases transitive deps of external deps are not included. Neither are deps that are only
 // available to APEXes; they are developed with updatability in mind and don't need manual approval.
-func (d *ApexBundleDepsInfo) BuildDepsInfoLists(ctx ModuleContext, minSdkVersion string, depInfos DepNameToDepInfoMap) {
+func (d *ApexBundleDepsInfo) BuildDepsInfoLists(ctx ModuleContext, minSdkVersion string, depInfos DepNameToDepInfoMap,
+	graph ApexDepGraph) {
 	var fullContent strings.Builder
 	var flatContent strings.Builder
 
+	paths := graph.ShortestPaths(ctx.ModuleName())
+	jsonContent := apexDepsInfoJson{
+		Name:          ctx.ModuleName(),
+		MinSdkVersion: minSdkVersion,
+		Dependencies:  []apexDepInfoJson{},
+	}
+
 	fmt.Fprintf(&fullContent, "%s(minSdkVersion:%s):\n", ctx.ModuleName(), minSdkVersion)
 	for _, key := range FirstUniqueStrings(SortedKeys(depInfos)) {
 		info := depInfos[key]
@@ -679,6 +745,13 @@ func (d *ApexBundleDepsInfo) BuildDepsInfoLists(ctx ModuleContext, minSdkVersion
 		}
 		fmt.Fprintf(&fullContent, "  %s <- %s\n", toName, strings.Join(SortedUniqueStrings(info.From), ", "))
 		fmt.Fprintf(&flatContent, "%s\n", toName)
+		jsonContent.Dependencies = append(jsonContent.Dependencies, apexDepInfoJson{
+			Name:          info.To,
+			MinSdkVersion: info.MinSdkVersion,
+			External:      info.IsExternal,
+			From:          SortedUniqueStrings(info.From),
+			Path:          paths[info.To],
+		})
 	}
 
 	fullListPath := PathForModuleOut(ctx, "depsinfo", "fulllist.txt")
@@ -689,7 +762,16 @@ func (d *ApexBundleDepsInfo) BuildDepsInfoLists(ctx ModuleContext, minSdkVersion
 	WriteFileRule(ctx, flatListPath, flatContent.String())
 	d.flatListPath = flatListPath
 
-	ctx.Phony(fmt.Sprintf("%s-depsinfo", ctx.ModuleName()), fullListPath, flatListPath)
+	jsonBytes, err := json.MarshalIndent(jsonContent, "", "  ")
+	if err != nil {
+		ctx.ModuleErrorf("failed to marshal dependency info: %s", err)
+		return
+	}
+	jsonPath := PathForModuleOut(ctx, "depsinfo", "deps.json")
+	WriteFileRule(ctx, jsonPath, string(jsonBytes))
+	d.jsonPath = jsonPath
+
+	ctx.Phony(fmt.Sprintf("%s-depsinfo", ctx.ModuleName()), fullListPath, flatListPath, jsonPath)
 }
 
 // Function called while walking an APEX's payload dependencies.
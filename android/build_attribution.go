@@ -0,0 +1,98 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+)
+
+// When SOONG_BUILD_ATTRIBUTION is set, every build statement records the module variant or
+// singleton that owns it in its "tags" variable.  Ninja reports the tags of each finished edge
+// back to soong_ui, which uses them to produce a per-module and per-directory build time report.
+
+const (
+	buildAttributionEnv = "SOONG_BUILD_ATTRIBUTION"
+
+	// buildAttributionArg is the build statement variable that ninja reports back in the
+	// action results, in the same ";key=value" format used by Kati.
+	buildAttributionArg = "tags"
+)
+
+var buildAttributionEnabledKey = NewOnceKey("buildAttributionEnabled")
+
+// BuildAttributionEnabled returns true if the build should record which module owns each ninja
+// build statement.
+func (c *config) BuildAttributionEnabled() bool {
+	return c.Once(buildAttributionEnabledKey, func() interface{} {
+		return c.IsEnvTrue(buildAttributionEnv)
+	}).(bool)
+}
+
+// buildAttributionRules is the set of rules that declare buildAttributionArg.  Blueprint rejects
+// arguments that a rule doesn't declare, so build statements using other rules, like the builtin
+// phony rule or rules created directly with blueprint, are left untagged.  Module and singleton
+// rules are only added when build attribution is enabled.
+var buildAttributionRules sync.Map
+
+// buildAttributionArgNames returns argNames with buildAttributionArg added.
+func buildAttributionArgNames(argNames []string) []string {
+	return append(append([]string(nil), argNames...), buildAttributionArg)
+}
+
+func registerBuildAttributionRule(rule blueprint.Rule) blueprint.Rule {
+	buildAttributionRules.Store(rule, true)
+	return rule
+}
+
+// addBuildAttributionTags returns params with the owner tags added to its Args if the rule
+// supports them.
+func addBuildAttributionTags(params BuildParams, tags ...string) BuildParams {
+	if _, ok := buildAttributionRules.Load(params.Rule); !ok {
+		return params
+	}
+	var sb strings.Builder
+	for i := 0; i < len(tags); i += 2 {
+		if tags[i+1] != "" {
+			sb.WriteString(";" + tags[i] + "=" + tags[i+1])
+		}
+	}
+	args := make(map[string]string, len(params.Args)+1)
+	for k, v := range params.Args {
+		args[k] = v
+	}
+	args[buildAttributionArg] = proptools.NinjaEscape(sb.String())
+	params.Args = args
+	return params
+}
+
+func moduleBuildAttributionTags(ctx ModuleContext, params BuildParams) BuildParams {
+	return addBuildAttributionTags(params,
+		"module", ctx.ModuleName(),
+		"variant", ctx.ModuleSubDir(),
+		"type", ctx.ModuleType(),
+		"dir", ctx.ModuleDir(),
+		// Modules in different namespaces may have the same name.
+		"namespace", ctx.Namespace().Path)
+}
+
+func singletonBuildAttributionTags(name string, params BuildParams) BuildParams {
+	return addBuildAttributionTags(params,
+		"module", name,
+		"type", "singleton")
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+
+	"github.com/google/blueprint"
+)
+
+type buildAttributionTestModule struct {
+	ModuleBase
+}
+
+func (m *buildAttributionTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	ctx.Build(pctx, BuildParams{
+		Rule:           Touch,
+		Output:         PathForModuleOut(ctx, "out"),
+		ImplicitOutput: PathForModuleOut(ctx, "out.d"),
+	})
+
+	rule := NewRuleBuilder(pctx, ctx)
+	rule.Command().Text("cp").Input(PathForModuleOut(ctx, "out")).Output(PathForModuleOut(ctx, "copy"))
+	rule.Build("copy", "copy")
+}
+
+func buildAttributionTestModuleFactory() Module {
+	module := &buildAttributionTestModule{}
+	InitAndroidArchModule(module, HostAndDeviceDefault, MultilibCommon)
+	return module
+}
+
+type buildAttributionTestSingleton struct{}
+
+func (s *buildAttributionTestSingleton) GenerateBuildActions(ctx SingletonContext) {
+	ctx.Build(pctx, BuildParams{
+		Rule:   Touch,
+		Output: PathForOutput(ctx, "singleton_out"),
+	})
+	ctx.Build(pctx, BuildParams{
+		Rule:   blueprint.Phony,
+		Output: PathForPhony(ctx, "singleton_phony"),
+		Input:  PathForOutput(ctx, "singleton_out"),
+	})
+}
+
+var prepareForBuildAttributionTest = GroupFixturePreparers(
+	FixtureRegisterWithContext(func(ctx RegistrationContext) {
+		ctx.RegisterModuleType("test_module", buildAttributionTestModuleFactory)
+		ctx.RegisterParallelSingletonType("test_singleton", func() Singleton {
+			return &buildAttributionTestSingleton{}
+		})
+	}),
+	FixtureAddFile("foo/Android.bp", []byte(`
+		test_module {
+			name: "foo",
+			host_supported: true,
+		}
+	`)),
+)
+
+func TestBuildAttribution(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForBuildAttributionTest,
+		FixtureMergeEnv(map[string]string{"SOONG_BUILD_ATTRIBUTION": "true"}),
+	).RunTest(t)
+
+	foo := result.ModuleForTests(t, "foo", "android_common")
+	AssertStringEquals(t, "tags of the module's build statement",
+		";module=foo;variant=android_common;type=test_module;dir=foo;namespace=.",
+		foo.Output("out").Args["tags"])
+	AssertStringEquals(t, "tags of the module's rule builder statement",
+		";module=foo;variant=android_common;type=test_module;dir=foo;namespace=.",
+		foo.Output("copy").Args["tags"])
+
+	singleton := result.SingletonForTests(t, "test_singleton")
+	AssertStringEquals(t, "tags of the singleton's build statement",
+		";module=test_singleton;type=singleton",
+		singleton.Output("singleton_out").Args["tags"])
+	// The builtin phony rule doesn't accept the tags variable.
+	AssertStringEquals(t, "tags of the phony", "", singleton.Output("singleton_phony").Args["tags"])
+}
+
+func TestBuildAttributionNamespaces(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForBuildAttributionTest,
+		PrepareForTestWithNamespace,
+		FixtureMergeEnv(map[string]string{"SOONG_BUILD_ATTRIBUTION": "true"}),
+		FixtureAddFile("a/Android.bp", []byte(`
+			soong_namespace {}
+			test_module {
+				name: "bar",
+			}
+		`)),
+	).RunTest(t)
+
+	bar := result.ModuleForTests(t, "bar", "android_common")
+	AssertStringEquals(t, "tags of the namespaced module",
+		";module=bar;variant=android_common;type=test_module;dir=a;namespace=a",
+		bar.Output("out").Args["tags"])
+}
+
+func TestBuildAttributionDisabled(t *testing.T) {
+	result := prepareForBuildAttributionTest.RunTest(t)
+
+	AssertBoolEquals(t, "enabled", false, result.Config.BuildAttributionEnabled())
+	foo := result.ModuleForTests(t, "foo", "android_common")
+	AssertStringEquals(t, "tags", "", foo.Output("out").Args["tags"])
+}
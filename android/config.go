@@ -412,6 +412,10 @@ type partialCompileFlags struct {
 	// Whether to disable api lint.
 	Disable_api_lint bool
 
+	// Whether to compile java incrementally, recompiling only the changed sources (and the
+	// sources that depend on them) when no ABI-relevant input changed.
+	Use_incremental_javac bool
+
 	// Add others as needed.
 }
 
@@ -423,6 +427,7 @@ var enabledPartialCompileFlags = partialCompileFlags{
 	Use_d8:                  true,
 	Disable_stub_validation: false,
 	Disable_api_lint:        false,
+	Use_incremental_javac:   false,
 }
 
 type deviceConfig struct {
@@ -503,6 +508,7 @@ func (c *config) parsePartialCompileFlags(isEngBuild bool) (partialCompileFlags,
 				Use_d8:                  true,
 				Disable_stub_validation: true,
 				Disable_api_lint:        true,
+				Use_incremental_javac:   true,
 			}
 		case "true":
 			ret = enabledPartialCompileFlags
@@ -522,6 +528,9 @@ func (c *config) parsePartialCompileFlags(isEngBuild bool) (partialCompileFlags,
 
 		case "use_d8":
 			ret.Use_d8 = makeVal(state, defaultPartialCompileFlags.Use_d8)
+
+		case "incremental_javac", "use_incremental_javac":
+			ret.Use_incremental_javac = makeVal(state, defaultPartialCompileFlags.Use_incremental_javac)
 		default:
 			return partialCompileFlags{}, fmt.Errorf("Unknown SOONG_PARTIAL_COMPILE value: %v", tok)
 		}
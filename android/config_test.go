@@ -228,6 +228,11 @@ func (p partialCompileFlags) updateDisableStubValidation(value bool) partialComp
 	return p
 }
 
+func (p partialCompileFlags) updateUseIncrementalJavac(value bool) partialCompileFlags {
+	p.Use_incremental_javac = value
+	return p
+}
+
 func TestPartialCompile(t *testing.T) {
 	mockConfig := func(value string) *config {
 		c := &config{
@@ -246,7 +251,7 @@ func TestPartialCompile(t *testing.T) {
 		{"false", true, partialCompileFlags{}},
 		{"true", true, enabledPartialCompileFlags},
 		{"true", false, partialCompileFlags{}},
-		{"all", true, partialCompileFlags{}.updateUseD8(true).updateDisableApiLint(true).updateDisableStubValidation(true)},
+		{"all", true, partialCompileFlags{}.updateUseD8(true).updateDisableApiLint(true).updateDisableStubValidation(true).updateUseIncrementalJavac(true)},
 
 		// This verifies both use_d8 and the processing order.
 		{"true,use_d8", true, enabledPartialCompileFlags.updateUseD8(true)},
@@ -269,6 +274,11 @@ func TestPartialCompile(t *testing.T) {
 		{"false,+stub_validation", true, partialCompileFlags{}.updateDisableStubValidation(false)},
 		{"false,+enable_stub_validation", true, partialCompileFlags{}.updateDisableStubValidation(false)},
 		{"false,-disable_stub_validation", true, partialCompileFlags{}.updateDisableStubValidation(false)},
+
+		// use_incremental_javac can be specified with either name.
+		{"true,incremental_javac", true, enabledPartialCompileFlags.updateUseIncrementalJavac(true)},
+		{"true,+use_incremental_javac", true, enabledPartialCompileFlags.updateUseIncrementalJavac(true)},
+		{"all,-incremental_javac", true, partialCompileFlags{}.updateUseD8(true).updateDisableApiLint(true).updateDisableStubValidation(true)},
 	}
 
 	for _, test := range tests {
@@ -428,7 +428,10 @@ func (m *moduleContext) Rule(pctx PackageContext, name string, params blueprint.
 		}
 	}
 
-	rule := m.bp.Rule(pctx.PackageContext, name, params, argNames...)
+	rule := m.bp.Rule(pctx.PackageContext, name, params, buildAttributionArgNames(argNames)...)
+	if m.config.BuildAttributionEnabled() {
+		registerBuildAttributionRule(rule)
+	}
 
 	if m.config.captureBuild {
 		m.ruleParams[rule] = params
@@ -447,6 +450,10 @@ func (m *moduleContext) Build(pctx PackageContext, params BuildParams) {
 			m.ModuleName(), strings.Join(missingDeps, ", ")))
 	}
 
+	if m.config.BuildAttributionEnabled() {
+		params = moduleBuildAttributionTags(m, params)
+	}
+
 	if m.config.captureBuild {
 		m.buildParams = append(m.buildParams, params)
 	}
@@ -118,7 +118,7 @@ func (p PackageContext) PoolFunc(name string,
 func (p PackageContext) RuleFunc(name string,
 	f func(PackageRuleContext) blueprint.RuleParams, argNames ...string) blueprint.Rule {
 
-	return p.PackageContext.RuleFunc(name, func(config interface{}) (blueprint.RuleParams, error) {
+	return registerBuildAttributionRule(p.PackageContext.RuleFunc(name, func(config interface{}) (blueprint.RuleParams, error) {
 		ctx := &configErrorWrapper{p, config.(Config), nil}
 		params := f(ctx)
 		if len(ctx.errors) > 0 {
@@ -130,7 +130,7 @@ func (p PackageContext) RuleFunc(name string,
 			params.Pool = localPool
 		}
 		return params, nil
-	}, argNames...)
+	}, buildAttributionArgNames(argNames)...))
 }
 
 // SourcePathVariable returns a Variable whose value is the source directory
@@ -258,7 +258,7 @@ type RemoteRuleSupports struct {
 func (p PackageContext) AndroidRemoteStaticRule(name string, supports RemoteRuleSupports, params blueprint.RuleParams,
 	argNames ...string) blueprint.Rule {
 
-	return p.PackageContext.RuleFunc(name, func(config interface{}) (blueprint.RuleParams, error) {
+	return registerBuildAttributionRule(p.PackageContext.RuleFunc(name, func(config interface{}) (blueprint.RuleParams, error) {
 		ctx := &configErrorWrapper{p, config.(Config), nil}
 		if ctx.Config().UseGoma() && !supports.Goma {
 			// When USE_GOMA=true is set and the rule is not supported by goma, restrict jobs to the
@@ -273,7 +273,7 @@ func (p PackageContext) AndroidRemoteStaticRule(name string, supports RemoteRule
 		}
 
 		return params, nil
-	}, argNames...)
+	}, buildAttributionArgNames(argNames)...))
 }
 
 // RemoteStaticRules returns a pair of rules based on the given RuleParams, where the first rule is a
@@ -213,7 +213,10 @@ func (s *singletonContextAdaptor) Rule(pctx PackageContext, name string, params
 			params.Pool = nil
 		}
 	}
-	rule := s.SingletonContext.Rule(pctx.PackageContext, name, params, argNames...)
+	rule := s.SingletonContext.Rule(pctx.PackageContext, name, params, buildAttributionArgNames(argNames)...)
+	if s.Config().BuildAttributionEnabled() {
+		registerBuildAttributionRule(rule)
+	}
 	if s.Config().captureBuild {
 		s.ruleParams[rule] = params
 	}
@@ -221,6 +224,9 @@ func (s *singletonContextAdaptor) Rule(pctx PackageContext, name string, params
 }
 
 func (s *singletonContextAdaptor) Build(pctx PackageContext, params BuildParams) {
+	if s.Config().BuildAttributionEnabled() {
+		params = singletonBuildAttributionTags(s.SingletonContext.Name(), params)
+	}
 	if s.Config().captureBuild {
 		s.buildParams = append(s.buildParams, params)
 	}
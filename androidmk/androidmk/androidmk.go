@@ -42,6 +42,30 @@ type bpFile struct {
 	bpPos scanner.Position // Position of the last emitted line to the blueprint file
 
 	inModule bool
+
+	// selectTerms are the conditionals translated to select() that apply to the assignment being
+	// handled.  Module properties set by the assignment are wrapped in a select().
+	selectTerms []selectTerm
+
+	// unpack converts makefile positions to file, line and column, and nodePos is the position of
+	// the node being handled, for the conversion report.
+	unpack  func(mkparser.Pos) scanner.Position
+	nodePos scanner.Position
+	report  []ReportEntry
+}
+
+// A ReportEntry describes a construct in an Android.mk file that could not be translated, or that
+// was translated with a warning.
+type ReportEntry struct {
+	Pos      scanner.Position
+	Severity string
+	Message  string
+	// Source is the original makefile text of the construct, if any.
+	Source string
+}
+
+func (e ReportEntry) String() string {
+	return fmt.Sprintf("%s: %s: %s", e.Pos, e.Severity, e.Message)
 }
 
 var invalidVariableStringToReplacement = map[string]string{
@@ -86,6 +110,7 @@ func (f *bpFile) errorf(failedNode mkparser.Node, message string, args ...interf
 	orig := failedNode.Dump()
 	message = fmt.Sprintf(message, args...)
 	f.addErrorText(fmt.Sprintf("// ANDROIDMK TRANSLATION ERROR: %s", message))
+	f.addReportEntry("error", message, orig)
 
 	lines := strings.Split(orig, "\n")
 	for _, l := range lines {
@@ -97,6 +122,17 @@ func (f *bpFile) errorf(failedNode mkparser.Node, message string, args ...interf
 func (f *bpFile) warnf(message string, args ...interface{}) {
 	message = fmt.Sprintf(message, args...)
 	f.addErrorText(fmt.Sprintf("// ANDROIDMK TRANSLATION WARNING: %s", message))
+	f.addReportEntry("warning", message, "")
+}
+
+// records an entry in the conversion report at the position of the node being handled
+func (f *bpFile) addReportEntry(severity, message, source string) {
+	f.report = append(f.report, ReportEntry{
+		Pos:      f.nodePos,
+		Severity: severity,
+		Message:  message,
+		Source:   source,
+	})
 }
 
 // adds the given error message as-is to the bottom of the (in-progress) file
@@ -127,14 +163,41 @@ func (f *bpFile) setMkPos(pos, end scanner.Position) {
 type conditional struct {
 	cond string
 	eq   bool
+
+	// selectTerm is set instead of cond for conditionals that are translated to select().
+	selectTerm *selectTerm
+	// desc is the original conditional, for error messages.
+	desc string
+	// inModule is true if the conditional is inside a module definition, in which case it
+	// applies to assignments, otherwise it applies to whole modules.
+	inModule bool
+}
+
+// activeSelectTerms returns the select() terms of the conditionals that apply at the current
+// position, which are the ones inside the current module definition if there is one.
+func activeSelectTerms(file *bpFile, conds []*conditional) []selectTerm {
+	var terms []selectTerm
+	for _, c := range conds {
+		if c != nil && c.selectTerm != nil && c.inModule == file.inModule {
+			terms = append(terms, *c.selectTerm)
+		}
+	}
+	return terms
 }
 
 func ConvertFile(filename string, buffer *bytes.Buffer) (string, []error) {
+	out, _, errs := ConvertFileWithReport(filename, buffer)
+	return out, errs
+}
+
+// ConvertFileWithReport converts an Android.mk file like ConvertFile, and also returns a report
+// of the constructs that could not be translated, or were translated with warnings.
+func ConvertFileWithReport(filename string, buffer *bytes.Buffer) (string, []ReportEntry, []error) {
 	p := mkparser.NewParser(filename, buffer)
 
 	nodes, errs := p.Parse()
 	if len(errs) > 0 {
-		return "", errs
+		return "", nil, errs
 	}
 
 	file := &bpFile{
@@ -142,6 +205,7 @@ func ConvertFile(filename string, buffer *bytes.Buffer) (string, []error) {
 		localAssignments:  make(map[string]*bpparser.Property),
 		globalAssignments: make(map[string]*bpparser.Expression),
 		variableRenames:   make(map[string]string),
+		unpack:            p.Unpack,
 	}
 
 	var conds []*conditional
@@ -150,6 +214,7 @@ func ConvertFile(filename string, buffer *bytes.Buffer) (string, []error) {
 
 	for _, node := range nodes {
 		file.setMkPos(p.Unpack(node.Pos()), p.Unpack(node.End()))
+		file.nodePos = p.Unpack(node.Pos())
 
 		switch x := node.(type) {
 		case *mkparser.Comment:
@@ -162,7 +227,9 @@ func ConvertFile(filename string, buffer *bytes.Buffer) (string, []error) {
 				file.insertComment("//" + chunks[i])
 			}
 		case *mkparser.Assignment:
+			file.selectTerms = activeSelectTerms(file, conds)
 			handleAssignment(file, x, assignmentCond)
+			file.selectTerms = nil
 		case *mkparser.Directive:
 			switch x.Name {
 			case "include", "-include":
@@ -185,7 +252,7 @@ func ConvertFile(filename string, buffer *bytes.Buffer) (string, []error) {
 				args := x.Args.Dump()
 				eq := x.Name == "ifeq" || x.Name == "ifdef"
 				if _, ok := conditionalTranslations[args]; ok {
-					newCond := conditional{args, eq}
+					newCond := conditional{cond: args, eq: eq, desc: x.Dump(), inModule: file.inModule}
 					conds = append(conds, &newCond)
 					if file.inModule {
 						if assignmentCond == nil {
@@ -194,6 +261,8 @@ func ConvertFile(filename string, buffer *bytes.Buffer) (string, []error) {
 							file.errorf(x, "unsupported nested conditional in module")
 						}
 					}
+				} else if term, ok := selectTermForConditional(x); ok {
+					conds = append(conds, &conditional{selectTerm: &term, desc: x.Dump(), inModule: file.inModule})
 				} else {
 					file.errorf(x, "unsupported conditional")
 					conds = append(conds, nil)
@@ -208,6 +277,9 @@ func ConvertFile(filename string, buffer *bytes.Buffer) (string, []error) {
 					continue
 				}
 				conds[len(conds)-1].eq = !conds[len(conds)-1].eq
+				if term := conds[len(conds)-1].selectTerm; term != nil {
+					term.negated = !term.negated
+				}
 			case "endif":
 				if len(conds) == 0 {
 					file.errorf(x, "missing if before endif")
@@ -247,10 +319,10 @@ func ConvertFile(filename string, buffer *bytes.Buffer) (string, []error) {
 	out, err := bpparser.Print(tree)
 	if err != nil {
 		errs = append(errs, err)
-		return "", errs
+		return "", file.report, errs
 	}
 
-	return string(out), errs
+	return string(out), file.report, errs
 }
 
 func renameVariableWithInvalidCharacters(name string) string {
@@ -319,6 +391,10 @@ func handleAssignment(file *bpFile, assignment *mkparser.Assignment, c *conditio
 			}
 			file.errorf(assignment, "conditional %s %s on global assignment", eq, c.cond)
 		}
+		if len(file.selectTerms) > 0 {
+			file.errorf(assignment, "conditional on global assignment can't be translated to select()")
+			return
+		}
 	}
 
 	appendVariable := assignment.Type == "+="
@@ -351,11 +427,17 @@ func handleAssignment(file *bpFile, assignment *mkparser.Assignment, c *conditio
 }
 
 func handleModuleConditionals(file *bpFile, directive *mkparser.Directive, conds []*conditional) {
+	var terms []selectTerm
 	for _, c := range conds {
 		if c == nil {
 			continue
 		}
 
+		if c.selectTerm != nil {
+			terms = append(terms, *c.selectTerm)
+			continue
+		}
+
 		if _, ok := conditionalTranslations[c.cond]; !ok {
 			panic("unknown conditional " + c.cond)
 		}
@@ -371,6 +453,17 @@ func handleModuleConditionals(file *bpFile, directive *mkparser.Directive, conds
 			file.errorf(directive, err.Error())
 		}
 	}
+
+	if len(terms) > 0 {
+		// Enable the module only if the conditionals that contain it are true.
+		val, err := makeSelect(terms, &bpparser.Bool{Value: true, Token: "true"}, &bpparser.Bool{Value: false, Token: "false"})
+		if err == nil {
+			err = setVariable(file, false, "", "enabled", val, true)
+		}
+		if err != nil {
+			file.errorf(directive, err.Error())
+		}
+	}
 }
 
 func makeModule(file *bpFile, t string) {
@@ -446,6 +539,28 @@ func setVariable(file *bpFile, plusequals bool, prefix, name string, value bppar
 		oldValue = file.globalAssignments[name]
 	}
 
+	if local && len(file.selectTerms) > 0 {
+		// The assignment is inside conditionals that were translated to select().  Appending
+		// adds a select() that is empty if the conditionals are false, while assigning replaces
+		// the value with a select() that keeps the old value if they are false.
+		otherwise := emptyValue(value.Type())
+		if oldValue != nil && !plusequals {
+			if containsSelect(*oldValue) {
+				return fmt.Errorf("unsupported conditional reassignment of %s, which is already conditional", name)
+			}
+			otherwise = *oldValue
+		}
+		var err error
+		value, err = makeSelect(file.selectTerms, value, otherwise)
+		if err != nil {
+			return err
+		}
+		if oldValue != nil && !plusequals {
+			*oldValue = value
+			return nil
+		}
+	}
+
 	if local {
 		if oldValue != nil && plusequals {
 			val, err := addValues(*oldValue, value)
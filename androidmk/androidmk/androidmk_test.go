@@ -17,6 +17,7 @@ package androidmk
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -1763,6 +1764,91 @@ android_app {
 		local_include_dirs: ["protos"],
     },
 }
+`,
+	},
+	{
+		desc: "build variant conditionals in module",
+		in: `
+include $(CLEAR_VARS)
+LOCAL_MODULE := libfoo
+LOCAL_CFLAGS := -Wall
+ifeq ($(TARGET_BUILD_VARIANT),eng)
+LOCAL_CFLAGS += -DENG
+endif
+ifneq ($(TARGET_BUILD_VARIANT),userdebug)
+LOCAL_CFLAGS += -DNOT_USERDEBUG
+endif
+ifeq ($(TARGET_BUILD_VARIANT),user)
+LOCAL_SDK_VERSION := current
+endif
+include $(BUILD_SHARED_LIBRARY)
+`,
+		expected: `
+cc_library_shared {
+    name: "libfoo",
+    cflags: ["-Wall"] + select(product_variable("eng"), {
+        true: ["-DENG"],
+        default: [],
+    }) + select((product_variable("debuggable"), product_variable("eng")), {
+        (true, false): [],
+        (any, any): ["-DNOT_USERDEBUG"],
+    }),
+    sdk_version: select(product_variable("debuggable"), {
+        false: "current",
+        default: unset,
+    }),
+}
+`,
+	},
+	{
+		desc: "nested soong config and release flag conditionals",
+		in: `
+include $(CLEAR_VARS)
+LOCAL_MODULE := libfoo
+LOCAL_SRC_FILES := common.c
+ifeq ($(call soong_config_get,acme,board),alpha)
+LOCAL_SRC_FILES += alpha.c
+ifeq ($(RELEASE_ACME_FEATURE),true)
+LOCAL_SRC_FILES += feature.c
+endif
+else
+LOCAL_SRC_FILES += generic.c
+endif
+include $(BUILD_SHARED_LIBRARY)
+`,
+		expected: `
+cc_library_shared {
+    name: "libfoo",
+    srcs: ["common.c"] + select(soong_config_variable("acme", "board"), {
+        "alpha": ["alpha.c"],
+        default: [],
+    }) + select((soong_config_variable("acme", "board"), release_flag("RELEASE_ACME_FEATURE")), {
+        ("alpha", true): ["feature.c"],
+        (any, any): [],
+    }) + select(soong_config_variable("acme", "board"), {
+        "alpha": [],
+        default: ["generic.c"],
+    }),
+}
+`,
+	},
+	{
+		desc: "conditional module",
+		in: `
+ifneq (,$(filter userdebug eng,$(TARGET_BUILD_VARIANT)))
+include $(CLEAR_VARS)
+LOCAL_MODULE := debugtool
+include $(BUILD_EXECUTABLE)
+endif
+`,
+		expected: `
+cc_binary {
+    name: "debugtool",
+    enabled: select(product_variable("debuggable"), {
+        true: true,
+        default: false,
+    }),
+}
 `,
 	},
 }
@@ -1788,3 +1874,37 @@ func TestEndToEnd(t *testing.T) {
 		}
 	}
 }
+
+func TestConversionReport(t *testing.T) {
+	in := `
+include $(CLEAR_VARS)
+LOCAL_MODULE := libfoo
+ifeq ($(call soong_config_get,acme,board),)
+LOCAL_CFLAGS := -DNO_BOARD
+endif
+ifeq ($(RELEASE_ACME_FEATURE),true)
+LOCAL_CFLAGS += -DFEATURE
+ifeq ($(TARGET_BUILD_VARIANT),eng)
+LOCAL_CFLAGS := -DFEATURE_ENG
+endif
+endif
+include $(BUILD_SHARED_LIBRARY)
+`
+	_, report, errs := ConvertFileWithReport("Android.mk", bytes.NewBufferString(in))
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %q", errs)
+	}
+
+	var got []string
+	for _, entry := range report {
+		got = append(got, fmt.Sprintf("%d: %s: %s: %s", entry.Pos.Line, entry.Severity, entry.Message, entry.Source))
+	}
+	expected := []string{
+		"4: error: unsupported conditional: ifeq ($(call soong_config_get,acme,board),)",
+		"6: error: endif from unsupported conditional: endif ",
+		"10: error: unsupported conditional reassignment of cflags, which is already conditional: LOCAL_CFLAGS := -DFEATURE_ENG",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected report:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
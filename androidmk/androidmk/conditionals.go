@@ -0,0 +1,353 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package androidmk
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	mkparser "android/soong/androidmk/parser"
+
+	bpparser "github.com/google/blueprint/parser"
+)
+
+// The values that blueprint's parser uses to represent the default and any keywords in the
+// patterns of select() cases.
+const (
+	selectDefaultPattern = "__soong_conditions_default__"
+	selectAnyPattern     = "__soong_conditions_any__"
+)
+
+// A selectAtom is a single select() condition and the pattern it has to match, e.g.
+// product_variable("eng") and true.
+type selectAtom struct {
+	function string
+	args     []string
+	pattern  bpparser.Expression
+}
+
+func (a selectAtom) condition() string {
+	return a.function + "(" + strings.Join(a.args, ", ") + ")"
+}
+
+// A selectTerm is a make conditional translated to select() conditions.  It holds if all of its
+// atoms match, or if negated is set, if any of them doesn't match.
+type selectTerm struct {
+	atoms   []selectAtom
+	negated bool
+}
+
+// boolProductVariables are make variables that correspond to the product variables that can be
+// used as select() conditions.
+var boolProductVariables = map[string]string{
+	"BUILD_FROM_TEXT_STUB":       "build_from_text_stub",
+	"SELINUX_IGNORE_NEVERALLOWS": "selinux_ignore_neverallows",
+}
+
+func productVariableAtom(name string, value bool) selectAtom {
+	return selectAtom{"product_variable", []string{name}, boolPattern(value)}
+}
+
+// buildVariantTerms are the select() conditions that hold for a set of values of
+// TARGET_BUILD_VARIANT, keyed by the sorted, space separated set.
+var buildVariantTerms = map[string][]selectAtom{
+	"eng":            {productVariableAtom("eng", true)},
+	"user":           {productVariableAtom("debuggable", false)},
+	"userdebug":      {productVariableAtom("debuggable", true), productVariableAtom("eng", false)},
+	"eng userdebug":  {productVariableAtom("debuggable", true)},
+	"user userdebug": {productVariableAtom("eng", false)},
+}
+
+var (
+	makeVariableRegexp       = regexp.MustCompile(`^\$\(\s*([A-Za-z0-9_]+)\s*\)$`)
+	soongConfigGetRegexp     = regexp.MustCompile(`^\$\(\s*call\s+soong_config_get\s*,\s*([A-Za-z0-9_]+)\s*,\s*([A-Za-z0-9_]+)\s*\)$`)
+	filterBuildVariantRegexp = regexp.MustCompile(`^\$\(\s*filter\s+([^,$]+),\s*\$\(TARGET_BUILD_VARIANT\)\s*\)$`)
+)
+
+func boolPattern(b bool) bpparser.Expression {
+	return &bpparser.Bool{Value: b, Token: strconv.FormatBool(b)}
+}
+
+// selectTermForConditional translates an ifeq, ifneq, ifdef or ifndef directive on the build
+// variant, a release flag, a product variable or a soong config variable to a select() term.
+func selectTermForConditional(directive *mkparser.Directive) (selectTerm, bool) {
+	var term selectTerm
+	var ok bool
+	switch directive.Name {
+	case "ifdef", "ifndef":
+		variable := strings.TrimSpace(directive.Args.Dump())
+		if productVariable, isProductVariable := boolProductVariables[variable]; isProductVariable {
+			term, ok = selectTerm{atoms: []selectAtom{productVariableAtom(productVariable, true)}}, true
+		}
+	case "ifeq", "ifneq":
+		if lhs, rhs, isComparison := splitConditionalArgs(directive.Args.Dump()); isComparison {
+			term, ok = equalityTerm(lhs, rhs)
+			if !ok {
+				term, ok = equalityTerm(rhs, lhs)
+			}
+		}
+	}
+	if ok && (directive.Name == "ifndef" || directive.Name == "ifneq") {
+		term.negated = !term.negated
+	}
+	return term, ok
+}
+
+// splitConditionalArgs splits the arguments of ifeq or ifneq, which are either (a,b), "a" "b" or
+// 'a' 'b'.
+func splitConditionalArgs(args string) (string, string, bool) {
+	args = strings.TrimSpace(args)
+	if strings.HasPrefix(args, "(") && strings.HasSuffix(args, ")") {
+		inner := args[1 : len(args)-1]
+		depth := 0
+		for i, c := range inner {
+			switch c {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			case ',':
+				if depth == 0 {
+					return strings.TrimSpace(inner[:i]), strings.TrimSpace(inner[i+1:]), true
+				}
+			}
+		}
+		return "", "", false
+	}
+	for _, quote := range []string{`"`, `'`} {
+		fields := strings.Split(args, quote)
+		if len(fields) == 5 && fields[0] == "" && strings.TrimSpace(fields[2]) == "" && fields[4] == "" {
+			return fields[1], fields[3], true
+		}
+	}
+	return "", "", false
+}
+
+// equalityTerm returns the select() term that holds when the make expression is equal to the
+// constant value.
+func equalityTerm(expr, value string) (selectTerm, bool) {
+	if strings.Contains(value, "$") {
+		return selectTerm{}, false
+	}
+
+	if match := filterBuildVariantRegexp.FindStringSubmatch(expr); match != nil && value == "" {
+		// $(filter eng userdebug,$(TARGET_BUILD_VARIANT)) is empty if the build variant is not
+		// one of the filtered values.
+		variants := strings.Fields(match[1])
+		sort.Strings(variants)
+		atoms, ok := buildVariantTerms[strings.Join(variants, " ")]
+		return selectTerm{atoms: atoms, negated: true}, ok
+	}
+
+	if match := soongConfigGetRegexp.FindStringSubmatch(expr); match != nil && value != "" {
+		// Unset soong config variables only match the default case, so comparisons to the empty
+		// string aren't supported.
+		return selectTerm{atoms: []selectAtom{{
+			function: "soong_config_variable",
+			args:     []string{match[1], match[2]},
+			pattern:  &bpparser.String{Value: value},
+		}}}, true
+	}
+
+	match := makeVariableRegexp.FindStringSubmatch(expr)
+	if match == nil {
+		return selectTerm{}, false
+	}
+	variable := match[1]
+	switch {
+	case variable == "TARGET_BUILD_VARIANT":
+		atoms, ok := buildVariantTerms[value]
+		return selectTerm{atoms: atoms}, ok
+	case boolProductVariables[variable] != "":
+		if value != "true" && value != "" {
+			return selectTerm{}, false
+		}
+		return selectTerm{atoms: []selectAtom{productVariableAtom(boolProductVariables[variable], value == "true")}}, true
+	case strings.HasPrefix(variable, "RELEASE_"):
+		var pattern bpparser.Expression
+		switch value {
+		case "true":
+			pattern = boolPattern(true)
+		case "false", "":
+			pattern = boolPattern(false)
+		default:
+			pattern = &bpparser.String{Value: value}
+		}
+		return selectTerm{atoms: []selectAtom{{"release_flag", []string{variable}, pattern}}}, true
+	}
+	return selectTerm{}, false
+}
+
+func patternKey(e bpparser.Expression) string {
+	switch e := e.(type) {
+	case *bpparser.Bool:
+		return strconv.FormatBool(e.Value)
+	case *bpparser.String:
+		return strconv.Quote(e.Value)
+	default:
+		panic(fmt.Errorf("unexpected select pattern %T", e))
+	}
+}
+
+// makeSelect returns a select() expression that evaluates to value if all the terms hold, and to
+// otherwise if they don't.  Terms are composed with a case per negated term that evaluates to
+// otherwise, followed by a case for the non-negated terms, relying on select() using the first
+// case that matches.
+func makeSelect(terms []selectTerm, value, otherwise bpparser.Expression) (bpparser.Expression, error) {
+	var positive []selectAtom
+	var negative [][]selectAtom
+	for _, term := range terms {
+		switch {
+		case !term.negated:
+			positive = append(positive, term.atoms...)
+		case len(term.atoms) == 1:
+			if b, ok := term.atoms[0].pattern.(*bpparser.Bool); ok {
+				// A negated boolean condition is the same as the opposite condition.
+				atom := term.atoms[0]
+				atom.pattern = boolPattern(!b.Value)
+				positive = append(positive, atom)
+				continue
+			}
+			fallthrough
+		default:
+			negative = append(negative, term.atoms)
+		}
+	}
+
+	var conditions []string
+	conditionIndex := make(map[string]int)
+	var selectConditions []bpparser.ConfigurableCondition
+	addCondition := func(atom selectAtom) int {
+		key := atom.condition()
+		if i, ok := conditionIndex[key]; ok {
+			return i
+		}
+		conditionIndex[key] = len(conditions)
+		conditions = append(conditions, key)
+		args := make([]bpparser.String, len(atom.args))
+		for i, arg := range atom.args {
+			args[i] = bpparser.String{Value: arg}
+		}
+		selectConditions = append(selectConditions, bpparser.ConfigurableCondition{
+			FunctionName: atom.function,
+			Args:         args,
+		})
+		return len(conditions) - 1
+	}
+	for _, atom := range positive {
+		addCondition(atom)
+	}
+	for _, atoms := range negative {
+		for _, atom := range atoms {
+			addCondition(atom)
+		}
+	}
+
+	// tuple returns the patterns matching the atoms, with any for the other conditions.
+	tuple := func(atoms []selectAtom) ([]bpparser.Expression, error) {
+		patterns := make([]bpparser.Expression, len(conditions))
+		for _, atom := range atoms {
+			i := conditionIndex[atom.condition()]
+			if patterns[i] != nil && patternKey(patterns[i]) != patternKey(atom.pattern) {
+				return nil, fmt.Errorf("conditions on %s can never be true at the same time", atom.condition())
+			}
+			patterns[i] = atom.pattern
+		}
+		for i := range patterns {
+			if patterns[i] == nil {
+				patterns[i] = &bpparser.String{Value: selectAnyPattern}
+			}
+		}
+		return patterns, nil
+	}
+	newCase := func(patterns []bpparser.Expression, value bpparser.Expression) *bpparser.SelectCase {
+		c := &bpparser.SelectCase{Value: value}
+		for _, p := range patterns {
+			c.Patterns = append(c.Patterns, bpparser.SelectPattern{Value: p})
+		}
+		return c
+	}
+	defaultPatterns := func() []bpparser.Expression {
+		if len(conditions) == 1 {
+			return []bpparser.Expression{&bpparser.String{Value: selectDefaultPattern}}
+		}
+		patterns, _ := tuple(nil)
+		return patterns
+	}
+
+	tupleKey := func(patterns []bpparser.Expression) string {
+		keys := make([]string, len(patterns))
+		for i, p := range patterns {
+			keys[i] = patternKey(p)
+		}
+		return strings.Join(keys, ",")
+	}
+
+	var positivePatterns []bpparser.Expression
+	if len(positive) > 0 {
+		var err error
+		if positivePatterns, err = tuple(positive); err != nil {
+			return nil, err
+		}
+	}
+
+	var cases []*bpparser.SelectCase
+	for _, atoms := range negative {
+		patterns, err := tuple(atoms)
+		if err != nil {
+			return nil, err
+		}
+		if positivePatterns != nil && tupleKey(patterns) == tupleKey(positivePatterns) {
+			return nil, fmt.Errorf("conditions on %s can never be true at the same time", atoms[0].condition())
+		}
+		cases = append(cases, newCase(patterns, otherwise))
+	}
+	if positivePatterns != nil {
+		cases = append(cases, newCase(positivePatterns, value), newCase(defaultPatterns(), otherwise))
+	} else {
+		cases = append(cases, newCase(defaultPatterns(), value))
+	}
+
+	return &bpparser.Select{
+		Conditions:     selectConditions,
+		Cases:          cases,
+		ExpressionType: value.Type(),
+	}, nil
+}
+
+// emptyValue returns the value a property has when a conditional assignment to it doesn't
+// apply: an empty list for lists, or unset otherwise.
+func emptyValue(typ bpparser.Type) bpparser.Expression {
+	if typ == bpparser.ListType {
+		return &bpparser.List{}
+	}
+	return &bpparser.UnsetProperty{}
+}
+
+// containsSelect returns true if the expression is or adds a select(), which can't be nested in
+// the cases of another select().
+func containsSelect(e bpparser.Expression) bool {
+	switch e := e.(type) {
+	case *bpparser.Select:
+		return true
+	case *bpparser.Operator:
+		return containsSelect(e.Args[0]) || containsSelect(e.Args[1])
+	default:
+		return false
+	}
+}
@@ -20,10 +20,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"android/soong/androidmk/androidmk"
 )
 
+var reportFile = flag.String("report", "", "write a report of the constructs that could not be translated to this file")
+
 var usage = func() {
 	fmt.Fprintf(os.Stderr, "usage: androidmk [flags] <inputFile>\n"+
 		"\nandroidmk parses <inputFile> as an Android.mk file and attempts to output an analogous Android.bp file (to standard out)\n")
@@ -44,10 +47,16 @@ func main() {
 		return
 	}
 
-	output, errs := androidmk.ConvertFile(os.Args[1], bytes.NewBuffer(b))
+	output, report, errs := androidmk.ConvertFileWithReport(filePathToRead, bytes.NewBuffer(b))
 	if len(output) > 0 {
 		fmt.Print(output)
 	}
+	if *reportFile != "" {
+		if err := writeReport(*reportFile, report); err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: ", err)
+			os.Exit(1)
+		}
+	}
 	if len(errs) > 0 {
 		for _, err := range errs {
 			fmt.Fprintln(os.Stderr, "ERROR: ", err)
@@ -55,3 +64,25 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// writeReport writes one line per construct that could not be translated, followed by the
+// original makefile text indented, and a summary line.
+func writeReport(filename string, report []androidmk.ReportEntry) error {
+	buf := &bytes.Buffer{}
+	errors, warnings := 0, 0
+	for _, entry := range report {
+		fmt.Fprintln(buf, entry.String())
+		for _, line := range strings.Split(entry.Source, "\n") {
+			if line != "" {
+				fmt.Fprintln(buf, "    "+line)
+			}
+		}
+		if entry.Severity == "error" {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	fmt.Fprintf(buf, "%d untranslated constructs, %d warnings\n", errors, warnings)
+	return ioutil.WriteFile(filename, buf.Bytes(), 0666)
+}
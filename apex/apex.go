@@ -2231,6 +2231,7 @@ func (a *apexBundle) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 
 	android.SetProvider(ctx, android.ApexBundleDepsDataProvider, android.ApexBundleDepsData{
 		FlatListPath: a.FlatListPath(),
+		JsonPath:     a.DepsInfoJsonPath(),
 		Updatable:    a.Updatable(),
 	})
 
@@ -57,7 +57,8 @@ var (
 		RspfileContent: "$in",
 	})
 
-	// Diff two given lists while ignoring comments in the allowed deps file.
+	// Diff two given lists while ignoring comments in the allowed deps file. On failure, explain
+	// which APEX includes each new dependency using the deps.json files in ${deps_info_list}.
 	diffAllowedApexDepsInfoRule = pctx.AndroidStaticRule("diffAllowedApexDepsInfoRule", blueprint.RuleParams{
 		Description: "Diff ${allowed_deps} and ${new_allowed_deps}",
 		Command: `
@@ -69,6 +70,9 @@ var (
 				echo "ERROR: go/apex-allowed-deps-error contains more information";
 				echo "******************************";
 				echo "Detected changes to allowed dependencies in updatable modules.";
+				echo;
+				${apex_allowed_deps_explain} --allowed_deps ${allowed_deps} --new_allowed_deps ${new_allowed_deps} --deps_info_list ${deps_info_list};
+				echo;
 				echo "To fix and update packages/modules/common/build/allowed_deps.txt, please run:";
 				echo "$$ (croot && packages/modules/common/build/update-apex-allowed-deps.sh)";
 				echo;
@@ -85,11 +89,13 @@ var (
 				exit 1;
 			fi;
 		`,
-	}, "allowed_deps", "new_allowed_deps")
+		CommandDeps: []string{"${apex_allowed_deps_explain}"},
+	}, "allowed_deps", "new_allowed_deps", "deps_info_list")
 )
 
 func (s *apexDepsInfoSingleton) GenerateBuildActions(ctx android.SingletonContext) {
 	updatableFlatLists := android.Paths{}
+	updatableDepsInfos := android.Paths{}
 	ctx.VisitAllModuleProxies(func(module android.ModuleProxy) {
 		if binaryInfo, ok := android.OtherModuleProvider(ctx, module, android.ApexBundleDepsDataProvider); ok {
 			apexInfo, _ := android.OtherModuleProvider(ctx, module, android.ApexInfoProvider)
@@ -97,6 +103,9 @@ func (s *apexDepsInfoSingleton) GenerateBuildActions(ctx android.SingletonContex
 				if binaryInfo.Updatable || apexInfo.Updatable {
 					if strings.HasPrefix(module.String(), "com.android.") {
 						updatableFlatLists = append(updatableFlatLists, path)
+						if binaryInfo.JsonPath != nil {
+							updatableDepsInfos = append(updatableDepsInfos, binaryInfo.JsonPath)
+						}
 					}
 				}
 			}
@@ -122,13 +131,18 @@ func (s *apexDepsInfoSingleton) GenerateBuildActions(ctx android.SingletonContex
 			Output: newAllowedDeps,
 		})
 
+		depsInfoList := android.PathForOutput(ctx, "apex", "depsinfo", "deps-info-list.txt")
+		android.WriteFileRule(ctx, depsInfoList, strings.Join(updatableDepsInfos.Strings(), "\n"))
+
 		ctx.Build(pctx, android.BuildParams{
-			Rule:   diffAllowedApexDepsInfoRule,
-			Input:  newAllowedDeps,
-			Output: s.allowedApexDepsInfoCheckResult,
+			Rule:      diffAllowedApexDepsInfoRule,
+			Input:     newAllowedDeps,
+			Implicits: append(android.Paths{depsInfoList}, updatableDepsInfos...),
+			Output:    s.allowedApexDepsInfoCheckResult,
 			Args: map[string]string{
 				"allowed_deps":     allowedDeps.String(),
 				"new_allowed_deps": newAllowedDeps.String(),
+				"deps_info_list":   depsInfoList.String(),
 			},
 		})
 	}
@@ -15,6 +15,7 @@
 package apex
 
 import (
+	"encoding/json"
 	"fmt"
 	"path"
 	"path/filepath"
@@ -2497,6 +2498,34 @@ func TestTrackAllowedDepsForAndroidApex(t *testing.T) {
 		flatlist, "mylib:(minSdkVersion:29)")
 	android.AssertStringListContains(t, "track platform-available lib",
 		flatlist, "yourlib(minSdkVersion:29)")
+
+	var depsInfoJson struct {
+		Name          string
+		MinSdkVersion string
+		Dependencies  []struct {
+			Name     string
+			External bool
+			Path     []string
+		}
+	}
+	if err := json.Unmarshal([]byte(android.ContentFromFileRuleForTests(t, ctx,
+		myapex.Output("depsinfo/deps.json"))), &depsInfoJson); err != nil {
+		t.Fatal(err)
+	}
+	android.AssertStringEquals(t, "deps.json min_sdk_version", "29", depsInfoJson.MinSdkVersion)
+	paths := map[string][]string{}
+	for _, dep := range depsInfoJson.Dependencies {
+		paths[dep.Name] = dep.Path
+	}
+	android.AssertArrayString(t, "path through a lib that is not available for platform",
+		[]string{"com.android.myapex", "mylib", "libbar"}, paths["libbar"])
+	android.AssertArrayString(t, "path of a direct dependency",
+		[]string{"com.android.myapex", "yourlib"}, paths["yourlib"])
+
+	diff := depsinfo.Rule("diffAllowedApexDepsInfoRule")
+	android.AssertStringListContains(t, "the allowed deps check explains changes with deps.json",
+		diff.Implicits.Strings(),
+		"out/soong/.intermediates/com.android.myapex/android_common_com.android.myapex/depsinfo/deps.json")
 }
 
 func TestNotTrackAllowedDepsForNonAndroidApex(t *testing.T) {
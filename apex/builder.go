@@ -84,6 +84,7 @@ func init() {
 	pctx.HostBinToolVariable("conv_linker_config", "conv_linker_config")
 	pctx.HostBinToolVariable("assemble_vintf", "assemble_vintf")
 	pctx.HostBinToolVariable("apex_elf_checker", "apex_elf_checker")
+	pctx.HostBinToolVariable("apex_allowed_deps_explain", "apex_allowed_deps_explain")
 	pctx.HostBinToolVariable("aconfig", "aconfig")
 	pctx.HostBinToolVariable("host_apex_verifier", "host_apex_verifier")
 }
@@ -1102,12 +1103,14 @@ func (a *apexBundle) buildApexDependencyInfo(ctx android.ModuleContext) {
 	}
 
 	depInfos := android.DepNameToDepInfoMap{}
+	depGraph := android.ApexDepGraph{}
 	a.WalkPayloadDeps(ctx, func(ctx android.BaseModuleContext, from, to android.ModuleProxy, externalDep bool) bool {
 		if from.Name() == to.Name() {
 			// This can happen for cc.reuseObjTag. We are not interested in tracking this.
 			// As soon as the dependency graph crosses the APEX boundary, don't go further.
 			return !externalDep
 		}
+		depGraph.AddEdge(from.Name(), to.Name())
 
 		// Skip dependencies that are only available to APEXes; they are developed with updatability
 		// in mind and don't need manual approval.
@@ -1145,7 +1148,7 @@ func (a *apexBundle) buildApexDependencyInfo(ctx android.ModuleContext) {
 		return !externalDep
 	})
 
-	a.ApexBundleDepsInfo.BuildDepsInfoLists(ctx, a.MinSdkVersion(ctx).String(), depInfos)
+	a.ApexBundleDepsInfo.BuildDepsInfoLists(ctx, a.MinSdkVersion(ctx).String(), depInfos, depGraph)
 
 	ctx.Build(pctx, android.BuildParams{
 		Rule:   android.Phony,
@@ -1153,6 +1156,7 @@ func (a *apexBundle) buildApexDependencyInfo(ctx android.ModuleContext) {
 		Inputs: []android.Path{
 			a.ApexBundleDepsInfo.FullListPath(),
 			a.ApexBundleDepsInfo.FlatListPath(),
+			a.ApexBundleDepsInfo.DepsInfoJsonPath(),
 		},
 	})
 }
@@ -0,0 +1,516 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file implements the lint mode of bpfix, which reports style problems instead of rewriting
+// files, and optionally fixes them.
+
+package bpfix
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/scanner"
+
+	"github.com/google/blueprint/parser"
+)
+
+// LintConfig configures the checks run in lint mode. It is read from a JSON file, e.g.
+//
+//	{
+//	    "Checks": {
+//	        "unsorted-lists": {"Fix": true, "Properties": ["srcs", "static_libs"]},
+//	        "deprecated-module-types": {"ModuleTypes": {"cc_library_static": "cc_library"}}
+//	    },
+//	    "Exclude": ["external"]
+//	}
+type LintConfig struct {
+	// The checks to run, by name. Checks that are not listed are not run.
+	Checks map[string]*LintCheckConfig
+
+	// Directories whose Android.bp files are not linted.
+	Exclude []string
+}
+
+type LintCheckConfig struct {
+	// Whether to rewrite the files to fix the findings of this check when fixes are requested.
+	Fix bool
+
+	// unsorted-lists: the properties whose values must be sorted. Defaults to srcs and deps.
+	Properties []string
+
+	// deprecated-module-types: maps each deprecated module type to the module type that replaces
+	// it, or to "" if there is no direct replacement.
+	ModuleTypes map[string]string
+}
+
+// ReadLintConfig reads a LintConfig and checks that it only contains known checks.
+func ReadLintConfig(r io.Reader) (*LintConfig, error) {
+	var config LintConfig
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("parsing lint config: %w", err)
+	}
+	for name, check := range config.Checks {
+		if findLintCheck(name) == nil {
+			return nil, fmt.Errorf("unknown lint check %q, expected one of %s", name,
+				strings.Join(LintCheckNames(), ", "))
+		}
+		if check == nil {
+			config.Checks[name] = &LintCheckConfig{}
+		}
+	}
+	return &config, nil
+}
+
+// DefaultLintConfig returns the config used when there is no config file: all the checks with
+// their default options, without fixes.
+func DefaultLintConfig() *LintConfig {
+	config := &LintConfig{Checks: make(map[string]*LintCheckConfig)}
+	for _, check := range lintChecks {
+		config.Checks[check.name] = &LintCheckConfig{}
+	}
+	return config
+}
+
+// A LintFinding is a problem reported by a lint check.
+type LintFinding struct {
+	File    string
+	Line    int
+	Column  int
+	Check   string
+	Module  string
+	Message string
+	// Whether the problem was fixed in the tree.
+	Fixed bool
+}
+
+func (f LintFinding) String() string {
+	s := fmt.Sprintf("%s:%d:%d: %s: %s", f.File, f.Line, f.Column, f.Check, f.Message)
+	if f.Fixed {
+		s += " (fixed)"
+	}
+	return s
+}
+
+// SortLintFindings sorts findings by file and position.
+func SortLintFindings(findings []LintFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Check < b.Check
+	})
+}
+
+type lintCheck struct {
+	name string
+	run  func(c *lintCheckContext)
+}
+
+var lintChecks = []lintCheck{
+	{
+		name: "unsorted-lists",
+		run:  lintUnsortedLists,
+	},
+	{
+		name: "redundant-defaults",
+		run:  lintRedundantDefaults,
+	},
+	{
+		name: "property-equals-default",
+		run:  lintPropertyEqualsDefault,
+	},
+	{
+		name: "deprecated-module-types",
+		run:  lintDeprecatedModuleTypes,
+	},
+	{
+		name: "unused-cc-defaults",
+		run:  lintUnusedCcDefaults,
+	},
+}
+
+// LintCheckNames returns the names of all the lint checks.
+func LintCheckNames() []string {
+	var names []string
+	for _, check := range lintChecks {
+		names = append(names, check.name)
+	}
+	return names
+}
+
+func findLintCheck(name string) *lintCheck {
+	for i := range lintChecks {
+		if lintChecks[i].name == name {
+			return &lintChecks[i]
+		}
+	}
+	return nil
+}
+
+// lintDefaults is the information about a defaults module that the checks of other modules use.
+// It is copied out of the tree so that the trees can be fixed while other files are linted.
+type lintDefaults struct {
+	defaults []string
+	// The literal boolean and string properties, by their dotted name.
+	values map[string]string
+}
+
+// LintContext contains the information about all the linted files that checks need to lint a
+// single file, e.g. whether a defaults module is used anywhere. Findings that depend on it are only
+// accurate if all the files of the tree are linted.
+type LintContext struct {
+	defaults     map[string]*lintDefaults
+	usedDefaults map[string]bool
+}
+
+// NewLintContext collects the information about the given files needed by the checks. It must be
+// called before any file is fixed.
+func NewLintContext(files []*parser.File) *LintContext {
+	ctx := &LintContext{
+		defaults:     make(map[string]*lintDefaults),
+		usedDefaults: make(map[string]bool),
+	}
+	for _, file := range files {
+		for _, def := range file.Defs {
+			mod, ok := def.(*parser.Module)
+			if !ok {
+				continue
+			}
+			defaults, _ := getLiteralListPropertyValue(mod, "defaults")
+			for _, d := range defaults {
+				ctx.usedDefaults[d] = true
+			}
+			name, ok := getLiteralStringPropertyValue(mod, "name")
+			if !ok || !isDefaultsModuleType(mod.Type) {
+				continue
+			}
+			ctx.defaults[name] = &lintDefaults{
+				defaults: defaults,
+				values:   literalValues(mod.Properties),
+			}
+		}
+	}
+	return ctx
+}
+
+func isDefaultsModuleType(moduleType string) bool {
+	return strings.HasSuffix(moduleType, "_defaults") || moduleType == "defaults"
+}
+
+// transitiveDefaults returns the defaults modules that the given defaults modules include,
+// including themselves, in the order in which they are applied. Unknown defaults modules are
+// ignored.
+func (ctx *LintContext) transitiveDefaults(defaults []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	var visit func(names []string)
+	visit = func(names []string) {
+		for _, name := range names {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if d, ok := ctx.defaults[name]; ok {
+				visit(d.defaults)
+				result = append(result, name)
+			}
+		}
+	}
+	visit(defaults)
+	return result
+}
+
+// literalValues returns the literal boolean and string properties by their dotted name, e.g.
+// target.host.enabled.
+func literalValues(props []*parser.Property) map[string]string {
+	values := make(map[string]string)
+	visitProperties(props, func(name string, prop *parser.Property) {
+		if value, ok := literalValue(prop.Value); ok {
+			values[name] = value
+		}
+	})
+	return values
+}
+
+func literalValue(value parser.Expression) (string, bool) {
+	switch v := value.(type) {
+	case *parser.Bool:
+		return fmt.Sprintf("%t", v.Value), true
+	case *parser.String:
+		return fmt.Sprintf("%q", v.Value), true
+	}
+	return "", false
+}
+
+// visitProperties calls visit for each property, including the properties of maps, with its
+// dotted name.
+func visitProperties(props []*parser.Property, visit func(name string, prop *parser.Property)) {
+	var visitPrefixed func(prefix string, props []*parser.Property)
+	visitPrefixed = func(prefix string, props []*parser.Property) {
+		for _, prop := range props {
+			name := prefix + prop.Name
+			visit(name, prop)
+			if m, ok := prop.Value.(*parser.Map); ok {
+				visitPrefixed(name+".", m.Properties)
+			}
+		}
+	}
+	visitPrefixed("", props)
+}
+
+type lintCheckContext struct {
+	*LintContext
+	file     *parser.File
+	check    string
+	config   *LintCheckConfig
+	fix      bool
+	findings []LintFinding
+}
+
+func (c *lintCheckContext) report(pos scanner.Position, mod *parser.Module, fixed bool, format string, args ...interface{}) {
+	name, _ := getLiteralStringPropertyValue(mod, "name")
+	c.findings = append(c.findings, LintFinding{
+		File:    c.file.Name,
+		Line:    pos.Line,
+		Column:  pos.Column,
+		Check:   c.check,
+		Module:  name,
+		Message: fmt.Sprintf(format, args...),
+		Fixed:   fixed,
+	})
+}
+
+func (c *lintCheckContext) modules() []*parser.Module {
+	var modules []*parser.Module
+	for _, def := range c.file.Defs {
+		if mod, ok := def.(*parser.Module); ok {
+			modules = append(modules, mod)
+		}
+	}
+	return modules
+}
+
+// Lint runs the checks of the config on a file and returns their findings. If fix is true, the
+// findings of the checks whose config enables fixes are fixed in the tree of the file.
+func Lint(ctx *LintContext, file *parser.File, config *LintConfig, fix bool) []LintFinding {
+	var findings []LintFinding
+	for _, check := range lintChecks {
+		checkConfig, ok := config.Checks[check.name]
+		if !ok {
+			continue
+		}
+		c := &lintCheckContext{
+			LintContext: ctx,
+			file:        file,
+			check:       check.name,
+			config:      checkConfig,
+			fix:         fix && checkConfig.Fix,
+		}
+		check.run(c)
+		findings = append(findings, c.findings...)
+	}
+	SortLintFindings(findings)
+	return findings
+}
+
+var defaultSortedProperties = []string{"srcs", "deps"}
+
+// lintUnsortedLists reports lists of strings that are not sorted, like bpfmt -s would sort them.
+func lintUnsortedLists(c *lintCheckContext) {
+	properties := c.config.Properties
+	if len(properties) == 0 {
+		properties = defaultSortedProperties
+	}
+	for _, mod := range c.modules() {
+		visitProperties(mod.Properties, func(name string, prop *parser.Property) {
+			if !inList(prop.Name, properties) {
+				return
+			}
+			list, ok := prop.Value.(*parser.List)
+			if !ok || parser.ListIsSorted(list) {
+				return
+			}
+			if c.fix {
+				parser.SortList(c.file, list)
+			}
+			c.report(prop.NamePos, mod, c.fix, "%s is not sorted", name)
+		})
+	}
+}
+
+// lintRedundantDefaults reports defaults that are listed more than once, or that are already
+// included by another defaults module in the list.
+func lintRedundantDefaults(c *lintCheckContext) {
+	for _, mod := range c.modules() {
+		list, ok := getLiteralListProperty(mod, "defaults")
+		if !ok {
+			continue
+		}
+		var names []string
+		for _, v := range list.Values {
+			if s, ok := v.(*parser.String); ok {
+				names = append(names, s.Value)
+			}
+		}
+
+		var kept []parser.Expression
+		seen := make(map[string]bool)
+		for _, v := range list.Values {
+			s, ok := v.(*parser.String)
+			if !ok {
+				kept = append(kept, v)
+				continue
+			}
+			if seen[s.Value] {
+				c.report(s.LiteralPos, mod, c.fix, "redundant defaults %q: it is listed more than once", s.Value)
+				continue
+			}
+			if includedBy := c.includingDefaults(s.Value, names); includedBy != "" {
+				c.report(s.LiteralPos, mod, c.fix, "redundant defaults %q: it is already included by %q",
+					s.Value, includedBy)
+				continue
+			}
+			seen[s.Value] = true
+			kept = append(kept, v)
+		}
+		if c.fix && len(kept) != len(list.Values) {
+			list.Values = kept
+		}
+	}
+}
+
+// includingDefaults returns the first of the defaults modules that includes the given one, or "".
+func (ctx *LintContext) includingDefaults(name string, defaults []string) string {
+	for _, other := range defaults {
+		if other != name && inList(name, ctx.transitiveDefaults([]string{other})) {
+			return other
+		}
+	}
+	return ""
+}
+
+// lintPropertyEqualsDefault reports boolean and string properties that are set to the value they
+// already get from the defaults of the module.
+func lintPropertyEqualsDefault(c *lintCheckContext) {
+	for _, mod := range c.modules() {
+		defaults, ok := getLiteralListPropertyValue(mod, "defaults")
+		if !ok {
+			continue
+		}
+		applied := c.transitiveDefaults(defaults)
+		if len(applied) == 0 {
+			continue
+		}
+
+		var redundant []string
+		visitProperties(mod.Properties, func(name string, prop *parser.Property) {
+			if name == "name" {
+				return
+			}
+			value, ok := literalValue(prop.Value)
+			if !ok {
+				return
+			}
+			// Defaults modules that are applied later override earlier ones.
+			for i := len(applied) - 1; i >= 0; i-- {
+				d := applied[i]
+				if defaultValue, ok := c.defaults[d].values[name]; ok {
+					if defaultValue == value {
+						c.report(prop.NamePos, mod, c.fix, "%s is set to %s, which is already the value from defaults %q",
+							name, value, d)
+						redundant = append(redundant, name)
+					}
+					return
+				}
+			}
+		})
+		if c.fix {
+			for _, name := range redundant {
+				removeNestedPropertyFromModule(mod, name)
+			}
+		}
+	}
+}
+
+// removeNestedPropertyFromModule removes a property by its dotted name, and the maps that become
+// empty.
+func removeNestedPropertyFromModule(mod *parser.Module, name string) {
+	var remove func(props []*parser.Property, path []string) []*parser.Property
+	remove = func(props []*parser.Property, path []string) []*parser.Property {
+		var kept []*parser.Property
+		for _, prop := range props {
+			if prop.Name == path[0] {
+				if len(path) == 1 {
+					continue
+				}
+				if m, ok := prop.Value.(*parser.Map); ok {
+					m.Properties = remove(m.Properties, path[1:])
+					if len(m.Properties) == 0 {
+						continue
+					}
+				}
+			}
+			kept = append(kept, prop)
+		}
+		return kept
+	}
+	mod.Properties = remove(mod.Properties, strings.Split(name, "."))
+}
+
+// lintDeprecatedModuleTypes reports modules of the configured deprecated module types, and
+// replaces the module type if there is a replacement.
+func lintDeprecatedModuleTypes(c *lintCheckContext) {
+	for _, mod := range c.modules() {
+		replacement, deprecated := c.config.ModuleTypes[mod.Type]
+		if !deprecated {
+			continue
+		}
+		pos := mod.TypePos
+		if replacement == "" {
+			c.report(pos, mod, false, "module type %s is deprecated", mod.Type)
+			continue
+		}
+		c.report(pos, mod, c.fix, "module type %s is deprecated, use %s instead", mod.Type, replacement)
+		if c.fix {
+			mod.Type = replacement
+		}
+	}
+}
+
+// lintUnusedCcDefaults reports cc_defaults modules that are not used by any linted module. They are
+// not removed, as they may be used by files that are not linted.
+func lintUnusedCcDefaults(c *lintCheckContext) {
+	for _, mod := range c.modules() {
+		if mod.Type != "cc_defaults" {
+			continue
+		}
+		name, ok := getLiteralStringPropertyValue(mod, "name")
+		if !ok || c.usedDefaults[name] {
+			continue
+		}
+		c.report(mod.TypePos, mod, false, "cc_defaults %q is not used", name)
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpfix
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/blueprint/parser"
+)
+
+func parseLintTestFile(t *testing.T, name, in string) *parser.File {
+	t.Helper()
+	file, errs := parser.Parse(name, strings.NewReader(in))
+	if len(errs) > 0 {
+		t.Fatalf("failed to parse %s: %v", name, errs)
+	}
+	return file
+}
+
+func lintFindingStrings(findings []LintFinding) []string {
+	var s []string
+	for _, f := range findings {
+		s = append(s, f.String())
+	}
+	return s
+}
+
+func TestLint(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   string
+		in       string
+		expected []string
+	}{
+		{
+			name:   "unsorted lists",
+			config: `{"Checks": {"unsorted-lists": {"Properties": ["srcs", "static_libs"]}}}`,
+			in: `
+cc_library {
+    name: "libfoo",
+    srcs: [
+        "b.cpp",
+        "a.cpp",
+
+        "d.cpp",
+        "c.cpp",
+    ],
+    shared_libs: ["libz", "liba"],
+    target: {
+        android: {
+            static_libs: ["libb", "liba"],
+        },
+    },
+}
+`,
+			expected: []string{
+				"Android.bp:4:5: unsorted-lists: srcs is not sorted",
+				"Android.bp:14:13: unsorted-lists: target.android.static_libs is not sorted",
+			},
+		},
+		{
+			name:   "redundant defaults",
+			config: `{"Checks": {"redundant-defaults": {}}}`,
+			in: `
+cc_defaults {
+    name: "base_defaults",
+}
+cc_defaults {
+    name: "foo_defaults",
+    defaults: ["base_defaults"],
+}
+cc_library {
+    name: "libfoo",
+    defaults: ["foo_defaults", "base_defaults", "foo_defaults"],
+}
+`,
+			expected: []string{
+				`Android.bp:11:32: redundant-defaults: redundant defaults "base_defaults": it is already included by "foo_defaults"`,
+				`Android.bp:11:49: redundant-defaults: redundant defaults "foo_defaults": it is listed more than once`,
+			},
+		},
+		{
+			name:   "property equals default",
+			config: `{"Checks": {"property-equals-default": {}}}`,
+			in: `
+cc_defaults {
+    name: "base_defaults",
+    vendor_available: true,
+    stem: "base",
+    target: {
+        host: {
+            enabled: false,
+        },
+    },
+}
+cc_defaults {
+    name: "foo_defaults",
+    defaults: ["base_defaults"],
+    stem: "foo",
+}
+cc_library {
+    name: "libfoo",
+    defaults: ["foo_defaults"],
+    vendor_available: true,
+    stem: "base",
+    target: {
+        host: {
+            enabled: false,
+        },
+    },
+}
+`,
+			expected: []string{
+				`Android.bp:20:5: property-equals-default: vendor_available is set to true, which is already the value from defaults "base_defaults"`,
+				`Android.bp:24:13: property-equals-default: target.host.enabled is set to false, which is already the value from defaults "base_defaults"`,
+			},
+		},
+		{
+			name: "deprecated module types",
+			config: `{"Checks": {"deprecated-module-types": {"ModuleTypes": {
+				"cc_library_static": "cc_library",
+				"cc_prebuilt_binary": ""
+			}}}}`,
+			in: `
+cc_library_static {
+    name: "libfoo",
+}
+cc_prebuilt_binary {
+    name: "foo",
+}
+`,
+			expected: []string{
+				"Android.bp:2:1: deprecated-module-types: module type cc_library_static is deprecated, use cc_library instead",
+				"Android.bp:5:1: deprecated-module-types: module type cc_prebuilt_binary is deprecated",
+			},
+		},
+		{
+			name:   "unused cc_defaults",
+			config: `{"Checks": {"unused-cc-defaults": {}}}`,
+			in: `
+cc_defaults {
+    name: "used_defaults",
+}
+cc_defaults {
+    name: "unused_defaults",
+}
+cc_library {
+    name: "libfoo",
+    defaults: ["used_defaults", "other_defaults"],
+}
+`,
+			expected: []string{
+				`Android.bp:5:1: unused-cc-defaults: cc_defaults "unused_defaults" is not used`,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := ReadLintConfig(strings.NewReader(tc.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			file := parseLintTestFile(t, "Android.bp", tc.in)
+			findings := Lint(NewLintContext([]*parser.File{file}), file, config, false)
+			if got := lintFindingStrings(findings); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected findings:\n%s\ngot:\n%s", strings.Join(tc.expected, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestLintFix(t *testing.T) {
+	config, err := ReadLintConfig(strings.NewReader(`{"Checks": {
+		"unsorted-lists": {"Fix": true},
+		"redundant-defaults": {"Fix": true},
+		"property-equals-default": {"Fix": true},
+		"deprecated-module-types": {"ModuleTypes": {"cc_library_static": "cc_library"}}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultsFile := parseLintTestFile(t, "a/Android.bp", `
+cc_defaults {
+    name: "foo_defaults",
+    vendor_available: true,
+    target: {
+        host: {
+            enabled: false,
+        },
+    },
+}
+`)
+	file := parseLintTestFile(t, "b/Android.bp", `
+cc_library_static {
+    name: "libfoo",
+    defaults: ["foo_defaults", "foo_defaults"],
+    srcs: ["b.cpp", "a.cpp"],
+    vendor_available: true,
+    target: {
+        host: {
+            enabled: false,
+        },
+    },
+}
+`)
+	ctx := NewLintContext([]*parser.File{defaultsFile, file})
+	findings := Lint(ctx, file, config, true)
+
+	var fixed []bool
+	for _, f := range findings {
+		fixed = append(fixed, f.Fixed)
+	}
+	if expected := []bool{false, true, true, true, true}; !reflect.DeepEqual(fixed, expected) {
+		t.Errorf("expected fixed %v, got %v for findings:\n%s", expected, fixed,
+			strings.Join(lintFindingStrings(findings), "\n"))
+	}
+
+	mod := file.Defs[0].(*parser.Module)
+	if mod.Type != "cc_library_static" {
+		t.Errorf("expected the module type not to be fixed, got %s", mod.Type)
+	}
+	var props []string
+	for _, prop := range mod.Properties {
+		props = append(props, prop.Name)
+	}
+	if expected := []string{"name", "defaults", "srcs"}; !reflect.DeepEqual(props, expected) {
+		t.Errorf("expected properties %v, got %v", expected, props)
+	}
+	if defaults, _ := getLiteralListPropertyValue(mod, "defaults"); !reflect.DeepEqual(defaults, []string{"foo_defaults"}) {
+		t.Errorf("expected defaults [foo_defaults], got %v", defaults)
+	}
+	if srcs, _ := getLiteralListPropertyValue(mod, "srcs"); !reflect.DeepEqual(srcs, []string{"a.cpp", "b.cpp"}) {
+		t.Errorf("expected sorted srcs, got %v", srcs)
+	}
+}
+
+func TestReadLintConfig(t *testing.T) {
+	_, err := ReadLintConfig(strings.NewReader(`{"Checks": {"no-such-check": {}}}`))
+	if err == nil || !strings.Contains(err.Error(), `unknown lint check "no-such-check"`) {
+		t.Errorf("expected an unknown check error, got %v", err)
+	}
+	_, err = ReadLintConfig(strings.NewReader(`{"Checks": {"unsorted-lists": {"Sort": true}}}`))
+	if err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("expected an unknown field error, got %v", err)
+	}
+}
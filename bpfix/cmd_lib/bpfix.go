@@ -131,6 +131,14 @@ func walkDir(path string, fixRequest bpfix.FixRequest) {
 func Run() {
 	flag.Parse()
 
+	if *lint {
+		runLint(flag.Args())
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return
+	}
+
 	fixRequest := bpfix.NewFixRequest().AddAll()
 
 	if flag.NArg() == 0 {
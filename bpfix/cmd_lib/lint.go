@@ -0,0 +1,229 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file provides the lint mode of the bpfix command-line library
+
+package cmd_lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/google/blueprint/parser"
+
+	"android/soong/bpfix/bpfix"
+)
+
+var (
+	lint       = flag.Bool("lint", false, "run the lint checks instead of the fixes and report their findings")
+	lintConfig = flag.String("lint_config", "", "JSON file configuring the lint checks, defaults to all checks without fixes")
+	jsonOutput = flag.Bool("json", false, "print the lint findings as JSON")
+	jobs       = flag.Int("j", runtime.NumCPU(), "number of files to lint in parallel")
+)
+
+type lintFile struct {
+	path string
+	src  []byte
+	file *parser.File
+}
+
+func readLintConfig() (*bpfix.LintConfig, error) {
+	if *lintConfig == "" {
+		return bpfix.DefaultLintConfig(), nil
+	}
+	f, err := os.Open(*lintConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	config, err := bpfix.ReadLintConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", *lintConfig, err)
+	}
+	return config, nil
+}
+
+func isExcluded(path string, exclude []string) bool {
+	path = filepath.Clean(path)
+	for _, dir := range exclude {
+		dir = filepath.Clean(dir)
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// findLintFiles returns the Android.bp files under the given paths that are not excluded.
+func findLintFiles(paths []string, exclude []string) []string {
+	var files []string
+	for _, path := range paths {
+		switch dir, err := os.Stat(path); {
+		case err != nil:
+			report(err)
+		case dir.IsDir():
+			filepath.Walk(path, func(path string, f os.FileInfo, err error) error {
+				if err != nil {
+					report(err)
+					return nil
+				}
+				if isExcluded(path, exclude) {
+					if f.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if !f.IsDir() && f.Name() == "Android.bp" {
+					files = append(files, path)
+				}
+				return nil
+			})
+		default:
+			if !isExcluded(path, exclude) {
+				files = append(files, path)
+			}
+		}
+	}
+	return files
+}
+
+// parallel calls f for each index in [0, n) using up to *jobs goroutines.
+func parallel(n int, f func(i int)) {
+	workers := *jobs
+	if workers < 1 {
+		workers = 1
+	}
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
+
+func parseLintFile(path string) (*lintFile, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, errs := parser.Parse(path, bytes.NewBuffer(append([]byte(nil), src...)))
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return nil, fmt.Errorf("%s: %d parsing errors", path, len(errs))
+	}
+	return &lintFile{path: path, src: src, file: file}, nil
+}
+
+// writeFixedFile writes back a file if fixing its findings changed it.
+func writeFixedFile(f *lintFile) error {
+	res, err := parser.Print(f.file)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(f.src, res) {
+		return nil
+	}
+	return ioutil.WriteFile(f.path, res, 0644)
+}
+
+// runLint lints the Android.bp files under the given paths, fixing the findings of the checks
+// configured to be fixed when -w is set. All the files are parsed before any of them is linted so
+// that checks can look at modules defined in other files, e.g. defaults.
+func runLint(paths []string) {
+	config, err := readLintConfig()
+	if err != nil {
+		report(err)
+		return
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	paths = findLintFiles(paths, config.Exclude)
+	files := make([]*lintFile, len(paths))
+	errs := make([]error, len(paths))
+	parallel(len(paths), func(i int) {
+		files[i], errs[i] = parseLintFile(paths[i])
+	})
+	var parsed []*lintFile
+	var parsedFiles []*parser.File
+	for i, f := range files {
+		if errs[i] != nil {
+			report(errs[i])
+			continue
+		}
+		parsed = append(parsed, f)
+		parsedFiles = append(parsedFiles, f.file)
+	}
+
+	ctx := bpfix.NewLintContext(parsedFiles)
+	results := make([][]bpfix.LintFinding, len(parsed))
+	errs = make([]error, len(parsed))
+	parallel(len(parsed), func(i int) {
+		results[i] = bpfix.Lint(ctx, parsed[i].file, config, *write)
+		if *write {
+			errs[i] = writeFixedFile(parsed[i])
+		}
+	})
+
+	var findings []bpfix.LintFinding
+	for i := range parsed {
+		if errs[i] != nil {
+			report(errs[i])
+		}
+		findings = append(findings, results[i]...)
+	}
+	bpfix.SortLintFindings(findings)
+
+	if *jsonOutput {
+		if findings == nil {
+			findings = []bpfix.LintFinding{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(findings); err != nil {
+			report(err)
+		}
+	} else {
+		for _, f := range findings {
+			fmt.Println(f.String())
+		}
+	}
+
+	for _, f := range findings {
+		if !f.Fixed && exitCode == 0 {
+			exitCode = 1
+		}
+	}
+}
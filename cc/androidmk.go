@@ -395,6 +395,7 @@ func (fuzz *fuzzBinary) prepareAndroidMKProviderInfo(config android.Config, ctx
 		// TOOD: move to install dep
 		entries.AddStrings("LOCAL_FUZZ_INSTALLED_SHARED_DEPS", fuzz.installedSharedDeps...)
 	}
+	fuzz.smokeTest.AndroidMkInfo(entries)
 }
 
 func (test *testLibrary) prepareAndroidMKProviderInfo(config android.Config, ctx AndroidMkContext, entries *android.AndroidMkInfo) {
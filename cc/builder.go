@@ -249,6 +249,27 @@ var (
 			Platform: map[string]string{remoteexec.PoolKey: "${config.REClangTidyPool}"},
 		}, []string{"cFlags", "ccCmd", "clangCmd", "tidyCmd", "tidyFlags", "tidyVars"}, []string{})
 
+	// Rules for invoking clang-tidy and exporting the suggested fixes. clang-tidy only writes the
+	// fixes file when there are findings, so create an empty one otherwise.
+	clangTidyExportFixes, clangTidyExportFixesRE = pctx.RemoteStaticRules("clangTidyExportFixes",
+		blueprint.RuleParams{
+			Depfile: "${out}.d",
+			Deps:    blueprint.DepsGCC,
+			Command: "rm -f $tidyFixes && CLANG_CMD=$clangCmd TIDY_FILE=$out " +
+				"$tidyVars$reTemplate${config.ClangBin}/clang-tidy.sh $in $tidyFlags -export-fixes=$tidyFixes -- $cFlags && " +
+				"(test -f $tidyFixes || touch $tidyFixes)",
+			CommandDeps: []string{"${config.ClangBin}/clang-tidy.sh", "$ccCmd", "$tidyCmd"},
+		},
+		&remoteexec.REParams{
+			Labels:               map[string]string{"type": "lint", "tool": "clang-tidy", "lang": "cpp"},
+			ExecStrategy:         "${config.REClangTidyExecStrategy}",
+			Inputs:               []string{"$in"},
+			OutputFiles:          []string{"${out}", "${out}.d", "$tidyFixes"},
+			ToolchainInputs:      []string{"$ccCmd", "$tidyCmd"},
+			EnvironmentVariables: []string{"CLANG_CMD", "TIDY_FILE", "TIDY_TIMEOUT"},
+			Platform:             map[string]string{remoteexec.PoolKey: "${config.REClangTidyPool}"},
+		}, []string{"cFlags", "ccCmd", "clangCmd", "tidyCmd", "tidyFlags", "tidyVars", "tidyFixes"}, []string{})
+
 	_ = pctx.SourcePathVariable("yasmCmd", "prebuilts/misc/${config.HostPrebuiltTag}/yasm/yasm")
 
 	// Rule for invoking yasm to compile .asm assembly files.
@@ -408,12 +429,13 @@ type builderFlags struct {
 	toolchain       config.Toolchain
 
 	// True if these extra features are enabled.
-	tidy          bool
-	needTidyFiles bool
-	gcovCoverage  bool
-	sAbiDump      bool
-	emitXrefs     bool
-	clangVerify   bool
+	tidy            bool
+	needTidyFiles   bool
+	tidyExportFixes bool
+	gcovCoverage    bool
+	sAbiDump        bool
+	emitXrefs       bool
+	clangVerify     bool
 
 	assemblerWithCpp bool // True if .s files should be processed with the c preprocessor.
 
@@ -441,33 +463,36 @@ type StripFlags struct {
 
 // Objects is a collection of file paths corresponding to outputs for C++ related build statements.
 type Objects struct {
-	objFiles      android.Paths
-	tidyFiles     android.Paths
-	tidyDepFiles  android.Paths // link dependent .tidy files
-	coverageFiles android.Paths
-	sAbiDumpFiles android.Paths
-	kytheFiles    android.Paths
+	objFiles       android.Paths
+	tidyFiles      android.Paths
+	tidyDepFiles   android.Paths // link dependent .tidy files
+	tidyFixesFiles android.Paths // clang-tidy --export-fixes YAML files
+	coverageFiles  android.Paths
+	sAbiDumpFiles  android.Paths
+	kytheFiles     android.Paths
 }
 
 func (a Objects) Copy() Objects {
 	return Objects{
-		objFiles:      append(android.Paths{}, a.objFiles...),
-		tidyFiles:     append(android.Paths{}, a.tidyFiles...),
-		tidyDepFiles:  append(android.Paths{}, a.tidyDepFiles...),
-		coverageFiles: append(android.Paths{}, a.coverageFiles...),
-		sAbiDumpFiles: append(android.Paths{}, a.sAbiDumpFiles...),
-		kytheFiles:    append(android.Paths{}, a.kytheFiles...),
+		objFiles:       append(android.Paths{}, a.objFiles...),
+		tidyFiles:      append(android.Paths{}, a.tidyFiles...),
+		tidyDepFiles:   append(android.Paths{}, a.tidyDepFiles...),
+		tidyFixesFiles: append(android.Paths{}, a.tidyFixesFiles...),
+		coverageFiles:  append(android.Paths{}, a.coverageFiles...),
+		sAbiDumpFiles:  append(android.Paths{}, a.sAbiDumpFiles...),
+		kytheFiles:     append(android.Paths{}, a.kytheFiles...),
 	}
 }
 
 func (a Objects) Append(b Objects) Objects {
 	return Objects{
-		objFiles:      append(a.objFiles, b.objFiles...),
-		tidyFiles:     append(a.tidyFiles, b.tidyFiles...),
-		tidyDepFiles:  append(a.tidyDepFiles, b.tidyDepFiles...),
-		coverageFiles: append(a.coverageFiles, b.coverageFiles...),
-		sAbiDumpFiles: append(a.sAbiDumpFiles, b.sAbiDumpFiles...),
-		kytheFiles:    append(a.kytheFiles, b.kytheFiles...),
+		objFiles:       append(a.objFiles, b.objFiles...),
+		tidyFiles:      append(a.tidyFiles, b.tidyFiles...),
+		tidyDepFiles:   append(a.tidyDepFiles, b.tidyDepFiles...),
+		tidyFixesFiles: append(a.tidyFixesFiles, b.tidyFixesFiles...),
+		coverageFiles:  append(a.coverageFiles, b.coverageFiles...),
+		sAbiDumpFiles:  append(a.sAbiDumpFiles, b.sAbiDumpFiles...),
+		kytheFiles:     append(a.kytheFiles, b.kytheFiles...),
 	}
 }
 
@@ -487,6 +512,7 @@ func transformSourceToObj(ctx android.ModuleContext, subdir string, srcFiles, no
 	// Source files are one-to-one with tidy, coverage, or kythe files, if enabled.
 	objFiles := make(android.Paths, len(srcObjFiles))
 	var tidyFiles android.Paths
+	var tidyFixesFiles android.Paths
 	noTidySrcsMap := make(map[string]bool)
 	var tidyVars string
 	if flags.tidy {
@@ -715,22 +741,36 @@ func transformSourceToObj(ctx android.ModuleContext, subdir string, srcFiles, no
 			sharedCFlags := shareFlags("cFlags", moduleFlags)
 			srcRelPath := srcFile.Rel()
 
+			args := map[string]string{
+				"cFlags":    sharedCFlags,
+				"ccCmd":     ccCmd,
+				"clangCmd":  ccDesc,
+				"tidyCmd":   tidyCmd,
+				"tidyFlags": shareFlags("tidyFlags", config.TidyFlagsForSrcFile(srcFile, flags.tidyFlags)),
+				"tidyVars":  tidyVars, // short and not shared
+			}
+
+			var tidyFixesFile android.WritablePath
+			if flags.tidyExportFixes {
+				tidyFixesFile = android.ObjPathWithExt(ctx, subdir, srcFile, "tidy.yaml")
+				tidyFixesFiles = append(tidyFixesFiles, tidyFixesFile)
+				args["tidyFixes"] = tidyFixesFile.String()
+				rule = clangTidyExportFixes
+				if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_CLANG_TIDY") {
+					rule = clangTidyExportFixesRE
+				}
+			}
+
 			// Add the .tidy rule
 			ctx.Build(pctx, android.BuildParams{
-				Rule:        rule,
-				Description: "clang-tidy " + srcRelPath,
-				Output:      tidyFile,
-				Input:       srcFile,
-				Implicits:   cFlagsDeps,
-				OrderOnly:   pathDeps,
-				Args: map[string]string{
-					"cFlags":    sharedCFlags,
-					"ccCmd":     ccCmd,
-					"clangCmd":  ccDesc,
-					"tidyCmd":   tidyCmd,
-					"tidyFlags": shareFlags("tidyFlags", config.TidyFlagsForSrcFile(srcFile, flags.tidyFlags)),
-					"tidyVars":  tidyVars, // short and not shared
-				},
+				Rule:           rule,
+				Description:    "clang-tidy " + srcRelPath,
+				Output:         tidyFile,
+				ImplicitOutput: tidyFixesFile,
+				Input:          srcFile,
+				Implicits:      cFlagsDeps,
+				OrderOnly:      pathDeps,
+				Args:           args,
 			})
 		}
 
@@ -764,12 +804,13 @@ func transformSourceToObj(ctx android.ModuleContext, subdir string, srcFiles, no
 		tidyDepFiles = tidyFiles
 	}
 	return Objects{
-		objFiles:      objFiles,
-		tidyFiles:     tidyFiles,
-		tidyDepFiles:  tidyDepFiles,
-		coverageFiles: coverageFiles,
-		sAbiDumpFiles: sAbiDumpFiles,
-		kytheFiles:    kytheFiles,
+		objFiles:       objFiles,
+		tidyFiles:      tidyFiles,
+		tidyDepFiles:   tidyDepFiles,
+		tidyFixesFiles: tidyFixesFiles,
+		coverageFiles:  coverageFiles,
+		sAbiDumpFiles:  sAbiDumpFiles,
+		kytheFiles:     kytheFiles,
 	}
 }
 
@@ -45,9 +45,10 @@ type CcMakeVarsInfo struct {
 var CcMakeVarsInfoProvider = blueprint.NewProvider[*CcMakeVarsInfo]()
 
 type CcObjectInfo struct {
-	ObjFiles   android.Paths
-	TidyFiles  android.Paths
-	KytheFiles android.Paths
+	ObjFiles       android.Paths
+	TidyFiles      android.Paths
+	TidyFixesFiles android.Paths
+	KytheFiles     android.Paths
 }
 
 var CcObjectInfoProvider = blueprint.NewProvider[CcObjectInfo]()
@@ -444,6 +445,14 @@ type Flags struct {
 	EmitXrefs     bool // If true, generate Ninja rules to generate emitXrefs input files for Kythe
 	ClangVerify   bool // If true, append cflags "-Xclang -verify" and append "&& touch $out" to the clang command line.
 
+	// True if clang-tidy should export its suggested fixes for each source file.
+	TidyExportFixes bool
+	// Baseline of known clang-tidy findings. When valid, new findings of TidyChecksAsErrors are
+	// reported by a per-module check instead of by clang-tidy itself.
+	TidyBaseline android.OptionalPath
+	// Checks whose findings are errors, in clang-tidy -warnings-as-errors format.
+	TidyChecksAsErrors string
+
 	// The instruction set required for clang ("arm" or "thumb").
 	RequiredInstructionSet string
 	// The target-device system path to the dynamic linker.
@@ -2248,6 +2257,7 @@ func (c *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 		if ctx.Failed() {
 			return
 		}
+		objs = transformTidyFixes(ctx, flags, objs)
 	}
 
 	if c.linker != nil {
@@ -2320,6 +2330,7 @@ func (c *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 	if !ctx.Config().KatiEnabled() || !android.ShouldSkipAndroidMkProcessing(ctx, c) {
 		ccObjectInfo.ObjFiles = objs.objFiles
 		ccObjectInfo.TidyFiles = objs.tidyFiles
+		ccObjectInfo.TidyFixesFiles = objs.tidyFixesFiles
 	}
 	if len(ccObjectInfo.KytheFiles)+len(ccObjectInfo.ObjFiles)+len(ccObjectInfo.TidyFiles) > 0 {
 		android.SetProvider(ctx, CcObjectInfoProvider, ccObjectInfo)
@@ -145,6 +145,7 @@ type fuzzBinary struct {
 	installedSharedDeps []string
 	sharedLibraries     android.RuleBuilderInstalls
 	data                []android.DataPath
+	smokeTest           *fuzz.SmokeTest
 }
 
 func (fuzz *fuzzBinary) fuzzBinary() bool {
@@ -209,6 +210,7 @@ func (fuzz *fuzzBinary) linkerFlags(ctx ModuleContext, flags Flags) Flags {
 func (fuzz *fuzzBinary) moduleInfoJSON(ctx ModuleContext, moduleInfoJSON *android.ModuleInfoJSON) {
 	fuzz.binaryDecorator.moduleInfoJSON(ctx, moduleInfoJSON)
 	moduleInfoJSON.Class = []string{"EXECUTABLES"}
+	fuzz.smokeTest.ModuleInfoJSON(moduleInfoJSON)
 }
 
 // isValidSharedDependency takes a module and determines if it is a unique shared library
@@ -290,6 +292,13 @@ func SharedLibrarySymbolsInstallLocation(libraryBase string, isVendor bool, fuzz
 
 func (fuzzBin *fuzzBinary) install(ctx ModuleContext, file android.Path) {
 	fuzzBin.fuzzPackagedModule = PackageFuzzModule(ctx, fuzzBin.fuzzPackagedModule)
+	if module, ok := ctx.Module().(*Module); ok {
+		fuzzBin.fuzzPackagedModule.Sanitizers = FuzzSanitizers(module)
+		// The AFL fuzz targets can't be run like libFuzzer ones.
+		if module.fuzzer.Properties.FuzzFramework != fuzz.AFL {
+			fuzzBin.smokeTest = fuzz.BuildSmokeTest(ctx, fuzzBin.fuzzPackagedModule)
+		}
+	}
 
 	installBase := "fuzz"
 
@@ -346,6 +355,18 @@ func (fuzzBin *fuzzBinary) install(ctx ModuleContext, file android.Path) {
 	fuzzBin.binaryDecorator.baseInstaller.install(ctx, file)
 }
 
+// FuzzSanitizers returns the sanitizers a fuzz target is built with, as in SANITIZE_HOST and
+// SANITIZE_TARGET.
+func FuzzSanitizers(mod PlatformSanitizeable) []string {
+	var sanitizers []string
+	for _, t := range Sanitizers {
+		if mod.IsSanitizerEnabled(t) {
+			sanitizers = append(sanitizers, t.name())
+		}
+	}
+	return sanitizers
+}
+
 func PackageFuzzModule(ctx android.ModuleContext, fuzzPackagedModule fuzz.FuzzPackagedModule) fuzz.FuzzPackagedModule {
 	fuzzPackagedModule.Corpus = android.PathsForModuleSrc(ctx, fuzzPackagedModule.FuzzProperties.Corpus)
 	fuzzPackagedModule.Corpus = append(fuzzPackagedModule.Corpus, android.PathsForModuleSrc(ctx, fuzzPackagedModule.FuzzProperties.Device_common_corpus)...)
@@ -379,8 +400,9 @@ func NewFuzzer(hod android.HostOrDeviceSupported) *Module {
 	binary.baseInstaller = NewBaseInstaller(baseInstallerPath, baseInstallerPath, InstallInData)
 
 	fuzzBin := &fuzzBinary{
-		binaryDecorator: binary,
-		baseCompiler:    NewBaseCompiler(),
+		binaryDecorator:    binary,
+		baseCompiler:       NewBaseCompiler(),
+		fuzzPackagedModule: fuzz.FuzzPackagedModule{Lang: fuzz.Cc},
 	}
 	module.compiler = fuzzBin
 	module.linker = fuzzBin
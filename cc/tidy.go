@@ -38,6 +38,12 @@ type TidyProperties struct {
 
 	// Checks that should be treated as errors.
 	Tidy_checks_as_errors []string
+
+	// Path to a clang-tidy baseline file. Findings listed in the baseline do not fail the build,
+	// so that tidy_checks_as_errors can be extended with checks that already have findings in
+	// the module. A baseline with all current findings is written to tidy/tidy-baseline.txt in
+	// the module's intermediates directory.
+	Tidy_baseline *string `android:"path"`
 }
 
 type tidyFeature struct {
@@ -97,6 +103,16 @@ func (tidy *tidyFeature) flags(ctx ModuleContext, flags Flags) Flags {
 		flags.NeedTidyFiles = true
 	}
 
+	// Export the suggested fixes of every source file when requested globally with
+	// TIDY_EXPORT_FIXES, or when the module has a baseline that is checked against them.
+	if tidy.Properties.Tidy_baseline != nil {
+		flags.TidyBaseline = android.OptionalPathForPath(android.PathForModuleSrc(ctx, *tidy.Properties.Tidy_baseline))
+		flags.TidyExportFixes = true
+	}
+	if ctx.Config().IsEnvTrue("TIDY_EXPORT_FIXES") {
+		flags.TidyExportFixes = true
+	}
+
 	// Add global WITH_TIDY_FLAGS and local tidy_flags.
 	withTidyFlags := ctx.Config().Getenv("WITH_TIDY_FLAGS")
 	if len(withTidyFlags) > 0 {
@@ -190,15 +206,64 @@ func (tidy *tidyFeature) flags(ctx ModuleContext, flags Flags) Flags {
 	// Default clang-tidy flags does not contain -warning-as-errors.
 	// If a module has tidy_checks_as_errors, add the list to -warnings-as-errors
 	// and then append the TidyGlobalNoErrorChecks.
+	// With a baseline, clang-tidy only reports warnings and the per-module baseline check fails
+	// on findings of tidy_checks_as_errors that are not in the baseline.
 	if len(tidy.Properties.Tidy_checks_as_errors) > 0 {
-		tidyChecksAsErrors := "-warnings-as-errors=" +
-			strings.Join(esc(ctx, "tidy_checks_as_errors", tidy.Properties.Tidy_checks_as_errors), ",") +
-			config.TidyGlobalNoErrorChecks()
-		flags.TidyFlags = append(flags.TidyFlags, tidyChecksAsErrors)
+		if flags.TidyBaseline.Valid() {
+			checkNinjaAndShellEscapeList(ctx, "tidy_checks_as_errors", tidy.Properties.Tidy_checks_as_errors)
+			flags.TidyChecksAsErrors = strings.Join(tidy.Properties.Tidy_checks_as_errors, ",") +
+				config.TidyGlobalNoErrorChecks()
+		} else {
+			tidyChecksAsErrors := "-warnings-as-errors=" +
+				strings.Join(esc(ctx, "tidy_checks_as_errors", tidy.Properties.Tidy_checks_as_errors), ",") +
+				config.TidyGlobalNoErrorChecks()
+			flags.TidyFlags = append(flags.TidyFlags, tidyChecksAsErrors)
+		}
 	}
 	return flags
 }
 
+// transformTidyFixes merges the clang-tidy fixes exported for each source file of a module into
+// a single clang-apply-replacements compatible file, and checks them against the module's
+// tidy_baseline if it has one.  The per source file fixes in objs are replaced with the merged
+// file.
+func transformTidyFixes(ctx ModuleContext, flags Flags, objs Objects) Objects {
+	if len(objs.tidyFixesFiles) == 0 {
+		return objs
+	}
+
+	fixes := android.PathForModuleOut(ctx, "tidy", "fixes.yaml")
+	rule := android.NewRuleBuilder(pctx, ctx)
+	rule.Command().
+		BuiltTool("tidy_fixes").
+		FlagWithOutput("-o ", fixes).
+		FlagWithRspFileInputList("@", android.PathForModuleOut(ctx, "tidy", "fixes.rsp"), objs.tidyFixesFiles)
+	rule.Build("tidy_fixes", "clang-tidy fixes")
+
+	objs.tidyFixesFiles = android.Paths{fixes}
+	objs.tidyFiles = append(objs.tidyFiles, fixes)
+
+	if flags.TidyBaseline.Valid() {
+		checkOutput := android.PathForModuleOut(ctx, "tidy", "baseline_check.timestamp")
+		referenceBaseline := android.PathForModuleOut(ctx, "tidy", "tidy-baseline.txt")
+		rule := android.NewRuleBuilder(pctx, ctx)
+		rule.Command().
+			BuiltTool("tidy_fixes").
+			FlagWithInput("-baseline ", flags.TidyBaseline.Path()).
+			FlagWithArg("-checks_as_errors ", proptools.ShellEscape(flags.TidyChecksAsErrors)).
+			FlagWithOutput("-write_reference_baseline ", referenceBaseline).
+			FlagWithOutput("-check_output ", checkOutput).
+			Input(fixes)
+		rule.Build("tidy_baseline_check", "clang-tidy baseline check")
+
+		objs.tidyFiles = append(objs.tidyFiles, checkOutput)
+		if flags.NeedTidyFiles {
+			objs.tidyDepFiles = append(objs.tidyDepFiles, checkOutput)
+		}
+	}
+	return objs
+}
+
 func init() {
 	android.RegisterParallelSingletonType("tidy_phony_targets", TidyPhonySingleton)
 }
@@ -212,7 +277,8 @@ type tidyPhonySingleton struct{}
 
 // Given a final module, add its tidy/obj phony targets to tidy/objModulesInDirGroup.
 func collectTidyObjModuleTargets(ctx android.SingletonContext, module android.ModuleProxy,
-	tidyModulesInDirGroup, objModulesInDirGroup map[string]map[string]android.Paths) {
+	tidyModulesInDirGroup, objModulesInDirGroup map[string]map[string]android.Paths,
+	tidyFixesInDir map[string]android.Paths) {
 	allObjFileGroups := make(map[string]android.Paths)     // variant group name => obj file Paths
 	allTidyFileGroups := make(map[string]android.Paths)    // variant group name => tidy file Paths
 	subsetObjFileGroups := make(map[string]android.Paths)  // subset group name => obj file Paths
@@ -224,6 +290,10 @@ func collectTidyObjModuleTargets(ctx android.SingletonContext, module android.Mo
 		info := android.OtherModuleProviderOrDefault(ctx, variant, CcObjectInfoProvider)
 		addToOSGroup(osName, info.ObjFiles, allObjFileGroups, subsetObjFileGroups)
 		addToOSGroup(osName, info.TidyFiles, allTidyFileGroups, subsetTidyFileGroups)
+		if len(info.TidyFixesFiles) > 0 {
+			moduleDir := ctx.ModuleDir(variant)
+			tidyFixesInDir[moduleDir] = append(tidyFixesInDir[moduleDir], info.TidyFixesFiles...)
+		}
 	})
 
 	// (2) Add an all-OS group, with "" or "subset" name, to include all os-specific phony targets.
@@ -251,11 +321,13 @@ func (m *tidyPhonySingleton) GenerateBuildActions(ctx android.SingletonContext)
 	tidyModulesInDirGroup := make(map[string]map[string]android.Paths)
 	// Also for obj-* directory phony targets.
 	objModulesInDirGroup := make(map[string]map[string]android.Paths)
+	// tidyFixesInDir[D] is the merged clang-tidy fixes of all modules in directory D.
+	tidyFixesInDir := make(map[string]android.Paths)
 
 	// Collect tidy/obj targets from the 'final' modules.
 	ctx.VisitAllModuleProxies(func(module android.ModuleProxy) {
 		if ctx.IsFinalModule(module) {
-			collectTidyObjModuleTargets(ctx, module, tidyModulesInDirGroup, objModulesInDirGroup)
+			collectTidyObjModuleTargets(ctx, module, tidyModulesInDirGroup, objModulesInDirGroup, tidyFixesInDir)
 		}
 	})
 
@@ -265,6 +337,42 @@ func (m *tidyPhonySingleton) GenerateBuildActions(ctx android.SingletonContext)
 	}
 	generateObjTidyPhonyTargets(ctx, suffix, "obj", objModulesInDirGroup)
 	generateObjTidyPhonyTargets(ctx, suffix, "tidy", tidyModulesInDirGroup)
+	generateTidyFixesBundle(ctx, tidyFixesInDir)
+}
+
+// Merge the clang-tidy fixes of the modules in each directory into a tidy-fixes.yaml file, and
+// package them into a tidy-fixes.zip file that can be extracted into a directory and passed to
+// clang-apply-replacements.
+func generateTidyFixesBundle(ctx android.SingletonContext, tidyFixesInDir map[string]android.Paths) {
+	if len(tidyFixesInDir) == 0 {
+		return
+	}
+
+	fixesDir := android.PathForOutput(ctx, "tidy-fixes")
+	var dirFixes android.Paths
+	for _, dir := range android.SortedKeys(tidyFixesInDir) {
+		fixes := fixesDir.Join(ctx, dir, "tidy-fixes.yaml")
+		rsp := fixesDir.Join(ctx, dir, "tidy-fixes.rsp")
+		rule := android.NewRuleBuilder(pctx, ctx)
+		rule.Command().
+			BuiltTool("tidy_fixes").
+			FlagWithOutput("-o ", fixes).
+			FlagWithRspFileInputList("@", rsp, android.SortedUniquePaths(tidyFixesInDir[dir]))
+		rule.Build("tidy_fixes_"+strings.ReplaceAll(filepath.Clean(dir), "/", "_"), "clang-tidy fixes "+dir)
+		dirFixes = append(dirFixes, fixes)
+	}
+
+	bundle := android.PathForOutput(ctx, "tidy-fixes.zip")
+	rule := android.NewRuleBuilder(pctx, ctx)
+	rule.Command().
+		BuiltTool("soong_zip").
+		FlagWithOutput("-o ", bundle).
+		FlagWithArg("-C ", fixesDir.String()).
+		FlagForEachInput("-f ", dirFixes)
+	rule.Build("tidy_fixes_bundle", "clang-tidy fixes bundle")
+
+	ctx.Phony("tidy-fixes", bundle)
+	ctx.DistForGoal("tidy-fixes", bundle)
 }
 
 // The name for an obj/tidy module variant group phony target is Name_group-obj/tidy,
@@ -271,3 +271,66 @@ func TestWithGeneratedCode(t *testing.T) {
 			[]string{tidyFileForCpp}, depFiles)
 	})
 }
+
+func TestTidyExportFixes(t *testing.T) {
+	bp := `
+		cc_library_shared { // no fixes exported without TIDY_EXPORT_FIXES
+			name: "libfoo",
+			srcs: ["foo.c"],
+		}
+		cc_library_shared { // baseline implies exported fixes
+			name: "libbar",
+			srcs: ["bar.c"],
+			tidy_checks_as_errors: ["xyz-*", "abc"],
+			tidy_baseline: "tidy_baseline.txt",
+		}`
+	variant := "android_arm64_armv8-a_shared"
+
+	ctx := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureMergeEnv(map[string]string{"WITH_TIDY": "1"}),
+		android.FixtureAddTextFile("tidy_baseline.txt", ""),
+	).RunTestWithBp(t, bp)
+
+	libfooOut := "out/soong/.intermediates/libfoo/" + variant + "/"
+	libbarOut := "out/soong/.intermediates/libbar/" + variant + "/"
+
+	libfoo := ctx.ModuleForTests(t, "libfoo", variant)
+	android.AssertStringDoesNotContain(t, "libfoo tidy rule",
+		libfoo.Output(libfooOut+"obj/foo.tidy").Rule.String(), "ExportFixes")
+	android.AssertBoolEquals(t, "libfoo has merged fixes", false,
+		libfoo.MaybeOutput(libfooOut+"tidy/fixes.yaml").Rule != nil)
+
+	libbar := ctx.ModuleForTests(t, "libbar", variant)
+	tidy := libbar.Rule("clangTidyExportFixes")
+	android.AssertPathRelativeToTopEquals(t, "fixes file",
+		libbarOut+"obj/bar.tidy.yaml", tidy.ImplicitOutput)
+	android.AssertStringDoesNotContain(t, "checks as errors are left to the baseline check",
+		tidy.Args["tidyFlags"], "-warnings-as-errors")
+
+	fixes := libbar.Output(libbarOut + "tidy/fixes.yaml")
+	android.AssertPathsRelativeToTopEquals(t, "merged fixes inputs",
+		[]string{libbarOut + "obj/bar.tidy.yaml"}, fixes.Inputs)
+
+	check := libbar.Output(libbarOut + "tidy/baseline_check.timestamp")
+	android.AssertStringDoesContain(t, "baseline", check.RuleParams.Command, "-baseline tidy_baseline.txt")
+	android.AssertStringDoesContain(t, "checks as errors", check.RuleParams.Command,
+		"-checks_as_errors 'xyz-*,abc,${config.TidyGlobalNoErrorChecks}'")
+	android.AssertStringDoesContain(t, "reference baseline", check.RuleParams.Command,
+		"-write_reference_baseline "+libbarOut+"tidy/tidy-baseline.txt")
+
+	validations := libbar.Rule("ld").Validations.Strings()
+	android.AssertStringListContains(t, "link depends on baseline check", validations,
+		libbarOut+"tidy/baseline_check.timestamp")
+
+	ctx = android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureMergeEnv(map[string]string{"TIDY_EXPORT_FIXES": "true"}),
+		android.FixtureAddTextFile("tidy_baseline.txt", ""),
+	).RunTestWithBp(t, bp)
+	libfoo = ctx.ModuleForTests(t, "libfoo", variant)
+	libfoo.Output(libfooOut + "obj/foo.tidy.yaml")
+	libfoo.Output(libfooOut + "tidy/fixes.yaml")
+	android.AssertBoolEquals(t, "libfoo has baseline check", false,
+		libfoo.MaybeOutput(libfooOut+"tidy/baseline_check.timestamp").Rule != nil)
+}
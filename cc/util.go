@@ -66,6 +66,7 @@ func flagsToBuilderFlags(in Flags) builderFlags {
 		gcovCoverage:    in.GcovCoverage,
 		tidy:            in.Tidy,
 		needTidyFiles:   in.NeedTidyFiles,
+		tidyExportFixes: in.TidyExportFixes,
 		sAbiDump:        in.SAbiDump,
 		emitXrefs:       in.EmitXrefs,
 		clangVerify:     in.ClangVerify,
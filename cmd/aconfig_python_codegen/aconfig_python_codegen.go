@@ -0,0 +1,73 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// aconfig_python_codegen generates a python module of accessors for the flags of an aconfig
+// package from the output of aconfig dump-cache.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s -package <package> [-mode <mode>] -o <output.py> <dump-cache output>\n", os.Args[0])
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+
+	pkg := flags.String("package", "", "aconfig package of the flags")
+	mode := flags.String("mode", "production", "production, test, exported or force-read-only")
+	output := flags.String("o", "", "python file to write")
+
+	flags.Parse(os.Args[1:])
+
+	if *pkg == "" || *output == "" || flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	switch *mode {
+	case "production", "test", "exported", "force-read-only":
+	default:
+		fmt.Fprintf(os.Stderr, "unsupported mode %q\n", *mode)
+		os.Exit(1)
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	parsed, err := parseFlags(f, *pkg)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", flags.Arg(0), err)
+		os.Exit(1)
+	}
+
+	data, err := generatePython(*pkg, parsed, *mode == "test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, data, 0666); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// flagInfo is a single flag read from the output of aconfig dump-cache with the format
+// '{package} {name} {state:bool} {permission}'.
+type flagInfo struct {
+	Package        string
+	Name           string
+	Value          bool
+	ReadWrite      bool
+	Identifier     string
+	ConstantName   string
+	QualifiedName  string
+	PythonBoolText string
+}
+
+var flagNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// pythonKeywords are the lowercase keywords of python 3, which can't be used as function names.
+var pythonKeywords = map[string]bool{
+	"and": true, "as": true, "assert": true, "async": true, "await": true, "break": true,
+	"class": true, "continue": true, "def": true, "del": true, "elif": true, "else": true,
+	"except": true, "finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true,
+	"or": true, "pass": true, "raise": true, "return": true, "try": true, "while": true,
+	"with": true, "yield": true,
+}
+
+func parseFlags(r io.Reader, pkg string) ([]flagInfo, error) {
+	var flags []flagInfo
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("line %d: expected <package> <name> <value> <permission>, got %q", lineNum, line)
+		}
+		f := flagInfo{
+			Package:   fields[0],
+			Name:      fields[1],
+			Value:     fields[2] == "true",
+			ReadWrite: fields[3] == "READ_WRITE",
+		}
+		if f.Package != pkg {
+			return nil, fmt.Errorf("line %d: flag %s.%s is not in package %s", lineNum, f.Package, f.Name, pkg)
+		}
+		if !flagNameRegexp.MatchString(f.Name) {
+			return nil, fmt.Errorf("line %d: invalid flag name %q", lineNum, f.Name)
+		}
+		f.Identifier = f.Name
+		if pythonKeywords[f.Identifier] {
+			f.Identifier += "_"
+		}
+		f.ConstantName = "FLAG_" + strings.ToUpper(f.Name)
+		f.QualifiedName = f.Package + "." + f.Name
+		f.PythonBoolText = "False"
+		if f.Value {
+			f.PythonBoolText = "True"
+		}
+		flags = append(flags, f)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags, nil
+}
+
+var pythonTemplate = template.Must(template.New("python").Parse(`# Generated by aconfig_python_codegen for the {{.Package}} aconfig package, do not edit.
+"""Accessors for the flags of the {{.Package}} aconfig package.
+
+Flag values are the values configured for the build.  Read-write flags can't be read from
+device storage on the host, so they also return the value configured for the build.
+"""
+
+from typing import Dict
+
+PACKAGE = "{{.Package}}"
+{{range .Flags}}
+# Fully qualified name of {{.Name}}.
+{{.ConstantName}} = "{{.QualifiedName}}"
+{{- end}}
+
+_VALUES: Dict[str, bool] = {
+{{- range .Flags}}
+  "{{.Name}}": {{.PythonBoolText}},
+{{- end}}
+}
+
+_READ_WRITE: Dict[str, bool] = {
+{{- range .Flags}}
+  "{{.Name}}": {{if .ReadWrite}}True{{else}}False{{end}},
+{{- end}}
+}
+{{if .Test}}
+_overrides: Dict[str, bool] = {}
+
+
+def set_flag(name: str, value: bool) -> None:
+  """Overrides the value of a flag until reset_flags is called.
+
+  Args:
+    name: the name of the flag, with or without the package name.
+    value: the value to return for the flag.
+  """
+  name = _short_name(name)
+  if name not in _VALUES:
+    raise KeyError("no flag %r in package %s" % (name, PACKAGE))
+  _overrides[name] = bool(value)
+
+
+def reset_flags() -> None:
+  """Removes all overrides set by set_flag."""
+  _overrides.clear()
+
+
+def _value(name: str) -> bool:
+  return _overrides.get(name, _VALUES[name])
+{{else}}
+
+def _value(name: str) -> bool:
+  return _VALUES[name]
+{{end}}
+
+def _short_name(name: str) -> str:
+  if name.startswith(PACKAGE + "."):
+    return name[len(PACKAGE) + 1:]
+  return name
+
+
+def is_read_write(name: str) -> bool:
+  """Returns whether a flag, with or without the package name, is read-write."""
+  return _READ_WRITE[_short_name(name)]
+
+
+def all_flags() -> Dict[str, bool]:
+  """Returns the values of all flags in the package, keyed by fully qualified name."""
+  return {PACKAGE + "." + name: _value(name) for name in sorted(_VALUES)}
+{{- range .Flags}}
+
+
+def {{.Identifier}}() -> bool:
+  """Returns the value of {{.QualifiedName}}."""
+  return _value("{{.Name}}")
+{{- end}}
+`))
+
+// generatePython returns the python module for the flags of an aconfig package.  In test mode
+// the module also allows tests to override flag values.
+func generatePython(pkg string, flags []flagInfo, test bool) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := pythonTemplate.Execute(buf, struct {
+		Package string
+		Flags   []flagInfo
+		Test    bool
+	}{pkg, flags, test})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
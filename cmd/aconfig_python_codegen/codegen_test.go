@@ -0,0 +1,95 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const dumpCache = `com.example.pkg enabled_rw false READ_WRITE
+com.example.pkg enabled_ro true READ_ONLY
+
+com.example.pkg import false READ_ONLY
+`
+
+func TestParseFlags(t *testing.T) {
+	flags, err := parseFlags(strings.NewReader(dumpCache), "com.example.pkg")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(flags) != 3 {
+		t.Fatalf("expected 3 flags, got %d", len(flags))
+	}
+	ro := flags[0]
+	if ro.Name != "enabled_ro" || !ro.Value || ro.ReadWrite || ro.ConstantName != "FLAG_ENABLED_RO" ||
+		ro.QualifiedName != "com.example.pkg.enabled_ro" {
+		t.Errorf("unexpected flag %+v", ro)
+	}
+	if rw := flags[1]; rw.Name != "enabled_rw" || rw.Value || !rw.ReadWrite {
+		t.Errorf("unexpected flag %+v", rw)
+	}
+	if keyword := flags[2]; keyword.Identifier != "import_" {
+		t.Errorf("expected keyword to be renamed, got %q", keyword.Identifier)
+	}
+
+	errorTests := map[string]string{
+		"wrong package": "com.other enabled true READ_ONLY\n",
+		"invalid name":  "com.example.pkg Enabled true READ_ONLY\n",
+		"wrong fields":  "com.example.pkg enabled true\n",
+	}
+	for name, contents := range errorTests {
+		if _, err := parseFlags(strings.NewReader(contents), "com.example.pkg"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestGeneratePython(t *testing.T) {
+	flags, err := parseFlags(strings.NewReader(dumpCache), "com.example.pkg")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	production, err := generatePython("com.example.pkg", flags, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, want := range []string{
+		`PACKAGE = "com.example.pkg"`,
+		`FLAG_ENABLED_RO = "com.example.pkg.enabled_ro"`,
+		`"enabled_ro": True,`,
+		`"enabled_rw": False,`,
+		"def enabled_rw() -> bool:\n",
+		"def import_() -> bool:\n  \"\"\"Returns the value of com.example.pkg.import.\"\"\"\n  return _value(\"import\")\n",
+	} {
+		if !strings.Contains(string(production), want) {
+			t.Errorf("expected production module to contain %q:\n%s", want, production)
+		}
+	}
+	if strings.Contains(string(production), "def set_flag") {
+		t.Errorf("expected production module to not allow overrides:\n%s", production)
+	}
+
+	test, err := generatePython("com.example.pkg", flags, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, want := range []string{"def set_flag(", "def reset_flags(", "_overrides.get(name, _VALUES[name])"} {
+		if !strings.Contains(string(test), want) {
+			t.Errorf("expected test module to contain %q:\n%s", want, test)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// apex_allowed_deps_explain explains the differences between allowed_deps.txt and the
+// dependencies of the updatable APEXes, naming the shortest dependency path through which each
+// new dependency is included.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	allowedDeps    = flag.String("allowed_deps", "", "allowed_deps.txt")
+	newAllowedDeps = flag.String("new_allowed_deps", "", "the allowed_deps.txt generated from the current dependencies")
+	depsInfoList   = flag.String("deps_info_list", "", "file with the paths of the deps.json files of the APEXes, one per line")
+)
+
+func readEntriesFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readEntries(f)
+}
+
+func readDepsInfoFile(path string) (*depsInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := readDepsInfo(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return info, nil
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: %s --allowed_deps <file> --new_allowed_deps <file> --deps_info_list <file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *allowedDeps == "" || *newAllowedDeps == "" || *depsInfoList == "" || flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	allowed, err := readEntriesFile(*allowedDeps)
+	if err != nil {
+		return err
+	}
+	current, err := readEntriesFile(*newAllowedDeps)
+	if err != nil {
+		return err
+	}
+	list, err := os.ReadFile(*depsInfoList)
+	if err != nil {
+		return err
+	}
+	var apexes []*depsInfo
+	for _, path := range strings.Fields(string(list)) {
+		info, err := readDepsInfoFile(path)
+		if err != nil {
+			return err
+		}
+		apexes = append(apexes, info)
+	}
+	explain(os.Stdout, allowed, current, apexes)
+	return nil
+}
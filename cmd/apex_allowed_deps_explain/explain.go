@@ -0,0 +1,203 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// depsInfo is the content of the depsinfo/deps.json of an APEX or APK.
+type depsInfo struct {
+	Name          string
+	MinSdkVersion string
+	Dependencies  []*dependency
+}
+
+type dependency struct {
+	Name          string
+	MinSdkVersion string
+	External      bool
+	From          []string
+	Path          []string
+}
+
+func readDepsInfo(r io.Reader) (*depsInfo, error) {
+	var info depsInfo
+	if err := json.NewDecoder(r).Decode(&info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// readEntries returns the entries of an allowed_deps.txt file, without the comments.
+func readEntries(r io.Reader) ([]string, error) {
+	var entries []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries, scanner.Err()
+}
+
+// entry is a line of allowed_deps.txt, e.g. libfoo(minSdkVersion:30).
+type entry struct {
+	line          string
+	name          string
+	minSdkVersion string
+}
+
+func parseEntry(line string) entry {
+	e := entry{line: line, name: line}
+	if i := strings.Index(line, "(minSdkVersion:"); i >= 0 && strings.HasSuffix(line, ")") {
+		e.name = line[:i]
+		e.minSdkVersion = strings.TrimSuffix(line[i+len("(minSdkVersion:"):], ")")
+	}
+	return e
+}
+
+// inclusion is a dependency of an APEX that matches an entry.
+type inclusion struct {
+	apex *depsInfo
+	dep  *dependency
+}
+
+func (i inclusion) describe() string {
+	if len(i.dep.Path) > 0 {
+		return fmt.Sprintf("%s: %s", i.apex.Name, strings.Join(i.dep.Path, " -> "))
+	}
+	return fmt.Sprintf("%s: depended on by %s", i.apex.Name, strings.Join(i.dep.From, ", "))
+}
+
+// findInclusions returns the non-external dependencies of the APEXes that match an entry, with the
+// shortest dependency path first.
+func findInclusions(apexes []*depsInfo, e entry) []inclusion {
+	var inclusions []inclusion
+	for _, apex := range apexes {
+		for _, dep := range apex.Dependencies {
+			if !dep.External && dep.Name == e.name && dep.MinSdkVersion == e.minSdkVersion {
+				inclusions = append(inclusions, inclusion{apex, dep})
+			}
+		}
+	}
+	pathLen := func(i inclusion) int {
+		if len(i.dep.Path) == 0 {
+			// Sort dependencies without a known path last.
+			return int(^uint(0) >> 1)
+		}
+		return len(i.dep.Path)
+	}
+	sort.SliceStable(inclusions, func(a, b int) bool {
+		if pathLen(inclusions[a]) != pathLen(inclusions[b]) {
+			return pathLen(inclusions[a]) < pathLen(inclusions[b])
+		}
+		return inclusions[a].apex.Name < inclusions[b].apex.Name
+	})
+	return inclusions
+}
+
+// explain writes the entries that were added to and removed from allowed_deps.txt, with the
+// shortest path through which each added entry is included in an APEX.
+func explain(w io.Writer, allowed, current []string, apexes []*depsInfo) {
+	inAllowed := make(map[string]bool)
+	for _, line := range allowed {
+		inAllowed[line] = true
+	}
+	inCurrent := make(map[string]bool)
+	for _, line := range current {
+		inCurrent[line] = true
+	}
+	var added, removed []entry
+	for _, line := range current {
+		if !inAllowed[line] {
+			added = append(added, parseEntry(line))
+		}
+	}
+	for _, line := range allowed {
+		if !inCurrent[line] {
+			removed = append(removed, parseEntry(line))
+		}
+	}
+	removedVersions := make(map[string][]string)
+	for _, e := range removed {
+		removedVersions[e.name] = append(removedVersions[e.name], e.minSdkVersion)
+	}
+
+	if len(added) > 0 {
+		fmt.Fprintln(w, "New dependencies:")
+	}
+	for _, e := range added {
+		fmt.Fprintf(w, "  %s\n", e.line)
+		if versions := removedVersions[e.name]; len(versions) > 0 {
+			fmt.Fprintf(w, "    min_sdk_version changed from %s to %s\n", strings.Join(versions, ", "), e.minSdkVersion)
+		}
+		inclusions := findInclusions(apexes, e)
+		if len(inclusions) == 0 {
+			fmt.Fprintln(w, "    not found in the dependency info of the updatable APEXes")
+			continue
+		}
+		fmt.Fprintf(w, "    included by %s\n", inclusions[0].describe())
+		if len(inclusions) > 1 {
+			var others []string
+			for _, i := range inclusions[1:] {
+				others = append(others, i.apex.Name)
+			}
+			others = uniqueStrings(others)
+			fmt.Fprintf(w, "    also included by %s\n", strings.Join(others, ", "))
+		}
+	}
+
+	var removedOnly []entry
+	for _, e := range removed {
+		if !containsName(added, e.name) {
+			removedOnly = append(removedOnly, e)
+		}
+	}
+	if len(removedOnly) > 0 {
+		fmt.Fprintln(w, "Removed dependencies:")
+	}
+	for _, e := range removedOnly {
+		fmt.Fprintf(w, "  %s\n", e.line)
+	}
+}
+
+func containsName(entries []entry, name string) bool {
+	for _, e := range entries {
+		if e.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueStrings(s []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
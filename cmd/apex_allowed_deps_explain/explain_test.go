@@ -0,0 +1,87 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	allowed, err := readEntries(strings.NewReader(`
+# comment
+libbar(minSdkVersion:29)
+libold(minSdkVersion:29)
+libupdated(minSdkVersion:29)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := []string{
+		"libbar(minSdkVersion:29)",
+		"libbaz(minSdkVersion:30)",
+		"libmissing(minSdkVersion:30)",
+		"libupdated(minSdkVersion:30)",
+	}
+
+	foo, err := readDepsInfo(strings.NewReader(`{
+		"Name": "com.android.foo",
+		"MinSdkVersion": "29",
+		"Dependencies": [
+			{"Name": "libbar", "MinSdkVersion": "29", "From": ["com.android.foo"], "Path": ["com.android.foo", "libbar"]},
+			{"Name": "libbaz", "MinSdkVersion": "30", "From": ["libqux"], "Path": ["com.android.foo", "libbar", "libqux", "libbaz"]},
+			{"Name": "libupdated", "MinSdkVersion": "30", "From": ["libbar"], "Path": ["com.android.foo", "libbar", "libupdated"]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bar, err := readDepsInfo(strings.NewReader(`{
+		"Name": "com.android.bar",
+		"MinSdkVersion": "30",
+		"Dependencies": [
+			{"Name": "libbaz", "MinSdkVersion": "30", "From": ["libquux"], "Path": ["com.android.bar", "libquux", "libbaz"]},
+			{"Name": "libmissing", "MinSdkVersion": "30", "External": true, "From": ["com.android.bar"], "Path": ["com.android.bar", "libmissing"]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	explain(&out, allowed, current, []*depsInfo{foo, bar})
+	expected := `New dependencies:
+  libbaz(minSdkVersion:30)
+    included by com.android.bar: com.android.bar -> libquux -> libbaz
+    also included by com.android.foo
+  libmissing(minSdkVersion:30)
+    not found in the dependency info of the updatable APEXes
+  libupdated(minSdkVersion:30)
+    min_sdk_version changed from 29 to 30
+    included by com.android.foo: com.android.foo -> libbar -> libupdated
+Removed dependencies:
+  libold(minSdkVersion:29)
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestParseEntry(t *testing.T) {
+	e := parseEntry("libfoo(minSdkVersion:(no version))")
+	if e.name != "libfoo" || e.minSdkVersion != "(no version)" {
+		t.Errorf("unexpected entry %#v", e)
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+)
+
+// cursorContext describes the syntactic context of a position in an Android.bp file.  It is
+// computed by scanning the text before the position rather than by parsing the file, so that it
+// works on files that are incomplete while they are being edited.
+type cursorContext struct {
+	// topLevel is true if the position is outside of any module definition or expression.
+	topLevel bool
+	// lineStart is true if the position is preceded only by whitespace and prefix on its line.
+	lineStart bool
+
+	// moduleType is the type of the module definition containing the position.
+	moduleType string
+	// propertyPath is the path of property names from the module to the property struct
+	// containing the position, e.g. ["target", "android"].
+	propertyPath []string
+	// property is the name of the property whose value contains the position.  It is empty if a
+	// property name is expected at the position.
+	property string
+
+	// inString is true if the position is inside a string literal.
+	inString bool
+	// prefix is the partial word or string contents before the position.
+	prefix string
+
+	// inSelectConditions is true if the position is inside the condition list of a select().
+	inSelectConditions bool
+	// inSelectPattern is true if the position is where a pattern of a select() case is expected,
+	// in which case selectCondition is the condition the pattern is matched against.
+	inSelectPattern bool
+	selectCondition string
+}
+
+type frameKind int
+
+const (
+	frameModule frameKind = iota
+	frameMap
+	frameList
+	frameParen
+	frameSelect
+)
+
+type frame struct {
+	kind frameKind
+	// name is the module type of a module, the name of the property of a map or select, or the
+	// name of the function called by a paren.
+	name string
+	// key is the most recent property name in a module or map.
+	key string
+	// inValue is true after the colon that follows a property name or select pattern.
+	inValue bool
+	// conditions are the functions called in the condition list of a select.
+	conditions []string
+	// commas is the number of commas seen in a paren.
+	commas int
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// contextAt returns the context of the byte offset in text.
+func contextAt(text string, offset int) cursorContext {
+	var stack []*frame
+	top := func() *frame {
+		if len(stack) == 0 {
+			return nil
+		}
+		return stack[len(stack)-1]
+	}
+	pop := func(kind frameKind) *frame {
+		// Tolerate mismatched brackets by popping up to the nearest frame of the right kind.
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].kind == kind || kind == frameMap && stack[i].kind != frameList && stack[i].kind != frameParen {
+				f := stack[i]
+				stack = stack[:i]
+				return f
+			}
+		}
+		return nil
+	}
+
+	var ctx cursorContext
+	lastWord := ""
+	lastWasWord := false
+
+	i := 0
+scan:
+	for i < offset {
+		c := text[i]
+		switch {
+		case c == '/' && i+1 < len(text) && text[i+1] == '/':
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 || i+end >= offset {
+				// Nothing to complete inside a comment.
+				return cursorContext{}
+			}
+			i += end
+			continue
+		case c == '/' && i+1 < len(text) && text[i+1] == '*':
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 || i+2+end+2 > offset {
+				return cursorContext{}
+			}
+			i += 2 + end + 2
+			continue
+		case c == '"' || c == '`':
+			j := i + 1
+			for j < offset && text[j] != c {
+				if c == '"' && text[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= offset {
+				ctx.inString = true
+				ctx.prefix = text[i+1 : offset]
+				break scan
+			}
+			i = j + 1
+			lastWasWord = false
+			continue
+		case isWordByte(c):
+			j := i
+			for j < offset && isWordByte(text[j]) {
+				j++
+			}
+			if j >= offset {
+				ctx.prefix = text[i:offset]
+				break scan
+			}
+			lastWord = text[i:j]
+			lastWasWord = true
+			i = j
+			continue
+		}
+
+		t := top()
+		switch c {
+		case ':':
+			if t != nil && (t.kind == frameModule || t.kind == frameMap) {
+				t.key = lastWord
+				t.inValue = true
+			} else if t != nil && t.kind == frameSelect {
+				t.inValue = true
+			}
+		case ',':
+			if t != nil && t.kind == frameParen {
+				t.commas++
+			} else if t != nil && t.kind != frameList {
+				t.inValue = false
+				t.key = ""
+			}
+		case '{':
+			switch {
+			case t == nil:
+				stack = append(stack, &frame{kind: frameModule, name: lastWord})
+			case t.kind == frameParen && t.name == "select":
+				stack = append(stack, &frame{kind: frameSelect, name: propertyOf(stack), conditions: t.conditions})
+			default:
+				stack = append(stack, &frame{kind: frameMap, name: propertyOf(stack)})
+			}
+		case '}':
+			pop(frameMap)
+		case '[':
+			stack = append(stack, &frame{kind: frameList})
+		case ']':
+			pop(frameList)
+		case '(':
+			name := ""
+			if lastWasWord {
+				name = lastWord
+				if s := selectConditionList(stack); s != nil {
+					s.conditions = append(s.conditions, name)
+				}
+			}
+			stack = append(stack, &frame{kind: frameParen, name: name})
+		case ')':
+			pop(frameParen)
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			lastWasWord = false
+		}
+		i++
+	}
+
+	lineStart := strings.LastIndexByte(text[:offset-len(ctx.prefix)], '\n') + 1
+	ctx.lineStart = strings.TrimSpace(text[lineStart:offset-len(ctx.prefix)]) == ""
+	if ctx.inString {
+		ctx.lineStart = false
+	}
+
+	t := top()
+	if t == nil {
+		ctx.topLevel = true
+		return ctx
+	}
+	for _, f := range stack {
+		switch f.kind {
+		case frameModule:
+			ctx.moduleType = f.name
+		case frameMap:
+			ctx.propertyPath = append(ctx.propertyPath, f.name)
+		}
+	}
+
+	switch t.kind {
+	case frameModule, frameMap:
+		if t.inValue {
+			ctx.property = t.key
+		}
+	case frameList:
+		ctx.property = propertyOf(stack)
+	case frameSelect:
+		if t.inValue {
+			ctx.property = t.name
+		} else {
+			ctx.inSelectPattern = true
+			if len(t.conditions) > 0 {
+				ctx.selectCondition = t.conditions[0]
+			}
+		}
+	case frameParen:
+		if selectConditionList(stack) != nil {
+			ctx.inSelectConditions = true
+			break
+		}
+		// A tuple of patterns in a select case, e.g. ("arm64", "android").
+		if len(stack) > 1 {
+			if s := stack[len(stack)-2]; s.kind == frameSelect && !s.inValue {
+				ctx.inSelectPattern = true
+				if t.commas < len(s.conditions) {
+					ctx.selectCondition = s.conditions[t.commas]
+				}
+				break
+			}
+		}
+		ctx.property = propertyOf(stack)
+	}
+	return ctx
+}
+
+// propertyOf returns the name of the property whose value the top of the stack is in.
+func propertyOf(stack []*frame) string {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch f := stack[i]; f.kind {
+		case frameModule, frameMap:
+			return f.key
+		case frameSelect:
+			return f.name
+		}
+	}
+	return ""
+}
+
+// selectConditionList returns the select() call if the top of the stack is in its condition list,
+// either directly or in a tuple of conditions, or nil.
+func selectConditionList(stack []*frame) *frame {
+	n := len(stack)
+	if n > 0 && stack[n-1].kind == frameParen && stack[n-1].name == "select" {
+		if stack[n-1].commas == 0 {
+			return stack[n-1]
+		}
+		return nil
+	}
+	if n > 1 && stack[n-1].kind == frameParen && stack[n-1].name == "" &&
+		stack[n-2].kind == frameParen && stack[n-2].name == "select" && stack[n-2].commas == 0 {
+		return stack[n-2]
+	}
+	return nil
+}
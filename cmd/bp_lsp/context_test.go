@@ -0,0 +1,131 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContextAt(t *testing.T) {
+	testCases := []struct {
+		name string
+		// text is the contents of the file, with | marking the position.
+		text string
+		want cursorContext
+	}{
+		{
+			name: "module type",
+			text: "cc_|",
+			want: cursorContext{topLevel: true, lineStart: true, prefix: "cc_"},
+		},
+		{
+			name: "after module",
+			text: "cc_library {\n    name: \"libfoo\",\n}\n\nja|",
+			want: cursorContext{topLevel: true, lineStart: true, prefix: "ja"},
+		},
+		{
+			name: "assignment value",
+			text: "x = ja|",
+			want: cursorContext{topLevel: true, prefix: "ja"},
+		},
+		{
+			name: "property name",
+			text: "cc_library {\n    name: \"libfoo\",\n    sr|\n}",
+			want: cursorContext{moduleType: "cc_library", lineStart: true, prefix: "sr"},
+		},
+		{
+			name: "nested property name",
+			text: "cc_library {\n    target: {\n        android: {\n            cf|",
+			want: cursorContext{moduleType: "cc_library", propertyPath: []string{"target", "android"},
+				lineStart: true, prefix: "cf"},
+		},
+		{
+			name: "property value",
+			text: "cc_library {\n    enabled: t|",
+			want: cursorContext{moduleType: "cc_library", property: "enabled", prefix: "t"},
+		},
+		{
+			name: "string in list",
+			text: "cc_library {\n    shared_libs: [\"libbar\", \"libb|\"],\n}",
+			want: cursorContext{moduleType: "cc_library", property: "shared_libs", inString: true, prefix: "libb"},
+		},
+		{
+			name: "escaped quote",
+			text: `cc_library { cflags: ["-DX=\"a\"", "-D|`,
+			want: cursorContext{moduleType: "cc_library", property: "cflags", inString: true, prefix: "-D"},
+		},
+		{
+			name: "comment",
+			text: "cc_library {\n    // sr|",
+			want: cursorContext{},
+		},
+		{
+			name: "after comment",
+			text: "cc_library {\n    /* name: { */ sr|",
+			want: cursorContext{moduleType: "cc_library", prefix: "sr"},
+		},
+		{
+			name: "select conditions",
+			text: "cc_library {\n    srcs: select(ar|",
+			want: cursorContext{moduleType: "cc_library", inSelectConditions: true, prefix: "ar"},
+		},
+		{
+			name: "select condition tuple",
+			text: "cc_library {\n    srcs: select((arch(), o|",
+			want: cursorContext{moduleType: "cc_library", inSelectConditions: true, prefix: "o"},
+		},
+		{
+			name: "select pattern",
+			text: "cc_library {\n    srcs: select(arch(), {\n        \"arm|",
+			want: cursorContext{moduleType: "cc_library", inSelectPattern: true, selectCondition: "arch",
+				inString: true, prefix: "arm"},
+		},
+		{
+			name: "select tuple pattern",
+			text: "cc_library {\n    srcs: select((arch(), os()), {\n        (\"arm64\", \"l|",
+			want: cursorContext{moduleType: "cc_library", inSelectPattern: true, selectCondition: "os",
+				inString: true, prefix: "l"},
+		},
+		{
+			name: "select value",
+			text: "cc_library {\n    srcs: select(arch(), {\n        \"arm\": [\":gen|",
+			want: cursorContext{moduleType: "cc_library", property: "srcs", inString: true, prefix: ":gen"},
+		},
+		{
+			name: "after select",
+			text: "cc_library {\n    srcs: select(arch(), {\n        default: [],\n    }),\n    sh|",
+			want: cursorContext{moduleType: "cc_library", lineStart: true, prefix: "sh"},
+		},
+		{
+			name: "map in select",
+			text: "cc_library {\n    target: select(os(), {\n        \"android\": {\n            cf|",
+			want: cursorContext{moduleType: "cc_library", propertyPath: []string{"target"},
+				lineStart: true, prefix: "cf"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			offset := strings.Index(tc.text, "|")
+			text := tc.text[:offset] + tc.text[offset+1:]
+			got := contextAt(text, offset)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want:\n%+v\ngot:\n%+v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/scanner"
+
+	"github.com/google/blueprint/parser"
+)
+
+// dynamicProperties are property structs whose contents are created per architecture, product
+// variable or soong config variable, so their nested property names aren't checked.
+var dynamicProperties = map[string]bool{
+	"arch":                   true,
+	"codegen":                true,
+	"multilib":               true,
+	"product_variables":      true,
+	"soong_config_variables": true,
+	"target":                 true,
+}
+
+// diagnose returns the syntax errors in an Android.bp file, and the unknown module types,
+// unknown properties and mismatched property types if the module type docs are available.  It
+// doesn't evaluate variables or selects, and doesn't need a build.
+func (s *server) diagnose(path, text string) []diagnostic {
+	diags := []diagnostic{}
+	report := func(pos scanner.Position, length int, severity int, format string, args ...interface{}) {
+		diags = append(diags, diagnostic{
+			Range: lspRange{
+				Start: offsetToPosition(text, pos.Offset),
+				End:   offsetToPosition(text, pos.Offset+length),
+			},
+			Severity: severity,
+			Source:   "bp_lsp",
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	file, errs := parser.Parse(path, strings.NewReader(text))
+	for _, err := range errs {
+		if parseErr, ok := err.(*parser.ParseError); ok {
+			report(parseErr.Pos, 0, diagnosticSeverityError, "%s", parseErr.Err)
+		} else {
+			report(scanner.Position{}, 0, diagnosticSeverityError, "%s", err)
+		}
+	}
+	if file == nil || s.docs == nil {
+		return diags
+	}
+
+	customModuleTypes := customModuleTypes(file)
+	for _, def := range file.Defs {
+		module, ok := def.(*parser.Module)
+		if !ok {
+			continue
+		}
+		if _, ok := s.docs.moduleTypes[module.Type]; !ok {
+			if !customModuleTypes[module.Type] {
+				report(module.TypePos, len(module.Type), diagnosticSeverityWarning,
+					"unrecognized module type %q", module.Type)
+			}
+			continue
+		}
+		s.checkProperties(module.Type, nil, module.Properties, report)
+	}
+	return diags
+}
+
+// checkProperties reports properties of a module, or of a property struct at path in a module,
+// that aren't known or have the wrong type of value.
+func (s *server) checkProperties(moduleType string, path []string, props []*parser.Property,
+	report func(pos scanner.Position, length int, severity int, format string, args ...interface{})) {
+
+	propDocs, ok := s.docs.properties(moduleType, path)
+	if !ok {
+		return
+	}
+	for _, prop := range props {
+		propPath := append(append([]string(nil), path...), prop.Name)
+		doc := findProperty(propDocs, prop.Name)
+		if doc == nil {
+			report(prop.NamePos, len(prop.Name), diagnosticSeverityError,
+				"unrecognized property %q", strings.Join(propPath, "."))
+			continue
+		}
+		expected, found := doc.kind(), valueKind(prop.Value)
+		if expected != kindUnknown && found != kindUnknown && expected != found {
+			report(prop.NamePos, len(prop.Name), diagnosticSeverityError,
+				"can't assign %s value to %s property %q", found, expected, strings.Join(propPath, "."))
+			continue
+		}
+		if m, ok := prop.Value.(*parser.Map); ok && !dynamicProperties[prop.Name] {
+			s.checkProperties(moduleType, propPath, m.Properties, report)
+		}
+	}
+}
+
+// valueKind returns the kind of a literal value, or kindUnknown for expressions like variables,
+// operators and selects that need to be evaluated.
+func valueKind(value parser.Expression) string {
+	switch value.(type) {
+	case *parser.Bool:
+		return kindBool
+	case *parser.Int64:
+		return kindInt
+	case *parser.String:
+		return kindString
+	case *parser.List:
+		return kindList
+	case *parser.Map:
+		return kindMap
+	default:
+		return kindUnknown
+	}
+}
+
+// customModuleTypes returns the module types defined or imported by soong_config_module_type and
+// soong_config_module_type_import in the file, which don't have docs.
+func customModuleTypes(file *parser.File) map[string]bool {
+	ret := make(map[string]bool)
+	for _, def := range file.Defs {
+		module, ok := def.(*parser.Module)
+		if !ok {
+			continue
+		}
+		switch module.Type {
+		case "soong_config_module_type":
+			ret[moduleName(module)] = true
+		case "soong_config_module_type_import":
+			for _, prop := range module.Properties {
+				if list, ok := prop.Value.(*parser.List); ok && prop.Name == "module_types" {
+					for _, v := range list.Values {
+						if s, ok := v.(*parser.String); ok {
+							ret[s.Value] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,202 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// moduleTypeDocs is the documentation of a module type as written to soong_build.json by
+// soong_build --soong_docs.
+type moduleTypeDocs struct {
+	Name       string
+	Package    string
+	Text       string
+	Properties []*propertyDocs
+}
+
+type propertyDocs struct {
+	Name       string
+	OtherNames []string
+	Type       string
+	Text       string
+	Default    string
+	Properties []*propertyDocs
+}
+
+// docs is the documentation of all module types.
+type docs struct {
+	moduleTypes map[string]*moduleTypeDocs
+}
+
+func loadDocs(r io.Reader) (*docs, error) {
+	var moduleTypes []*moduleTypeDocs
+	if err := json.NewDecoder(r).Decode(&moduleTypes); err != nil {
+		return nil, fmt.Errorf("failed to parse module type docs: %w", err)
+	}
+	d := &docs{moduleTypes: make(map[string]*moduleTypeDocs, len(moduleTypes))}
+	for _, m := range moduleTypes {
+		d.moduleTypes[m.Name] = m
+	}
+	return d, nil
+}
+
+// sortedModuleTypes returns the module types sorted by name.
+func (d *docs) sortedModuleTypes() []*moduleTypeDocs {
+	ret := make([]*moduleTypeDocs, 0, len(d.moduleTypes))
+	for _, m := range d.moduleTypes {
+		ret = append(ret, m)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+// properties returns the properties of the property struct at path in a module type, and false
+// if the module type or the path is unknown.
+func (d *docs) properties(moduleType string, path []string) ([]*propertyDocs, bool) {
+	m := d.moduleTypes[moduleType]
+	if m == nil {
+		return nil, false
+	}
+	props := m.Properties
+	for _, name := range path {
+		prop := findProperty(props, name)
+		if prop == nil || len(prop.Properties) == 0 {
+			return nil, false
+		}
+		props = prop.Properties
+	}
+	return props, true
+}
+
+// property returns the property at path in a module type, or nil if it is unknown.
+func (d *docs) property(moduleType string, path []string) *propertyDocs {
+	if len(path) == 0 {
+		return nil
+	}
+	props, ok := d.properties(moduleType, path[:len(path)-1])
+	if !ok {
+		return nil
+	}
+	return findProperty(props, path[len(path)-1])
+}
+
+func findProperty(props []*propertyDocs, name string) *propertyDocs {
+	for _, prop := range props {
+		if prop.Name == name {
+			return prop
+		}
+		for _, otherName := range prop.OtherNames {
+			if otherName == name {
+				return prop
+			}
+		}
+	}
+	return nil
+}
+
+// Value kinds of properties, matching the Android.bp expression types.
+const (
+	kindUnknown = ""
+	kindBool    = "bool"
+	kindInt     = "int64"
+	kindString  = "string"
+	kindList    = "list"
+	kindMap     = "map"
+)
+
+// kind returns the kind of value that can be assigned to the property.
+func (p *propertyDocs) kind() string {
+	if len(p.Properties) > 0 {
+		return kindMap
+	}
+	t := strings.TrimPrefix(p.Type, "configurable ")
+	switch {
+	case strings.HasPrefix(t, "list of "):
+		return kindList
+	case t == "bool":
+		return kindBool
+	case t == "string":
+		return kindString
+	case t == "int64" || t == "int":
+		return kindInt
+	default:
+		return kindUnknown
+	}
+}
+
+var htmlTagRegexp = regexp.MustCompile(`<[^>]*>`)
+
+// plainText converts the HTML documentation from bpdoc to plain text.
+func plainText(s string) string {
+	return strings.TrimSpace(html.UnescapeString(htmlTagRegexp.ReplaceAllString(s, "")))
+}
+
+// markdown returns the documentation of the module type for hover and completion.
+func (m *moduleTypeDocs) markdown() string {
+	ret := fmt.Sprintf("**%s** (package %s)", m.Name, m.Package)
+	if text := plainText(m.Text); text != "" {
+		ret += "\n\n" + text
+	}
+	return ret
+}
+
+// markdown returns the documentation of the property for hover and completion.
+func (p *propertyDocs) markdown() string {
+	ret := "**" + p.Name + "**"
+	if p.Type != "" {
+		ret += " _" + p.Type + "_"
+	}
+	if text := plainText(p.Text); text != "" {
+		ret += "\n\n" + text
+	}
+	if p.Default != "" {
+		ret += "\n\nDefault: " + p.Default
+	}
+	return ret
+}
+
+// selectConditions are the functions that can be used as conditions in select().
+var selectConditions = []struct {
+	name       string
+	insertText string
+	doc        string
+}{
+	{"arch", "arch()", "The architecture of the variant."},
+	{"os", "os()", "The operating system of the variant."},
+	{"product_variable", `product_variable("")`, "A product variable that is supported in selects."},
+	{"release_flag", `release_flag("")`, "A release flag from the release config."},
+	{"soong_config_variable", `soong_config_variable("", "")`, "A soong config variable, given its namespace and name."},
+}
+
+// selectPatterns returns the values that are commonly used as patterns for a select() condition.
+func selectPatterns(condition string) []string {
+	switch condition {
+	case "arch":
+		return []string{`"arm"`, `"arm64"`, `"riscv64"`, `"x86"`, `"x86_64"`}
+	case "os":
+		return []string{`"android"`, `"darwin"`, `"linux_bionic"`, `"linux_glibc"`, `"linux_musl"`, `"windows"`}
+	case "product_variable", "release_flag", "soong_config_variable":
+		return []string{"true", "false"}
+	default:
+		return nil
+	}
+}
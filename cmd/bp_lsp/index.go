@@ -0,0 +1,151 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/blueprint/parser"
+)
+
+// moduleInfo is what the module graph records about a module name.
+type moduleInfo struct {
+	Type string
+	// Blueprint is the path of the Android.bp file that defines the module relative to the root
+	// of the source tree.
+	Blueprint string
+}
+
+// moduleIndex maps module names to the Android.bp files that define them, read from the module
+// graph written by soong_build --module_graph_file.
+type moduleIndex struct {
+	modules map[string]moduleInfo
+	names   []string
+}
+
+// loadModuleIndex reads the module graph one module at a time, as it can be too large to load
+// into memory at once.
+func loadModuleIndex(r io.Reader) (*moduleIndex, error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, fmt.Errorf("failed to parse module graph: expected a JSON array")
+	}
+	index := &moduleIndex{modules: make(map[string]moduleInfo)}
+	for dec.More() {
+		var m struct {
+			Name string
+			moduleInfo
+		}
+		if err := dec.Decode(&m); err != nil {
+			return nil, fmt.Errorf("failed to parse module graph: %w", err)
+		}
+		if _, exists := index.modules[m.Name]; !exists {
+			index.modules[m.Name] = m.moduleInfo
+			index.names = append(index.names, m.Name)
+		}
+	}
+	sort.Strings(index.names)
+	return index, nil
+}
+
+// moduleReferenceName returns the name of the module referenced by a string in a property value,
+// e.g. libfoo for ":libfoo{.tag}" or "//external/foo:libfoo".
+func moduleReferenceName(s string) string {
+	if i := strings.IndexByte(s, '{'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		s = s[i+1:]
+	}
+	return s
+}
+
+var moduleNameRegexp = regexp.MustCompile(`(?m)^\s*name\s*:\s*"([^"]+)"`)
+
+// documentModuleNames returns the names of the modules defined in the text of an Android.bp file
+// without parsing it, so that it works while the file is being edited.
+func documentModuleNames(text string) []string {
+	var ret []string
+	for _, match := range moduleNameRegexp.FindAllStringSubmatch(text, -1) {
+		ret = append(ret, match[1])
+	}
+	return ret
+}
+
+// findModule returns the locations of the definitions of a module.  It searches the open
+// documents and the Android.bp files that define the module according to the module graph.
+func (s *server) findModule(name string) []location {
+	var files []string
+	seen := make(map[string]bool)
+	addFile := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	for _, uri := range s.sortedDocumentURIs() {
+		addFile(uriToPath(uri))
+	}
+	if s.modules != nil {
+		if m, ok := s.modules.modules[name]; ok {
+			addFile(filepath.Join(s.root, m.Blueprint))
+		}
+	}
+
+	var ret []location
+	for _, path := range files {
+		text, ok := s.documents[pathToURI(path)]
+		if !ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			text = string(data)
+		}
+		file, _ := parser.Parse(path, strings.NewReader(text))
+		if file == nil {
+			continue
+		}
+		for _, def := range file.Defs {
+			module, ok := def.(*parser.Module)
+			if !ok || moduleName(module) != name {
+				continue
+			}
+			start := offsetToPosition(text, module.TypePos.Offset)
+			end := offsetToPosition(text, module.TypePos.Offset+len(module.Type))
+			ret = append(ret, location{URI: pathToURI(path), Range: lspRange{start, end}})
+		}
+	}
+	return ret
+}
+
+// moduleName returns the value of the name property of a module definition.
+func moduleName(module *parser.Module) string {
+	for _, prop := range module.Properties {
+		if prop.Name == "name" {
+			if s, ok := prop.Value.(*parser.String); ok {
+				return s.Value
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// bp_lsp is a language server for Android.bp files.  It provides completion of module types,
+// properties and select() conditions, hover docs, go to definition of referenced modules, and
+// diagnostics from parsing and type checking Android.bp files without running a build.
+//
+// The module type docs are read from soong_build.json, generated by `m soong_docs`, and module
+// definitions are found through the module graph generated by `m json-module-graph`.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+var (
+	docsFile        = flag.String("docs", "", "path to soong_build.json, defaults to $OUT_DIR/soong/docs/soong_build.json")
+	moduleGraphFile = flag.String("module_graph", "", "path to module-graph.json, defaults to $OUT_DIR/soong/module-graph.json")
+	logFile         = flag.String("log", "", "write logs to this file instead of stderr")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: bp_lsp [options]\n\n")
+	fmt.Fprintf(os.Stderr, "Serves the language server protocol for Android.bp files on stdin and stdout.\n\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 0 {
+		usage()
+	}
+
+	var logOutput io.Writer = os.Stderr
+	if *logFile != "" {
+		f, err := os.Create(*logFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		logOutput = f
+	}
+
+	s := newServer(os.Stdout, log.New(logOutput, "bp_lsp: ", log.LstdFlags))
+	s.docsFile = *docsFile
+	s.moduleGraphFile = *moduleGraphFile
+	os.Exit(s.run(os.Stdin))
+}
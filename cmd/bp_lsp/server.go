@@ -0,0 +1,510 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxModuleCompletions limits the number of module names returned for a completion request, the
+// client asks again as more of the name is typed.
+const maxModuleCompletions = 200
+
+type server struct {
+	// root is the root of the source tree, module graph paths are relative to it.
+	root string
+
+	// docsFile and moduleGraphFile override the default locations of soong_build.json and
+	// module-graph.json in the out directory.
+	docsFile        string
+	moduleGraphFile string
+
+	docs    *docs
+	modules *moduleIndex
+
+	// documents are the texts of the open documents by URI.
+	documents map[string]string
+
+	out          io.Writer
+	log          *log.Logger
+	shutdownSeen bool
+}
+
+func newServer(out io.Writer, logger *log.Logger) *server {
+	return &server{
+		documents: make(map[string]string),
+		out:       out,
+		log:       logger,
+	}
+}
+
+// run handles messages from in until the client sends exit, and returns the exit code.
+func (s *server) run(in io.Reader) int {
+	r := bufio.NewReader(in)
+	for {
+		data, err := readMessage(r)
+		if err != nil {
+			if err != io.EOF {
+				s.log.Printf("failed to read message: %s", err)
+			}
+			return 1
+		}
+		var req request
+		if err := json.Unmarshal(data, &req); err != nil {
+			s.reply(nil, nil, &responseError{errorCodeParseError, err.Error()})
+			continue
+		}
+		if req.Method == "exit" {
+			if s.shutdownSeen {
+				return 0
+			}
+			return 1
+		}
+		result, err := s.handle(&req)
+		if req.isNotification() {
+			if err != nil {
+				s.log.Printf("%s: %s", req.Method, err)
+			}
+			continue
+		}
+		s.reply(req.ID, result, err)
+	}
+}
+
+func (s *server) reply(id json.RawMessage, result interface{}, err error) {
+	var msg interface{} = resultResponse{JSONRPC: "2.0", ID: id, Result: result}
+	if err != nil {
+		var respErr *responseError
+		if !errors.As(err, &respErr) {
+			respErr = &responseError{errorCodeInvalidParams, err.Error()}
+		}
+		if id == nil {
+			id = json.RawMessage("null")
+		}
+		msg = errorResponse{JSONRPC: "2.0", ID: id, Error: respErr}
+	}
+	if err := writeMessage(s.out, msg); err != nil {
+		s.log.Printf("failed to write response: %s", err)
+	}
+}
+
+func (s *server) notify(method string, params interface{}) {
+	if err := writeMessage(s.out, notification{JSONRPC: "2.0", Method: method, Params: params}); err != nil {
+		s.log.Printf("failed to write notification: %s", err)
+	}
+}
+
+func (s *server) handle(req *request) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.initialize(params), nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdownSeen = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		s.documents[params.TextDocument.URI] = params.TextDocument.Text
+		s.publishDiagnostics(params.TextDocument.URI)
+		return nil, nil
+	case "textDocument/didChange":
+		var params didChangeTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			s.documents[params.TextDocument.URI] = params.ContentChanges[n-1].Text
+			s.publishDiagnostics(params.TextDocument.URI)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params didCloseTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []diagnostic{},
+		})
+		return nil, nil
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.completion(params), nil
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if h := s.hover(params); h != nil {
+			return h, nil
+		}
+		return nil, nil
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if locations := s.definition(params); len(locations) > 0 {
+			return locations, nil
+		}
+		return nil, nil
+	default:
+		if req.isNotification() {
+			return nil, nil
+		}
+		return nil, &responseError{errorCodeMethodNotFound, "method not found: " + req.Method}
+	}
+}
+
+// initialize loads the module type docs and the module graph from the out directory of the
+// source tree being edited.  Both are optional, without them only syntax errors are reported.
+func (s *server) initialize(params initializeParams) *initializeResult {
+	s.root = params.RootPath
+	if params.RootURI != "" {
+		s.root = uriToPath(params.RootURI)
+	}
+	if s.root == "" {
+		s.root, _ = os.Getwd()
+	}
+
+	outDir := os.Getenv("OUT_DIR")
+	if outDir == "" {
+		outDir = "out"
+	}
+	if !filepath.IsAbs(outDir) {
+		outDir = filepath.Join(s.root, outDir)
+	}
+	if s.docsFile == "" {
+		s.docsFile = filepath.Join(outDir, "soong", "docs", "soong_build.json")
+	}
+	if s.moduleGraphFile == "" {
+		s.moduleGraphFile = filepath.Join(outDir, "soong", "module-graph.json")
+	}
+
+	if f, err := os.Open(s.docsFile); err == nil {
+		s.docs, err = loadDocs(f)
+		f.Close()
+		if err != nil {
+			s.log.Printf("%s: %s", s.docsFile, err)
+		}
+	} else {
+		s.log.Printf("module type docs not available, run `m soong_docs` to generate %s", s.docsFile)
+	}
+	if f, err := os.Open(s.moduleGraphFile); err == nil {
+		s.modules, err = loadModuleIndex(f)
+		f.Close()
+		if err != nil {
+			s.log.Printf("%s: %s", s.moduleGraphFile, err)
+		}
+	} else {
+		s.log.Printf("module graph not available, run `m json-module-graph` to generate %s", s.moduleGraphFile)
+	}
+
+	result := &initializeResult{
+		Capabilities: serverCapabilities{
+			TextDocumentSync: textDocumentSyncKindFull,
+			CompletionProvider: &completionOptions{
+				TriggerCharacters: []string{":", "\"", "("},
+			},
+			HoverProvider:      true,
+			DefinitionProvider: true,
+		},
+	}
+	result.ServerInfo.Name = "bp_lsp"
+	return result
+}
+
+func (s *server) publishDiagnostics(uri string) {
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: s.diagnose(uriToPath(uri), s.documents[uri]),
+	})
+}
+
+func (s *server) sortedDocumentURIs() []string {
+	ret := make([]string, 0, len(s.documents))
+	for uri := range s.documents {
+		ret = append(ret, uri)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (s *server) completion(params textDocumentPositionParams) *completionList {
+	list := &completionList{Items: []completionItem{}}
+	text, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return list
+	}
+	ctx := contextAt(text, positionToOffset(text, params.Position))
+	add := func(item completionItem) {
+		if strings.HasPrefix(item.Label, ctx.prefix) || strings.HasPrefix(item.InsertText, ctx.prefix) {
+			list.Items = append(list.Items, item)
+		}
+	}
+
+	switch {
+	case ctx.topLevel:
+		if ctx.lineStart && s.docs != nil {
+			for _, m := range s.docs.sortedModuleTypes() {
+				add(completionItem{
+					Label:         m.Name,
+					Kind:          completionItemKindClass,
+					Detail:        m.Package,
+					Documentation: &markupContent{"markdown", m.markdown()},
+				})
+			}
+		}
+	case ctx.inSelectConditions:
+		if !ctx.inString {
+			for _, c := range selectConditions {
+				add(completionItem{
+					Label:         c.name,
+					Kind:          completionItemKindFunction,
+					Documentation: &markupContent{"markdown", c.doc},
+					InsertText:    c.insertText,
+				})
+			}
+		}
+	case ctx.inSelectPattern:
+		for _, p := range selectPatterns(ctx.selectCondition) {
+			if ctx.inString {
+				if !strings.HasPrefix(p, `"`) {
+					continue
+				}
+				p = strings.Trim(p, `"`)
+			}
+			add(completionItem{Label: p, Kind: completionItemKindValue})
+		}
+		if !ctx.inString {
+			add(completionItem{Label: "default", Kind: completionItemKindKeyword})
+			add(completionItem{Label: "any", Kind: completionItemKindKeyword})
+		}
+	case ctx.property == "":
+		if ctx.inString || s.docs == nil {
+			break
+		}
+		props, _ := s.docs.properties(ctx.moduleType, ctx.propertyPath)
+		for _, p := range props {
+			add(completionItem{
+				Label:         p.Name,
+				Kind:          completionItemKindProperty,
+				Detail:        p.Type,
+				Documentation: &markupContent{"markdown", p.markdown()},
+				InsertText:    p.Name + ": ",
+			})
+		}
+	case ctx.inString:
+		if !strings.HasPrefix(ctx.prefix, ":") && !isModuleReferenceProperty(ctx.property) {
+			break
+		}
+		ctx.prefix = strings.TrimPrefix(ctx.prefix, ":")
+		for _, name := range s.moduleNames(params.TextDocument.URI) {
+			if len(list.Items) == maxModuleCompletions {
+				list.IsIncomplete = true
+				break
+			}
+			add(completionItem{Label: name, Kind: completionItemKindModule})
+		}
+	default:
+		if s.docs != nil {
+			if p := s.docs.property(ctx.moduleType, append(ctx.propertyPath, ctx.property)); p != nil && p.kind() == kindBool {
+				add(completionItem{Label: "true", Kind: completionItemKindValue})
+				add(completionItem{Label: "false", Kind: completionItemKindValue})
+			}
+		}
+		add(completionItem{
+			Label:      "select",
+			Kind:       completionItemKindKeyword,
+			Detail:     "select(condition, { pattern: value, ... })",
+			InsertText: "select(",
+		})
+	}
+	return list
+}
+
+// isModuleReferenceProperty returns true for properties whose values are usually module names.
+func isModuleReferenceProperty(name string) bool {
+	for _, suffix := range []string{"deps", "libs", "defaults", "required", "overrides"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleNames returns the names of the modules in the module graph and in the document, sorted
+// and deduplicated.
+func (s *server) moduleNames(uri string) []string {
+	names := documentModuleNames(s.documents[uri])
+	if s.modules != nil {
+		names = append(names, s.modules.names...)
+	}
+	sort.Strings(names)
+	ret := names[:0]
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+func (s *server) hover(params textDocumentPositionParams) *hover {
+	text, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	offset := positionToOffset(text, params.Position)
+	markdown := func(value string) *hover {
+		return &hover{Contents: markupContent{"markdown", value}}
+	}
+
+	if str, ok := stringAt(text, offset); ok {
+		name := moduleReferenceName(str)
+		if s.modules != nil {
+			if m, ok := s.modules.modules[name]; ok {
+				return markdown("**" + name + "** _" + m.Type + "_\n\nDefined in " + m.Blueprint)
+			}
+		}
+		return nil
+	}
+
+	start, end := offset, offset
+	for start > 0 && isWordByte(text[start-1]) {
+		start--
+	}
+	for end < len(text) && isWordByte(text[end]) {
+		end++
+	}
+	word := text[start:end]
+	if word == "" {
+		return nil
+	}
+	ctx := contextAt(text, start)
+	switch {
+	case ctx.inSelectConditions:
+		for _, c := range selectConditions {
+			if c.name == word {
+				return markdown("**" + c.name + "**\n\n" + c.doc)
+			}
+		}
+	case ctx.topLevel:
+		if s.docs != nil && s.docs.moduleTypes[word] != nil {
+			return markdown(s.docs.moduleTypes[word].markdown())
+		}
+	case ctx.property == "" && !ctx.inSelectPattern:
+		if s.docs != nil {
+			if p := s.docs.property(ctx.moduleType, append(ctx.propertyPath, word)); p != nil {
+				return markdown(p.markdown())
+			}
+		}
+	}
+	return nil
+}
+
+func (s *server) definition(params textDocumentPositionParams) []location {
+	text, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	str, ok := stringAt(text, positionToOffset(text, params.Position))
+	if !ok {
+		return nil
+	}
+	name := moduleReferenceName(str)
+	if name == "" {
+		return nil
+	}
+	return s.findModule(name)
+}
+
+// stringAt returns the contents of the string literal containing the offset, if any.
+func stringAt(text string, offset int) (string, bool) {
+	ctx := contextAt(text, offset)
+	if !ctx.inString {
+		return "", false
+	}
+	end := strings.IndexAny(text[offset:], "\"\n")
+	if end < 0 {
+		end = len(text) - offset
+	}
+	return ctx.prefix + text[offset:offset+end], true
+}
+
+// positionToOffset converts an LSP position, which counts UTF-16 code units, to a byte offset.
+func positionToOffset(text string, pos position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+	for units := 0; units < pos.Character && offset < len(text) && text[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		units += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+	return offset
+}
+
+// offsetToPosition converts a byte offset to an LSP position, which counts UTF-16 code units.
+func offsetToPosition(text string, offset int) position {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	lineStart := strings.LastIndexByte(text[:offset], '\n') + 1
+	return position{
+		Line:      strings.Count(text[:lineStart], "\n"),
+		Character: len(utf16.Encode([]rune(text[lineStart:offset]))),
+	}
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return u.Path
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
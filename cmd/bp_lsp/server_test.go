@@ -0,0 +1,506 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDocs = `[
+  {
+    "Name": "cc_library",
+    "Package": "cc",
+    "Text": "<p>cc_library creates both static and shared libraries.</p>",
+    "Properties": [
+      {"Name": "name", "Type": "string", "Text": "The name of the module."},
+      {"Name": "srcs", "Type": "list of string", "Text": "The source files."},
+      {"Name": "shared_libs", "Type": "list of string", "Text": "Shared libraries to link against."},
+      {"Name": "enabled", "Type": "configurable bool", "Text": "Whether the module is enabled.", "Default": "true"},
+      {"Name": "stl", "Type": "string", "Text": "The C++ standard library."},
+      {"Name": "target", "Type": "", "Text": "", "Properties": [
+        {"Name": "android", "Type": "", "Text": "", "Properties": [
+          {"Name": "cflags", "Type": "list of string", "Text": "Flags for android."}
+        ]}
+      ]},
+      {"Name": "sanitize", "Type": "", "Text": "", "Properties": [
+        {"Name": "address", "Type": "bool", "Text": "Enable AddressSanitizer."}
+      ]}
+    ]
+  },
+  {
+    "Name": "soong_config_module_type",
+    "Package": "android",
+    "Text": "soong_config_module_type defines module types with soong config variables.",
+    "Properties": [
+      {"Name": "name", "Type": "string", "Text": "The name of the module type."}
+    ]
+  },
+  {
+    "Name": "java_library",
+    "Package": "java",
+    "Text": "java_library builds a jar.",
+    "Properties": [
+      {"Name": "name", "Type": "string", "Text": "The name of the module."},
+      {"Name": "static_libs", "Type": "list of string", "Text": "Static libraries."}
+    ]
+  }
+]`
+
+const testModuleGraph = `[
+  {"Name": "libbar", "Variant": "android_arm64_shared", "Type": "cc_library", "Blueprint": "external/bar/Android.bp", "Deps": []},
+  {"Name": "libbar", "Variant": "android_arm64_static", "Type": "cc_library", "Blueprint": "external/bar/Android.bp", "Deps": []},
+  {"Name": "libbaz", "Variant": "android_arm64_shared", "Type": "cc_library", "Blueprint": "external/baz/Android.bp", "Deps": []}
+]`
+
+const testBarBlueprint = `cc_defaults {
+    name: "bar_defaults",
+}
+
+cc_library {
+    name: "libbar",
+    defaults: ["bar_defaults"],
+}
+`
+
+func newTestServer(t *testing.T) (*server, *bytes.Buffer) {
+	t.Helper()
+	root := t.TempDir()
+	writeFile := func(path, contents string) string {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0666); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	writeFile("out/soong/docs/soong_build.json", testDocs)
+	writeFile("out/soong/module-graph.json", testModuleGraph)
+	writeFile("external/bar/Android.bp", testBarBlueprint)
+
+	out := &bytes.Buffer{}
+	s := newServer(out, log.New(io.Discard, "", 0))
+	t.Setenv("OUT_DIR", "")
+	s.initialize(initializeParams{RootURI: pathToURI(root)})
+	if s.docs == nil || s.modules == nil {
+		t.Fatal("failed to load docs and module graph")
+	}
+	return s, out
+}
+
+// openTestDocument opens a document whose text contains | to mark a position, and returns the
+// parameters for a request at that position.
+func openTestDocument(s *server, text string) textDocumentPositionParams {
+	uri := pathToURI(filepath.Join(s.root, "foo", "Android.bp"))
+	offset := strings.Index(text, "|")
+	if offset >= 0 {
+		text = text[:offset] + text[offset+1:]
+	} else {
+		offset = 0
+	}
+	s.documents[uri] = text
+	return textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: uri},
+		Position:     offsetToPosition(text, offset),
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	testCases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "valid",
+			text: `
+soong_config_module_type {
+    name: "custom_cc_library",
+}
+
+custom_cc_library {
+    name: "libcustom",
+}
+
+cc_library {
+    name: "libfoo",
+    srcs: ["foo.c"] + ["bar.c"],
+    enabled: select(arch(), {
+        "arm": false,
+        default: true,
+    }),
+    target: {
+        android: {
+            cflags: ["-DANDROID"],
+        },
+        // Architecture specific properties aren't checked.
+        android_arm64: {
+            cflags: ["-DARM64"],
+        },
+    },
+}`,
+		},
+		{
+			name: "unknown module type",
+			text: `cc_libary {
+    name: "libfoo",
+}`,
+			want: []string{`1:1-1:10 warning: unrecognized module type "cc_libary"`},
+		},
+		{
+			name: "unknown properties",
+			text: `cc_library {
+    name: "libfoo",
+    src: ["foo.c"],
+    sanitize: {
+        adress: true,
+    },
+}`,
+			want: []string{
+				`3:5-3:8 error: unrecognized property "src"`,
+				`5:9-5:15 error: unrecognized property "sanitize.adress"`,
+			},
+		},
+		{
+			name: "wrong types",
+			text: `cc_library {
+    name: ["libfoo"],
+    enabled: "true",
+    sanitize: true,
+}`,
+			want: []string{
+				`2:5-2:9 error: can't assign list value to string property "name"`,
+				`3:5-3:12 error: can't assign string value to bool property "enabled"`,
+				`4:5-4:13 error: can't assign bool value to map property "sanitize"`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, d := range s.diagnose("Android.bp", tc.text) {
+				severity := "error"
+				if d.Severity == diagnosticSeverityWarning {
+					severity = "warning"
+				}
+				got = append(got, formatRange(d.Range)+" "+severity+": "+d.Message)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want:\n%s\ngot:\n%s", strings.Join(tc.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestSyntaxErrorDiagnostics(t *testing.T) {
+	s, _ := newTestServer(t)
+	diags := s.diagnose("Android.bp", "cc_library {\n    name: \"libfoo\"\n    srcs: [],\n}\n")
+	if len(diags) == 0 {
+		t.Fatal("expected a syntax error")
+	}
+	if d := diags[0]; d.Severity != diagnosticSeverityError || d.Range.Start.Line != 2 {
+		t.Errorf("expected an error on line 3, got %+v", d)
+	}
+}
+
+func formatRange(r lspRange) string {
+	pos := func(p position) string {
+		return strings.Join([]string{itoa(p.Line + 1), itoa(p.Character + 1)}, ":")
+	}
+	return pos(r.Start) + "-" + pos(r.End)
+}
+
+func itoa(i int) string {
+	data, _ := json.Marshal(i)
+	return string(data)
+}
+
+func completionLabels(list *completionList) []string {
+	ret := []string{}
+	for _, item := range list.Items {
+		ret = append(ret, item.Label)
+	}
+	return ret
+}
+
+func TestCompletion(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	testCases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "module types",
+			text: "|",
+			want: []string{"cc_library", "java_library", "soong_config_module_type"},
+		},
+		{
+			name: "module type prefix",
+			text: "cc_library {\n    name: \"libfoo\",\n}\n\nja|",
+			want: []string{"java_library"},
+		},
+		{
+			name: "properties",
+			text: "cc_library {\n    s|\n}",
+			want: []string{"srcs", "shared_libs", "stl", "sanitize"},
+		},
+		{
+			name: "nested properties",
+			text: "cc_library {\n    target: {\n        android: {\n            |",
+			want: []string{"cflags"},
+		},
+		{
+			name: "bool values",
+			text: "cc_library {\n    enabled: |",
+			want: []string{"true", "false", "select"},
+		},
+		{
+			name: "module names in deps",
+			text: "cc_library {\n    shared_libs: [\"lib|\"],\n}",
+			want: []string{"libbar", "libbaz"},
+		},
+		{
+			name: "module names in srcs",
+			text: "cc_library {\n    name: \"libfoo\",\n    srcs: [\":libf|\"],\n}",
+			want: []string{"libfoo"},
+		},
+		{
+			name: "no module names in srcs",
+			text: "cc_library {\n    srcs: [\"lib|\"],\n}",
+			want: []string{},
+		},
+		{
+			name: "select conditions",
+			text: "cc_library {\n    srcs: select(|",
+			want: []string{"arch", "os", "product_variable", "release_flag", "soong_config_variable"},
+		},
+		{
+			name: "select patterns",
+			text: "cc_library {\n    srcs: select(arch(), {\n        |",
+			want: []string{`"arm"`, `"arm64"`, `"riscv64"`, `"x86"`, `"x86_64"`, "default", "any"},
+		},
+		{
+			name: "select patterns in string",
+			text: "cc_library {\n    srcs: select(os(), {\n        \"linux|",
+			want: []string{"linux_bionic", "linux_glibc", "linux_musl"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := completionLabels(s.completion(openTestDocument(s, tc.text)))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHover(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	testCases := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "module type",
+			text: "cc_lib|rary {\n}",
+			want: "**cc_library** (package cc)\n\ncc_library creates both static and shared libraries.",
+		},
+		{
+			name: "property",
+			text: "cc_library {\n    ena|bled: true,\n}",
+			want: "**enabled** _configurable bool_\n\nWhether the module is enabled.\n\nDefault: true",
+		},
+		{
+			name: "nested property",
+			text: "cc_library {\n    sanitize: {\n        |address: true,\n    },\n}",
+			want: "**address** _bool_\n\nEnable AddressSanitizer.",
+		},
+		{
+			name: "module reference",
+			text: "cc_library {\n    shared_libs: [\"lib|bar\"],\n}",
+			want: "**libbar** _cc_library_\n\nDefined in external/bar/Android.bp",
+		},
+		{
+			name: "property value",
+			text: "cc_library {\n    enabled: t|rue,\n}",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ""
+			if h := s.hover(openTestDocument(s, tc.text)); h != nil {
+				got = h.Contents.Value
+			}
+			if got != tc.want {
+				t.Errorf("want:\n%s\ngot:\n%s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	s, _ := newTestServer(t)
+	barURI := pathToURI(filepath.Join(s.root, "external/bar/Android.bp"))
+	fooURI := pathToURI(filepath.Join(s.root, "foo/Android.bp"))
+
+	testCases := []struct {
+		name string
+		text string
+		want []location
+	}{
+		{
+			name: "module in another file",
+			text: "cc_library {\n    shared_libs: [\"libb|ar\"],\n}",
+			want: []location{{URI: barURI, Range: lspRange{position{4, 0}, position{4, 10}}}},
+		},
+		{
+			name: "module reference in srcs",
+			text: "cc_library {\n    srcs: [\":libbar{.|tag}\"],\n}",
+			want: []location{{URI: barURI, Range: lspRange{position{4, 0}, position{4, 10}}}},
+		},
+		{
+			name: "module in the same file",
+			text: "cc_defaults {\n    name: \"foo_defaults\",\n}\n\ncc_library {\n    defaults: [\"foo_|defaults\"],\n}",
+			want: []location{{URI: fooURI, Range: lspRange{position{0, 0}, position{0, 11}}}},
+		},
+		{
+			name: "unknown module",
+			text: "cc_library {\n    shared_libs: [\"libq|ux\"],\n}",
+		},
+		{
+			name: "not a string",
+			text: "cc_library {\n    enab|led: true,\n}",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := s.definition(openTestDocument(s, tc.text))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPositions(t *testing.T) {
+	text := "a\né\U0001F600x\n"
+	for _, tc := range []struct {
+		offset int
+		pos    position
+	}{
+		{0, position{0, 0}},
+		{2, position{1, 0}},
+		{4, position{1, 1}},
+		{8, position{1, 3}},
+		{9, position{1, 4}},
+		{10, position{2, 0}},
+	} {
+		if got := offsetToPosition(text, tc.offset); got != tc.pos {
+			t.Errorf("offsetToPosition(%d): want %v, got %v", tc.offset, tc.pos, got)
+		}
+		if got := positionToOffset(text, tc.pos); got != tc.offset {
+			t.Errorf("positionToOffset(%v): want %d, got %d", tc.pos, tc.offset, got)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	s, _ := newTestServer(t)
+	in := &bytes.Buffer{}
+	out := &bytes.Buffer{}
+	s.out = out
+
+	uri := pathToURI(filepath.Join(s.root, "foo/Android.bp"))
+	for _, msg := range []string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"rootUri": "` + pathToURI(s.root) + `"}}`,
+		`{"jsonrpc": "2.0", "method": "initialized", "params": {}}`,
+		`{"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": {"textDocument": {"uri": "` + uri + `", "version": 1, "text": "cc_library {\n    src: []\n}\n"}}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "textDocument/hover", "params": {"textDocument": {"uri": "` + uri + `"}, "position": {"line": 1, "character": 0}}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "unknown/method", "params": {}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "shutdown"}`,
+		`{"jsonrpc": "2.0", "method": "exit"}`,
+	} {
+		if err := writeMessage(in, json.RawMessage(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if code := s.run(in); code != 0 {
+		t.Errorf("want exit code 0, got %d", code)
+	}
+
+	var got []string
+	r := bufio.NewReader(out)
+	for {
+		data, err := readMessage(r)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		var msg struct {
+			ID     json.RawMessage
+			Method string
+			Params struct{ Diagnostics []diagnostic }
+			Result json.RawMessage
+			Error  *responseError
+		}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case msg.Method != "":
+			for _, d := range msg.Params.Diagnostics {
+				got = append(got, msg.Method+": "+d.Message)
+			}
+		case msg.Error != nil:
+			got = append(got, string(msg.ID)+": error "+msg.Error.Message)
+		default:
+			got = append(got, string(msg.ID)+": "+strings.SplitN(string(msg.Result), ",", 2)[0])
+		}
+	}
+
+	want := []string{
+		`1: {"capabilities":{"textDocumentSync":1`,
+		`textDocument/publishDiagnostics: unrecognized property "src"`,
+		`2: null`,
+		`3: error method not found: unknown/method`,
+		`4: null`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpgen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	m := &Module{Type: "rust_test", Comments: []string{"A comment."}}
+	m.Add("name", "foo")
+	m.Add("empty", "")
+	m.Add("host_supported", true)
+	m.Add("srcs", []string{"a.rs", "b.rs"})
+	m.Add("no_srcs", []string(nil))
+	m.Add("test_options", []Property{{Name: "unit_test", Value: true}})
+
+	var sb strings.Builder
+	m.Write(&sb)
+	expected := `
+// A comment.
+rust_test {
+    name: "foo",
+    host_supported: true,
+    srcs: [
+        "a.rs",
+        "b.rs",
+    ],
+    test_options: {
+        unit_test: true,
+    },
+}
+`
+	if got := sb.String(); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestLicenseKinds(t *testing.T) {
+	testCases := []struct {
+		license  string
+		expected []string
+	}{
+		{
+			license:  "MIT OR Apache-2.0",
+			expected: []string{"SPDX-license-identifier-MIT", "SPDX-license-identifier-Apache-2.0"},
+		},
+		{
+			license:  "MIT/Apache-2.0",
+			expected: []string{"SPDX-license-identifier-MIT", "SPDX-license-identifier-Apache-2.0"},
+		},
+		{
+			license:  "(MIT and BSD-3-Clause) or MIT",
+			expected: []string{"SPDX-license-identifier-MIT", "SPDX-license-identifier-BSD-3-Clause"},
+		},
+		{
+			license:  "Apache-2.0 WITH LLVM-exception OR MIT",
+			expected: []string{"SPDX-license-identifier-Apache-2.0", "SPDX-license-identifier-MIT"},
+		},
+		{
+			license:  "GPL-2.0-only with Classpath-exception-2.0",
+			expected: []string{"SPDX-license-identifier-GPL-2.0-only"},
+		},
+		{
+			license: "",
+		},
+	}
+	for _, tc := range testCases {
+		if got := LicenseKinds(tc.license); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%q: expected %q, got %q", tc.license, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpgen
+
+import (
+	"strings"
+)
+
+// LicenseKinds converts an SPDX license expression such as "MIT OR Apache-2.0" to license kinds.
+// The operators can be upper or lower case, and "/" is accepted as an OR, as in the license
+// field of older crates. The exception after a WITH operator is not a license, so it is skipped.
+func LicenseKinds(license string) []string {
+	fields := strings.FieldsFunc(license, func(r rune) bool {
+		return r == ' ' || r == '(' || r == ')' || r == '/'
+	})
+	var kinds []string
+	seen := make(map[string]bool)
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "OR", "AND", "or", "and":
+			continue
+		case "WITH", "with":
+			i++
+			continue
+		}
+		kind := "SPDX-license-identifier-" + fields[i]
+		if !seen[kind] {
+			seen[kind] = true
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
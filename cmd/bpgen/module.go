@@ -0,0 +1,87 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bpgen writes the modules of the Android.bp files generated by the *2bp tools, such as
+// cargo2bp and pip2bp.
+package bpgen
+
+import (
+	"fmt"
+	"io"
+)
+
+// Property is a property of a generated module. Its value is a string, a bool, a []string or a
+// []Property for nested properties.
+type Property struct {
+	Name  string
+	Value interface{}
+}
+
+// Module is a generated module.
+type Module struct {
+	Type string
+	// Comments are written before the module, one per line.
+	Comments   []string
+	Properties []Property
+}
+
+// Add adds a property to the module, unless its value is an empty string or list.
+func (m *Module) Add(name string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return
+		}
+	case []string:
+		if len(v) == 0 {
+			return
+		}
+	}
+	m.Properties = append(m.Properties, Property{Name: name, Value: value})
+}
+
+func writeProperties(w io.Writer, properties []Property, indent string) {
+	for _, p := range properties {
+		switch v := p.Value.(type) {
+		case string:
+			fmt.Fprintf(w, "%s%s: %q,\n", indent, p.Name, v)
+		case bool:
+			fmt.Fprintf(w, "%s%s: %t,\n", indent, p.Name, v)
+		case []string:
+			fmt.Fprintf(w, "%s%s: [\n", indent, p.Name)
+			for _, s := range v {
+				fmt.Fprintf(w, "%s    %q,\n", indent, s)
+			}
+			fmt.Fprintf(w, "%s],\n", indent)
+		case []Property:
+			fmt.Fprintf(w, "%s%s: {\n", indent, p.Name)
+			writeProperties(w, v, indent+"    ")
+			fmt.Fprintf(w, "%s},\n", indent)
+		default:
+			panic(fmt.Errorf("unsupported value %#v for property %s", p.Value, p.Name))
+		}
+	}
+}
+
+// Write writes the module preceded by an empty line.  The output is meant to be formatted with
+// bpfix.Reformat.
+func (m *Module) Write(w io.Writer) {
+	fmt.Fprintln(w)
+	for _, c := range m.Comments {
+		fmt.Fprintf(w, "// %s\n", c)
+	}
+	fmt.Fprintf(w, "%s {\n", m.Type)
+	writeProperties(w, m.Properties, "    ")
+	fmt.Fprintln(w, "}")
+}
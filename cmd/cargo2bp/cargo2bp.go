@@ -0,0 +1,239 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/bpfix/bpfix"
+)
+
+type StringList []string
+
+func (l *StringList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *StringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func rerunForRegen(filename string) error {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewBuffer(buf))
+
+	// Skip the first line in the file
+	for i := 0; i < 2; i++ {
+		if !scanner.Scan() {
+			if scanner.Err() != nil {
+				return scanner.Err()
+			} else {
+				return fmt.Errorf("unexpected EOF")
+			}
+		}
+	}
+
+	// Extract the old args from the file
+	line := scanner.Text()
+	if strings.HasPrefix(line, "// cargo2bp") {
+		line = strings.TrimPrefix(line, "// cargo2bp")
+	} else {
+		return fmt.Errorf("unexpected second line: %q", line)
+	}
+	args := strings.Fields(line)
+
+	// Append all current command line args except -regen <file> to the ones from the file
+	for i := 1; i < len(os.Args); i++ {
+		if os.Args[i] == "-regen" || os.Args[i] == "--regen" {
+			i++
+		} else {
+			args = append(args, os.Args[i])
+		}
+	}
+
+	cmd := os.Args[0] + " " + strings.Join(args, " ")
+	// Re-exec cargo2bp with the new arguments
+	output, err := exec.Command("/bin/sh", "-c", cmd).Output()
+	if exitErr, _ := err.(*exec.ExitError); exitErr != nil {
+		return fmt.Errorf("failed to run %s\n%s", cmd, string(exitErr.Stderr))
+	} else if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, output, 0666)
+}
+
+func readMetadata(metadataFile string) (*Metadata, error) {
+	if metadataFile != "" {
+		f, err := os.Open(metadataFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ReadMetadata(f)
+	}
+
+	cmd := exec.Command("cargo", "metadata", "--format-version", "1", "--offline", "--locked")
+	var stdoutb, stderrb bytes.Buffer
+	cmd.Stdout = &stdoutb
+	cmd.Stderr = &stderrb
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("running %q to dump the crate metadata failed: %v, stderr:\n%s",
+			cmd.String(), err, stderrb.Bytes())
+	}
+	return ReadMetadata(&stdoutb)
+}
+
+func readCargoLock(lockFile string) ([]LockedPackage, error) {
+	f, err := os.Open(lockFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCargoLock(f)
+}
+
+func listDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `cargo2bp, a tool to create Android.bp files from vendored Rust crates
+
+The tool will extract the necessary information from the cargo metadata and Cargo.lock of a crate to
+create an Android.bp that can compile it. This needs to be run from the same directory as the
+Cargo.toml file. For a workspace with several members, run it from the directory of each member
+to write the member's Android.bp.
+
+Usage: %s [-metadata <file>] [-lock <file>] [-cfg <cfg>] [-skip-tests] [-regen <file>]
+
+  -metadata <file>
+     Read the output of 'cargo metadata --format-version 1' from <file> instead of running cargo.
+  -lock <file>
+     The Cargo.lock file pinning the versions of the dependencies. Defaults to Cargo.lock.
+  -cfg <cfg>
+     Add <cfg> to the cfgs of all the modules, e.g. for cfgs that a build script would set. The
+     -cfg option can be specified multiple times.
+  -skip-tests
+     If passed, don't write out rust_test modules for the unit and integration tests.
+  -regen <file>
+     Read arguments from <file> and overwrite it.
+
+Crates with a build script (build.rs) are reported on stderr, and the generated modules are
+marked with a TODO comment, as Soong does not run build scripts.
+
+`, os.Args[0])
+	}
+
+	var regen, metadataFile, lockFile string
+	var skipTests bool
+	cfgs := StringList{}
+
+	flag.StringVar(&metadataFile, "metadata", "", "File with the output of cargo metadata")
+	flag.StringVar(&lockFile, "lock", "Cargo.lock", "Cargo.lock file")
+	flag.Var(&cfgs, "cfg", "Cfg to add to all the modules")
+	flag.BoolVar(&skipTests, "skip-tests", false, "Whether to skip tests")
+	flag.StringVar(&regen, "regen", "", "Rewrite specified file")
+	flag.Parse()
+
+	if regen != "" {
+		err := rerunForRegen(regen)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if flag.NArg() != 0 {
+		fmt.Fprintf(os.Stderr, "Unused argument detected: %v\n", flag.Args())
+		os.Exit(1)
+	}
+
+	if _, err := os.Stat("Cargo.toml"); err != nil {
+		fmt.Fprintln(os.Stderr, "Cargo.toml file not found")
+		os.Exit(1)
+	}
+
+	metadata, err := readMetadata(metadataFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	lock, err := readCargoLock(lockFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	g, err := newGenerator(metadata, lock)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	g.cfgs = cfgs
+	g.skipTests = skipTests
+	g.listDir = listDir
+	if g.dir, err = os.Getwd(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	buf := &bytes.Buffer{}
+
+	fmt.Fprintln(buf, "// Automatically generated with:")
+	fmt.Fprintln(buf, "// cargo2bp", strings.Join(proptools.ShellEscapeList(os.Args[1:]), " "))
+
+	if err := g.generate(buf); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing modules:", err)
+		os.Exit(1)
+	}
+	for _, w := range g.warnings {
+		fmt.Fprintln(os.Stderr, "warning:", w)
+	}
+
+	out, err := bpfix.Reformat(buf.String())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error formatting output", err)
+		os.Exit(1)
+	}
+
+	os.Stdout.WriteString(out)
+}
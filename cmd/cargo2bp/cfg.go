@@ -0,0 +1,133 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"text/scanner"
+)
+
+// The cfg values of the platforms the generated modules are built for. Dependencies limited to
+// other platforms, e.g. `[target.'cfg(windows)'.dependencies]`, are dropped.
+var targetCfgs = []map[string][]string{
+	{
+		"unix":          nil,
+		"target_os":     {"android"},
+		"target_family": {"unix"},
+		"target_env":    {""},
+	},
+	{
+		"unix":          nil,
+		"target_os":     {"linux"},
+		"target_family": {"unix"},
+		"target_env":    {"gnu", "musl"},
+	},
+}
+
+// targetApplies returns whether a dependency limited to a target, given as a cfg expression or a
+// target triple, applies to Android or Linux.
+func targetApplies(target string) bool {
+	if target == "" {
+		return true
+	}
+	if !strings.HasPrefix(target, "cfg(") {
+		return strings.Contains(target, "linux") || strings.Contains(target, "android")
+	}
+	for _, cfgs := range targetCfgs {
+		p := cfgParser{cfgs: cfgs}
+		p.s.Init(strings.NewReader(target))
+		p.s.Error = func(*scanner.Scanner, string) { p.failed = true }
+		p.next()
+		if p.eval() && !p.failed {
+			return true
+		}
+	}
+	return false
+}
+
+// cfgParser evaluates a cfg expression such as cfg(any(unix, target_os = "wasi")).
+type cfgParser struct {
+	s      scanner.Scanner
+	tok    rune
+	cfgs   map[string][]string
+	failed bool
+}
+
+func (p *cfgParser) next() {
+	p.tok = p.s.Scan()
+}
+
+func (p *cfgParser) expect(tok rune) {
+	if p.tok != tok {
+		p.failed = true
+	}
+	p.next()
+}
+
+func (p *cfgParser) eval() bool {
+	if p.tok != scanner.Ident {
+		p.failed = true
+		return false
+	}
+	name := p.s.TokenText()
+	p.next()
+	switch {
+	case p.tok == '(' && (name == "cfg" || name == "all" || name == "any" || name == "not"):
+		p.next()
+		var results []bool
+		for p.tok != ')' && p.tok != scanner.EOF && !p.failed {
+			results = append(results, p.eval())
+			if p.tok == ',' {
+				p.next()
+			}
+		}
+		p.expect(')')
+		switch name {
+		case "not":
+			return len(results) == 1 && !results[0]
+		case "any":
+			for _, r := range results {
+				if r {
+					return true
+				}
+			}
+			return false
+		default:
+			for _, r := range results {
+				if !r {
+					return false
+				}
+			}
+			return len(results) > 0 || name == "all"
+		}
+	case p.tok == '=':
+		p.next()
+		if p.tok != scanner.String {
+			p.failed = true
+			return false
+		}
+		value := strings.Trim(p.s.TokenText(), `"`)
+		p.next()
+		for _, v := range p.cfgs[name] {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	default:
+		_, ok := p.cfgs[name]
+		return ok
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"android/soong/cmd/bpgen"
+)
+
+type generator struct {
+	metadata *Metadata
+	packages map[string]*Package
+	nodes    map[string]*Node
+
+	// The locked versions of each crate, used to give a version suffix to the modules of crates
+	// that are vendored in more than one version.
+	lockedVersions map[string][]string
+
+	// Extra cfgs to set on every module, e.g. the ones a build script would have set.
+	cfgs []string
+
+	skipTests bool
+
+	// The directory cargo2bp runs in, which selects the member of a workspace with several
+	// members to write the modules of.
+	dir string
+
+	// listDir returns the names of the files in a directory, used to find license files.
+	listDir func(dir string) ([]string, error)
+
+	// Problems that need manual handling, reported on stderr.
+	warnings []string
+}
+
+func newGenerator(metadata *Metadata, lock []LockedPackage) (*generator, error) {
+	g := &generator{
+		metadata:       metadata,
+		packages:       make(map[string]*Package),
+		nodes:          make(map[string]*Node),
+		lockedVersions: make(map[string][]string),
+	}
+	for _, p := range metadata.Packages {
+		g.packages[p.Id] = p
+	}
+	for _, n := range metadata.Resolve.Nodes {
+		g.nodes[n.Id] = n
+	}
+
+	locked := make(map[string]bool)
+	for _, p := range lock {
+		locked[p.Name+" "+p.Version] = true
+		g.lockedVersions[p.Name] = append(g.lockedVersions[p.Name], p.Version)
+	}
+	for _, n := range metadata.Resolve.Nodes {
+		p := g.packages[n.Id]
+		if p == nil {
+			return nil, fmt.Errorf("cargo metadata resolves unknown package %q", n.Id)
+		}
+		if !locked[p.Name+" "+p.Version] {
+			return nil, fmt.Errorf("%s %s is not in Cargo.lock, run `cargo generate-lockfile` "+
+				"or `cargo update` to update it", p.Name, p.Version)
+		}
+	}
+	return g, nil
+}
+
+func (g *generator) warn(format string, args ...interface{}) {
+	g.warnings = append(g.warnings, fmt.Sprintf(format, args...))
+}
+
+// versionSuffix returns the suffix that distinguishes the modules of a crate vendored in more than
+// one version, based on the semver compatible part of the version: "_v1" for 1.2.3, "_v0_4" for
+// 0.4.1 and "_v0_0_3" for 0.0.3.
+func (g *generator) versionSuffix(p *Package) string {
+	if len(g.lockedVersions[p.Name]) < 2 {
+		return ""
+	}
+	parts := strings.SplitN(strings.SplitN(p.Version, "-", 2)[0], ".", 3)
+	n := 1
+	for n < len(parts) && parts[n-1] == "0" {
+		n++
+	}
+	return "_v" + strings.Join(parts[:n], "_")
+}
+
+// libModuleName returns the name of the module of the library or proc-macro of a package.
+func (g *generator) libModuleName(p *Package) string {
+	t := p.LibTarget()
+	if t == nil {
+		return ""
+	}
+	return "lib" + t.CrateName() + g.versionSuffix(p)
+}
+
+// relativeSrc returns the path of a source file of a package relative to the package directory.
+func relativeSrc(p *Package, src string) string {
+	rel, err := filepath.Rel(filepath.Dir(p.ManifestPath), src)
+	if err != nil || strings.HasPrefix(rel, "../") {
+		return src
+	}
+	return rel
+}
+
+type crateDeps struct {
+	rustlibs   []string
+	procMacros []string
+	aliases    []string
+}
+
+// deps returns the dependencies of a package of the given kinds ("" for normal dependencies, "dev"
+// for dev-dependencies) that apply to Android or Linux.
+func (g *generator) deps(p *Package, kinds ...string) crateDeps {
+	var deps crateDeps
+	node := g.nodes[p.Id]
+	if node == nil {
+		return deps
+	}
+	for _, dep := range node.Deps {
+		if !dependencyApplies(dep, kinds) {
+			continue
+		}
+		depPkg := g.packages[dep.Pkg]
+		if depPkg == nil || depPkg.LibTarget() == nil {
+			continue
+		}
+		name := g.libModuleName(depPkg)
+		if depPkg.LibTarget().IsProcMacro() {
+			deps.procMacros = append(deps.procMacros, name)
+		} else {
+			deps.rustlibs = append(deps.rustlibs, name)
+		}
+		if crateName := depPkg.LibTarget().CrateName(); crateName != dep.Name {
+			deps.aliases = append(deps.aliases, crateName+":"+dep.Name)
+		}
+	}
+	sort.Strings(deps.rustlibs)
+	sort.Strings(deps.procMacros)
+	sort.Strings(deps.aliases)
+	return deps
+}
+
+func dependencyApplies(dep *NodeDep, kinds []string) bool {
+	for _, k := range dep.DepKinds {
+		for _, kind := range kinds {
+			if k.Kind == kind && targetApplies(k.Target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (g *generator) features(p *Package) []string {
+	node := g.nodes[p.Id]
+	if node == nil {
+		return nil
+	}
+	features := append([]string(nil), node.Features...)
+	sort.Strings(features)
+	return features
+}
+
+// addCommonProperties adds the properties shared by all the modules built from a target.
+func (g *generator) addCommonProperties(m *bpgen.Module, p *Package, t *Target, deps crateDeps) {
+	m.Add("crate_name", t.CrateName())
+	m.Add("cargo_env_compat", true)
+	m.Add("cargo_pkg_version", p.Version)
+	m.Add("srcs", []string{relativeSrc(p, t.SrcPath)})
+	edition := t.Edition
+	if edition == "" {
+		edition = p.Edition
+	}
+	m.Add("edition", edition)
+	m.Add("features", g.features(p))
+	m.Add("cfgs", g.cfgs)
+	m.Add("rustlibs", deps.rustlibs)
+	m.Add("proc_macros", deps.procMacros)
+	m.Add("aliases", deps.aliases)
+}
+
+func (g *generator) libModule(p *Package) *bpgen.Module {
+	t := p.LibTarget()
+	m := &bpgen.Module{Type: "rust_library"}
+	if t.IsProcMacro() {
+		m.Type = "rust_proc_macro"
+	}
+	m.Add("name", g.libModuleName(p))
+	if !t.IsProcMacro() {
+		m.Add("host_supported", true)
+	}
+	g.addCommonProperties(m, p, t, g.deps(p, ""))
+	return m
+}
+
+func testModuleName(p *Package, t *Target) string {
+	src := strings.TrimSuffix(relativeSrc(p, t.SrcPath), ".rs")
+	return strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(p.Name + "_test_" + src)
+}
+
+func (g *generator) testModule(p *Package, t *Target, deps crateDeps) *bpgen.Module {
+	m := &bpgen.Module{Type: "rust_test"}
+	m.Add("name", testModuleName(p, t))
+	m.Add("host_supported", true)
+	m.Add("test_suites", []string{"general-tests"})
+	m.Add("auto_gen_config", true)
+	m.Add("test_options", []bpgen.Property{{Name: "unit_test", Value: true}})
+	g.addCommonProperties(m, p, t, deps)
+	return m
+}
+
+// testModules returns the modules for the unit tests of the library and for the integration tests
+// of a package. Integration tests also depend on the library of the package.
+func (g *generator) testModules(p *Package) []*bpgen.Module {
+	if g.skipTests {
+		return nil
+	}
+	var modules []*bpgen.Module
+	lib := p.LibTarget()
+	if lib != nil && lib.Test && !lib.IsProcMacro() {
+		modules = append(modules, g.testModule(p, lib, g.deps(p, "", "dev")))
+	}
+	for _, t := range p.Targets {
+		if !t.IsTest() {
+			continue
+		}
+		deps := g.deps(p, "", "dev")
+		if lib != nil {
+			if lib.IsProcMacro() {
+				deps.procMacros = append(deps.procMacros, g.libModuleName(p))
+				sort.Strings(deps.procMacros)
+			} else {
+				deps.rustlibs = append(deps.rustlibs, g.libModuleName(p))
+				sort.Strings(deps.rustlibs)
+			}
+		}
+		modules = append(modules, g.testModule(p, t, deps))
+	}
+	return modules
+}
+
+func licenseModuleName(p *Package) string {
+	return strings.ReplaceAll(p.Name, "-", "_") + "_license"
+}
+
+// licenseTexts returns the license files of a package relative to the package directory.
+func (g *generator) licenseTexts(p *Package) []string {
+	if p.LicenseFile != "" {
+		return []string{p.LicenseFile}
+	}
+	if g.listDir == nil {
+		return nil
+	}
+	files, err := g.listDir(filepath.Dir(p.ManifestPath))
+	if err != nil {
+		g.warn("%s: failed to look for license files: %s", p.Name, err)
+		return nil
+	}
+	var texts []string
+	for _, f := range files {
+		upper := strings.ToUpper(f)
+		if strings.HasPrefix(upper, "LICENSE") || strings.HasPrefix(upper, "LICENCE") ||
+			strings.HasPrefix(upper, "COPYING") {
+			texts = append(texts, f)
+		}
+	}
+	sort.Strings(texts)
+	return texts
+}
+
+func (g *generator) licenseModules(p *Package) []*bpgen.Module {
+	name := licenseModuleName(p)
+	pkg := &bpgen.Module{Type: "package"}
+	pkg.Add("default_applicable_licenses", []string{name})
+
+	license := &bpgen.Module{Type: "license"}
+	license.Add("name", name)
+	license.Add("visibility", []string{":__subpackages__"})
+	kinds := bpgen.LicenseKinds(p.License)
+	if len(kinds) == 0 {
+		g.warn("%s has no SPDX license expression in Cargo.toml, set license_kinds manually", p.Name)
+		license.Comments = append(license.Comments, "TODO: set license_kinds, the crate has no SPDX license expression.")
+	}
+	license.Add("license_kinds", kinds)
+	texts := g.licenseTexts(p)
+	if len(texts) == 0 {
+		g.warn("%s has no license file", p.Name)
+	}
+	license.Add("license_text", texts)
+	return []*bpgen.Module{pkg, license}
+}
+
+// member returns the workspace member to write the modules of.  The sources are relative to the
+// member's directory and an Android.bp can only have one package module, so each member of a
+// workspace gets its own Android.bp, written by running cargo2bp from the member's directory.
+func (g *generator) member() (*Package, error) {
+	var members []*Package
+	for _, id := range g.metadata.WorkspaceMembers {
+		p := g.packages[id]
+		if p == nil {
+			return nil, fmt.Errorf("unknown workspace member %q", id)
+		}
+		members = append(members, p)
+	}
+	switch len(members) {
+	case 0:
+		return nil, fmt.Errorf("the metadata has no workspace members")
+	case 1:
+		return members[0], nil
+	}
+
+	var names []string
+	for _, p := range members {
+		if g.dir != "" && filepath.Dir(p.ManifestPath) == filepath.Clean(g.dir) {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("the workspace has %d members (%s), run cargo2bp from the directory of "+
+		"each member to write its Android.bp", len(members), strings.Join(names, ", "))
+}
+
+// generate writes the modules for the workspace member selected by member.
+func (g *generator) generate(w io.Writer) error {
+	p, err := g.member()
+	if err != nil {
+		return err
+	}
+	var modules []*bpgen.Module
+	modules = append(modules, g.licenseModules(p)...)
+
+	lib := p.LibTarget()
+	if lib != nil {
+		m := g.libModule(p)
+		if build := p.BuildScript(); build != nil {
+			g.warn("%s has a build script (%s) that needs manual handling: Soong does not run it, "+
+				"so any generated sources, cfgs or environment variables it provides must be added by hand",
+				p.Name, relativeSrc(p, build.SrcPath))
+			m.Comments = append(m.Comments,
+				fmt.Sprintf("TODO: %s has a build script (%s) that is not run by Soong.", p.Name,
+					relativeSrc(p, build.SrcPath)),
+				"Check it for generated sources, cfgs and environment variables the crate needs.")
+		}
+		modules = append(modules, m)
+	}
+	modules = append(modules, g.testModules(p)...)
+
+	for _, t := range p.Targets {
+		if !t.IsLibrary() && !t.IsProcMacro() && !t.IsTest() && !t.IsBuildScript() {
+			g.warn("%s: skipping %s target %s", p.Name, strings.Join(t.Kind, ","), t.Name)
+		}
+	}
+	for _, m := range modules {
+		m.Write(w)
+	}
+	return nil
+}
@@ -0,0 +1,430 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testMetadata = `{
+  "packages": [
+    {
+      "name": "foo-rs",
+      "version": "1.2.3",
+      "id": "foo-rs 1.2.3 (path+file:///crates/foo-rs)",
+      "license": "MIT OR Apache-2.0",
+      "manifest_path": "/crates/foo-rs/Cargo.toml",
+      "edition": "2021",
+      "targets": [
+        {"name": "foo-rs", "kind": ["lib"], "src_path": "/crates/foo-rs/src/lib.rs", "edition": "2021", "test": true},
+        {"name": "build-script-build", "kind": ["custom-build"], "src_path": "/crates/foo-rs/build.rs", "edition": "2021"},
+        {"name": "integration", "kind": ["test"], "src_path": "/crates/foo-rs/tests/integration.rs", "edition": "2021", "test": true},
+        {"name": "foo-cli", "kind": ["bin"], "src_path": "/crates/foo-rs/src/main.rs", "edition": "2021", "test": true}
+      ]
+    },
+    {
+      "name": "bar", "version": "0.4.1", "id": "bar 0.4.1 (registry+https://github.com/rust-lang/crates.io-index)",
+      "manifest_path": "/registry/bar-0.4.1/Cargo.toml", "edition": "2018",
+      "targets": [{"name": "bar", "kind": ["lib"], "src_path": "/registry/bar-0.4.1/src/lib.rs", "edition": "2018"}]
+    },
+    {
+      "name": "bar", "version": "1.0.0", "id": "bar 1.0.0 (registry+https://github.com/rust-lang/crates.io-index)",
+      "manifest_path": "/registry/bar-1.0.0/Cargo.toml", "edition": "2021",
+      "targets": [{"name": "bar", "kind": ["lib"], "src_path": "/registry/bar-1.0.0/src/lib.rs", "edition": "2021"}]
+    },
+    {
+      "name": "baz-derive", "version": "0.1.0", "id": "baz-derive 0.1.0 (registry+https://github.com/rust-lang/crates.io-index)",
+      "manifest_path": "/registry/baz-derive-0.1.0/Cargo.toml", "edition": "2021",
+      "targets": [{"name": "baz-derive", "kind": ["proc-macro"], "src_path": "/registry/baz-derive-0.1.0/src/lib.rs", "edition": "2021"}]
+    },
+    {
+      "name": "winapi", "version": "0.3.9", "id": "winapi 0.3.9 (registry+https://github.com/rust-lang/crates.io-index)",
+      "manifest_path": "/registry/winapi-0.3.9/Cargo.toml", "edition": "2015",
+      "targets": [{"name": "winapi", "kind": ["lib"], "src_path": "/registry/winapi-0.3.9/src/lib.rs", "edition": "2015"}]
+    },
+    {
+      "name": "quickcheck", "version": "1.0.3", "id": "quickcheck 1.0.3 (registry+https://github.com/rust-lang/crates.io-index)",
+      "manifest_path": "/registry/quickcheck-1.0.3/Cargo.toml", "edition": "2018",
+      "targets": [{"name": "quickcheck", "kind": ["lib"], "src_path": "/registry/quickcheck-1.0.3/src/lib.rs", "edition": "2018"}]
+    }
+  ],
+  "workspace_members": ["foo-rs 1.2.3 (path+file:///crates/foo-rs)"],
+  "resolve": {
+    "nodes": [
+      {
+        "id": "foo-rs 1.2.3 (path+file:///crates/foo-rs)",
+        "deps": [
+          {"name": "bar", "pkg": "bar 1.0.0 (registry+https://github.com/rust-lang/crates.io-index)", "dep_kinds": [{"kind": null, "target": null}]},
+          {"name": "old_bar", "pkg": "bar 0.4.1 (registry+https://github.com/rust-lang/crates.io-index)", "dep_kinds": [{"kind": null, "target": "cfg(unix)"}]},
+          {"name": "baz_derive", "pkg": "baz-derive 0.1.0 (registry+https://github.com/rust-lang/crates.io-index)", "dep_kinds": [{"kind": null, "target": null}]},
+          {"name": "winapi", "pkg": "winapi 0.3.9 (registry+https://github.com/rust-lang/crates.io-index)", "dep_kinds": [{"kind": null, "target": "cfg(windows)"}]},
+          {"name": "quickcheck", "pkg": "quickcheck 1.0.3 (registry+https://github.com/rust-lang/crates.io-index)", "dep_kinds": [{"kind": "dev", "target": null}]}
+        ],
+        "features": ["std", "default"]
+      },
+      {"id": "bar 0.4.1 (registry+https://github.com/rust-lang/crates.io-index)", "deps": [], "features": []},
+      {"id": "bar 1.0.0 (registry+https://github.com/rust-lang/crates.io-index)", "deps": [], "features": []},
+      {"id": "baz-derive 0.1.0 (registry+https://github.com/rust-lang/crates.io-index)", "deps": [], "features": []},
+      {"id": "winapi 0.3.9 (registry+https://github.com/rust-lang/crates.io-index)", "deps": [], "features": []},
+      {"id": "quickcheck 1.0.3 (registry+https://github.com/rust-lang/crates.io-index)", "deps": [], "features": []}
+    ]
+  },
+  "workspace_root": "/crates/foo-rs"
+}`
+
+const testCargoLock = `# This file is automatically @generated by Cargo.
+# It is not intended for manual editing.
+version = 3
+
+[[package]]
+name = "bar"
+version = "0.4.1"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "0123"
+
+[[package]]
+name = "bar"
+version = "1.0.0"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "baz-derive"
+version = "0.1.0"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "foo-rs"
+version = "1.2.3"
+dependencies = [
+ "bar 0.4.1",
+ "bar 1.0.0",
+ "baz-derive",
+]
+
+[[package]]
+name = "quickcheck"
+version = "1.0.3"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "winapi"
+version = "0.3.9"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[metadata]
+"checksum foo" = "bar"
+`
+
+func TestGenerate(t *testing.T) {
+	metadata, err := ReadMetadata(strings.NewReader(testMetadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := ReadCargoLock(strings.NewReader(testCargoLock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := newGenerator(metadata, lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.cfgs = []string{"has_foo"}
+	g.listDir = func(dir string) ([]string, error) {
+		return []string{"Cargo.toml", "LICENSE-MIT", "LICENSE-APACHE", "README.md"}, nil
+	}
+
+	var out strings.Builder
+	if err := g.generate(&out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+package {
+    default_applicable_licenses: [
+        "foo_rs_license",
+    ],
+}
+
+license {
+    name: "foo_rs_license",
+    visibility: [
+        ":__subpackages__",
+    ],
+    license_kinds: [
+        "SPDX-license-identifier-MIT",
+        "SPDX-license-identifier-Apache-2.0",
+    ],
+    license_text: [
+        "LICENSE-APACHE",
+        "LICENSE-MIT",
+    ],
+}
+
+// TODO: foo-rs has a build script (build.rs) that is not run by Soong.
+// Check it for generated sources, cfgs and environment variables the crate needs.
+rust_library {
+    name: "libfoo_rs",
+    host_supported: true,
+    crate_name: "foo_rs",
+    cargo_env_compat: true,
+    cargo_pkg_version: "1.2.3",
+    srcs: [
+        "src/lib.rs",
+    ],
+    edition: "2021",
+    features: [
+        "default",
+        "std",
+    ],
+    cfgs: [
+        "has_foo",
+    ],
+    rustlibs: [
+        "libbar_v0_4",
+        "libbar_v1",
+    ],
+    proc_macros: [
+        "libbaz_derive",
+    ],
+    aliases: [
+        "bar:old_bar",
+    ],
+}
+
+rust_test {
+    name: "foo_rs_test_src_lib",
+    host_supported: true,
+    test_suites: [
+        "general-tests",
+    ],
+    auto_gen_config: true,
+    test_options: {
+        unit_test: true,
+    },
+    crate_name: "foo_rs",
+    cargo_env_compat: true,
+    cargo_pkg_version: "1.2.3",
+    srcs: [
+        "src/lib.rs",
+    ],
+    edition: "2021",
+    features: [
+        "default",
+        "std",
+    ],
+    cfgs: [
+        "has_foo",
+    ],
+    rustlibs: [
+        "libbar_v0_4",
+        "libbar_v1",
+        "libquickcheck",
+    ],
+    proc_macros: [
+        "libbaz_derive",
+    ],
+    aliases: [
+        "bar:old_bar",
+    ],
+}
+
+rust_test {
+    name: "foo_rs_test_tests_integration",
+    host_supported: true,
+    test_suites: [
+        "general-tests",
+    ],
+    auto_gen_config: true,
+    test_options: {
+        unit_test: true,
+    },
+    crate_name: "integration",
+    cargo_env_compat: true,
+    cargo_pkg_version: "1.2.3",
+    srcs: [
+        "tests/integration.rs",
+    ],
+    edition: "2021",
+    features: [
+        "default",
+        "std",
+    ],
+    cfgs: [
+        "has_foo",
+    ],
+    rustlibs: [
+        "libbar_v0_4",
+        "libbar_v1",
+        "libfoo_rs",
+        "libquickcheck",
+    ],
+    proc_macros: [
+        "libbaz_derive",
+    ],
+    aliases: [
+        "bar:old_bar",
+    ],
+}
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	expectedWarnings := []string{
+		"foo-rs has a build script (build.rs) that needs manual handling: Soong does not run it, " +
+			"so any generated sources, cfgs or environment variables it provides must be added by hand",
+		"foo-rs: skipping bin target foo-cli",
+	}
+	if !reflect.DeepEqual(g.warnings, expectedWarnings) {
+		t.Errorf("expected warnings %q, got %q", expectedWarnings, g.warnings)
+	}
+}
+
+const testWorkspaceMetadata = `{
+  "packages": [
+    {
+      "name": "a", "version": "0.1.0", "id": "a 0.1.0 (path+file:///ws/a)", "license": "MIT",
+      "manifest_path": "/ws/a/Cargo.toml", "edition": "2021",
+      "targets": [{"name": "a", "kind": ["lib"], "src_path": "/ws/a/src/lib.rs", "edition": "2021"}]
+    },
+    {
+      "name": "b", "version": "0.2.0", "id": "b 0.2.0 (path+file:///ws/b)", "license": "MIT",
+      "manifest_path": "/ws/b/Cargo.toml", "edition": "2021",
+      "targets": [{"name": "b", "kind": ["lib"], "src_path": "/ws/b/src/lib.rs", "edition": "2021"}]
+    }
+  ],
+  "workspace_members": ["a 0.1.0 (path+file:///ws/a)", "b 0.2.0 (path+file:///ws/b)"],
+  "resolve": {
+    "nodes": [
+      {"id": "a 0.1.0 (path+file:///ws/a)", "deps": [], "features": []},
+      {"id": "b 0.2.0 (path+file:///ws/b)", "deps": [], "features": []}
+    ]
+  },
+  "workspace_root": "/ws"
+}`
+
+const testWorkspaceCargoLock = `version = 3
+
+[[package]]
+name = "a"
+version = "0.1.0"
+
+[[package]]
+name = "b"
+version = "0.2.0"
+`
+
+func TestGenerateWorkspace(t *testing.T) {
+	testCases := []struct {
+		dir     string
+		modules []string
+		err     string
+	}{
+		{
+			dir: "/ws",
+			err: "the workspace has 2 members (a, b), run cargo2bp from the directory of each member to write its Android.bp",
+		},
+		{
+			dir:     "/ws/b",
+			modules: []string{"b_license", "libb"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.dir, func(t *testing.T) {
+			metadata, err := ReadMetadata(strings.NewReader(testWorkspaceMetadata))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lock, err := ReadCargoLock(strings.NewReader(testWorkspaceCargoLock))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g, err := newGenerator(metadata, lock)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.skipTests = true
+			g.dir = tc.dir
+
+			var out strings.Builder
+			err = g.generate(&out)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Errorf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Count(out.String(), "package {"); got != 1 {
+				t.Errorf("expected 1 package module, got %d", got)
+			}
+			for _, name := range tc.modules {
+				if !strings.Contains(out.String(), fmt.Sprintf("name: %q", name)) {
+					t.Errorf("expected module %s in:\n%s", name, out.String())
+				}
+			}
+			if strings.Contains(out.String(), `"liba"`) {
+				t.Errorf("unexpected module of another member in:\n%s", out.String())
+			}
+			if !strings.Contains(out.String(), `"src/lib.rs"`) {
+				t.Errorf("expected srcs relative to the member directory in:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestNewGeneratorOutdatedLock(t *testing.T) {
+	metadata, err := ReadMetadata(strings.NewReader(testMetadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := ReadCargoLock(strings.NewReader(strings.Replace(testCargoLock, `"1.0.3"`, `"1.0.2"`, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newGenerator(metadata, lock)
+	if err == nil || !strings.Contains(err.Error(), "quickcheck 1.0.3 is not in Cargo.lock") {
+		t.Errorf("expected an outdated Cargo.lock error, got %v", err)
+	}
+}
+
+func TestTargetApplies(t *testing.T) {
+	testCases := []struct {
+		target   string
+		expected bool
+	}{
+		{"", true},
+		{"cfg(unix)", true},
+		{"cfg(windows)", false},
+		{`cfg(target_os = "android")`, true},
+		{`cfg(target_os = "macos")`, false},
+		{`cfg(any(target_os = "linux", target_os = "macos"))`, true},
+		{`cfg(all(unix, not(target_os = "android")))`, true},
+		{`cfg(all(target_os = "android", target_env = "gnu"))`, false},
+		{`cfg(not(unix))`, false},
+		{"x86_64-unknown-linux-gnu", true},
+		{"x86_64-pc-windows-msvc", false},
+		{"cfg(unix", false},
+	}
+	for _, tc := range testCases {
+		if got := targetApplies(tc.target); got != tc.expected {
+			t.Errorf("targetApplies(%q): expected %t, got %t", tc.target, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Metadata is the subset of the output of `cargo metadata --format-version 1` used by cargo2bp.
+type Metadata struct {
+	Packages         []*Package
+	WorkspaceMembers []string `json:"workspace_members"`
+	Resolve          *Resolve
+	WorkspaceRoot    string `json:"workspace_root"`
+}
+
+type Package struct {
+	Name         string
+	Version      string
+	Id           string
+	License      string
+	LicenseFile  string `json:"license_file"`
+	ManifestPath string `json:"manifest_path"`
+	Edition      string
+	Targets      []*Target
+}
+
+type Target struct {
+	Name    string
+	Kind    []string
+	SrcPath string `json:"src_path"`
+	Edition string
+	Test    bool
+}
+
+func (t *Target) hasKind(kinds ...string) bool {
+	for _, k := range t.Kind {
+		for _, kind := range kinds {
+			if k == kind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t *Target) IsLibrary() bool {
+	return t.hasKind("lib", "rlib", "dylib")
+}
+
+func (t *Target) IsProcMacro() bool {
+	return t.hasKind("proc-macro")
+}
+
+func (t *Target) IsTest() bool {
+	return t.hasKind("test")
+}
+
+func (t *Target) IsBuildScript() bool {
+	return t.hasKind("custom-build")
+}
+
+// CrateName returns the name of the crate built from the target, as used in Rust code.
+func (t *Target) CrateName() string {
+	return strings.ReplaceAll(t.Name, "-", "_")
+}
+
+// LibTarget returns the library or proc-macro target of the package, or nil if it has neither.
+func (p *Package) LibTarget() *Target {
+	for _, t := range p.Targets {
+		if t.IsLibrary() || t.IsProcMacro() {
+			return t
+		}
+	}
+	return nil
+}
+
+func (p *Package) BuildScript() *Target {
+	for _, t := range p.Targets {
+		if t.IsBuildScript() {
+			return t
+		}
+	}
+	return nil
+}
+
+type Resolve struct {
+	Nodes []*Node
+}
+
+// Node is the resolved dependencies and features of a package.
+type Node struct {
+	Id       string
+	Deps     []*NodeDep
+	Features []string
+}
+
+type NodeDep struct {
+	// The name of the crate as used in the Rust code of the dependent, after renames.
+	Name     string
+	Pkg      string
+	DepKinds []DepKind `json:"dep_kinds"`
+}
+
+type DepKind struct {
+	// "dev", "build" or empty for normal dependencies.
+	Kind string
+	// The cfg expression or target triple the dependency is limited to, if any.
+	Target string
+}
+
+func ReadMetadata(r io.Reader) (*Metadata, error) {
+	var metadata Metadata
+	if err := json.NewDecoder(r).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("parsing cargo metadata: %w", err)
+	}
+	if metadata.Resolve == nil {
+		return nil, fmt.Errorf("cargo metadata has no dependency resolution, it must not be run with --no-deps")
+	}
+	return &metadata, nil
+}
+
+// LockedPackage is a [[package]] entry of Cargo.lock.
+type LockedPackage struct {
+	Name    string
+	Version string
+	Source  string
+}
+
+// ReadCargoLock reads the packages of a Cargo.lock file. Cargo.lock is TOML, but it is generated
+// by cargo in a fixed format, so only the [[package]] tables and their string values are parsed.
+func ReadCargoLock(r io.Reader) ([]LockedPackage, error) {
+	var packages []LockedPackage
+	var current *LockedPackage
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "[[package]]":
+			packages = append(packages, LockedPackage{})
+			current = &packages[len(packages)-1]
+		case strings.HasPrefix(line, "["):
+			current = nil
+		case current != nil && strings.Contains(line, "="):
+			key, value, _ := strings.Cut(line, "=")
+			key = strings.TrimSpace(key)
+			value = strings.TrimSpace(value)
+			if !strings.HasPrefix(value, `"`) {
+				// Lists such as dependencies are not needed.
+				continue
+			}
+			s, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("Cargo.lock:%d: invalid string %s", lineNum, value)
+			}
+			switch key {
+			case "name":
+				current.Name = s
+			case "version":
+				current.Version = s
+			case "source":
+				current.Source = s
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i, p := range packages {
+		if p.Name == "" || p.Version == "" {
+			return nil, fmt.Errorf("Cargo.lock: package %d has no name or version", i+1)
+		}
+	}
+	return packages, nil
+}
@@ -0,0 +1,343 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// fsimage inspects ext4 and erofs images, sparse or not, without mounting them or running the
+// host tools of the filesystems. It lists the files in an image with their owners, permissions,
+// SELinux labels and capabilities, extracts files from it, and verifies that it contains the
+// files of the installed-files.json of its partition.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"android/soong/fsimage"
+)
+
+var commands = map[string]func(args []string) error{
+	"list":    listCommand,
+	"cat":     catCommand,
+	"extract": extractCommand,
+	"verify":  verifyCommand,
+}
+
+// usages is kept out of commands to avoid an initialization cycle, as the commands print their
+// own usage.
+var usages = map[string]string{
+	"list":    "list [--json] <image>",
+	"cat":     "cat <image> <path>",
+	"extract": "extract [--path <path>] <image> <dir>",
+	"verify": "verify --installed_files <json> [--prefix <prefix>] [--require_selinux_labels] " +
+		"[--stamp <file>] <image>",
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage:\n")
+	for _, name := range sortedCommands() {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", os.Args[0], usages[name])
+	}
+}
+
+func sortedCommands() []string {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(1)
+	}
+	if err := cmd(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s\n", os.Args[0], usages[name])
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// modeString formats a mode like ls -l.
+func modeString(mode uint32) string {
+	var b strings.Builder
+	switch mode & fsimage.ModeType {
+	case fsimage.ModeDir:
+		b.WriteByte('d')
+	case fsimage.ModeSymlink:
+		b.WriteByte('l')
+	case fsimage.ModeChar:
+		b.WriteByte('c')
+	case fsimage.ModeBlock:
+		b.WriteByte('b')
+	case fsimage.ModeFifo:
+		b.WriteByte('p')
+	case fsimage.ModeSocket:
+		b.WriteByte('s')
+	default:
+		b.WriteByte('-')
+	}
+	const rwx = "rwxrwxrwx"
+	for i := 0; i < 9; i++ {
+		if mode&(1<<(8-i)) != 0 {
+			b.WriteByte(rwx[i])
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	s := []byte(b.String())
+	// The setuid, setgid and sticky bits replace the execute bits.
+	for _, special := range []struct {
+		bit      uint32
+		index    int
+		set, off byte
+	}{{04000, 3, 's', 'S'}, {02000, 6, 's', 'S'}, {01000, 9, 't', 'T'}} {
+		if mode&special.bit != 0 {
+			if s[special.index] == 'x' {
+				s[special.index] = special.set
+			} else {
+				s[special.index] = special.off
+			}
+		}
+	}
+	return string(s)
+}
+
+type listEntry struct {
+	Path         string `json:"path"`
+	Mode         string `json:"mode"`
+	Uid          uint32 `json:"uid"`
+	Gid          uint32 `json:"gid"`
+	Size         int64  `json:"size"`
+	SELinux      string `json:"selinux,omitempty"`
+	Capabilities string `json:"capabilities,omitempty"`
+	Link         string `json:"link,omitempty"`
+}
+
+func newListEntry(e *fsimage.Entry) (listEntry, error) {
+	entry := listEntry{
+		Path:    e.Path,
+		Mode:    fmt.Sprintf("%06o", e.Mode),
+		Uid:     e.Uid,
+		Gid:     e.Gid,
+		Size:    e.Size,
+		SELinux: e.SELinuxContext(),
+		Link:    e.Link,
+	}
+	caps, err := e.Capabilities()
+	if err != nil {
+		return listEntry{}, err
+	}
+	if caps != 0 {
+		entry.Capabilities = fmt.Sprintf("%#x", caps)
+	}
+	return entry, nil
+}
+
+func writeList(w io.Writer, img *fsimage.Image, asJSON bool) error {
+	var entries []listEntry
+	err := img.Walk(func(e *fsimage.Entry) error {
+		entry, err := newListEntry(e)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			entries = append(entries, entry)
+			return nil
+		}
+		line := fmt.Sprintf("%s %5d %5d %10d %s", modeString(e.Mode), e.Uid, e.Gid, e.Size, e.Path)
+		if e.IsSymlink() {
+			line += " -> " + e.Link
+		}
+		if entry.SELinux != "" {
+			line += " selinux=" + entry.SELinux
+		}
+		if entry.Capabilities != "" {
+			line += " capabilities=" + entry.Capabilities
+		}
+		_, err = fmt.Fprintln(w, line)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	return nil
+}
+
+func listCommand(args []string) error {
+	flags := newFlagSet("list")
+	asJSON := flags.Bool("json", false, "write the entries as JSON")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	img, closer, err := fsimage.OpenFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	return writeList(os.Stdout, img, *asJSON)
+}
+
+func catCommand(args []string) error {
+	flags := newFlagSet("cat")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	img, closer, err := fsimage.OpenFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	e, err := img.Lookup(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	return img.Copy(os.Stdout, e)
+}
+
+// extract writes the entry at path in the image and everything under it to dir. Owners and
+// extended attributes are not preserved.
+func extract(img *fsimage.Image, path, dir string) error {
+	root, err := img.Lookup(path)
+	if err != nil {
+		return err
+	}
+	prefix := root.Path + "/"
+	return img.Walk(func(e *fsimage.Entry) error {
+		var rel string
+		switch {
+		case root.Path == ".":
+			rel = e.Path
+		case e.Path == root.Path:
+			rel = filepath.Base(e.Path)
+		case strings.HasPrefix(e.Path, prefix):
+			rel = filepath.Join(filepath.Base(root.Path), strings.TrimPrefix(e.Path, prefix))
+		default:
+			return nil
+		}
+		out := filepath.Join(dir, filepath.FromSlash(rel))
+		switch {
+		case e.IsDir():
+			return os.MkdirAll(out, os.FileMode(e.Mode&0777)|0700)
+		case e.IsSymlink():
+			return os.Symlink(e.Link, out)
+		case e.IsRegular():
+			f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(e.Mode&0777))
+			if err != nil {
+				return err
+			}
+			if err := img.Copy(f, e); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		default:
+			fmt.Fprintf(os.Stderr, "warning: skipping special file %s\n", e.Path)
+			return nil
+		}
+	})
+}
+
+func extractCommand(args []string) error {
+	flags := newFlagSet("extract")
+	path := flags.String("path", ".", "the file or directory in the image to extract")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	img, closer, err := fsimage.OpenFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	if err := os.MkdirAll(flags.Arg(1), 0777); err != nil {
+		return err
+	}
+	return extract(img, *path, flags.Arg(1))
+}
+
+func verifyCommand(args []string) error {
+	flags := newFlagSet("verify")
+	installedFiles := flags.String("installed_files", "", "installed-files.json of the partition")
+	prefix := flags.String("prefix", "", "prefix of the names in the installed files that is the root of the image")
+	requireLabels := flags.Bool("require_selinux_labels", false, "fail if an entry doesn't have an SELinux label")
+	stamp := flags.String("stamp", "", "file to write if the image is valid")
+	flags.Parse(args)
+	if flags.NArg() != 1 || *installedFiles == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	f, err := os.Open(*installedFiles)
+	if err != nil {
+		return err
+	}
+	installed, err := fsimage.ReadInstalledFiles(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	img, closer, err := fsimage.OpenFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	problems, err := fsimage.Verify(img, installed, fsimage.VerifyOptions{
+		Prefix:               *prefix,
+		RequireSELinuxLabels: *requireLabels,
+	})
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p)
+		}
+		return fmt.Errorf("%s doesn't match %s: %d problems", flags.Arg(0), *installedFiles, len(problems))
+	}
+	if *stamp != "" {
+		return os.WriteFile(*stamp, nil, 0666)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"android/soong/fsimage"
+)
+
+func TestModeString(t *testing.T) {
+	for _, test := range []struct {
+		mode     uint32
+		expected string
+	}{
+		{fsimage.ModeDir | 0755, "drwxr-xr-x"},
+		{fsimage.ModeRegular | 0644, "-rw-r--r--"},
+		{fsimage.ModeSymlink | 0777, "lrwxrwxrwx"},
+		{fsimage.ModeRegular | 04755, "-rwsr-xr-x"},
+		{fsimage.ModeRegular | 02640, "-rw-r-S---"},
+		{fsimage.ModeDir | 01777, "drwxrwxrwt"},
+		{fsimage.ModeDir | 01770, "drwxrwx--T"},
+		{fsimage.ModeChar | 0600, "crw-------"},
+		{fsimage.ModeBlock | 0600, "brw-------"},
+		{fsimage.ModeFifo | 0600, "prw-------"},
+		{fsimage.ModeSocket | 0600, "srw-------"},
+	} {
+		if got := modeString(test.mode); got != test.expected {
+			t.Errorf("modeString(%#o): expected %q, got %q", test.mode, test.expected, got)
+		}
+	}
+}
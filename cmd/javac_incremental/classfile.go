@@ -0,0 +1,348 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	accPrivate   = 0x0002
+	accSuper     = 0x0020
+	accSynthetic = 0x1000
+)
+
+// Constant pool tags, see JVMS 4.4.
+const (
+	constantUtf8               = 1
+	constantInteger            = 3
+	constantFloat              = 4
+	constantLong               = 5
+	constantDouble             = 6
+	constantClass              = 7
+	constantString             = 8
+	constantFieldref           = 9
+	constantMethodref          = 10
+	constantInterfaceMethodref = 11
+	constantNameAndType        = 12
+	constantMethodHandle       = 15
+	constantMethodType         = 16
+	constantDynamic            = 17
+	constantInvokeDynamic      = 18
+	constantModule             = 19
+	constantPackage            = 20
+)
+
+var errTruncated = errors.New("truncated class file")
+
+type constant struct {
+	tag   byte
+	index uint16
+	utf8  string
+	value uint64
+}
+
+// member is a field or a method of a class.
+type member struct {
+	access     uint16
+	name       string
+	descriptor string
+	// signature is the generic signature of the member, if any.
+	signature string
+	// constantValue is the value of a constant field, which javac inlines into the classes that
+	// use it.
+	constantValue string
+	// exceptions are the checked exceptions thrown by a method.
+	exceptions []string
+}
+
+// classFile is the part of a class file that matters to the compilation of other classes.
+type classFile struct {
+	access     uint16
+	name       string
+	superName  string
+	interfaces []string
+	signature  string
+	// sourceFile is the name of the source file the class was compiled from, without its
+	// directory.
+	sourceFile string
+	// innerClasses describes the nested classes of the class, and the class itself if it is
+	// nested, as the access flags of nested classes are only recorded there.
+	innerClasses         []string
+	permittedSubclasses  []string
+	fields               []member
+	methods              []member
+	referencedClassNames []string
+}
+
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.data) < n {
+		r.err = errTruncated
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *reader) u1() byte {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) u2() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) u4() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+// descriptorClassRegexp matches the class names in field and method descriptors and in generic
+// signatures.
+var descriptorClassRegexp = regexp.MustCompile(`L([^;<>:.\[]+)[;<]`)
+
+// parseClassFile parses the parts of a class file needed to compute its ABI and the classes it
+// references.
+func parseClassFile(data []byte) (*classFile, error) {
+	r := &reader{data: data}
+	if magic := r.u4(); r.err == nil && magic != 0xcafebabe {
+		return nil, fmt.Errorf("bad magic number %#x", magic)
+	}
+	r.u2() // minor_version
+	r.u2() // major_version
+
+	pool := make([]constant, r.u2())
+	for i := 1; i < len(pool) && r.err == nil; i++ {
+		c := constant{tag: r.u1()}
+		switch c.tag {
+		case constantUtf8:
+			c.utf8 = string(r.bytes(int(r.u2())))
+		case constantInteger, constantFloat:
+			c.value = uint64(r.u4())
+		case constantLong, constantDouble:
+			c.value = uint64(r.u4())<<32 | uint64(r.u4())
+		case constantClass, constantString, constantMethodType, constantModule, constantPackage:
+			c.index = r.u2()
+		case constantFieldref, constantMethodref, constantInterfaceMethodref, constantNameAndType,
+			constantDynamic, constantInvokeDynamic:
+			c.index = r.u2()
+			r.u2()
+		case constantMethodHandle:
+			r.u1()
+			c.index = r.u2()
+		default:
+			if r.err == nil {
+				return nil, fmt.Errorf("unknown constant pool tag %d", c.tag)
+			}
+		}
+		pool[i] = c
+		if c.tag == constantLong || c.tag == constantDouble {
+			// 8-byte constants take two entries of the constant pool.
+			i++
+		}
+	}
+
+	utf8 := func(index uint16) string {
+		if int(index) < len(pool) && pool[index].tag == constantUtf8 {
+			return pool[index].utf8
+		}
+		return ""
+	}
+	className := func(index uint16) string {
+		if int(index) < len(pool) && pool[index].tag == constantClass {
+			return utf8(pool[index].index)
+		}
+		return ""
+	}
+	constantValue := func(index uint16) string {
+		if int(index) >= len(pool) {
+			return ""
+		}
+		c := pool[index]
+		switch c.tag {
+		case constantInteger:
+			return fmt.Sprintf("I%d", int32(c.value))
+		case constantFloat:
+			return fmt.Sprintf("F%v", math.Float32frombits(uint32(c.value)))
+		case constantLong:
+			return fmt.Sprintf("J%d", int64(c.value))
+		case constantDouble:
+			return fmt.Sprintf("D%v", math.Float64frombits(c.value))
+		case constantString:
+			return fmt.Sprintf("S%q", utf8(c.index))
+		}
+		return ""
+	}
+
+	cf := &classFile{}
+	cf.access = r.u2()
+	cf.name = className(r.u2())
+	cf.superName = className(r.u2())
+	for n := r.u2(); n > 0 && r.err == nil; n-- {
+		cf.interfaces = append(cf.interfaces, className(r.u2()))
+	}
+
+	readMembers := func() []member {
+		var members []member
+		for n := r.u2(); n > 0 && r.err == nil; n-- {
+			m := member{access: r.u2(), name: utf8(r.u2()), descriptor: utf8(r.u2())}
+			for a := r.u2(); a > 0 && r.err == nil; a-- {
+				attr := utf8(r.u2())
+				ar := &reader{data: r.bytes(int(r.u4()))}
+				switch attr {
+				case "Signature":
+					m.signature = utf8(ar.u2())
+				case "ConstantValue":
+					m.constantValue = constantValue(ar.u2())
+				case "Exceptions":
+					for e := ar.u2(); e > 0 && ar.err == nil; e-- {
+						m.exceptions = append(m.exceptions, className(ar.u2()))
+					}
+				}
+				if ar.err != nil {
+					r.err = ar.err
+				}
+			}
+			members = append(members, m)
+		}
+		return members
+	}
+	cf.fields = readMembers()
+	cf.methods = readMembers()
+
+	for a := r.u2(); a > 0 && r.err == nil; a-- {
+		attr := utf8(r.u2())
+		ar := &reader{data: r.bytes(int(r.u4()))}
+		switch attr {
+		case "SourceFile":
+			cf.sourceFile = utf8(ar.u2())
+		case "Signature":
+			cf.signature = utf8(ar.u2())
+		case "InnerClasses":
+			for n := ar.u2(); n > 0 && ar.err == nil; n-- {
+				inner, outer, name, access := className(ar.u2()), className(ar.u2()), utf8(ar.u2()), ar.u2()
+				if inner == cf.name || outer == cf.name {
+					cf.innerClasses = append(cf.innerClasses,
+						fmt.Sprintf("%s %s %s %#x", inner, outer, name, access))
+				}
+			}
+		case "PermittedSubclasses":
+			for n := ar.u2(); n > 0 && ar.err == nil; n-- {
+				cf.permittedSubclasses = append(cf.permittedSubclasses, className(ar.u2()))
+			}
+		}
+		if ar.err != nil {
+			r.err = ar.err
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if cf.name == "" {
+		return nil, fmt.Errorf("missing class name")
+	}
+
+	// Collect the classes referenced by the class, from the class constants and from the
+	// descriptors and signatures that mention classes without a class constant, like the types of
+	// the parameters of a method that is called.
+	refs := make(map[string]bool)
+	for _, c := range pool {
+		switch c.tag {
+		case constantClass:
+			name := utf8(c.index)
+			if strings.HasPrefix(name, "[") {
+				for _, m := range descriptorClassRegexp.FindAllStringSubmatch(name, -1) {
+					refs[m[1]] = true
+				}
+			} else if name != "" {
+				refs[name] = true
+			}
+		case constantUtf8:
+			if strings.Contains(c.utf8, ";") {
+				for _, m := range descriptorClassRegexp.FindAllStringSubmatch(c.utf8, -1) {
+					refs[m[1]] = true
+				}
+			}
+		}
+	}
+	delete(refs, cf.name)
+	for name := range refs {
+		cf.referencedClassNames = append(cf.referencedClassNames, name)
+	}
+	sort.Strings(cf.referencedClassNames)
+
+	return cf, nil
+}
+
+// packageName returns the package of the class, in internal form.
+func (cf *classFile) packageName() string {
+	if dir := path.Dir(cf.name); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+// abi returns a digest of the parts of the class that other classes can depend on: its
+// declaration and its non-private, non-synthetic members, including the values of its constants.
+// A change to the code of a method or to a private member does not change the ABI of the class.
+func (cf *classFile) abi() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "class %#x %s %s %s\n", cf.access&^accSuper, cf.name, cf.superName, cf.signature)
+	for _, list := range [][]string{cf.interfaces, cf.innerClasses, cf.permittedSubclasses} {
+		sorted := append([]string(nil), list...)
+		sort.Strings(sorted)
+		fmt.Fprintf(h, "%q\n", sorted)
+	}
+	for _, members := range [][]member{cf.fields, cf.methods} {
+		var lines []string
+		for _, m := range members {
+			if m.access&(accPrivate|accSynthetic) != 0 {
+				continue
+			}
+			exceptions := append([]string(nil), m.exceptions...)
+			sort.Strings(exceptions)
+			lines = append(lines, fmt.Sprintf("%#x %s %s %s %s %q",
+				m.access, m.name, m.descriptor, m.signature, m.constantValue, exceptions))
+		}
+		sort.Strings(lines)
+		fmt.Fprintf(h, "%q\n", lines)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
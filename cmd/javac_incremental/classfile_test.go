@@ -0,0 +1,252 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type testMember struct {
+	access     uint16
+	name       string
+	descriptor string
+	// constant is the ConstantValue of a field, if hasConstant is set.
+	constant    int32
+	hasConstant bool
+}
+
+// testClass is written to a class file by bytes.
+type testClass struct {
+	name       string
+	superName  string
+	interfaces []string
+	sourceFile string
+	fields     []testMember
+	methods    []testMember
+	// refs are classes referenced by the code of the class.
+	refs []string
+}
+
+type constantPool struct {
+	buf   bytes.Buffer
+	count uint16
+	index map[string]uint16
+}
+
+func (p *constantPool) add(key string, write func(*bytes.Buffer)) uint16 {
+	if i, ok := p.index[key]; ok {
+		return i
+	}
+	write(&p.buf)
+	p.count++
+	p.index[key] = p.count
+	return p.count
+}
+
+func (p *constantPool) utf8(s string) uint16 {
+	return p.add("utf8:"+s, func(b *bytes.Buffer) {
+		b.WriteByte(constantUtf8)
+		binary.Write(b, binary.BigEndian, uint16(len(s)))
+		b.WriteString(s)
+	})
+}
+
+func (p *constantPool) class(name string) uint16 {
+	nameIndex := p.utf8(name)
+	return p.add("class:"+name, func(b *bytes.Buffer) {
+		b.WriteByte(constantClass)
+		binary.Write(b, binary.BigEndian, nameIndex)
+	})
+}
+
+func (p *constantPool) integer(v int32) uint16 {
+	return p.add(fmt.Sprintf("int:%d", v), func(b *bytes.Buffer) {
+		b.WriteByte(constantInteger)
+		binary.Write(b, binary.BigEndian, v)
+	})
+}
+
+func (tc testClass) bytes() []byte {
+	p := &constantPool{index: make(map[string]uint16)}
+	body := &bytes.Buffer{}
+	u2 := func(v uint16) { binary.Write(body, binary.BigEndian, v) }
+
+	u2(0x0021) // ACC_PUBLIC | ACC_SUPER
+	u2(p.class(tc.name))
+	u2(p.class(tc.superName))
+	u2(uint16(len(tc.interfaces)))
+	for _, i := range tc.interfaces {
+		u2(p.class(i))
+	}
+	for _, members := range [][]testMember{tc.fields, tc.methods} {
+		u2(uint16(len(members)))
+		for _, m := range members {
+			u2(m.access)
+			u2(p.utf8(m.name))
+			u2(p.utf8(m.descriptor))
+			if m.hasConstant {
+				u2(1)
+				u2(p.utf8("ConstantValue"))
+				binary.Write(body, binary.BigEndian, uint32(2))
+				u2(p.integer(m.constant))
+			} else {
+				u2(0)
+			}
+		}
+	}
+	for _, ref := range tc.refs {
+		p.class(ref)
+	}
+	if tc.sourceFile != "" {
+		u2(1)
+		u2(p.utf8("SourceFile"))
+		binary.Write(body, binary.BigEndian, uint32(2))
+		u2(p.utf8(tc.sourceFile))
+	} else {
+		u2(0)
+	}
+
+	out := &bytes.Buffer{}
+	binary.Write(out, binary.BigEndian, uint32(0xcafebabe))
+	binary.Write(out, binary.BigEndian, uint16(0))
+	binary.Write(out, binary.BigEndian, uint16(52))
+	binary.Write(out, binary.BigEndian, p.count+1)
+	out.Write(p.buf.Bytes())
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+var testBar = testClass{
+	name:       "foo/Bar",
+	superName:  "java/lang/Object",
+	interfaces: []string{"foo/Iface"},
+	sourceFile: "Bar.java",
+	fields: []testMember{
+		{access: 0x0019, name: "X", descriptor: "I", constant: 1, hasConstant: true},
+		{access: 0x0002, name: "secret", descriptor: "Ljava/lang/String;"},
+	},
+	methods: []testMember{
+		{access: 0x0001, name: "m", descriptor: "(Lfoo/Baz;)V"},
+		{access: 0x0002, name: "helper", descriptor: "()V"},
+	},
+	refs: []string{"foo/Qux", "[Lfoo/Elem;"},
+}
+
+func TestParseClassFile(t *testing.T) {
+	cf, err := parseClassFile(testBar.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cf.name != "foo/Bar" || cf.superName != "java/lang/Object" || cf.sourceFile != "Bar.java" {
+		t.Errorf("unexpected class %q super %q source %q", cf.name, cf.superName, cf.sourceFile)
+	}
+	if cf.packageName() != "foo" {
+		t.Errorf("expected package foo, got %q", cf.packageName())
+	}
+	if len(cf.fields) != 2 || cf.fields[0].constantValue != "I1" {
+		t.Errorf("unexpected fields %+v", cf.fields)
+	}
+	expectedRefs := []string{"foo/Baz", "foo/Elem", "foo/Iface", "foo/Qux", "java/lang/Object", "java/lang/String"}
+	if !reflect.DeepEqual(cf.referencedClassNames, expectedRefs) {
+		t.Errorf("expected references %q, got %q", expectedRefs, cf.referencedClassNames)
+	}
+
+	if _, err := parseClassFile([]byte{0xca, 0xfe}); err == nil {
+		t.Errorf("expected an error for a truncated class file")
+	}
+	if _, err := parseClassFile([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0}); err == nil {
+		t.Errorf("expected an error for a bad magic number")
+	}
+}
+
+func TestAbi(t *testing.T) {
+	abi := func(tc testClass) string {
+		t.Helper()
+		cf, err := parseClassFile(tc.bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cf.abi()
+	}
+	base := abi(testBar)
+
+	modify := func(f func(tc *testClass)) testClass {
+		tc := testBar
+		tc.fields = append([]testMember(nil), testBar.fields...)
+		tc.methods = append([]testMember(nil), testBar.methods...)
+		f(&tc)
+		return tc
+	}
+
+	testCases := []struct {
+		name    string
+		class   testClass
+		changed bool
+	}{
+		{
+			name:    "code references",
+			class:   modify(func(tc *testClass) { tc.refs = []string{"foo/Other"} }),
+			changed: false,
+		},
+		{
+			name:    "private method",
+			class:   modify(func(tc *testClass) { tc.methods[1].descriptor = "(I)V" }),
+			changed: false,
+		},
+		{
+			name: "synthetic method",
+			class: modify(func(tc *testClass) {
+				tc.methods = append(tc.methods, testMember{access: 0x1008, name: "access$000", descriptor: "()V"})
+			}),
+			changed: false,
+		},
+		{
+			name:    "member order",
+			class:   modify(func(tc *testClass) { tc.methods[0], tc.methods[1] = tc.methods[1], tc.methods[0] }),
+			changed: false,
+		},
+		{
+			name:    "constant value",
+			class:   modify(func(tc *testClass) { tc.fields[0].constant = 2 }),
+			changed: true,
+		},
+		{
+			name:    "public method",
+			class:   modify(func(tc *testClass) { tc.methods[0].descriptor = "(Lfoo/Baz;I)V" }),
+			changed: true,
+		},
+		{
+			name:    "method visibility",
+			class:   modify(func(tc *testClass) { tc.methods[1].access = 0 }),
+			changed: true,
+		},
+		{
+			name:    "super class",
+			class:   modify(func(tc *testClass) { tc.superName = "foo/Base" }),
+			changed: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if changed := abi(tc.class) != base; changed != tc.changed {
+				t.Errorf("expected ABI changed %v, got %v", tc.changed, changed)
+			}
+		})
+	}
+}
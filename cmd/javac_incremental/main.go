@@ -0,0 +1,429 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// javac_incremental runs javac for partial compile builds. It uses find_input_delta to find the
+// inputs that changed since the previous compile and, when only sources changed, recompiles them
+// and the sources that depend on them on top of the classes of the previous classes jar. It falls
+// back to compiling all the sources when an input that may change the ABI of the classes changed,
+// or when the ABI of the recompiled classes changed.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	fid_lib "android/soong/cmd/find_input_delta/find_input_delta_lib"
+)
+
+var fileSepRegex = regexp.MustCompile("[^[:space:]]+")
+
+func main() {
+	c := &compiler{}
+	var inputsFile, srcJarList, srcJars, depsFile string
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s --target <jar> --classes_dir <dir> --inputs_file <file> "+
+			"[--srcjar_list <file>] [--srcjars <srcjars>] [--deps_file <file>] -- <javac command>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.StringVar(&c.target, "target", "", "the classes jar the classes are zipped to after the compile")
+	flag.StringVar(&c.classesDir, "classes_dir", "", "the output directory of javac")
+	flag.StringVar(&inputsFile, "inputs_file", "", "file containing the list of sources")
+	flag.StringVar(&srcJarList, "srcjar_list", "", "file containing the list of sources extracted from srcjars")
+	flag.StringVar(&srcJars, "srcjars", "", "space separated list of srcjars")
+	flag.StringVar(&depsFile, "deps_file", "", "file containing the list of the other dependencies of the compile")
+	flag.Parse()
+
+	c.javac = flag.Args()
+	if c.target == "" || c.classesDir == "" || inputsFile == "" || len(c.javac) == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	c.srcJars = fileSepRegex.FindAllString(srcJars, -1)
+
+	var err error
+	if depsFile != "" {
+		if c.deps, err = readFileList(depsFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if c.sources, err = readFileList(inputsFile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	c.sourceLists = []string{inputsFile}
+	if srcJarList != "" {
+		if c.srcJarSources, err = readFileList(srcJarList); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		c.sourceLists = append(c.sourceLists, srcJarList)
+	}
+
+	if err := c.run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// javac already reported the errors.
+			os.Exit(exitErr.ExitCode())
+		}
+		fmt.Fprintln(os.Stderr, "javac_incremental:", err)
+		os.Exit(1)
+	}
+}
+
+func removeIfExists(filename string) error {
+	if err := os.Remove(filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func readFileList(filename string) ([]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return fileSepRegex.FindAllString(string(data), -1), nil
+}
+
+type compiler struct {
+	target     string
+	classesDir string
+	javac      []string
+
+	// sources are the sources given in the inputs file, srcJarSources are those extracted from
+	// the srcjars, and sourceLists are the files listing them.
+	sources       []string
+	srcJarSources []string
+	sourceLists   []string
+
+	srcJars []string
+	deps    []string
+}
+
+func (c *compiler) run() error {
+	// Find the inputs that changed since the previous compile. The new state is moved over the
+	// prior state by the rule once the classes jar is written.
+	inputs := append(append(append([]string(nil), c.sources...), c.srcJars...), c.deps...)
+	priorInputs, err := fid_lib.LoadState(c.target+".pc_state", fid_lib.OsFs)
+	if err != nil {
+		return err
+	}
+	newInputs, err := fid_lib.CreateState(inputs, true, fid_lib.OsFs)
+	if err != nil {
+		return err
+	}
+	if err := fid_lib.WriteState(newInputs, c.target+".pc_state.new"); err != nil {
+		return err
+	}
+	delta := fid_lib.CompareInternalState(priorInputs, newInputs, c.target)
+	if metricsDir := os.Getenv("SOONG_METRICS_AGGREGATION_DIR"); metricsDir != "" {
+		if err := delta.WriteMetrics(metricsDir, os.Getenv("OUT_DIR")); err != nil {
+			return err
+		}
+	}
+
+	prior, err := loadJavacState(c.target + ".javac_state")
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(c.target); err != nil {
+		// The state is only valid together with the jar it describes.
+		prior = nil
+	}
+	abis := newJarAbis(prior, delta)
+	command := commandDigest(c.javac)
+
+	var state *javacState
+	p := makePlan(prior, command, delta, c.sources, abis.get)
+	if !p.full {
+		state, err = c.partial(prior, p)
+		if err != nil {
+			p = fullPlan("%s", err)
+		}
+	}
+	if p.full {
+		// The state of the previous compile won't describe the new jar.
+		if err := removeIfExists(c.target + ".javac_state"); err != nil {
+			return err
+		}
+		if state, err = c.full(); err != nil {
+			return err
+		}
+	}
+	if state == nil {
+		// The next compile will be a full one.
+		return nil
+	}
+
+	state.Command = command
+	state.Jars = make(map[string]string)
+	for _, dep := range c.deps {
+		if isJar(dep) {
+			abi, err := abis.get(dep)
+			if err != nil {
+				// The next compile will be a full one.
+				return removeIfExists(c.target + ".javac_state")
+			}
+			state.Jars[dep] = abi
+		}
+	}
+	return writeJavacState(state, c.target+".javac_state.new")
+}
+
+// partial recompiles the sources of the plan on top of the classes of the previous jar. It
+// returns an error if the partial compile failed or changed the ABI of a class, in which case a
+// full compile is needed.
+func (c *compiler) partial(prior *javacState, p *plan) (*javacState, error) {
+	stale := make(map[string]bool, len(p.stale))
+	for _, name := range p.stale {
+		stale[name] = true
+	}
+	if err := extractClasses(c.target, c.classesDir, func(name string) bool { return !stale[name] }); err != nil {
+		return nil, err
+	}
+
+	state := &javacState{Classes: make(map[string]*classState)}
+	for name, cs := range prior.Classes {
+		if !stale[name] {
+			state.Classes[name] = cs
+		}
+	}
+	if len(p.recompile) == 0 {
+		return state, nil
+	}
+
+	listFile := c.target + ".incremental.rsp"
+	classpathFile := c.target + ".incremental.classpath"
+	defer os.Remove(listFile)
+	defer os.Remove(classpathFile)
+	if err := os.WriteFile(listFile, []byte(strings.Join(p.recompile, "\n")+"\n"), 0644); err != nil {
+		return nil, err
+	}
+	args, err := withClassesOnClasspath(c.javac, c.classesDir, classpathFile)
+	if err != nil {
+		return nil, err
+	}
+	output := &bytes.Buffer{}
+	cmd := exec.Command(args[0], append(args[1:], "@"+listFile)...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("partial compile failed: %w", err)
+	}
+
+	recompiled, err := readClasses(c.classesDir, func(name string) bool { return state.Classes[name] == nil })
+	if err != nil {
+		return nil, err
+	}
+	index, err := newSourceIndex(p.recompile, os.ReadFile)
+	if err != nil {
+		return nil, err
+	}
+	for name, cf := range recompiled {
+		old := prior.Classes[name]
+		if old == nil {
+			return nil, fmt.Errorf("class %s was added", name)
+		}
+		if old.Abi != cf.abi() {
+			return nil, fmt.Errorf("ABI of class %s changed", name)
+		}
+		if index.source(cf) != old.Source {
+			return nil, fmt.Errorf("class %s moved to another source", name)
+		}
+	}
+	for _, name := range p.stale {
+		if recompiled[name] == nil {
+			return nil, fmt.Errorf("class %s was removed", name)
+		}
+	}
+	addClasses(state, recompiled, index)
+
+	// Only report the warnings of a successful partial compile, the errors of a failed one are
+	// reported by the full compile.
+	os.Stderr.Write(output.Bytes())
+	return state, nil
+}
+
+// full compiles all the sources. It returns a nil state if the classes can't be mapped to their
+// sources, and an error if javac fails.
+func (c *compiler) full() (*javacState, error) {
+	if err := os.RemoveAll(c.classesDir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(c.classesDir, 0777); err != nil {
+		return nil, err
+	}
+	args := append([]string(nil), c.javac[1:]...)
+	for _, list := range c.sourceLists {
+		args = append(args, "@"+list)
+	}
+	cmd := exec.Command(c.javac[0], args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	classes, err := readClasses(c.classesDir, func(string) bool { return true })
+	if err != nil {
+		return nil, nil
+	}
+	index, err := newSourceIndex(append(append([]string(nil), c.sources...), c.srcJarSources...), os.ReadFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, cf := range classes {
+		if index.source(cf) == "" {
+			return nil, nil
+		}
+	}
+	state := &javacState{Classes: make(map[string]*classState)}
+	addClasses(state, classes, index)
+	return state, nil
+}
+
+// addClasses adds compiled classes to the state, with their dependencies on the classes of the
+// state.
+func addClasses(state *javacState, classes map[string]*classFile, index sourceIndex) {
+	for name, cf := range classes {
+		state.Classes[name] = &classState{Source: index.source(cf), Abi: cf.abi()}
+	}
+	for name, cf := range classes {
+		cs := state.Classes[name]
+		for _, dep := range cf.referencedClassNames {
+			if state.Classes[dep] != nil {
+				cs.Deps = append(cs.Deps, dep)
+			}
+		}
+	}
+}
+
+// readClasses parses the classes in dir whose names are accepted by filter.
+func readClasses(dir string, filter func(string) bool) (map[string]*classFile, error) {
+	classes := make(map[string]*classFile)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(p, ".class") || d.Name() == "module-info.class" {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.ToSlash(rel), ".class")
+		if !filter(name) {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		cf, err := parseClassFile(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		classes[name] = cf
+		return nil
+	})
+	return classes, err
+}
+
+// extractClasses extracts the classes of a jar accepted by filter to dir.
+func extractClasses(jar, dir string, filter func(string) bool) error {
+	r, err := zip.OpenReader(jar)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		name, ok := strings.CutSuffix(f.Name, ".class")
+		if !ok || !filter(name) {
+			continue
+		}
+		if err := extractFile(f, filepath.Join(dir, filepath.FromSlash(f.Name))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(f *zip.File, out string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := os.MkdirAll(filepath.Dir(out), 0777); err != nil {
+		return err
+	}
+	w, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, rc); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// jarAbis computes the ABI digests of jars, reusing those of the previous compile for the jars
+// whose classes didn't change.
+type jarAbis struct {
+	prior    map[string]string
+	changed  map[string]bool
+	computed map[string]string
+}
+
+func newJarAbis(prior *javacState, delta *fid_lib.FileList) *jarAbis {
+	a := &jarAbis{changed: make(map[string]bool), computed: make(map[string]string)}
+	if prior != nil {
+		a.prior = prior.Jars
+	}
+	for _, name := range delta.Additions {
+		a.changed[name] = true
+	}
+	for i := range delta.Changes {
+		if classesChanged(&delta.Changes[i]) {
+			a.changed[delta.Changes[i].Name] = true
+		}
+	}
+	return a
+}
+
+func (a *jarAbis) get(jar string) (string, error) {
+	if abi, ok := a.prior[jar]; ok && !a.changed[jar] {
+		return abi, nil
+	}
+	if abi, ok := a.computed[jar]; ok {
+		return abi, nil
+	}
+	abi, err := jarAbi(jar)
+	if err != nil {
+		return "", err
+	}
+	a.computed[jar] = abi
+	return abi, nil
+}
@@ -0,0 +1,315 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	fid_lib "android/soong/cmd/find_input_delta/find_input_delta_lib"
+)
+
+// javacState describes the classes jar written by the previous compile. It is persisted next to
+// the jar, and is only valid for that jar.
+type javacState struct {
+	// Command is a digest of the javac command line; any change to it requires a full compile.
+	Command string
+
+	// Classes maps the name of each class in the jar, in internal form, to its state.
+	Classes map[string]*classState
+
+	// Jars maps the jars the compile depends on to the digest of their ABI.
+	Jars map[string]string
+}
+
+// classState is the persisted state of a class, which is the class dependency graph of the jar.
+type classState struct {
+	// Source is the source file the class was compiled from.
+	Source string
+
+	// Abi is the digest of the ABI of the class.
+	Abi string
+
+	// Deps are the classes of the same jar that the class references.
+	Deps []string `json:",omitempty"`
+}
+
+func loadJavacState(filename string) (*javacState, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	s := &javacState{}
+	if err := json.Unmarshal(data, s); err != nil {
+		// A corrupt state only means that the next compile is a full one.
+		return nil, nil
+	}
+	return s, nil
+}
+
+func writeJavacState(s *javacState, filename string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// commandDigest returns the digest of a javac command line.
+func commandDigest(args []string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(args, "\x00"))))
+}
+
+// plan is the compile to perform.
+type plan struct {
+	// full is true when all the sources must be compiled, for the reason given in reason.
+	full   bool
+	reason string
+
+	// recompile are the sources to recompile: those that changed and those with classes that
+	// depend on the classes of a changed source.
+	recompile []string
+
+	// stale are the classes of the sources in recompile, which must be removed from the classes
+	// of the previous jar before recompiling.
+	stale []string
+}
+
+func fullPlan(format string, a ...interface{}) *plan {
+	return &plan{full: true, reason: fmt.Sprintf(format, a...)}
+}
+
+// makePlan decides what to compile, from the state of the previous compile, the digest of the
+// javac command line, the inputs that changed since the previous compile as found by
+// find_input_delta and the sources that are compiled directly rather than extracted from a
+// srcjar. A changed jar only requires a full compile if its ABI changed, jarAbi returns the ABI
+// digest of a jar.
+func makePlan(prior *javacState, command string, delta *fid_lib.FileList, sources []string,
+	jarAbi func(string) (string, error)) *plan {
+
+	if prior == nil {
+		return fullPlan("no state from a previous incremental compile")
+	}
+	if prior.Command != command {
+		return fullPlan("javac command line changed")
+	}
+	if len(delta.Additions) > 0 {
+		return fullPlan("%s was added", delta.Additions[0])
+	}
+	if len(delta.Deletions) > 0 {
+		return fullPlan("%s was removed", delta.Deletions[0])
+	}
+
+	isSource := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		isSource[s] = true
+	}
+	changed := make(map[string]bool)
+	for i := range delta.Changes {
+		ch := &delta.Changes[i]
+		switch {
+		case isSource[ch.Name]:
+			changed[ch.Name] = true
+		case isJar(ch.Name):
+			if !classesChanged(ch) {
+				// Only resources of the jar changed.
+				continue
+			}
+			abi, err := jarAbi(ch.Name)
+			if err != nil {
+				return fullPlan("failed to read the ABI of %s: %s", ch.Name, err)
+			}
+			if priorAbi, ok := prior.Jars[ch.Name]; !ok || abi != priorAbi {
+				return fullPlan("ABI of %s changed", ch.Name)
+			}
+		default:
+			// A srcjar, or a dependency that is not a jar, like a file listing the classpath.
+			return fullPlan("%s changed", ch.Name)
+		}
+	}
+
+	// Recompile the changed sources and the sources with classes that depend on their classes.
+	// The sources that depend on those in turn only need to be recompiled if the ABI of a
+	// recompiled class changes, in which case everything is recompiled.
+	changedClasses := make(map[string]bool)
+	for name, c := range prior.Classes {
+		if changed[c.Source] {
+			changedClasses[name] = true
+		}
+	}
+	recompile := make(map[string]bool)
+	for s := range changed {
+		recompile[s] = true
+	}
+	for _, c := range prior.Classes {
+		for _, dep := range c.Deps {
+			if changedClasses[dep] {
+				recompile[c.Source] = true
+				break
+			}
+		}
+	}
+
+	p := &plan{}
+	for s := range recompile {
+		p.recompile = append(p.recompile, s)
+	}
+	sort.Strings(p.recompile)
+	for name, c := range prior.Classes {
+		if recompile[c.Source] {
+			p.stale = append(p.stale, name)
+		}
+	}
+	sort.Strings(p.stale)
+	return p
+}
+
+// isJar returns true for the inputs whose contents find_input_delta inspects.
+func isJar(name string) bool {
+	return fid_lib.InspectExtsZipRegexp.MatchString(name)
+}
+
+// classesChanged returns true if a class of a changed jar changed, from the entries of the jar
+// that find_input_delta found changed. A jar that was only rewritten has no changed entries.
+func classesChanged(ch *fid_lib.FileList) bool {
+	for _, list := range [][]string{ch.Additions, ch.Deletions} {
+		for _, name := range list {
+			if strings.HasSuffix(name, ".class") {
+				return true
+			}
+		}
+	}
+	for _, entry := range ch.Changes {
+		if strings.HasSuffix(entry.Name, ".class") {
+			return true
+		}
+	}
+	return false
+}
+
+// jarAbi returns the digest of the ABI of all the classes of a jar.
+func jarAbi(name string) (string, error) {
+	r, err := zip.OpenReader(name)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	var lines []string
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, ".class") || path.Base(f.Name) == "module-info.class" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return "", err
+		}
+		cf, err := parseClassFile(data)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", f.Name, err)
+		}
+		lines = append(lines, f.Name+" "+cf.abi())
+	}
+	sort.Strings(lines)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(lines, "\n")))), nil
+}
+
+// javaCommentOrLiteralRegexp matches the comments, string literals and character literals of a
+// Java source.
+var javaCommentOrLiteralRegexp = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/|""".*?"""|"(?:\\.|[^"\\\n])*"|'(?:\\.|[^'\\\n])*'`)
+
+var javaPackageRegexp = regexp.MustCompile(`\bpackage\s+([\w.\s]+?)\s*;`)
+
+// javaPackage returns the package declared by a Java source, in internal form.
+func javaPackage(source []byte) string {
+	code := javaCommentOrLiteralRegexp.ReplaceAll(source, []byte(" "))
+	if m := javaPackageRegexp.FindSubmatch(code); m != nil {
+		return strings.ReplaceAll(strings.Join(strings.Fields(string(m[1])), ""), ".", "/")
+	}
+	return ""
+}
+
+// sourceIndex finds the source a class was compiled from, from the package of the class and the
+// name of its source file.
+type sourceIndex map[string][]string
+
+func newSourceIndex(sources []string, readFile func(string) ([]byte, error)) (sourceIndex, error) {
+	index := make(sourceIndex)
+	for _, s := range sources {
+		data, err := readFile(s)
+		if err != nil {
+			return nil, err
+		}
+		key := path.Join(javaPackage(data), path.Base(s))
+		index[key] = append(index[key], s)
+	}
+	return index, nil
+}
+
+// source returns the source of a class, or "" if it can't be determined.
+func (index sourceIndex) source(cf *classFile) string {
+	if cf.sourceFile == "" {
+		return ""
+	}
+	if sources := index[path.Join(cf.packageName(), cf.sourceFile)]; len(sources) == 1 {
+		return sources[0]
+	}
+	return ""
+}
+
+// withClassesOnClasspath returns the javac command line with dir prepended to the classpath, so
+// that the recompiled sources are compiled against the classes of the previous compile. The
+// classpath may be given in an argument file, which is rewritten to newArgFile.
+func withClassesOnClasspath(args []string, dir, newArgFile string) ([]string, error) {
+	ret := append([]string(nil), args...)
+	for i, arg := range ret {
+		switch {
+		case (arg == "-classpath" || arg == "-cp" || arg == "--class-path") && i+1 < len(ret):
+			ret[i+1] = dir + ":" + ret[i+1]
+			return ret, nil
+		case strings.HasPrefix(arg, "@"):
+			data, err := os.ReadFile(arg[1:])
+			if err != nil {
+				return nil, err
+			}
+			if classpath, ok := strings.CutPrefix(string(data), "-classpath "); ok {
+				err := os.WriteFile(newArgFile, []byte("-classpath "+dir+":"+classpath), 0644)
+				if err != nil {
+					return nil, err
+				}
+				ret[i] = "@" + newArgFile
+				return ret, nil
+			}
+		}
+	}
+	return append(ret, "-classpath", dir), nil
+}
@@ -0,0 +1,332 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	fid_lib "android/soong/cmd/find_input_delta/find_input_delta_lib"
+)
+
+func TestJavaPackage(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "simple",
+			source:   "package com.example.foo;\n\nclass Foo {}\n",
+			expected: "com/example/foo",
+		},
+		{
+			name: "comments and annotations",
+			source: "/* package not.this; */\n// package nor.this;\n" +
+				"@Deprecated(since = \"package no;\")\npackage com.example . bar ;\n",
+			expected: "com/example/bar",
+		},
+		{
+			name:     "default package",
+			source:   "class Foo { String s = \"package foo;\"; }\n",
+			expected: "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := javaPackage([]byte(tc.source)); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestSourceIndex(t *testing.T) {
+	files := map[string]string{
+		"a/Foo.java":         "package foo;",
+		"b/Bar.java":         "package foo;",
+		"c/Dup.java":         "package foo;",
+		"d/Dup.java":         "package foo;",
+		"srcjars/Gen.java":   "package foo.gen;",
+		"misplaced/Baz.java": "package baz;",
+	}
+	var sources []string
+	for s := range files {
+		sources = append(sources, s)
+	}
+	index, err := newSourceIndex(sources, func(s string) ([]byte, error) { return []byte(files[s]), nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		class, sourceFile, expected string
+	}{
+		{"foo/Foo", "Foo.java", "a/Foo.java"},
+		{"foo/Foo$Inner", "Foo.java", "a/Foo.java"},
+		{"foo/Helper", "Bar.java", "b/Bar.java"},
+		{"foo/gen/Gen", "Gen.java", "srcjars/Gen.java"},
+		{"baz/Baz", "Baz.java", "misplaced/Baz.java"},
+		{"foo/Dup", "Dup.java", ""},
+		{"foo/NoSource", "", ""},
+	} {
+		cf := &classFile{name: tc.class, sourceFile: tc.sourceFile}
+		if got := index.source(cf); got != tc.expected {
+			t.Errorf("class %s: expected source %q, got %q", tc.class, tc.expected, got)
+		}
+	}
+}
+
+func fileList(name string, additions, deletions []string, changes ...fid_lib.FileList) *fid_lib.FileList {
+	return &fid_lib.FileList{Name: name, Additions: additions, Deletions: deletions, Changes: changes}
+}
+
+func TestMakePlan(t *testing.T) {
+	prior := &javacState{
+		Command: "cmd",
+		Classes: map[string]*classState{
+			"foo/A":       {Source: "A.java", Abi: "a"},
+			"foo/A$Inner": {Source: "A.java", Abi: "a1"},
+			"foo/B":       {Source: "B.java", Abi: "b", Deps: []string{"foo/A$Inner"}},
+			"foo/C":       {Source: "C.java", Abi: "c", Deps: []string{"foo/B"}},
+			"foo/D":       {Source: "D.java", Abi: "d"},
+		},
+		Jars: map[string]string{"lib.jar": "lib-abi"},
+	}
+	sources := []string{"A.java", "B.java", "C.java", "D.java"}
+
+	testCases := []struct {
+		name      string
+		prior     *javacState
+		command   string
+		delta     *fid_lib.FileList
+		jarAbi    string
+		full      string
+		recompile []string
+		stale     []string
+	}{
+		{
+			name:    "no prior state",
+			command: "cmd",
+			delta:   fileList("out.jar", nil, nil),
+			full:    "no state from a previous incremental compile",
+		},
+		{
+			name:    "command changed",
+			prior:   prior,
+			command: "other",
+			delta:   fileList("out.jar", nil, nil),
+			full:    "javac command line changed",
+		},
+		{
+			name:    "source added",
+			prior:   prior,
+			command: "cmd",
+			delta:   fileList("out.jar", []string{"E.java"}, nil),
+			full:    "E.java was added",
+		},
+		{
+			name:    "source removed",
+			prior:   prior,
+			command: "cmd",
+			delta:   fileList("out.jar", nil, []string{"D.java"}),
+			full:    "D.java was removed",
+		},
+		{
+			name:    "srcjar changed",
+			prior:   prior,
+			command: "cmd",
+			delta:   fileList("out.jar", nil, nil, *fileList("gen.srcjar", nil, nil)),
+			full:    "gen.srcjar changed",
+		},
+		{
+			name:    "classpath ABI changed",
+			prior:   prior,
+			command: "cmd",
+			delta: fileList("out.jar", nil, nil,
+				*fileList("lib.jar", nil, nil, *fileList("lib/X.class", nil, nil))),
+			jarAbi: "new-abi",
+			full:   "ABI of lib.jar changed",
+		},
+		{
+			name:    "nothing changed",
+			prior:   prior,
+			command: "cmd",
+			delta:   fileList("out.jar", nil, nil),
+		},
+		{
+			name:    "classpath resources changed",
+			prior:   prior,
+			command: "cmd",
+			delta: fileList("out.jar", nil, nil,
+				*fileList("lib.jar", []string{"res/new.txt"}, nil, *fileList("res/x.txt", nil, nil))),
+		},
+		{
+			name:    "classpath classes changed without ABI change",
+			prior:   prior,
+			command: "cmd",
+			delta: fileList("out.jar", nil, nil,
+				*fileList("lib.jar", nil, nil, *fileList("lib/X.class", nil, nil))),
+			jarAbi: "lib-abi",
+		},
+		{
+			name:      "source changed",
+			prior:     prior,
+			command:   "cmd",
+			delta:     fileList("out.jar", nil, nil, *fileList("A.java", nil, nil)),
+			recompile: []string{"A.java", "B.java"},
+			stale:     []string{"foo/A", "foo/A$Inner", "foo/B"},
+		},
+		{
+			name:      "source without dependents changed",
+			prior:     prior,
+			command:   "cmd",
+			delta:     fileList("out.jar", nil, nil, *fileList("C.java", nil, nil)),
+			recompile: []string{"C.java"},
+			stale:     []string{"foo/C"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jarAbi := func(name string) (string, error) {
+				if tc.jarAbi == "" {
+					return "", fmt.Errorf("unexpected read of %s", name)
+				}
+				return tc.jarAbi, nil
+			}
+			p := makePlan(tc.prior, tc.command, tc.delta, sources, jarAbi)
+			if p.full != (tc.full != "") || p.reason != tc.full {
+				t.Fatalf("expected full compile %q, got %v %q", tc.full, p.full, p.reason)
+			}
+			if !reflect.DeepEqual(p.recompile, tc.recompile) {
+				t.Errorf("expected to recompile %q, got %q", tc.recompile, p.recompile)
+			}
+			if !reflect.DeepEqual(p.stale, tc.stale) {
+				t.Errorf("expected stale classes %q, got %q", tc.stale, p.stale)
+			}
+		})
+	}
+}
+
+func TestJarAbi(t *testing.T) {
+	dir := t.TempDir()
+	writeJar := func(name string, entries map[string][]byte) string {
+		t.Helper()
+		jar := filepath.Join(dir, name)
+		f, err := os.Create(jar)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := zip.NewWriter(f)
+		for entry, data := range entries {
+			ew, err := w.Create(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ew.Write(data)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		return jar
+	}
+	abi := func(jar string) string {
+		t.Helper()
+		abi, err := jarAbi(jar)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return abi
+	}
+
+	withPrivateChange := testBar
+	withPrivateChange.fields = []testMember{testBar.fields[0], {access: 0x0002, name: "other", descriptor: "I"}}
+	withNewConstant := testBar
+	withNewConstant.fields = []testMember{{access: 0x0019, name: "X", descriptor: "I", constant: 3, hasConstant: true}}
+
+	base := abi(writeJar("base.jar", map[string][]byte{
+		"foo/Bar.class": testBar.bytes(),
+		"res/data.txt":  []byte("data"),
+	}))
+	if got := abi(writeJar("private.jar", map[string][]byte{
+		"foo/Bar.class": withPrivateChange.bytes(),
+		"res/data.txt":  []byte("other data"),
+	})); got != base {
+		t.Errorf("expected private and resource changes to keep the ABI of the jar")
+	}
+	if got := abi(writeJar("constant.jar", map[string][]byte{
+		"foo/Bar.class": withNewConstant.bytes(),
+	})); got == base {
+		t.Errorf("expected a constant change to change the ABI of the jar")
+	}
+	if _, err := jarAbi(writeJar("bad.jar", map[string][]byte{"foo/Bad.class": []byte("bad")})); err == nil ||
+		!strings.Contains(err.Error(), "foo/Bad.class") {
+		t.Errorf("expected an error for a bad class, got %v", err)
+	}
+}
+
+func TestWithClassesOnClasspath(t *testing.T) {
+	dir := t.TempDir()
+	argFile := filepath.Join(dir, "classpath")
+	if err := os.WriteFile(argFile, []byte("-classpath a.jar:b.jar"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	newArgFile := filepath.Join(dir, "new_classpath")
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "classpath argument",
+			args:     []string{"javac", "-classpath", "a.jar:b.jar", "-d", "classes"},
+			expected: []string{"javac", "-classpath", "classes:a.jar:b.jar", "-d", "classes"},
+		},
+		{
+			name:     "classpath argument file",
+			args:     []string{"javac", "@" + argFile, "-d", "classes"},
+			expected: []string{"javac", "@" + newArgFile, "-d", "classes"},
+		},
+		{
+			name:     "no classpath",
+			args:     []string{"javac", "-d", "classes"},
+			expected: []string{"javac", "-d", "classes", "-classpath", "classes"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := withClassesOnClasspath(tc.args, "classes", newArgFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+	data, err := os.ReadFile(newArgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "-classpath classes:a.jar:b.jar" {
+		t.Errorf("unexpected rewritten argument file %q", data)
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// kernel_module_check verifies that a set of kernel modules can be loaded: that the symbols they
+// need are exported by the kernel or by the other modules, with the CRCs the modules were built
+// against, and that their vermagic matches the kernel.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return strings.Join(*m, ",")
+}
+
+func (m *multiFlag) Set(s string) error {
+	*m = append(*m, s)
+	return nil
+}
+
+func main() {
+	var output, symvers, kernelImagePath, kernelRelease string
+	var systemModules multiFlag
+
+	flag.StringVar(&output, "o", "", "File to write on success")
+	flag.StringVar(&symvers, "symvers", "", "Module.symvers of the kernel")
+	flag.StringVar(&kernelImagePath, "kernel", "", "vmlinux of the kernel")
+	flag.StringVar(&kernelRelease, "kernel_release", "", "Kernel release the modules must be built for, e.g. 6.1")
+	flag.Var(&systemModules, "system_module", "Module of the system_dlkm partition that may export symbols "+
+		"needed by the modules, can be repeated")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: kernel_module_check [-symvers <file>] [-kernel <vmlinux>] "+
+			"[-kernel_release <release>] [-system_module <ko>]... -o <output> <ko>...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if output == "" || (symvers == "" && kernelImagePath == "") {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	c := &checker{kernelRelease: kernelRelease, kernelExports: make(symbolTable)}
+	if symvers != "" {
+		f, err := os.Open(symvers)
+		if err != nil {
+			fatal(err)
+		}
+		exports, err := parseSymvers(f)
+		f.Close()
+		if err != nil {
+			fatal(fmt.Errorf("%s: %w", symvers, err))
+		}
+		c.kernelExports = exports
+	}
+	if kernelImagePath != "" {
+		f, err := os.Open(kernelImagePath)
+		if err != nil {
+			fatal(err)
+		}
+		image, err := readKernelImage(kernelImagePath, f)
+		f.Close()
+		if err != nil {
+			fatal(err)
+		}
+		// The CRCs of Module.symvers take precedence, as they are the ones the modules are built
+		// against.
+		for name, e := range image.exports {
+			if _, ok := c.kernelExports[name]; !ok {
+				c.kernelExports[name] = e
+			}
+		}
+		c.kernelVermagic = image.vermagic
+	}
+
+	var err error
+	if c.modules, err = readKernelModules(flag.Args()); err != nil {
+		fatal(err)
+	}
+	if c.systemModules, err = readKernelModules(systemModules); err != nil {
+		fatal(err)
+	}
+
+	if failures := c.check(); len(failures) > 0 {
+		fmt.Fprint(os.Stderr, formatFailures(failures))
+		os.Exit(1)
+	}
+	if err := os.WriteFile(output, nil, 0666); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "kernel_module_check:", err)
+	os.Exit(1)
+}
+
+func readKernelModules(paths []string) ([]*kernelModule, error) {
+	var modules []*kernelModule
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		m, err := readKernelModule(path, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, m)
+	}
+	return modules, nil
+}
+
+type checker struct {
+	modules       []*kernelModule
+	systemModules []*kernelModule
+
+	kernelExports  symbolTable
+	kernelVermagic string
+	kernelRelease  string
+}
+
+// check returns the reasons why each of the modules will fail to load, keyed by module path.
+// The system modules are only used to resolve symbols; they are checked by their own module.
+func (c *checker) check() map[string][]string {
+	failures := make(map[string][]string)
+
+	// The modules of the same partition take precedence over the system modules, as they are
+	// what is installed together.
+	exports := make(symbolTable)
+	for _, modules := range [][]*kernelModule{c.systemModules, c.modules} {
+		for _, m := range modules {
+			for name, e := range m.exports {
+				exports[name] = e
+			}
+		}
+	}
+	for name, e := range c.kernelExports {
+		exports[name] = e
+	}
+
+	byName := make(map[string]*kernelModule)
+	deps := make(map[*kernelModule][]string)
+	for _, m := range c.modules {
+		byName[m.name] = m
+		failures[m.path] = append(failures[m.path], c.checkVermagic(m)...)
+		for _, sym := range m.undefined {
+			e, ok := exports[sym]
+			if !ok {
+				failures[m.path] = append(failures[m.path], fmt.Sprintf("unresolved symbol %s", sym))
+				continue
+			}
+			if crc, ok := m.versions[sym]; ok && e.hasCRC && crc != e.crc {
+				failures[m.path] = append(failures[m.path],
+					fmt.Sprintf("symbol %s has CRC 0x%08x in %s, but the module expects 0x%08x",
+						sym, e.crc, e.provider, crc))
+			}
+			if e.provider != "vmlinux" && e.provider != m.name {
+				deps[m] = append(deps[m], e.provider)
+			}
+		}
+	}
+
+	// A module also fails to load if a module it needs symbols from fails to load.
+	for changed := true; changed; {
+		changed = false
+		for _, m := range c.modules {
+			if len(failures[m.path]) > 0 {
+				continue
+			}
+			for _, dep := range deps[m] {
+				if d, ok := byName[dep]; ok && len(failures[d.path]) > 0 {
+					failures[m.path] = append(failures[m.path],
+						fmt.Sprintf("needs symbols from %s, which fails to load", dep))
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	for path, reasons := range failures {
+		if len(reasons) == 0 {
+			delete(failures, path)
+		}
+	}
+	return failures
+}
+
+// checkVermagic checks the vermagic of a module like the module loader does: with modversions only
+// the part after the kernel release has to match, as the CRCs are checked instead.
+func (c *checker) checkVermagic(m *kernelModule) []string {
+	if m.vermagic == "" {
+		return []string{"missing vermagic in .modinfo"}
+	}
+	var reasons []string
+	release, rest, _ := strings.Cut(m.vermagic, " ")
+	if c.kernelRelease != "" && !matchesRelease(release, c.kernelRelease) {
+		reasons = append(reasons, fmt.Sprintf("built for kernel %s, expected %s", release, c.kernelRelease))
+	}
+	if c.kernelVermagic != "" {
+		expected, actual := c.kernelVermagic, m.vermagic
+		if len(m.versions) > 0 {
+			_, expected, _ = strings.Cut(expected, " ")
+			actual = rest
+		}
+		if expected != actual {
+			reasons = append(reasons, fmt.Sprintf("vermagic %q does not match the kernel vermagic %q",
+				m.vermagic, c.kernelVermagic))
+		}
+	}
+	return reasons
+}
+
+// matchesRelease returns whether a kernel release, e.g. 6.1.25-android14-11, is a release of
+// version, e.g. 6.1 or 6.1.25.
+func matchesRelease(release, version string) bool {
+	if !strings.HasPrefix(release, version) {
+		return false
+	}
+	rest := release[len(version):]
+	return rest == "" || rest[0] == '.' || rest[0] == '-' || rest[0] == '+'
+}
+
+func formatFailures(failures map[string][]string) string {
+	var paths []string
+	for path := range failures {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var sb strings.Builder
+	for _, path := range paths {
+		fmt.Fprintf(&sb, "%s will fail to load:\n", path)
+		for _, reason := range failures[path] {
+			fmt.Fprintf(&sb, "    %s\n", reason)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,166 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseModinfo(t *testing.T) {
+	data := []byte("license=GPL\x00name=foo\x00vermagic=6.1.25-android14-11 SMP preempt mod_unload modversions aarch64\x00\x00")
+	expected := map[string]string{
+		"license":  "GPL",
+		"name":     "foo",
+		"vermagic": "6.1.25-android14-11 SMP preempt mod_unload modversions aarch64",
+	}
+	if got := parseModinfo(data); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestParseVersions(t *testing.T) {
+	entry := func(crc uint64, name string) []byte {
+		b := make([]byte, 64)
+		binary.LittleEndian.PutUint64(b, crc)
+		copy(b[8:], name)
+		return b
+	}
+	data := append(entry(0x12345678, "module_layout"), entry(0xcafe, "printk")...)
+	got, err := parseVersions(data, elf.ELFCLASS64, binary.LittleEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]uint32{"module_layout": 0x12345678, "printk": 0xcafe}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if _, err := parseVersions(data[:100], elf.ELFCLASS64, binary.LittleEndian); err == nil {
+		t.Errorf("expected an error for a truncated __versions section")
+	}
+}
+
+func TestParseSymvers(t *testing.T) {
+	symvers := "0x12345678\tprintk\tvmlinux\tEXPORT_SYMBOL\t\n" +
+		"0x0000cafe\tbar_register\tdrivers/bar/bar\tEXPORT_SYMBOL_GPL\tBAR\n" +
+		"0xdeadbeef\tkmalloc\tvmlinux\tEXPORT_SYMBOL\n"
+	got, err := parseSymvers(strings.NewReader(symvers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := symbolTable{
+		"printk":  {provider: "vmlinux", crc: 0x12345678, hasCRC: true},
+		"kmalloc": {provider: "vmlinux", crc: 0xdeadbeef, hasCRC: true},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if _, err := parseSymvers(strings.NewReader("0x1\tprintk\n")); err == nil {
+		t.Errorf("expected an error for a malformed line")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	const vermagic = "6.1.25-android14-11 SMP preempt mod_unload modversions aarch64"
+	c := &checker{
+		kernelRelease:  "6.1",
+		kernelVermagic: "6.1.25-android14-11 SMP preempt mod_unload modversions aarch64",
+		kernelExports: symbolTable{
+			"printk":  {provider: "vmlinux", crc: 1, hasCRC: true},
+			"kmalloc": {provider: "vmlinux", crc: 2, hasCRC: true},
+		},
+		systemModules: []*kernelModule{{
+			path:     "system/core.ko",
+			name:     "core",
+			vermagic: vermagic,
+			exports:  symbolTable{"core_register": {provider: "core", crc: 3, hasCRC: true}},
+		}},
+		modules: []*kernelModule{
+			{
+				path:      "ok.ko",
+				name:      "ok",
+				vermagic:  vermagic,
+				undefined: []string{"printk", "core_register"},
+				versions:  map[string]uint32{"printk": 1, "core_register": 3},
+			},
+			{
+				path:      "bad_crc.ko",
+				name:      "bad_crc",
+				vermagic:  "6.1.30-android14-11 SMP preempt mod_unload modversions aarch64",
+				undefined: []string{"kmalloc"},
+				versions:  map[string]uint32{"kmalloc": 7},
+				exports:   symbolTable{"bad_crc_fn": {provider: "bad_crc"}},
+			},
+			{
+				path:      "unresolved.ko",
+				name:      "unresolved",
+				vermagic:  vermagic,
+				undefined: []string{"printk", "missing_fn"},
+			},
+			{
+				path:      "needs_bad.ko",
+				name:      "needs_bad",
+				vermagic:  vermagic,
+				undefined: []string{"bad_crc_fn"},
+			},
+			{
+				path:      "wrong_kernel.ko",
+				name:      "wrong_kernel",
+				vermagic:  "5.15.1 SMP preempt mod_unload aarch64",
+				undefined: []string{"printk"},
+			},
+		},
+	}
+
+	expected := map[string][]string{
+		"bad_crc.ko": {
+			"symbol kmalloc has CRC 0x00000002 in vmlinux, but the module expects 0x00000007",
+		},
+		"unresolved.ko": {"unresolved symbol missing_fn"},
+		"needs_bad.ko":  {"needs symbols from bad_crc, which fails to load"},
+		"wrong_kernel.ko": {
+			"built for kernel 5.15.1, expected 6.1",
+			`vermagic "5.15.1 SMP preempt mod_unload aarch64" does not match the kernel vermagic ` +
+				`"6.1.25-android14-11 SMP preempt mod_unload modversions aarch64"`,
+		},
+	}
+	got := c.check()
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected:\n%s\ngot:\n%s", formatFailures(expected), formatFailures(got))
+	}
+}
+
+func TestMatchesRelease(t *testing.T) {
+	testCases := []struct {
+		release, version string
+		expected         bool
+	}{
+		{"6.1.25-android14-11", "6.1", true},
+		{"6.1.25-android14-11", "6.1.25", true},
+		{"6.10.2", "6.1", false},
+		{"6.1", "6.1", true},
+		{"5.15.1", "6.1", false},
+	}
+	for _, tc := range testCases {
+		if got := matchesRelease(tc.release, tc.version); got != tc.expected {
+			t.Errorf("matchesRelease(%q, %q): expected %t, got %t", tc.release, tc.version, tc.expected, got)
+		}
+	}
+}
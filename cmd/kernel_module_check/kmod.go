@@ -0,0 +1,277 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// export is a symbol exported by the kernel or by a kernel module.
+type export struct {
+	// provider is "vmlinux" or the name of the exporting module.
+	provider string
+	crc      uint32
+	hasCRC   bool
+}
+
+type symbolTable map[string]export
+
+// kernelModule is the information read from a .ko file that decides whether it can be loaded.
+type kernelModule struct {
+	path     string
+	name     string
+	vermagic string
+
+	// undefined lists the symbols the module needs, excluding weak symbols.
+	undefined []string
+	// versions maps the symbols the module needs to the CRC they had when it was built. It is
+	// only populated if the kernel was built with CONFIG_MODVERSIONS.
+	versions map[string]uint32
+	exports  symbolTable
+}
+
+func readKernelModule(path string, r io.ReaderAt) (*kernelModule, error) {
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defer f.Close()
+
+	m := &kernelModule{
+		path: path,
+		name: strings.TrimSuffix(filepath.Base(path), ".ko"),
+	}
+
+	if s := f.Section(".modinfo"); s != nil {
+		data, err := s.Data()
+		if err != nil {
+			return nil, fmt.Errorf("%s: reading .modinfo: %w", path, err)
+		}
+		modinfo := parseModinfo(data)
+		m.vermagic = modinfo["vermagic"]
+		if name := modinfo["name"]; name != "" {
+			m.name = name
+		}
+	}
+
+	if s := f.Section("__versions"); s != nil {
+		data, err := s.Data()
+		if err != nil {
+			return nil, fmt.Errorf("%s: reading __versions: %w", path, err)
+		}
+		m.versions, err = parseVersions(data, f.Class, f.ByteOrder)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	syms, err := f.Symbols()
+	if err != nil {
+		return nil, fmt.Errorf("%s: reading symbols: %w", path, err)
+	}
+	for _, sym := range syms {
+		if sym.Section == elf.SHN_UNDEF && sym.Name != "" && elf.ST_BIND(sym.Info) != elf.STB_WEAK {
+			m.undefined = append(m.undefined, sym.Name)
+		}
+	}
+	m.exports, err = readExports(f, syms, m.name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// parseModinfo parses the NUL separated key=value strings of a .modinfo section.
+func parseModinfo(data []byte) map[string]string {
+	ret := make(map[string]string)
+	for _, entry := range bytes.Split(data, []byte{0}) {
+		if key, value, ok := strings.Cut(string(entry), "="); ok {
+			ret[key] = value
+		}
+	}
+	return ret
+}
+
+// parseVersions parses the __versions section, an array of struct modversion_info: a CRC stored in
+// an unsigned long followed by the NUL terminated symbol name, 64 bytes in total.
+func parseVersions(data []byte, class elf.Class, byteOrder binary.ByteOrder) (map[string]uint32, error) {
+	const entrySize = 64
+	crcSize := 4
+	if class == elf.ELFCLASS64 {
+		crcSize = 8
+	}
+	if len(data)%entrySize != 0 {
+		return nil, fmt.Errorf("__versions size %d is not a multiple of %d", len(data), entrySize)
+	}
+	ret := make(map[string]uint32)
+	for i := 0; i < len(data); i += entrySize {
+		entry := data[i : i+entrySize]
+		var crc uint64
+		if crcSize == 8 {
+			crc = byteOrder.Uint64(entry)
+		} else {
+			crc = uint64(byteOrder.Uint32(entry))
+		}
+		name, _, _ := bytes.Cut(entry[crcSize:], []byte{0})
+		ret[string(name)] = uint32(crc)
+	}
+	return ret, nil
+}
+
+// readExports returns the symbols exported by a kernel image or a kernel module, which have a
+// __ksymtab_<symbol> entry, and their CRCs from the __crc_<symbol> symbols.
+func readExports(f *elf.File, syms []elf.Symbol, provider string) (symbolTable, error) {
+	exports := make(symbolTable)
+	crcs := make(map[string]uint32)
+	for _, sym := range syms {
+		if elf.ST_TYPE(sym.Info) == elf.STT_SECTION {
+			continue
+		}
+		if name, ok := strings.CutPrefix(sym.Name, "__ksymtab_"); ok && name != "" {
+			exports[name] = export{provider: provider}
+		} else if name, ok := strings.CutPrefix(sym.Name, "__crc_"); ok && name != "" {
+			crc, err := readCRC(f, sym)
+			if err != nil {
+				return nil, err
+			}
+			crcs[name] = crc
+		}
+	}
+	for name, crc := range crcs {
+		if e, ok := exports[name]; ok {
+			e.crc, e.hasCRC = crc, true
+			exports[name] = e
+		}
+	}
+	return exports, nil
+}
+
+// readCRC returns the CRC of a __crc_<symbol> symbol. Older kernels store the CRC as the value of an
+// absolute symbol, newer ones store it in the __kcrctab sections at the address of the symbol.
+func readCRC(f *elf.File, sym elf.Symbol) (uint32, error) {
+	if sym.Section == elf.SHN_ABS {
+		return uint32(sym.Value), nil
+	}
+	if sym.Section >= elf.SHN_LORESERVE || int(sym.Section) >= len(f.Sections) {
+		return 0, fmt.Errorf("%s: invalid section index %d", sym.Name, sym.Section)
+	}
+	s := f.Sections[sym.Section]
+	offset := sym.Value
+	if f.Type != elf.ET_REL {
+		// The value is an address rather than an offset in the section.
+		if sym.Value < s.Addr {
+			return 0, fmt.Errorf("%s: address 0x%x is before %s", sym.Name, sym.Value, s.Name)
+		}
+		offset -= s.Addr
+	}
+	data, err := s.Data()
+	if err != nil {
+		return 0, fmt.Errorf("%s: reading %s: %w", sym.Name, s.Name, err)
+	}
+	// offset+4 could overflow, so compare the offset with the last offset a CRC can start at.
+	if len(data) < 4 || offset > uint64(len(data))-4 {
+		return 0, fmt.Errorf("%s: offset 0x%x is outside of %s", sym.Name, offset, s.Name)
+	}
+	return f.ByteOrder.Uint32(data[offset:]), nil
+}
+
+// kernelImage is the information read from the vmlinux ELF file of the kernel.
+type kernelImage struct {
+	vermagic string
+	exports  symbolTable
+}
+
+func readKernelImage(path string, r io.ReaderAt) (*kernelImage, error) {
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w (the kernel image must be an uncompressed vmlinux)", path, err)
+	}
+	defer f.Close()
+
+	syms, err := f.Symbols()
+	if err != nil {
+		return nil, fmt.Errorf("%s: reading symbols: %w", path, err)
+	}
+	k := &kernelImage{}
+	k.exports, err = readExports(f, syms, "vmlinux")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	// The vermagic modules are compared to by the module loader is a static string in
+	// kernel/module.
+	for _, sym := range syms {
+		if sym.Name == "vermagic" && elf.ST_TYPE(sym.Info) == elf.STT_OBJECT {
+			k.vermagic, err = readString(f, sym)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			break
+		}
+	}
+	return k, nil
+}
+
+func readString(f *elf.File, sym elf.Symbol) (string, error) {
+	if sym.Section >= elf.SHN_LORESERVE || int(sym.Section) >= len(f.Sections) {
+		return "", fmt.Errorf("%s: invalid section index %d", sym.Name, sym.Section)
+	}
+	s := f.Sections[sym.Section]
+	data, err := s.Data()
+	if err != nil {
+		return "", fmt.Errorf("%s: reading %s: %w", sym.Name, s.Name, err)
+	}
+	offset := sym.Value - s.Addr
+	if offset+sym.Size > uint64(len(data)) {
+		return "", fmt.Errorf("%s: outside of %s", sym.Name, s.Name)
+	}
+	str, _, _ := bytes.Cut(data[offset:offset+sym.Size], []byte{0})
+	return string(str), nil
+}
+
+// parseSymvers returns the symbols exported by vmlinux according to a Module.symvers file, whose
+// lines are <crc> <symbol> <module> <export type> [<namespace>], separated by tabs.
+func parseSymvers(r io.Reader) (symbolTable, error) {
+	exports := make(symbolTable)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) == 1 && fields[0] == "" {
+			continue
+		}
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("line %d: expected at least 4 fields, got %d", line, len(fields))
+		}
+		if fields[2] != "vmlinux" {
+			// Exported by a module, which is only available if that module is loaded.
+			continue
+		}
+		crc, err := strconv.ParseUint(fields[0], 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid CRC %q", line, fields[0])
+		}
+		exports[fields[1]] = export{provider: "vmlinux", crc: uint32(crc), hasCRC: true}
+	}
+	return exports, scanner.Err()
+}
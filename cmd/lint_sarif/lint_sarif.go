@@ -0,0 +1,142 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// lint_sarif post-processes the SARIF files written by Android Lint.  With -root it rewrites the
+// file locations of a single module's report to be relative to the top of the source tree and
+// attributes the report to the module.  Otherwise it merges the reports of multiple modules into
+// a single file, attributing each module's results to its team.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"android/soong/android/team_proto"
+	"android/soong/response"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func main() {
+	var expandedArgs []string
+	for _, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, "@") {
+			f, err := os.Open(strings.TrimPrefix(arg, "@"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			respArgs, err := response.ReadRspFile(f)
+			f.Close()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			expandedArgs = append(expandedArgs, respArgs...)
+		} else {
+			expandedArgs = append(expandedArgs, arg)
+		}
+	}
+
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s -o <output> -root <dir> -module <name> -module_dir <dir> <lint sarif>\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s -o <output> [-teams <all_teams.pb>] [<module sarif>...]\n", os.Args[0])
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+
+	output := flags.String("o", "", "file to write the SARIF output to")
+	root := flags.String("root", "", "directory lint ran in, locations are rewritten to be relative to it")
+	module := flags.String("module", "", "name of the module the report belongs to")
+	moduleDir := flags.String("module_dir", "", "directory of the module the report belongs to")
+	teamsFile := flags.String("teams", "", "all_teams.pb file used to attribute each module's results to its team")
+
+	flags.Parse(expandedArgs)
+
+	if *output == "" || (*root != "" && flags.NArg() != 1) {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	var logs []*sarifLog
+	for _, input := range flags.Args() {
+		f, err := os.Open(input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		log, err := parseSarif(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", input, err)
+			os.Exit(1)
+		}
+		logs = append(logs, log)
+	}
+
+	var result *sarifLog
+	if *root != "" {
+		result = logs[0]
+		for _, run := range result.Runs {
+			normalizeRun(run, *root)
+			attributeRun(run, runAttribution{Module: *module, Dir: *moduleDir})
+		}
+	} else {
+		teams := make(map[string]string)
+		if *teamsFile != "" {
+			var err error
+			teams, err = readTeams(*teamsFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", *teamsFile, err)
+				os.Exit(1)
+			}
+		}
+		result = mergeSarif(logs, teams)
+	}
+
+	data, err := writeSarif(result)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, data, 0666); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+// readTeams returns a map of module names to team ids from the all_teams.pb file written by the
+// all_teams singleton.
+func readTeams(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	allTeams := &team_proto.AllTeams{}
+	if err := proto.Unmarshal(data, allTeams); err != nil {
+		return nil, err
+	}
+	teams := make(map[string]string)
+	for _, team := range allTeams.Teams {
+		if team.GetTrendyTeamId() != "" {
+			teams[team.GetTargetName()] = team.GetTrendyTeamId()
+		}
+	}
+	return teams, nil
+}
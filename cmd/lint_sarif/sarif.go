@@ -0,0 +1,190 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// sarifLog is the top level object of a SARIF file.  Runs are kept as generic JSON objects so
+// that properties written by lint that aren't modified here are passed through unchanged.
+type sarifLog struct {
+	Schema  string           `json:"$schema,omitempty"`
+	Version string           `json:"version"`
+	Runs    []map[string]any `json:"runs"`
+}
+
+func parseSarif(r io.Reader) (*sarifLog, error) {
+	decoder := json.NewDecoder(r)
+	// Preserve numbers exactly as lint wrote them.
+	decoder.UseNumber()
+	log := &sarifLog{}
+	if err := decoder.Decode(log); err != nil {
+		return nil, err
+	}
+	if log.Version != sarifVersion {
+		return nil, fmt.Errorf("unsupported SARIF version %q", log.Version)
+	}
+	return log, nil
+}
+
+func writeSarif(log *sarifLog) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(log); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// normalizeRun rewrites every artifact location in the run to a path relative to the top of the
+// source tree, so that the output doesn't depend on the sandbox directory lint ran in.  root is
+// the directory lint ran in, which is the top of the source tree inside the sandbox.
+func normalizeRun(run map[string]any, root string) {
+	bases := make(map[string]string)
+	if originalUriBaseIds, ok := run["originalUriBaseIds"].(map[string]any); ok {
+		for id, base := range originalUriBaseIds {
+			if loc, ok := base.(map[string]any); ok {
+				if uri, ok := loc["uri"].(string); ok {
+					bases[id] = uri
+				}
+			}
+		}
+	}
+	// The base ids point into the sandbox, the rewritten locations don't need them anymore.
+	delete(run, "originalUriBaseIds")
+
+	root = strings.TrimSuffix(root, "/") + "/"
+	walkArtifactLocations(run, func(loc map[string]any) {
+		uri, _ := loc["uri"].(string)
+		if baseId, ok := loc["uriBaseId"].(string); ok {
+			uri = bases[baseId] + uri
+			delete(loc, "uriBaseId")
+		}
+		loc["uri"] = relativeToRoot(uri, root)
+	})
+}
+
+// relativeToRoot converts a file URI or path to a path relative to root.  URIs outside of root
+// are returned unchanged.
+func relativeToRoot(uri, root string) string {
+	p := uri
+	if strings.HasPrefix(uri, "file:") {
+		if u, err := url.Parse(uri); err == nil {
+			p = u.Path
+		}
+	}
+	if strings.HasPrefix(p, root) {
+		return path.Clean(strings.TrimPrefix(p, root))
+	}
+	if !path.IsAbs(p) && !strings.Contains(p, ":") {
+		return path.Clean(p)
+	}
+	return uri
+}
+
+// walkArtifactLocations calls f for every artifactLocation object, which is any object with a
+// "uri" property, nested anywhere inside v.
+func walkArtifactLocations(v any, f func(map[string]any)) {
+	switch v := v.(type) {
+	case map[string]any:
+		if _, ok := v["uri"].(string); ok {
+			f(v)
+		}
+		for _, child := range v {
+			walkArtifactLocations(child, f)
+		}
+	case []any:
+		for _, child := range v {
+			walkArtifactLocations(child, f)
+		}
+	}
+}
+
+// runAttribution is the module that produced the results of a run.  It is stored in the
+// property bag of the run, and in the automationDetails id so that code scanning tools treat the
+// results of each module as a separate category.
+type runAttribution struct {
+	Module string
+	Dir    string
+}
+
+func setRunProperty(run map[string]any, key, value string) {
+	if value == "" {
+		return
+	}
+	properties, ok := run["properties"].(map[string]any)
+	if !ok {
+		properties = make(map[string]any)
+		run["properties"] = properties
+	}
+	properties[key] = value
+}
+
+func runProperty(run map[string]any, key string) string {
+	if properties, ok := run["properties"].(map[string]any); ok {
+		if value, ok := properties[key].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func attributeRun(run map[string]any, attribution runAttribution) {
+	setRunProperty(run, "module", attribution.Module)
+	setRunProperty(run, "moduleDir", attribution.Dir)
+	if attribution.Module != "" {
+		run["automationDetails"] = map[string]any{
+			"id": "lint/" + attribution.Module + "/",
+		}
+	}
+}
+
+// mergeSarif combines the runs of multiple SARIF files into a single file, adding the team of
+// the module of each run from teams, a map of module names to team ids.  Runs are sorted by
+// module so that the output is deterministic.
+func mergeSarif(logs []*sarifLog, teams map[string]string) *sarifLog {
+	merged := &sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []map[string]any{},
+	}
+	for _, log := range logs {
+		for _, run := range log.Runs {
+			if team := teams[runProperty(run, "module")]; team != "" {
+				setRunProperty(run, "trendyTeamId", team)
+			}
+			merged.Runs = append(merged.Runs, run)
+		}
+	}
+	sort.SliceStable(merged.Runs, func(i, j int) bool {
+		return runProperty(merged.Runs[i], "module") < runProperty(merged.Runs[j], "module")
+	})
+	return merged
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const fooSarif = `{
+  "$schema" : "https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0-rtm.4.json",
+  "version" : "2.1.0",
+  "runs" : [
+    {
+      "tool": { "driver": { "name": "Android Lint", "rules": [ { "id": "NewApi" } ] } },
+      "originalUriBaseIds": {
+        "%SRCROOT%": { "uri": "file:///tmp/sbox/1234/" }
+      },
+      "results": [
+        {
+          "ruleId": "NewApi",
+          "ruleIndex": 0,
+          "message": { "text": "Call requires API level 31" },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": { "uriBaseId": "%SRCROOT%", "uri": "external/foo/src/Foo.java" },
+                "region": { "startLine": 12, "startColumn": 5 }
+              }
+            }
+          ],
+          "relatedLocations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": { "uri": "file:///tmp/sbox/1234/external/foo/AndroidManifest.xml" }
+              }
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}`
+
+func parseString(t *testing.T, s string) *sarifLog {
+	t.Helper()
+	log, err := parseSarif(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return log
+}
+
+func artifactUris(v any) []string {
+	var uris []string
+	walkArtifactLocations(v, func(loc map[string]any) {
+		uris = append(uris, loc["uri"].(string))
+		if _, ok := loc["uriBaseId"]; ok {
+			uris = append(uris, "uriBaseId")
+		}
+	})
+	return uris
+}
+
+func TestNormalizeRun(t *testing.T) {
+	log := parseString(t, fooSarif)
+	run := log.Runs[0]
+	normalizeRun(run, "/tmp/sbox/1234")
+	attributeRun(run, runAttribution{Module: "foo", Dir: "external/foo"})
+
+	// Map iteration order in walkArtifactLocations is random, sort the uris before comparing.
+	got := artifactUris(run["results"])
+	sort.Strings(got)
+	want := []string{"external/foo/AndroidManifest.xml", "external/foo/src/Foo.java"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want uris %q, got %q", want, got)
+	}
+	if _, ok := run["originalUriBaseIds"]; ok {
+		t.Errorf("expected originalUriBaseIds to be removed")
+	}
+	if got := runProperty(run, "module"); got != "foo" {
+		t.Errorf("want module property foo, got %q", got)
+	}
+	if got := runProperty(run, "moduleDir"); got != "external/foo" {
+		t.Errorf("want moduleDir property external/foo, got %q", got)
+	}
+
+	// The output must not depend on the sandbox directory.
+	out1, err := writeSarif(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := parseString(t, strings.ReplaceAll(fooSarif, "/tmp/sbox/1234", "/tmp/sbox/5678"))
+	normalizeRun(other.Runs[0], "/tmp/sbox/5678/")
+	attributeRun(other.Runs[0], runAttribution{Module: "foo", Dir: "external/foo"})
+	out2, err := writeSarif(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out1, out2) {
+		t.Errorf("output depends on the sandbox directory:\n%s\n%s", out1, out2)
+	}
+}
+
+func TestRelativeToRoot(t *testing.T) {
+	tests := map[string]string{
+		"file:///tmp/sbox/1234/external/foo/Foo.java": "external/foo/Foo.java",
+		"/tmp/sbox/1234/out/soong/gen/R.java":         "out/soong/gen/R.java",
+		"external/foo/./Foo.java":                     "external/foo/Foo.java",
+		"file:///usr/lib/jvm/Object.java":             "file:///usr/lib/jvm/Object.java",
+		"https://developer.android.com":               "https://developer.android.com",
+	}
+	for uri, want := range tests {
+		if got := relativeToRoot(uri, "/tmp/sbox/1234/"); got != want {
+			t.Errorf("%s: want %q, got %q", uri, want, got)
+		}
+	}
+}
+
+func TestMergeSarif(t *testing.T) {
+	foo := parseString(t, fooSarif)
+	attributeRun(foo.Runs[0], runAttribution{Module: "foo", Dir: "external/foo"})
+	bar := parseString(t, fooSarif)
+	attributeRun(bar.Runs[0], runAttribution{Module: "bar", Dir: "external/bar"})
+
+	merged := mergeSarif([]*sarifLog{foo, bar}, map[string]string{"foo": "trendy_team_foo"})
+
+	var modules, teams []string
+	for _, run := range merged.Runs {
+		modules = append(modules, runProperty(run, "module"))
+		teams = append(teams, runProperty(run, "trendyTeamId"))
+	}
+	if want := []string{"bar", "foo"}; !reflect.DeepEqual(modules, want) {
+		t.Errorf("want modules %q, got %q", want, modules)
+	}
+	if want := []string{"", "trendy_team_foo"}; !reflect.DeepEqual(teams, want) {
+		t.Errorf("want teams %q, got %q", want, teams)
+	}
+	if id := merged.Runs[1]["automationDetails"].(map[string]any)["id"]; id != "lint/foo/" {
+		t.Errorf("want automationDetails id lint/foo/, got %q", id)
+	}
+
+	out, err := writeSarif(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseSarif(bytes.NewReader(out)); err != nil {
+		t.Errorf("failed to parse merged output: %s\n%s", err, out)
+	}
+
+	if empty, err := writeSarif(mergeSarif(nil, nil)); err != nil || !bytes.Contains(empty, []byte(`"runs": []`)) {
+		t.Errorf("expected an empty runs list, got %s, %v", empty, err)
+	}
+
+	if _, err := parseSarif(strings.NewReader(`{"version": "1.0.0", "runs": []}`)); err == nil {
+		t.Errorf("expected an error for an unsupported version")
+	}
+}
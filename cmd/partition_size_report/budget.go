@@ -0,0 +1,107 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A budget is the maximum size of a module or of a directory in the partition.
+type budget struct {
+	module  string
+	dir     string
+	maxSize int64
+}
+
+func (b budget) String() string {
+	if b.module != "" {
+		return "module " + b.module
+	}
+	return "directory " + b.dir
+}
+
+// parseBudget parses a budget flag, which is either module:<name>=<bytes> or
+// dir:<path>=<bytes>.
+func parseBudget(s string) (budget, error) {
+	kind, rest, ok := strings.Cut(s, ":")
+	if !ok {
+		return budget{}, fmt.Errorf("invalid budget %q, expected module:<name>=<bytes> or dir:<path>=<bytes>", s)
+	}
+	name, size, ok := strings.Cut(rest, "=")
+	if !ok || name == "" {
+		return budget{}, fmt.Errorf("invalid budget %q, expected module:<name>=<bytes> or dir:<path>=<bytes>", s)
+	}
+	maxSize, err := strconv.ParseInt(size, 10, 64)
+	if err != nil || maxSize < 0 {
+		return budget{}, fmt.Errorf("invalid size in budget %q", s)
+	}
+	switch kind {
+	case "module":
+		return budget{module: name, maxSize: maxSize}, nil
+	case "dir":
+		return budget{dir: filepath.Clean(name), maxSize: maxSize}, nil
+	default:
+		return budget{}, fmt.Errorf("invalid budget %q, expected module:<name>=<bytes> or dir:<path>=<bytes>", s)
+	}
+}
+
+type budgetFlags []budget
+
+func (f *budgetFlags) String() string {
+	return ""
+}
+
+func (f *budgetFlags) Set(s string) error {
+	b, err := parseBudget(s)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, b)
+	return nil
+}
+
+// size returns the size of the module or directory that counts against the budget.
+func (b budget) size(r *report) int64 {
+	var size int64
+	if b.module != "" {
+		for _, m := range r.Modules {
+			if m.Module == b.module {
+				size += r.moduleCharged(m)
+			}
+		}
+		return size
+	}
+	for _, f := range r.Files {
+		if b.dir == "." || strings.HasPrefix(f.Path, b.dir+"/") {
+			size += r.fileCharged(f)
+		}
+	}
+	return size
+}
+
+// checkBudgets returns an error message for each budget that is exceeded.
+func checkBudgets(r *report, budgets []budget) []string {
+	var errs []string
+	for _, b := range budgets {
+		if size := b.size(r); size > b.maxSize {
+			errs = append(errs, fmt.Sprintf("%s in %s uses %d bytes, which is %d bytes over its budget of %d bytes",
+				b, r.Partition, size, size-b.maxSize, b.maxSize))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+var onDiskSizeRegexp = regexp.MustCompile(`On-disk size:\s*(\d+)`)
+
+// parseDumpErofs returns the on-disk size from the output of dump.erofs --path, which is the
+// size of the compressed blocks of the file.
+func parseDumpErofs(output string) (int64, error) {
+	match := onDiskSizeRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return 0, fmt.Errorf("no on-disk size in dump.erofs output:\n%s", output)
+	}
+	return strconv.ParseInt(match[1], 10, 64)
+}
+
+type erofsImage struct {
+	dumpErofs string
+	image     string
+}
+
+func (e erofsImage) compressedSize(path string) (int64, error) {
+	cmd := exec.Command(e.dumpErofs, "--path=/"+filepath.ToSlash(path), e.image)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w\n%s", cmd, err, output)
+	}
+	return parseDumpErofs(string(output))
+}
+
+// unsparseImage converts a sparse image to a raw image in tmpDir, as dump.erofs can only read raw
+// images.
+func unsparseImage(simg2img, image, tmpDir string) (string, error) {
+	raw := filepath.Join(tmpDir, filepath.Base(image)+".raw")
+	cmd := exec.Command(simg2img, image, raw)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("%s: %w\n%s", cmd, err, output)
+	}
+	return raw, nil
+}
+
+// addCompressedSizes sets the compressed size of each file from the erofs image.
+func addCompressedSizes(files []fileSize, compressedSize func(path string) (int64, error)) error {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	work := make(chan int)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				size, err := compressedSize(files[i].Path)
+				if err != nil {
+					once.Do(func() { firstErr = err })
+					continue
+				}
+				files[i].CompressedSize = size
+			}
+		}()
+	}
+	for i := range files {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	return firstErr
+}
+
+func readErofsSizes(files []fileSize, dumpErofs, simg2img, image string) error {
+	if simg2img != "" {
+		tmpDir, err := os.MkdirTemp("", "partition_size_report")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		if image, err = unsparseImage(simg2img, image, tmpDir); err != nil {
+			return err
+		}
+	}
+	return addCompressedSizes(files, erofsImage{dumpErofs, image}.compressedSize)
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// partition_size_report attributes the size of a filesystem image to the modules installed in it.
+// It writes the size of every file in the staging directory of the partition as JSON and CSV, and
+// optionally compares the sizes of the modules with a report from a previous build.  With --report,
+// it instead reads a report it wrote and fails if a module or a directory exceeds its budget.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s --root <dir> --owners <file> --json <file> [options]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s --report <file> --budget <budget>... --stamp <file>\n", os.Args[0])
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+
+	rootDir := flags.String("root", "", "staging directory of the partition")
+	ownersFile := flags.String("owners", "", "JSON file with the module that installs each file")
+	partition := flags.String("partition", "", "name of the partition")
+	fsType := flags.String("fs_type", "ext4", "type of the filesystem")
+	blockSize := flags.Int64("block_size", 4096, "block size of the filesystem")
+	image := flags.String("image", "", "the erofs image to read the compressed sizes from")
+	dumpErofs := flags.String("dump_erofs", "", "path to dump.erofs")
+	simg2img := flags.String("simg2img", "", "path to simg2img, if the image is sparse")
+	jsonOut := flags.String("json", "", "file to write the report to as JSON")
+	csvOut := flags.String("csv", "", "file to write the report to as CSV")
+	baseline := flags.String("baseline", "", "report from a previous build to compare with")
+	diffOut := flags.String("diff", "", "file to write the differences with the baseline to")
+	reportFile := flags.String("report", "", "report to check the budgets of")
+	var budgets budgetFlags
+	flags.Var(&budgets, "budget", "maximum size of a module or directory, as module:<name>=<bytes> "+
+		"or dir:<path>=<bytes> (can be repeated)")
+	stamp := flags.String("stamp", "", "file to write when the budgets are met")
+
+	flags.Parse(os.Args[1:])
+
+	if *reportFile != "" {
+		if *stamp == "" || flags.NArg() != 0 {
+			flags.Usage()
+			os.Exit(1)
+		}
+		r, err := readReport(*reportFile)
+		if err != nil {
+			fatal(err)
+		}
+		if errs := checkBudgets(r, budgets); len(errs) > 0 {
+			for _, e := range errs {
+				fmt.Fprintln(os.Stderr, "error:", e)
+			}
+			os.Exit(1)
+		}
+		if err := os.WriteFile(*stamp, nil, 0666); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if *rootDir == "" || *ownersFile == "" || *jsonOut == "" || flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if (*baseline == "") != (*diffOut == "") {
+		fmt.Fprintln(os.Stderr, "--baseline and --diff must be used together")
+		os.Exit(1)
+	}
+	compressed := *fsType == "erofs"
+	if compressed && (*image == "" || *dumpErofs == "") {
+		fmt.Fprintln(os.Stderr, "--image and --dump_erofs are required for erofs")
+		os.Exit(1)
+	}
+
+	owners, err := readOwners(*ownersFile)
+	if err != nil {
+		fatal(err)
+	}
+	files, err := collectFiles(*rootDir, owners, *blockSize)
+	if err != nil {
+		fatal(err)
+	}
+	if compressed {
+		if err := readErofsSizes(files, *dumpErofs, *simg2img, *image); err != nil {
+			fatal(err)
+		}
+	}
+	r := newReport(*partition, *fsType, compressed, files)
+
+	if err := writeFile(*jsonOut, r.writeJSON); err != nil {
+		fatal(err)
+	}
+	if *csvOut != "" {
+		if err := writeFile(*csvOut, r.writeCSV); err != nil {
+			fatal(err)
+		}
+	}
+	if *baseline != "" {
+		base, err := readReport(*baseline)
+		if err != nil {
+			fatal(err)
+		}
+		err = writeFile(*diffOut, func(w io.Writer) error { return writeDiff(w, base, r) })
+		if err != nil {
+			fatal(err)
+		}
+	}
+}
+
+func writeFile(file string, write func(io.Writer) error) error {
+	buf := &bytes.Buffer{}
+	if err := write(buf); err != nil {
+		return err
+	}
+	return os.WriteFile(file, buf.Bytes(), 0666)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(1)
+}
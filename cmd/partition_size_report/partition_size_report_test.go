@@ -0,0 +1,208 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, files map[string]int) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, size := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, bytes.Repeat([]byte{'x'}, size), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func testReport(t *testing.T) *report {
+	t.Helper()
+	root := writeTestFiles(t, map[string]int{
+		"system/bin/foo":       5000,
+		"system/lib64/foo.so":  100,
+		"system/app/Bar.apk":   9000,
+		"system/build.prop":    10,
+		"system/app/Bar.odex":  1,
+		"system/etc/empty.txt": 0,
+	})
+	if err := os.Symlink("foo", filepath.Join(root, "system/bin/foo_link")); err != nil {
+		t.Fatal(err)
+	}
+	owners := map[string]owner{
+		"system/bin/foo":      {Module: "foo", Variation: "android_arm64_armv8-a"},
+		"system/lib64/foo.so": {Module: "foo", Variation: "android_arm64_armv8-a_shared"},
+		"system/app/Bar.apk":  {Module: "Bar"},
+		"system/app/Bar.odex": {Module: "Bar"},
+	}
+	files, err := collectFiles(root, owners, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newReport("system", "ext4", false, files)
+}
+
+func TestReport(t *testing.T) {
+	r := testReport(t)
+
+	expectedModules := []moduleSize{
+		{Module: "Bar", Files: 2, Size: 9001, OnDiskSize: 16384},
+		{Module: "foo", Files: 2, Size: 5100, OnDiskSize: 12288},
+		{Module: unownedModule, Files: 2, Size: 10, OnDiskSize: 4096},
+	}
+	if !reflect.DeepEqual(r.Modules, expectedModules) {
+		t.Errorf("expected modules:\n%v\ngot:\n%v", expectedModules, r.Modules)
+	}
+	if r.Size != 14111 || r.OnDiskSize != 32768 {
+		t.Errorf("expected total size 14111 and on-disk size 32768, got %d and %d", r.Size, r.OnDiskSize)
+	}
+
+	var paths []string
+	for _, f := range r.Files {
+		paths = append(paths, f.Path)
+	}
+	expectedPaths := []string{
+		"system/app/Bar.apk",
+		"system/app/Bar.odex",
+		"system/bin/foo",
+		"system/build.prop",
+		"system/etc/empty.txt",
+		"system/lib64/foo.so",
+	}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("expected files %q, got %q", expectedPaths, paths)
+	}
+
+	csv := &bytes.Buffer{}
+	if err := r.writeCSV(csv); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(csv.String(), "\n")
+	if lines[0] != "path,module,variation,size,on_disk_size,compressed_size" {
+		t.Errorf("unexpected CSV header %q", lines[0])
+	}
+	if lines[3] != "system/bin/foo,foo,android_arm64_armv8-a,5000,8192,0" {
+		t.Errorf("unexpected CSV line %q", lines[3])
+	}
+
+	// The JSON report can be read back as a baseline.
+	json := &bytes.Buffer{}
+	if err := r.writeJSON(json); err != nil {
+		t.Fatal(err)
+	}
+	baselineFile := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(baselineFile, json.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := readReport(baselineFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(baseline, r) {
+		t.Errorf("report changed after writing and reading it:\n%v\n%v", r, baseline)
+	}
+}
+
+func TestCompressedSizes(t *testing.T) {
+	files := []fileSize{
+		{Path: "bin/foo", Module: "foo", Size: 5000, OnDiskSize: 8192},
+		{Path: "lib/foo.so", Module: "foo", Size: 100, OnDiskSize: 4096},
+	}
+	compressed := map[string]int64{"bin/foo": 4096, "lib/foo.so": 0}
+	err := addCompressedSizes(files, func(path string) (int64, error) {
+		return compressed[path], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newReport("vendor", "erofs", true, files)
+	if r.CompressedSize != 4096 || r.totalCharged() != 4096 {
+		t.Errorf("expected compressed size 4096, got %d", r.CompressedSize)
+	}
+
+	size, err := parseDumpErofs("Path : /bin/foo\nSize: 5000  On-disk size: 4096  compressed\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 4096 {
+		t.Errorf("expected on-disk size 4096 from dump.erofs, got %d", size)
+	}
+	if _, err := parseDumpErofs("File : /bin/foo\n"); err == nil {
+		t.Errorf("expected an error for dump.erofs output without a size")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	current := testReport(t)
+	baseline := &report{
+		Partition:  "system",
+		OnDiskSize: 20480,
+		Modules: []moduleSize{
+			{Module: "Bar", OnDiskSize: 16384},
+			{Module: "foo", OnDiskSize: 4096},
+			{Module: "baz", OnDiskSize: 4096},
+		},
+	}
+	buf := &bytes.Buffer{}
+	if err := writeDiff(buf, baseline, current); err != nil {
+		t.Fatal(err)
+	}
+	expected := "system: 20480 -> 32768 bytes (+12288)\n" +
+		"       +8192  foo: 4096 -> 12288\n" +
+		"       +4096  <unowned>: 0 -> 4096 (added)\n" +
+		"       -4096  baz: 4096 -> 0 (removed)\n"
+	if buf.String() != expected {
+		t.Errorf("expected diff:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestBudgets(t *testing.T) {
+	r := testReport(t)
+
+	var budgets budgetFlags
+	for _, flag := range []string{
+		"module:foo=12288",
+		"module:Bar=10000",
+		"dir:system/app=16384",
+		"dir:system/bin/=4096",
+	} {
+		if err := budgets.Set(flag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []string{
+		"module Bar in system uses 16384 bytes, which is 6384 bytes over its budget of 10000 bytes",
+		"directory system/bin in system uses 8192 bytes, which is 4096 bytes over its budget of 4096 bytes",
+	}
+	if errs := checkBudgets(r, budgets); !reflect.DeepEqual(errs, expected) {
+		t.Errorf("expected errors:\n%q\ngot:\n%q", expected, errs)
+	}
+
+	for _, invalid := range []string{"foo=1", "module:foo", "module:=1", "file:foo=1", "dir:foo=-1", "dir:foo=1k"} {
+		if _, err := parseBudget(invalid); err == nil {
+			t.Errorf("expected an error for budget %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// The module that files that aren't installed by any module are attributed to, e.g. build.prop
+// or the linker config.
+const unownedModule = "<unowned>"
+
+// owner is an entry of the owners file written by the android_filesystem module.
+type owner struct {
+	Path      string `json:"path"`
+	Module    string `json:"module"`
+	Variation string `json:"variation,omitempty"`
+}
+
+type fileSize struct {
+	Path      string `json:"path"`
+	Module    string `json:"module"`
+	Variation string `json:"variation,omitempty"`
+	// The size of the contents of the file.
+	Size int64 `json:"size"`
+	// The size rounded up to whole blocks.
+	OnDiskSize int64 `json:"on_disk_size"`
+	// The size of the blocks the file uses in the image, for compressed filesystems.
+	CompressedSize int64 `json:"compressed_size,omitempty"`
+}
+
+type moduleSize struct {
+	Module         string `json:"module"`
+	Files          int    `json:"files"`
+	Size           int64  `json:"size"`
+	OnDiskSize     int64  `json:"on_disk_size"`
+	CompressedSize int64  `json:"compressed_size,omitempty"`
+}
+
+type report struct {
+	Partition      string       `json:"partition"`
+	FsType         string       `json:"fs_type"`
+	Compressed     bool         `json:"compressed"`
+	Size           int64        `json:"size"`
+	OnDiskSize     int64        `json:"on_disk_size"`
+	CompressedSize int64        `json:"compressed_size,omitempty"`
+	Modules        []moduleSize `json:"modules"`
+	Files          []fileSize   `json:"files"`
+}
+
+// charged returns the size that counts against budgets and in diffs: the compressed size for
+// compressed filesystems, and the on-disk size otherwise.
+func (r *report) charged(onDiskSize, compressedSize int64) int64 {
+	if r.Compressed {
+		return compressedSize
+	}
+	return onDiskSize
+}
+
+func (r *report) moduleCharged(m moduleSize) int64 {
+	return r.charged(m.OnDiskSize, m.CompressedSize)
+}
+
+func (r *report) fileCharged(f fileSize) int64 {
+	return r.charged(f.OnDiskSize, f.CompressedSize)
+}
+
+func (r *report) totalCharged() int64 {
+	return r.charged(r.OnDiskSize, r.CompressedSize)
+}
+
+func readOwners(file string) (map[string]owner, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var owners []owner
+	if err := json.Unmarshal(data, &owners); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	ret := make(map[string]owner, len(owners))
+	for _, o := range owners {
+		ret[filepath.Clean(o.Path)] = o
+	}
+	return ret, nil
+}
+
+// collectFiles returns the sizes of the regular files in the staging directory of the partition,
+// attributed to the modules that install them.
+func collectFiles(rootDir string, owners map[string]owner, blockSize int64) ([]fileSize, error) {
+	var files []fileSize
+	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(rootDir, path)
+		if err != nil {
+			return err
+		}
+		file := fileSize{
+			Path:       rel,
+			Module:     unownedModule,
+			Size:       info.Size(),
+			OnDiskSize: roundUp(info.Size(), blockSize),
+		}
+		if o, ok := owners[rel]; ok {
+			file.Module = o.Module
+			file.Variation = o.Variation
+		}
+		files = append(files, file)
+		return nil
+	})
+	return files, err
+}
+
+func roundUp(size, blockSize int64) int64 {
+	return (size + blockSize - 1) / blockSize * blockSize
+}
+
+// newReport sums the sizes of the files per module and for the whole partition.
+func newReport(partition, fsType string, compressed bool, files []fileSize) *report {
+	r := &report{
+		Partition:  partition,
+		FsType:     fsType,
+		Compressed: compressed,
+		Files:      files,
+	}
+	sort.Slice(r.Files, func(i, j int) bool { return r.Files[i].Path < r.Files[j].Path })
+
+	modules := make(map[string]*moduleSize)
+	for _, f := range r.Files {
+		m := modules[f.Module]
+		if m == nil {
+			m = &moduleSize{Module: f.Module}
+			modules[f.Module] = m
+		}
+		m.Files++
+		m.Size += f.Size
+		m.OnDiskSize += f.OnDiskSize
+		m.CompressedSize += f.CompressedSize
+		r.Size += f.Size
+		r.OnDiskSize += f.OnDiskSize
+		r.CompressedSize += f.CompressedSize
+	}
+	for _, m := range modules {
+		r.Modules = append(r.Modules, *m)
+	}
+	// Largest modules first.
+	sort.Slice(r.Modules, func(i, j int) bool {
+		a, b := r.moduleCharged(r.Modules[i]), r.moduleCharged(r.Modules[j])
+		if a != b {
+			return a > b
+		}
+		return r.Modules[i].Module < r.Modules[j].Module
+	})
+	return r
+}
+
+func readReport(file string) (*report, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	r := &report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return r, nil
+}
+
+func (r *report) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// writeCSV writes a row per file.
+func (r *report) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"path", "module", "variation", "size", "on_disk_size", "compressed_size"})
+	for _, f := range r.Files {
+		cw.Write([]string{
+			f.Path,
+			f.Module,
+			f.Variation,
+			strconv.FormatInt(f.Size, 10),
+			strconv.FormatInt(f.OnDiskSize, 10),
+			strconv.FormatInt(f.CompressedSize, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type moduleDelta struct {
+	module            string
+	baseline, current int64
+	added, removed    bool
+}
+
+func (d moduleDelta) delta() int64 {
+	return d.current - d.baseline
+}
+
+// diffReports returns the modules whose size changed from the baseline report, largest changes
+// first.
+func diffReports(baseline, current *report) []moduleDelta {
+	baselineModules := make(map[string]moduleSize)
+	for _, m := range baseline.Modules {
+		baselineModules[m.Module] = m
+	}
+
+	var deltas []moduleDelta
+	for _, m := range current.Modules {
+		d := moduleDelta{module: m.Module, current: current.moduleCharged(m)}
+		if b, ok := baselineModules[m.Module]; ok {
+			d.baseline = baseline.moduleCharged(b)
+			delete(baselineModules, m.Module)
+		} else {
+			d.added = true
+		}
+		if d.delta() != 0 || d.added {
+			deltas = append(deltas, d)
+		}
+	}
+	for _, b := range baselineModules {
+		deltas = append(deltas, moduleDelta{module: b.Module, baseline: baseline.moduleCharged(b), removed: true})
+	}
+
+	sort.Slice(deltas, func(i, j int) bool {
+		a, b := abs(deltas[i].delta()), abs(deltas[j].delta())
+		if a != b {
+			return a > b
+		}
+		return deltas[i].module < deltas[j].module
+	})
+	return deltas
+}
+
+func abs(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func writeDiff(w io.Writer, baseline, current *report) error {
+	b, c := baseline.totalCharged(), current.totalCharged()
+	if _, err := fmt.Fprintf(w, "%s: %d -> %d bytes (%+d)\n", current.Partition, b, c, c-b); err != nil {
+		return err
+	}
+	for _, d := range diffReports(baseline, current) {
+		var note string
+		switch {
+		case d.added:
+			note = " (added)"
+		case d.removed:
+			note = " (removed)"
+		}
+		if _, err := fmt.Fprintf(w, "%+12d  %s: %d -> %d%s\n", d.delta(), d.module, d.baseline, d.current, note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,581 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"android/soong/cmd/bpgen"
+)
+
+// bpFile is a generated Android.bp file.
+type bpFile struct {
+	// path is the path of the file relative to the directory of all distributions.
+	path    string
+	modules []*bpgen.Module
+}
+
+// Top level packages and modules of sdists that are not part of what is installed.
+var excludedTopLevel = map[string]bool{
+	"benchmarks": true,
+	"conftest":   true,
+	"doc":        true,
+	"docs":       true,
+	"examples":   true,
+	"noxfile":    true,
+	"setup":      true,
+	"test":       true,
+	"tests":      true,
+}
+
+func isNativeExtension(name string) bool {
+	for _, ext := range []string{".so", ".pyd", ".dylib"} {
+		if strings.HasSuffix(name, ext) || strings.Contains(name, ext+".") {
+			return true
+		}
+	}
+	return false
+}
+
+type generator struct {
+	fsys  fs.FS
+	dists map[string]*Dist
+	// reqs are the pinned requirements, sorted by name.
+	reqs   []Requirement
+	pinned map[string]bool
+	env    markerEnv
+
+	// Problems that need manual handling, reported on stderr.
+	warnings []string
+}
+
+func newGenerator(fsys fs.FS, dists []*Dist, reqs []Requirement, env markerEnv) (*generator, error) {
+	g := &generator{
+		fsys:   fsys,
+		dists:  make(map[string]*Dist),
+		pinned: make(map[string]bool),
+		env:    env,
+	}
+	for _, d := range dists {
+		if other, ok := g.dists[d.Name]; ok {
+			return nil, fmt.Errorf("%s is unpacked in both %s and %s", d.Name, other.Dir, d.Dir)
+		}
+		g.dists[d.Name] = d
+	}
+
+	versions := make(map[string]string)
+	for _, req := range reqs {
+		if v, ok := versions[req.Name]; ok {
+			if v != req.Version {
+				return nil, fmt.Errorf("%s is pinned to both %s and %s", req.Name, v, req.Version)
+			}
+			// Merge the extras of duplicate requirements.
+			for i := range g.reqs {
+				if g.reqs[i].Name == req.Name {
+					g.reqs[i].Extras = append(g.reqs[i].Extras, req.Extras...)
+				}
+			}
+			continue
+		}
+		versions[req.Name] = req.Version
+		d, ok := g.dists[req.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s %s is not unpacked in the distributions directory", req.Name, req.Version)
+		}
+		if normalizeVersion(d.Version) != normalizeVersion(req.Version) {
+			return nil, fmt.Errorf("%s is pinned to %s, but version %s is unpacked in %s",
+				req.Name, req.Version, d.Version, d.Dir)
+		}
+		g.reqs = append(g.reqs, req)
+		g.pinned[req.Name] = true
+	}
+	sort.Slice(g.reqs, func(i, j int) bool { return g.reqs[i].Name < g.reqs[j].Name })
+	return g, nil
+}
+
+func normalizeVersion(v string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(v)), "v")
+}
+
+func (g *generator) warn(format string, args ...interface{}) {
+	g.warnings = append(g.warnings, fmt.Sprintf(format, args...))
+}
+
+func moduleName(name string) string {
+	return "py-" + name
+}
+
+func licenseModuleName(d *Dist) string {
+	return "py_" + strings.ReplaceAll(d.Name, "-", "_") + "_license"
+}
+
+// requires returns the requirements of a distribution that apply to the host Python, including
+// the ones of the given extras.
+func (g *generator) requires(d *Dist, extras []string) ([]Requirement, error) {
+	var ret []Requirement
+	for _, req := range d.Requires {
+		if req.Marker == "" {
+			ret = append(ret, req)
+			continue
+		}
+		for _, extra := range append([]string{""}, extras...) {
+			applies, err := g.env.withExtra(extra).evaluate(req.Marker)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", d.Name, err)
+			}
+			if applies {
+				ret = append(ret, req)
+				break
+			}
+		}
+	}
+	return ret, nil
+}
+
+// extras returns the extras of each distribution that are needed, by the requirements file or by
+// the requirements of the other distributions. As a module includes all the dependencies of its
+// needed extras, they are computed for the whole set of distributions.
+func (g *generator) extras() (map[string][]string, error) {
+	extras := make(map[string][]string)
+	add := func(name string, newExtras []string) bool {
+		changed := false
+		for _, e := range newExtras {
+			if !inList(e, extras[name]) {
+				extras[name] = append(extras[name], e)
+				changed = true
+			}
+		}
+		return changed
+	}
+	for _, req := range g.reqs {
+		add(req.Name, req.Extras)
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, req := range g.reqs {
+			deps, err := g.requires(g.dists[req.Name], extras[req.Name])
+			if err != nil {
+				return nil, err
+			}
+			for _, dep := range deps {
+				if add(dep.Name, dep.Extras) {
+					changed = true
+				}
+			}
+		}
+	}
+	for name := range extras {
+		sort.Strings(extras[name])
+	}
+	return extras, nil
+}
+
+func inList(s string, list []string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// libs returns the modules of the distributions a distribution depends on.
+func (g *generator) libs(d *Dist, extras []string) ([]string, error) {
+	deps, err := g.requires(d, extras)
+	if err != nil {
+		return nil, err
+	}
+	var libs []string
+	for _, dep := range deps {
+		if dep.Name == d.Name {
+			continue
+		}
+		if !g.pinned[dep.Name] {
+			g.warn("%s requires %s, which is not pinned in the requirements, add it to the requirements",
+				d.Name, dep.Name)
+			continue
+		}
+		if lib := moduleName(dep.Name); !inList(lib, libs) {
+			libs = append(libs, lib)
+		}
+	}
+	sort.Strings(libs)
+	return libs, nil
+}
+
+// layout describes where the importable code of a distribution is.
+type layout struct {
+	// root is the directory that is added to the Python path, relative to the distribution
+	// directory: "" for wheels, or e.g. "src" for sdists using a src layout.
+	root string
+	// pkgPath is set when the distribution directory is the package itself, as it then needs to
+	// be put under its package name.
+	pkgPath  string
+	packages []string
+	modules  []string
+}
+
+func (g *generator) exists(name string) bool {
+	_, err := fs.Stat(g.fsys, name)
+	return err == nil
+}
+
+func (g *generator) isDir(name string) bool {
+	info, err := fs.Stat(g.fsys, name)
+	return err == nil && info.IsDir()
+}
+
+// findLayout finds the top level packages and modules of a distribution, using its top_level.txt
+// if there is one, in the distribution directory or in the src or lib directories of an sdist.
+func (g *generator) findLayout(d *Dist) (*layout, error) {
+	if !d.Wheel && g.exists(path.Join(d.Dir, "__init__.py")) {
+		pkgPath := strings.ReplaceAll(d.Name, "-", "_")
+		if len(d.TopLevel) == 1 {
+			pkgPath = d.TopLevel[0]
+		}
+		return &layout{pkgPath: pkgPath}, nil
+	}
+
+	roots := []string{""}
+	if !d.Wheel {
+		roots = append(roots, "src", "lib")
+	}
+	for _, root := range roots {
+		l := &layout{root: root}
+		dir := path.Join(d.Dir, root)
+		if !g.isDir(dir) {
+			continue
+		}
+		for _, name := range d.TopLevel {
+			if excludedTopLevel[name] {
+				continue
+			}
+			if g.isDir(path.Join(dir, name)) {
+				l.packages = append(l.packages, name)
+			} else if g.exists(path.Join(dir, name+".py")) {
+				l.modules = append(l.modules, name+".py")
+			}
+		}
+		if len(l.packages) == 0 && len(l.modules) == 0 {
+			entries, err := fs.ReadDir(g.fsys, dir)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				name := e.Name()
+				if e.IsDir() {
+					if !excludedTopLevel[name] && g.exists(path.Join(dir, name, "__init__.py")) {
+						l.packages = append(l.packages, name)
+					}
+				} else if module, ok := strings.CutSuffix(name, ".py"); ok && !excludedTopLevel[module] {
+					l.modules = append(l.modules, name)
+				}
+			}
+		}
+		if len(l.packages) > 0 || len(l.modules) > 0 {
+			sort.Strings(l.packages)
+			sort.Strings(l.modules)
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: no Python packages or modules found in %s", d.Name, d.Dir)
+}
+
+// dataFiles returns the files that are not Python sources in a directory, relative to base.
+func (g *generator) dataFiles(d *Dist, base, dir string, topLevelFilter func(name string, isDir bool) bool) ([]string, error) {
+	var data []string
+	err := fs.WalkDir(g.fsys, dir, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := e.Name()
+		if topLevelFilter != nil && path.Dir(p) == dir && !topLevelFilter(name, e.IsDir()) {
+			if e.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if e.IsDir() {
+			if name == "__pycache__" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		rel := strings.TrimPrefix(p, base+"/")
+		switch {
+		case strings.HasSuffix(name, ".py"), strings.HasSuffix(name, ".pyc"), strings.HasSuffix(name, ".pyo"),
+			name == "Android.bp":
+		case isNativeExtension(name):
+			g.warn("%s contains the native extension %s, which needs a hand written module", d.Name, rel)
+		default:
+			data = append(data, rel)
+		}
+		return nil
+	})
+	sort.Strings(data)
+	return data, err
+}
+
+func (g *generator) libraryModule(d *Dist, l *layout, extras []string) (*bpgen.Module, error) {
+	m := &bpgen.Module{Type: "python_library_host"}
+	m.Add("name", moduleName(d.Name))
+	m.Add("pkg_path", l.pkgPath)
+
+	base := path.Join(d.Dir, l.root)
+	var srcs, excludeSrcs, data []string
+	if l.pkgPath != "" {
+		srcs = []string{"**/*.py"}
+		entries, err := fs.ReadDir(g.fsys, base)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() && excludedTopLevel[name] {
+				excludeSrcs = append(excludeSrcs, name+"/**/*.py")
+			} else if module, ok := strings.CutSuffix(name, ".py"); ok && !e.IsDir() && excludedTopLevel[module] {
+				excludeSrcs = append(excludeSrcs, name)
+			}
+		}
+		// The files of the sdist itself, like the README or setup.cfg, are next to the package data.
+		data, err = g.dataFiles(d, base, base, func(name string, isDir bool) bool {
+			if isDir {
+				return !excludedTopLevel[name] && !strings.HasSuffix(name, ".egg-info") &&
+					!strings.HasPrefix(name, ".")
+			}
+			return name == "py.typed" || strings.HasSuffix(name, ".pyi")
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, pkg := range l.packages {
+			srcs = append(srcs, pkg+"/**/*.py")
+			pkgData, err := g.dataFiles(d, base, path.Join(base, pkg), nil)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, pkgData...)
+		}
+		srcs = append(srcs, l.modules...)
+	}
+	m.Add("srcs", srcs)
+	m.Add("exclude_srcs", excludeSrcs)
+	m.Add("data", data)
+
+	libs, err := g.libs(d, extras)
+	if err != nil {
+		return nil, err
+	}
+	m.Add("libs", libs)
+
+	if len(extras) > 0 {
+		m.Comments = append(m.Comments, fmt.Sprintf("Includes the dependencies of the extras: %s.",
+			strings.Join(extras, ", ")))
+	}
+	if d.Wheel {
+		if matches, _ := fs.Glob(g.fsys, path.Join(d.Dir, "*.data")); len(matches) > 0 {
+			g.warn("%s has files in %s that are installed outside of the package, check whether they are needed",
+				d.Name, path.Base(matches[0]))
+		}
+	}
+	return m, nil
+}
+
+// Licenses of the "License ::" trove classifiers, for distributions without a License-Expression.
+var classifierLicenses = map[string]string{
+	"Apache Software License":                                 "Apache-2.0",
+	"BSD License":                                             "BSD",
+	"Boost Software License 1.0 (BSL-1.0)":                    "BSL-1.0",
+	"GNU General Public License v2 (GPLv2)":                   "GPL-2.0",
+	"GNU General Public License v2 or later (GPLv2+)":         "GPL-2.0+",
+	"GNU General Public License v3 (GPLv3)":                   "GPL-3.0",
+	"GNU Lesser General Public License v2 (LGPLv2)":           "LGPL-2.0",
+	"GNU Lesser General Public License v2 or later (LGPLv2+)": "LGPL-2.0+",
+	"GNU Lesser General Public License v3 (LGPLv3)":           "LGPL-3.0",
+	"Historical Permission Notice and Disclaimer (HPND)":      "HPND",
+	"ISC License (ISCL)":                                      "ISC",
+	"MIT License":                                             "MIT",
+	"MIT No Attribution License (MIT-0)":                      "MIT-0",
+	"Mozilla Public License 2.0 (MPL 2.0)":                    "MPL-2.0",
+	"Python Software Foundation License":                      "PSF-2.0",
+	"The Unlicense (Unlicense)":                               "Unlicense",
+	"Zope Public License":                                     "ZPL-2.1",
+}
+
+// Common free form values of the License field of distributions without a License-Expression.
+var licenseAliases = map[string]string{
+	"apache":                      "Apache-2.0",
+	"apache 2":                    "Apache-2.0",
+	"apache 2.0":                  "Apache-2.0",
+	"apache-2.0":                  "Apache-2.0",
+	"apache license 2.0":          "Apache-2.0",
+	"apache license, version 2.0": "Apache-2.0",
+	"apache software license":     "Apache-2.0",
+	"bsd":                         "BSD",
+	"bsd-2-clause":                "BSD-2-Clause",
+	"bsd-3-clause":                "BSD-3-Clause",
+	"isc":                         "ISC",
+	"mit":                         "MIT",
+	"mit license":                 "MIT",
+	"mpl-2.0":                     "MPL-2.0",
+	"psf":                         "PSF-2.0",
+	"psf-2.0":                     "PSF-2.0",
+	"unlicense":                   "Unlicense",
+}
+
+// distLicenseKinds returns the license kinds of a distribution from its License-Expression, its
+// "License ::" classifiers or its License field, in that order.
+func distLicenseKinds(d *Dist) []string {
+	if expr := d.Metadata.Get("License-Expression"); expr != "" {
+		return bpgen.LicenseKinds(expr)
+	}
+	var ids []string
+	for _, c := range d.Metadata.GetAll("Classifier") {
+		parts := strings.Split(c, " :: ")
+		if len(parts) < 2 || parts[0] != "License" {
+			continue
+		}
+		if id := classifierLicenses[parts[len(parts)-1]]; id != "" && !inList(id, ids) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		if id, ok := licenseAliases[strings.ToLower(strings.TrimSpace(d.Metadata.Get("License")))]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return bpgen.LicenseKinds(strings.Join(ids, " AND "))
+}
+
+func isLicenseFile(name string) bool {
+	upper := strings.ToUpper(name)
+	return strings.HasPrefix(upper, "LICENSE") || strings.HasPrefix(upper, "LICENCE") ||
+		strings.HasPrefix(upper, "COPYING")
+}
+
+// licenseTexts returns the license files of a distribution relative to its directory: the
+// License-File entries of its metadata, or the license files of its directory or its metadata
+// directory.
+func (g *generator) licenseTexts(d *Dist) ([]string, error) {
+	var texts []string
+	for _, f := range d.Metadata.GetAll("License-File") {
+		candidates := []string{f}
+		if d.MetadataDir != "" {
+			// Wheels put the license files in the licenses directory of the .dist-info
+			// directory, or directly in it for older versions of setuptools.
+			candidates = append(candidates, path.Join(d.MetadataDir, "licenses", f), path.Join(d.MetadataDir, f))
+		}
+		for _, c := range candidates {
+			if g.exists(path.Join(d.Dir, c)) {
+				texts = append(texts, c)
+				break
+			}
+		}
+	}
+	if len(texts) > 0 {
+		sort.Strings(texts)
+		return texts, nil
+	}
+
+	dirs := []string{""}
+	if d.MetadataDir != "" {
+		dirs = append(dirs, d.MetadataDir)
+	}
+	for _, dir := range dirs {
+		entries, err := fs.ReadDir(g.fsys, path.Join(d.Dir, dir))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && isLicenseFile(e.Name()) {
+				texts = append(texts, path.Join(dir, e.Name()))
+			}
+		}
+	}
+	sort.Strings(texts)
+	return texts, nil
+}
+
+func (g *generator) licenseModules(d *Dist) ([]*bpgen.Module, error) {
+	name := licenseModuleName(d)
+	pkg := &bpgen.Module{Type: "package"}
+	pkg.Add("default_applicable_licenses", []string{name})
+
+	license := &bpgen.Module{Type: "license"}
+	license.Add("name", name)
+	license.Add("visibility", []string{":__subpackages__"})
+	kinds := distLicenseKinds(d)
+	if len(kinds) == 0 {
+		g.warn("%s has no license metadata that maps to an SPDX identifier, set license_kinds manually", d.Name)
+		license.Comments = append(license.Comments,
+			"TODO: set license_kinds, the distribution has no license metadata that maps to an SPDX identifier.")
+	}
+	license.Add("license_kinds", kinds)
+	texts, err := g.licenseTexts(d)
+	if err != nil {
+		return nil, err
+	}
+	if len(texts) == 0 {
+		g.warn("%s has no license file", d.Name)
+	}
+	license.Add("license_text", texts)
+	return []*bpgen.Module{pkg, license}, nil
+}
+
+// generate returns the Android.bp files of the pinned distributions. The license of a distribution
+// is in the Android.bp file of its directory, and its python_library_host module in the Android.bp
+// file of the directory that is added to the Python path, which may be a subdirectory.
+func (g *generator) generate() ([]*bpFile, error) {
+	extras, err := g.extras()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*bpFile
+	for _, req := range g.reqs {
+		d := g.dists[req.Name]
+		l, err := g.findLayout(d)
+		if err != nil {
+			return nil, err
+		}
+		licenses, err := g.licenseModules(d)
+		if err != nil {
+			return nil, err
+		}
+		lib, err := g.libraryModule(d, l, extras[d.Name])
+		if err != nil {
+			return nil, err
+		}
+
+		if l.root == "" {
+			files = append(files, &bpFile{
+				path:    path.Join(d.Dir, "Android.bp"),
+				modules: append(licenses, lib),
+			})
+			continue
+		}
+		pkg := &bpgen.Module{Type: "package"}
+		pkg.Add("default_applicable_licenses", []string{licenseModuleName(d)})
+		files = append(files,
+			&bpFile{path: path.Join(d.Dir, "Android.bp"), modules: licenses},
+			&bpFile{path: path.Join(d.Dir, l.root, "Android.bp"), modules: []*bpgen.Module{pkg, lib}})
+	}
+	return files, nil
+}
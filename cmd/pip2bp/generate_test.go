@@ -0,0 +1,517 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const testRequirements = `#
+# This file is autogenerated by pip-compile
+#
+certifi==2023.7.22 \
+    --hash=sha256:0123
+charset-normalizer==3.2.0
+idna==3.4
+mypkg==1.0
+requests[socks]==2.31.0
+six==1.16.0 ; python_version >= "3"
+urllib3==2.0.4
+pywin32==306 ; sys_platform == "win32"
+`
+
+func file(s string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(s)}
+}
+
+var testDists = fstest.MapFS{
+	// A wheel with an extra and a License-File in the .dist-info directory.
+	"requests-2.31.0/requests-2.31.0.dist-info/METADATA": file(`Metadata-Version: 2.1
+Name: requests
+Version: 2.31.0
+License: Apache 2.0
+Classifier: License :: OSI Approved :: Apache Software License
+License-File: LICENSE
+Requires-Dist: charset-normalizer (<4,>=2)
+Requires-Dist: idna (<4,>=2.5)
+Requires-Dist: urllib3 (<3,>=1.21.1)
+Requires-Dist: certifi (>=2017.4.17)
+Provides-Extra: socks
+Requires-Dist: PySocks (!=1.5.7,>=1.5.6) ; extra == 'socks'
+Provides-Extra: use_chardet_on_py3
+Requires-Dist: chardet (<6,>=3.0.2) ; extra == 'use_chardet_on_py3'
+
+Requests is an HTTP library.
+`),
+	"requests-2.31.0/requests-2.31.0.dist-info/LICENSE":        file(""),
+	"requests-2.31.0/requests-2.31.0.dist-info/top_level.txt":  file("requests\n"),
+	"requests-2.31.0/requests/__init__.py":                     file(""),
+	"requests-2.31.0/requests/api.py":                          file(""),
+	"requests-2.31.0/requests/__pycache__/api.cpython-311.pyc": file(""),
+
+	// A wheel with package data, a native extension and a License-Expression.
+	"charset_normalizer-3.2.0/charset_normalizer-3.2.0.dist-info/METADATA": file(`Metadata-Version: 2.4
+Name: charset-normalizer
+Version: 3.2.0
+License-Expression: MIT
+`),
+	"charset_normalizer-3.2.0/charset_normalizer-3.2.0.dist-info/LICENSE":            file(""),
+	"charset_normalizer-3.2.0/charset_normalizer/__init__.py":                        file(""),
+	"charset_normalizer-3.2.0/charset_normalizer/py.typed":                           file(""),
+	"charset_normalizer-3.2.0/charset_normalizer/md.cpython-311-x86_64-linux-gnu.so": file(""),
+	"charset_normalizer-3.2.0/charset_normalizer/assets/frequencies.json":            file(""),
+
+	// A wheel with a PEP 639 license file and a requirement with a marker that doesn't apply.
+	"urllib3-2.0.4/urllib3-2.0.4.dist-info/METADATA": file(`Metadata-Version: 2.4
+Name: urllib3
+Version: 2.0.4
+License-Expression: MIT
+License-File: LICENSE.txt
+Requires-Dist: importlib-metadata; python_version < "3.8"
+Requires-Dist: brotli>=1.0.9; (platform_python_implementation == 'CPython') and extra == 'brotli'
+`),
+	"urllib3-2.0.4/urllib3-2.0.4.dist-info/licenses/LICENSE.txt": file(""),
+	"urllib3-2.0.4/urllib3/__init__.py":                          file(""),
+	"urllib3-2.0.4/urllib3/util/__init__.py":                     file(""),
+
+	"certifi-2023.7.22/certifi-2023.7.22.dist-info/METADATA": file(`Metadata-Version: 2.1
+Name: certifi
+Version: 2023.7.22
+License: MPL-2.0
+Classifier: License :: OSI Approved :: Mozilla Public License 2.0 (MPL 2.0)
+`),
+	"certifi-2023.7.22/certifi-2023.7.22.dist-info/LICENSE": file(""),
+	"certifi-2023.7.22/certifi/__init__.py":                 file(""),
+	"certifi-2023.7.22/certifi/cacert.pem":                  file(""),
+
+	// An sdist using a src layout, with tests next to it.
+	"idna-3.4/PKG-INFO": file(`Metadata-Version: 2.1
+Name: idna
+Version: 3.4
+License: BSD-3-Clause
+`),
+	"idna-3.4/LICENSE.md":            file(""),
+	"idna-3.4/setup.py":              file(""),
+	"idna-3.4/src/idna/__init__.py":  file(""),
+	"idna-3.4/src/idna/core.py":      file(""),
+	"idna-3.4/tests/test_idna.py":    file(""),
+	"idna-3.4/tests/__init__.py":     file(""),
+	"idna-3.4/docs/index.rst":        file(""),
+	"idna-3.4/src/idna/idnadata.txt": file(""),
+
+	// An sdist of a single module with an .egg-info directory.
+	"six-1.16.0/PKG-INFO": file(`Metadata-Version: 1.2
+Name: six
+Version: 1.16.0
+License: MIT
+`),
+	"six-1.16.0/six.egg-info/PKG-INFO":      file("Name: six\nVersion: 1.16.0\n"),
+	"six-1.16.0/six.egg-info/top_level.txt": file("six\n"),
+	"six-1.16.0/LICENSE":                    file(""),
+	"six-1.16.0/setup.py":                   file(""),
+	"six-1.16.0/six.py":                     file(""),
+	"six-1.16.0/test_six.py":                file(""),
+
+	// An sdist whose directory is the package, with requirements in requires.txt.
+	"mypkg/PKG-INFO": file(`Metadata-Version: 1.1
+Name: MyPkg
+Version: 1.0
+License: UNKNOWN
+`),
+	"mypkg/MyPkg.egg-info/PKG-INFO":      file("Name: MyPkg\nVersion: 1.0\n"),
+	"mypkg/MyPkg.egg-info/top_level.txt": file("mypkg\n"),
+	"mypkg/MyPkg.egg-info/requires.txt": file(`six>=1.10
+
+[:python_version < "3.8"]
+importlib-metadata
+
+[tests]
+pytest
+`),
+	"mypkg/__init__.py":           file(""),
+	"mypkg/util.py":               file(""),
+	"mypkg/mypkg.pyi":             file(""),
+	"mypkg/setup.py":              file(""),
+	"mypkg/README.md":             file(""),
+	"mypkg/templates/index.html":  file(""),
+	"mypkg/tests/test_util.py":    file(""),
+	"mypkg/tests/data/input.json": file(""),
+
+	// Not a distribution.
+	"notes/README": file(""),
+}
+
+func readTestFile(files map[string]string) func(string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		if s, ok := files[name]; ok {
+			return []byte(s), nil
+		}
+		return nil, fmt.Errorf("open %s: no such file", name)
+	}
+}
+
+func writeFiles(files []*bpFile) map[string]string {
+	ret := make(map[string]string)
+	for _, f := range files {
+		var sb strings.Builder
+		for _, m := range f.modules {
+			m.Write(&sb)
+		}
+		ret[f.path] = sb.String()
+	}
+	return ret
+}
+
+func TestGenerate(t *testing.T) {
+	env := hostMarkerEnv("3.11")
+	reqs, err := ReadRequirements("requirements.txt",
+		readTestFile(map[string]string{"requirements.txt": testRequirements}), env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dists, err := FindDists(testDists)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := newGenerator(testDists, dists, reqs, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bpFiles, err := g.generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := writeFiles(bpFiles)
+
+	var paths []string
+	for _, f := range bpFiles {
+		paths = append(paths, f.path)
+	}
+	expectedPaths := []string{
+		"certifi-2023.7.22/Android.bp",
+		"charset_normalizer-3.2.0/Android.bp",
+		"idna-3.4/Android.bp",
+		"idna-3.4/src/Android.bp",
+		"mypkg/Android.bp",
+		"requests-2.31.0/Android.bp",
+		"six-1.16.0/Android.bp",
+		"urllib3-2.0.4/Android.bp",
+	}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("expected files %q, got %q", expectedPaths, paths)
+	}
+
+	expected := map[string]string{
+		"requests-2.31.0/Android.bp": `
+package {
+    default_applicable_licenses: [
+        "py_requests_license",
+    ],
+}
+
+license {
+    name: "py_requests_license",
+    visibility: [
+        ":__subpackages__",
+    ],
+    license_kinds: [
+        "SPDX-license-identifier-Apache-2.0",
+    ],
+    license_text: [
+        "requests-2.31.0.dist-info/LICENSE",
+    ],
+}
+
+// Includes the dependencies of the extras: socks.
+python_library_host {
+    name: "py-requests",
+    srcs: [
+        "requests/**/*.py",
+    ],
+    libs: [
+        "py-certifi",
+        "py-charset-normalizer",
+        "py-idna",
+        "py-urllib3",
+    ],
+}
+`,
+		"charset_normalizer-3.2.0/Android.bp": `
+package {
+    default_applicable_licenses: [
+        "py_charset_normalizer_license",
+    ],
+}
+
+license {
+    name: "py_charset_normalizer_license",
+    visibility: [
+        ":__subpackages__",
+    ],
+    license_kinds: [
+        "SPDX-license-identifier-MIT",
+    ],
+    license_text: [
+        "charset_normalizer-3.2.0.dist-info/LICENSE",
+    ],
+}
+
+python_library_host {
+    name: "py-charset-normalizer",
+    srcs: [
+        "charset_normalizer/**/*.py",
+    ],
+    data: [
+        "charset_normalizer/assets/frequencies.json",
+        "charset_normalizer/py.typed",
+    ],
+}
+`,
+		"urllib3-2.0.4/Android.bp": `
+package {
+    default_applicable_licenses: [
+        "py_urllib3_license",
+    ],
+}
+
+license {
+    name: "py_urllib3_license",
+    visibility: [
+        ":__subpackages__",
+    ],
+    license_kinds: [
+        "SPDX-license-identifier-MIT",
+    ],
+    license_text: [
+        "urllib3-2.0.4.dist-info/licenses/LICENSE.txt",
+    ],
+}
+
+python_library_host {
+    name: "py-urllib3",
+    srcs: [
+        "urllib3/**/*.py",
+    ],
+}
+`,
+		"idna-3.4/Android.bp": `
+package {
+    default_applicable_licenses: [
+        "py_idna_license",
+    ],
+}
+
+license {
+    name: "py_idna_license",
+    visibility: [
+        ":__subpackages__",
+    ],
+    license_kinds: [
+        "SPDX-license-identifier-BSD-3-Clause",
+    ],
+    license_text: [
+        "LICENSE.md",
+    ],
+}
+`,
+		"idna-3.4/src/Android.bp": `
+package {
+    default_applicable_licenses: [
+        "py_idna_license",
+    ],
+}
+
+python_library_host {
+    name: "py-idna",
+    srcs: [
+        "idna/**/*.py",
+    ],
+    data: [
+        "idna/idnadata.txt",
+    ],
+}
+`,
+		"six-1.16.0/Android.bp": `
+package {
+    default_applicable_licenses: [
+        "py_six_license",
+    ],
+}
+
+license {
+    name: "py_six_license",
+    visibility: [
+        ":__subpackages__",
+    ],
+    license_kinds: [
+        "SPDX-license-identifier-MIT",
+    ],
+    license_text: [
+        "LICENSE",
+    ],
+}
+
+python_library_host {
+    name: "py-six",
+    srcs: [
+        "six.py",
+    ],
+}
+`,
+		"mypkg/Android.bp": `
+package {
+    default_applicable_licenses: [
+        "py_mypkg_license",
+    ],
+}
+
+// TODO: set license_kinds, the distribution has no license metadata that maps to an SPDX identifier.
+license {
+    name: "py_mypkg_license",
+    visibility: [
+        ":__subpackages__",
+    ],
+}
+
+python_library_host {
+    name: "py-mypkg",
+    pkg_path: "mypkg",
+    srcs: [
+        "**/*.py",
+    ],
+    exclude_srcs: [
+        "setup.py",
+        "tests/**/*.py",
+    ],
+    data: [
+        "mypkg.pyi",
+        "templates/index.html",
+    ],
+    libs: [
+        "py-six",
+    ],
+}
+`,
+	}
+	for path, want := range expected {
+		if got := files[path]; got != want {
+			t.Errorf("unexpected %s, expected:\n%s\ngot:\n%s", path, want, got)
+		}
+	}
+
+	expectedWarnings := []string{
+		"charset-normalizer contains the native extension charset_normalizer/md.cpython-311-x86_64-linux-gnu.so, which needs a hand written module",
+		"mypkg has no license metadata that maps to an SPDX identifier, set license_kinds manually",
+		"mypkg has no license file",
+		"requests requires pysocks, which is not pinned in the requirements, add it to the requirements",
+	}
+	if !reflect.DeepEqual(g.warnings, expectedWarnings) {
+		t.Errorf("expected warnings:\n%s\ngot:\n%s", strings.Join(expectedWarnings, "\n"),
+			strings.Join(g.warnings, "\n"))
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	testCases := []struct {
+		name         string
+		requirements string
+		err          string
+	}{
+		{
+			name:         "not pinned",
+			requirements: "six>=1.16\n",
+			err:          "requirements.txt:1: six is not pinned to a single version with ==",
+		},
+		{
+			name:         "version mismatch",
+			requirements: "six==1.15.0\n",
+			err:          "six is pinned to 1.15.0, but version 1.16.0 is unpacked in six-1.16.0",
+		},
+		{
+			name:         "missing distribution",
+			requirements: "attrs==23.1.0\n",
+			err:          "attrs 23.1.0 is not unpacked in the distributions directory",
+		},
+		{
+			name:         "editable",
+			requirements: "-e ./local\n",
+			err:          "requirements.txt:1: editable requirements are not supported",
+		},
+		{
+			name:         "conflicting pins",
+			requirements: "-r base.txt\nsix==1.15.0\n",
+			err:          "six is pinned to both 1.16.0 and 1.15.0",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := hostMarkerEnv("3.11")
+			reqs, err := ReadRequirements("requirements.txt", readTestFile(map[string]string{
+				"requirements.txt": tc.requirements,
+				"base.txt":         "six==1.16.0\n",
+			}), env)
+			if err == nil {
+				var dists []*Dist
+				dists, err = FindDists(testDists)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = newGenerator(testDists, dists, reqs, env)
+			}
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("expected error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestMarkers(t *testing.T) {
+	env := hostMarkerEnv("3.11")
+	testCases := []struct {
+		marker   string
+		extra    string
+		expected bool
+	}{
+		{marker: `python_version < "3.8"`, expected: false},
+		{marker: `python_version >= "3.10"`, expected: true},
+		{marker: `python_full_version ~= "3.11.0"`, expected: true},
+		{marker: `python_version == "3.*"`, expected: true},
+		{marker: `sys_platform == "win32" or os_name == "posix"`, expected: true},
+		{marker: `sys_platform != "linux" and python_version > "3"`, expected: false},
+		{marker: `"linux" in sys_platform`, expected: true},
+		{marker: `platform_system not in "Windows Darwin"`, expected: true},
+		{marker: `extra == "socks"`, expected: false},
+		{marker: `extra == 'Socks'`, extra: "socks", expected: true},
+		{marker: `(implementation_name == 'pypy') and extra == 'socks'`, extra: "socks", expected: false},
+	}
+	for _, tc := range testCases {
+		got, err := env.withExtra(tc.extra).evaluate(tc.marker)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", tc.marker, err)
+		} else if got != tc.expected {
+			t.Errorf("%s with extra %q: expected %t, got %t", tc.marker, tc.extra, tc.expected, got)
+		}
+	}
+
+	for _, marker := range []string{`python_version <`, `foo == "bar"`, `(os_name == "posix"`, `os_name == "posix`} {
+		if _, err := env.evaluate(marker); err == nil {
+			t.Errorf("%s: expected an error", marker)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// markerEnv holds the values of the PEP 508 environment marker variables of the Python the
+// modules are built for.
+type markerEnv map[string]string
+
+// hostMarkerEnv returns the marker environment of the host Python used by Soong.
+func hostMarkerEnv(pythonVersion string) markerEnv {
+	fullVersion := pythonVersion
+	if strings.Count(fullVersion, ".") < 2 {
+		fullVersion += ".0"
+	}
+	return markerEnv{
+		"python_version":                 pythonVersion,
+		"python_full_version":            fullVersion,
+		"implementation_version":         fullVersion,
+		"implementation_name":            "cpython",
+		"platform_python_implementation": "CPython",
+		"os_name":                        "posix",
+		"sys_platform":                   "linux",
+		"platform_system":                "Linux",
+		"platform_machine":               "x86_64",
+		"platform_release":               "",
+		"platform_version":               "",
+		"extra":                          "",
+	}
+}
+
+// withExtra returns a copy of the environment for evaluating the requirements of an extra.
+func (env markerEnv) withExtra(extra string) markerEnv {
+	ret := make(markerEnv, len(env))
+	for k, v := range env {
+		ret[k] = v
+	}
+	ret["extra"] = extra
+	return ret
+}
+
+var versionMarkers = map[string]bool{
+	"python_version":         true,
+	"python_full_version":    true,
+	"implementation_version": true,
+}
+
+// evaluate returns whether a marker such as `python_version < "3.8" and sys_platform == "win32"`
+// applies to the environment.
+func (env markerEnv) evaluate(marker string) (bool, error) {
+	tokens, err := tokenizeMarker(marker)
+	if err != nil {
+		return false, fmt.Errorf("marker %q: %w", marker, err)
+	}
+	p := &markerParser{env: env, tokens: tokens}
+	ret, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].value)
+	}
+	if err != nil {
+		return false, fmt.Errorf("marker %q: %w", marker, err)
+	}
+	return ret, nil
+}
+
+type markerToken struct {
+	value  string
+	quoted bool
+}
+
+func tokenizeMarker(s string) ([]markerToken, error) {
+	var tokens []markerToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, markerToken{value: string(c)})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, markerToken{value: s[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		case strings.IndexByte("=!<>~", c) >= 0:
+			j := i + 1
+			for j < len(s) && strings.IndexByte("=!<>~", s[j]) >= 0 {
+				j++
+			}
+			tokens = append(tokens, markerToken{value: s[i:j]})
+			i = j
+		default:
+			j := i
+			for j < len(s) && strings.IndexByte(" \t()\"'=!<>~", s[j]) < 0 {
+				j++
+			}
+			tokens = append(tokens, markerToken{value: s[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type markerParser struct {
+	env    markerEnv
+	tokens []markerToken
+	pos    int
+}
+
+func (p *markerParser) peek() (markerToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return markerToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *markerParser) next() (markerToken, error) {
+	t, ok := p.peek()
+	if !ok {
+		return t, fmt.Errorf("unexpected end of marker")
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *markerParser) accept(keyword string) bool {
+	if t, ok := p.peek(); ok && !t.quoted && t.value == keyword {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *markerParser) parseOr() (bool, error) {
+	ret, err := p.parseAnd()
+	for err == nil && p.accept("or") {
+		var rhs bool
+		rhs, err = p.parseAnd()
+		ret = ret || rhs
+	}
+	return ret, err
+}
+
+func (p *markerParser) parseAnd() (bool, error) {
+	ret, err := p.parseExpr()
+	for err == nil && p.accept("and") {
+		var rhs bool
+		rhs, err = p.parseExpr()
+		ret = ret && rhs
+	}
+	return ret, err
+}
+
+func (p *markerParser) parseExpr() (bool, error) {
+	if p.accept("(") {
+		ret, err := p.parseOr()
+		if err != nil {
+			return false, err
+		}
+		if !p.accept(")") {
+			return false, fmt.Errorf("missing )")
+		}
+		return ret, nil
+	}
+
+	lhs, err := p.next()
+	if err != nil {
+		return false, err
+	}
+	op, err := p.next()
+	if err != nil {
+		return false, err
+	}
+	if !op.quoted && op.value == "not" {
+		if !p.accept("in") {
+			return false, fmt.Errorf("expected in after not")
+		}
+		op.value = "not in"
+	}
+	rhs, err := p.next()
+	if err != nil {
+		return false, err
+	}
+
+	variable := ""
+	l, r := lhs.value, rhs.value
+	if !lhs.quoted {
+		if _, ok := p.env[l]; !ok {
+			return false, fmt.Errorf("unknown variable %q", l)
+		}
+		variable, l = l, p.env[l]
+	}
+	if !rhs.quoted {
+		if _, ok := p.env[r]; !ok {
+			return false, fmt.Errorf("unknown variable %q", r)
+		}
+		variable, r = r, p.env[r]
+	}
+	if variable == "extra" {
+		l, r = NormalizeName(l), NormalizeName(r)
+	}
+
+	switch op.value {
+	case "in":
+		return strings.Contains(r, l), nil
+	case "not in":
+		return !strings.Contains(r, l), nil
+	}
+	if versionMarkers[variable] {
+		return compareVersions(l, op.value, r)
+	}
+	switch op.value {
+	case "==", "===":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	}
+	return false, fmt.Errorf("unsupported operator %q for %s", op.value, variable)
+}
+
+// compareVersions compares release versions like 3.8 or 3.11.4 component by component.
+func compareVersions(a, op, b string) (bool, error) {
+	if op == "~=" {
+		// ~=3.8 means >=3.8 and ==3.*
+		parts := strings.Split(b, ".")
+		if len(parts) < 2 {
+			return false, fmt.Errorf("invalid version %q for ~=", b)
+		}
+		prefix := strings.Join(parts[:len(parts)-1], ".")
+		return versionCmp(a, b) >= 0 && (a == prefix || strings.HasPrefix(a, prefix+".")), nil
+	}
+	if strings.HasSuffix(b, ".*") && (op == "==" || op == "!=") {
+		prefix := strings.TrimSuffix(b, ".*")
+		matches := a == prefix || strings.HasPrefix(a, prefix+".")
+		return matches == (op == "=="), nil
+	}
+	c := versionCmp(a, b)
+	switch op {
+	case "==", "===":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	}
+	return false, fmt.Errorf("unsupported operator %q", op)
+}
+
+func versionCmp(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for len(as) < len(bs) {
+		as = append(as, "0")
+	}
+	for len(bs) < len(as) {
+		bs = append(bs, "0")
+	}
+	for i := range as {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		if aErr != nil || bErr != nil {
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if an != bn {
+			if an < bn {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
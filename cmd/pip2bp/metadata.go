@@ -0,0 +1,219 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Metadata holds the headers of the core metadata of a distribution, the METADATA file of a wheel
+// or the PKG-INFO file of an sdist.
+type Metadata struct {
+	headers map[string][]string
+}
+
+// ParseMetadata parses the email header format of the core metadata. The description that may
+// follow the headers is ignored.
+func ParseMetadata(data []byte) *Metadata {
+	m := &Metadata{headers: make(map[string][]string)}
+	var key string
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if line == "" {
+			break
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			// A continuation of the previous header, e.g. of a multi-line License.
+			if values := m.headers[key]; key != "" && len(values) > 0 {
+				values[len(values)-1] += "\n" + strings.TrimSpace(line)
+			}
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(k))
+		m.headers[key] = append(m.headers[key], strings.TrimSpace(v))
+	}
+	return m
+}
+
+// Get returns the first value of a header, or "".
+func (m *Metadata) Get(key string) string {
+	if values := m.headers[strings.ToLower(key)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// GetAll returns all the values of a header that can be repeated, like Requires-Dist.
+func (m *Metadata) GetAll(key string) []string {
+	return m.headers[strings.ToLower(key)]
+}
+
+// Dist is an unpacked wheel or sdist.
+type Dist struct {
+	// Dir is the directory of the distribution, relative to the directory of all distributions.
+	Dir string
+	// Name is the normalized name of the distribution.
+	Name    string
+	Version string
+	Wheel   bool
+
+	// MetadataDir is the .dist-info directory of a wheel or the .egg-info directory of an sdist,
+	// relative to Dir, or "" if the sdist doesn't have one.
+	MetadataDir string
+	Metadata    *Metadata
+
+	// TopLevel lists the top level packages and modules of the distribution from the
+	// top_level.txt file of the metadata directory, if there is one.
+	TopLevel []string
+	Requires []Requirement
+}
+
+// FindDists returns the distributions unpacked in the subdirectories of fsys.
+func FindDists(fsys fs.FS) ([]*Dist, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	var dists []*Dist
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		d, err := readDist(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		if d != nil {
+			dists = append(dists, d)
+		}
+	}
+	return dists, nil
+}
+
+// readDist reads the metadata of the distribution unpacked in dir, or returns nil if dir doesn't
+// contain a distribution.
+func readDist(fsys fs.FS, dir string) (*Dist, error) {
+	d := &Dist{Dir: dir}
+	var metadataFile string
+
+	if matches, _ := fs.Glob(fsys, path.Join(dir, "*.dist-info", "METADATA")); len(matches) > 0 {
+		d.Wheel = true
+		metadataFile = matches[0]
+		d.MetadataDir = path.Base(path.Dir(metadataFile))
+	} else {
+		// setuptools also writes the metadata of an sdist to an .egg-info directory next to the
+		// packages, with the top_level.txt and requires.txt files pip2bp needs.
+		for _, pattern := range []string{"*.egg-info", "src/*.egg-info", "lib/*.egg-info"} {
+			if matches, _ := fs.Glob(fsys, path.Join(dir, pattern, "PKG-INFO")); len(matches) > 0 {
+				metadataFile = matches[0]
+				d.MetadataDir = strings.TrimPrefix(path.Dir(metadataFile), dir+"/")
+				break
+			}
+		}
+		if _, err := fs.Stat(fsys, path.Join(dir, "PKG-INFO")); err == nil {
+			metadataFile = path.Join(dir, "PKG-INFO")
+		}
+	}
+	if metadataFile == "" {
+		return nil, nil
+	}
+
+	data, err := fs.ReadFile(fsys, metadataFile)
+	if err != nil {
+		return nil, err
+	}
+	d.Metadata = ParseMetadata(data)
+	if d.Metadata.Get("Name") == "" || d.Metadata.Get("Version") == "" {
+		return nil, fmt.Errorf("%s: missing Name or Version", metadataFile)
+	}
+	d.Name = NormalizeName(d.Metadata.Get("Name"))
+	d.Version = d.Metadata.Get("Version")
+
+	if d.MetadataDir != "" {
+		if data, err := fs.ReadFile(fsys, path.Join(dir, d.MetadataDir, "top_level.txt")); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					d.TopLevel = append(d.TopLevel, line)
+				}
+			}
+			sort.Strings(d.TopLevel)
+		}
+	}
+
+	for _, s := range d.Metadata.GetAll("Requires-Dist") {
+		req, _, err := ParseRequirement(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", metadataFile, err)
+		}
+		d.Requires = append(d.Requires, req)
+	}
+	// Sdists with older metadata only list their requirements in the requires.txt file of the
+	// .egg-info directory.
+	if len(d.Requires) == 0 && !d.Wheel && d.MetadataDir != "" {
+		requiresFile := path.Join(dir, d.MetadataDir, "requires.txt")
+		if data, err := fs.ReadFile(fsys, requiresFile); err == nil {
+			d.Requires, err = parseRequiresTxt(string(data))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", requiresFile, err)
+			}
+		}
+	}
+	return d, nil
+}
+
+// parseRequiresTxt parses the requires.txt file of an .egg-info directory, where the requirements
+// of an extra or with a marker are listed in sections like `[extra:python_version < "3.8"]`.
+func parseRequiresTxt(data string) ([]Requirement, error) {
+	var reqs []Requirement
+	var sectionMarker string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			extra, marker, _ := strings.Cut(line[1:len(line)-1], ":")
+			var conditions []string
+			if extra = strings.TrimSpace(extra); extra != "" {
+				conditions = append(conditions, fmt.Sprintf("extra == %q", extra))
+			}
+			if marker = strings.TrimSpace(marker); marker != "" {
+				conditions = append(conditions, "("+marker+")")
+			}
+			sectionMarker = strings.Join(conditions, " and ")
+			continue
+		}
+		req, _, err := ParseRequirement(line)
+		if err != nil {
+			return nil, err
+		}
+		if sectionMarker != "" {
+			if req.Marker != "" {
+				req.Marker = sectionMarker + " and (" + req.Marker + ")"
+			} else {
+				req.Marker = sectionMarker
+			}
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
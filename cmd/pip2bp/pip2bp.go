@@ -0,0 +1,174 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/bpfix/bpfix"
+)
+
+func rerunForRegen(filename string) error {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewBuffer(buf))
+
+	// Skip the first line in the file
+	for i := 0; i < 2; i++ {
+		if !scanner.Scan() {
+			if scanner.Err() != nil {
+				return scanner.Err()
+			} else {
+				return fmt.Errorf("unexpected EOF")
+			}
+		}
+	}
+
+	// Extract the old args from the file
+	line := scanner.Text()
+	if strings.HasPrefix(line, "// pip2bp") {
+		line = strings.TrimPrefix(line, "// pip2bp")
+	} else {
+		return fmt.Errorf("unexpected second line: %q", line)
+	}
+	args := strings.Fields(line)
+
+	// Append all current command line args except -regen <file> to the ones from the file
+	for i := 1; i < len(os.Args); i++ {
+		if os.Args[i] == "-regen" || os.Args[i] == "--regen" {
+			i++
+		} else {
+			args = append(args, os.Args[i])
+		}
+	}
+
+	// Re-exec pip2bp with the new arguments, which rewrites all the files of the import.
+	cmd := exec.Command("/bin/sh", "-c", os.Args[0]+" "+strings.Join(args, " "))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", cmd.String(), err)
+	}
+	return nil
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `pip2bp, a tool to create Android.bp files for third-party Python distributions
+
+The tool reads a requirements file pinning every distribution with ==, such as the output of
+pip-compile, and creates the python_library_host and license modules of each distribution unpacked
+in a subdirectory of <dists dir>. It writes the Android.bp file of a distribution to its directory,
+or to its src or lib directory for sdists whose packages are there.
+
+Usage: %s [-r <requirements>] [-python_version <version>] [-regen <file>] <dists dir>
+
+  -r <requirements>
+     The requirements file, defaults to requirements.txt.
+  -python_version <version>
+     The version of the host Python, used to evaluate the environment markers of the
+     requirements. Defaults to 3.11.
+  -regen <file>
+     Read arguments from <file>, one of the generated Android.bp files, and regenerate all the
+     files.
+
+Requirements that are not pinned and native extensions are reported on stderr, as they need manual
+handling.
+
+`, os.Args[0])
+	}
+
+	var regen, requirementsFile, pythonVersion string
+
+	flag.StringVar(&requirementsFile, "r", "requirements.txt", "Requirements file")
+	flag.StringVar(&pythonVersion, "python_version", "3.11", "Version of the host Python")
+	flag.StringVar(&regen, "regen", "", "Rewrite specified file")
+	flag.Parse()
+
+	if regen != "" {
+		err := rerunForRegen(regen)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	distsDir := flag.Arg(0)
+
+	env := hostMarkerEnv(pythonVersion)
+	reqs, err := ReadRequirements(requirementsFile, os.ReadFile, env)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fsys := os.DirFS(distsDir)
+	dists, err := FindDists(fsys)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	g, err := newGenerator(fsys, dists, reqs, env)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	files, err := g.generate()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error generating modules:", err)
+		os.Exit(1)
+	}
+	for _, w := range g.warnings {
+		fmt.Fprintln(os.Stderr, "warning:", w)
+	}
+
+	for _, f := range files {
+		buf := &bytes.Buffer{}
+		fmt.Fprintln(buf, "// Automatically generated with:")
+		fmt.Fprintln(buf, "// pip2bp", strings.Join(proptools.ShellEscapeList(os.Args[1:]), " "))
+		for _, m := range f.modules {
+			m.Write(buf)
+		}
+
+		out, err := bpfix.Reformat(buf.String())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error formatting output", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(filepath.Join(distsDir, f.path), []byte(out), 0666); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
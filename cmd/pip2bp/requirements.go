@@ -0,0 +1,156 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Requirement is a dependency on a distribution, either a line of a requirements file or a
+// Requires-Dist entry of the metadata of a distribution.
+type Requirement struct {
+	// Name is the normalized name of the distribution.
+	Name string
+	// Version is the pinned version, only set for the lines of a requirements file.
+	Version string
+	// Extras are the normalized names of the extras of the distribution that are needed.
+	Extras []string
+	// Marker is the environment marker deciding whether the requirement applies, e.g.
+	// `python_version < "3.8"`.
+	Marker string
+}
+
+var nameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// NormalizeName normalizes the name of a distribution or an extra as in PEP 503, so that
+// "Foo.Bar", "foo_bar" and "foo-bar" are the same distribution.
+func NormalizeName(name string) string {
+	return strings.ToLower(nameSeparators.ReplaceAllString(name, "-"))
+}
+
+var requirementName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*`)
+
+// ParseRequirement parses a PEP 508 requirement such as `foo[bar]>=1.0; python_version < "3.8"`.
+// The version specifier is returned as is, without the parentheses of the older
+// `foo (>=1.0)` form.
+func ParseRequirement(s string) (req Requirement, specifier string, err error) {
+	s, req.Marker, _ = strings.Cut(s, ";")
+	req.Marker = strings.TrimSpace(req.Marker)
+	s = strings.TrimSpace(s)
+
+	name := requirementName.FindString(s)
+	if name == "" {
+		return req, "", fmt.Errorf("invalid requirement %q", s)
+	}
+	req.Name = NormalizeName(name)
+	s = strings.TrimSpace(s[len(name):])
+
+	if strings.HasPrefix(s, "[") {
+		end := strings.Index(s, "]")
+		if end < 0 {
+			return req, "", fmt.Errorf("invalid extras in requirement %q", s)
+		}
+		for _, extra := range strings.Split(s[1:end], ",") {
+			if extra = strings.TrimSpace(extra); extra != "" {
+				req.Extras = append(req.Extras, NormalizeName(extra))
+			}
+		}
+		sort.Strings(req.Extras)
+		s = strings.TrimSpace(s[end+1:])
+	}
+	if strings.HasPrefix(s, "@") {
+		return req, "", fmt.Errorf("direct URL references are not supported: %q", s)
+	}
+	specifier = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(s, "("), ")"))
+	return req, specifier, nil
+}
+
+// ReadRequirements reads a requirements file pinning every distribution to a single version with
+// `==`, such as the output of pip-compile or `pip freeze`. Files included with -r are read
+// relative to the including file. Requirements whose marker doesn't apply to env are skipped.
+func ReadRequirements(path string, readFile func(string) ([]byte, error), env markerEnv) ([]Requirement, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var reqs []Requirement
+	// Backslashes continue a line, which pip-compile uses for the --hash options.
+	lines := strings.Split(strings.ReplaceAll(string(data), "\\\n", " "), "\n")
+	for i, line := range lines {
+		if comment := strings.Index(line, "#"); comment >= 0 &&
+			(comment == 0 || line[comment-1] == ' ' || line[comment-1] == '\t') {
+			line = line[:comment]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		errorf := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%s:%d: %s", path, i+1, fmt.Sprintf(format, args...))
+		}
+
+		if strings.HasPrefix(line, "-") {
+			option, value, _ := strings.Cut(strings.Replace(line, "=", " ", 1), " ")
+			value = strings.TrimSpace(value)
+			switch option {
+			case "-r", "--requirement":
+				included, err := ReadRequirements(filepath.Join(filepath.Dir(path), value), readFile, env)
+				if err != nil {
+					return nil, err
+				}
+				reqs = append(reqs, included...)
+			case "-e", "--editable":
+				return nil, errorf("editable requirements are not supported")
+			}
+			// Other options, like --index-url or constraint files, don't change what is imported.
+			continue
+		}
+
+		// Options of a requirement, e.g. --hash, follow the requirement.
+		if option := strings.Index(line, " -"); option >= 0 {
+			line = strings.TrimSpace(line[:option])
+		}
+		req, specifier, err := ParseRequirement(line)
+		if err != nil {
+			return nil, errorf("%s", err)
+		}
+		version, ok := strings.CutPrefix(specifier, "===")
+		if !ok {
+			version, ok = strings.CutPrefix(specifier, "==")
+		}
+		version = strings.TrimSpace(version)
+		if !ok || version == "" || strings.ContainsAny(version, ",*<>!=~") {
+			return nil, errorf("%s is not pinned to a single version with ==", req.Name)
+		}
+		req.Version = version
+
+		if req.Marker != "" {
+			applies, err := env.evaluate(req.Marker)
+			if err != nil {
+				return nil, errorf("%s", err)
+			}
+			if !applies {
+				continue
+			}
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
@@ -0,0 +1,267 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// change is a difference between the previous and the new snapshot.
+type change struct {
+	// Identifies the change in the acknowledged changes file, e.g. "member-removed libfoo".
+	key string
+	// Whether the change can break the modules that use the previous snapshot.
+	incompatible bool
+	details      []string
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// difference returns the elements of a that are not in b, in the order of a.
+func difference(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, s := range b {
+		inB[s] = true
+	}
+	var diff []string
+	for _, s := range a {
+		if !inB[s] {
+			diff = append(diff, s)
+		}
+	}
+	return diff
+}
+
+// compareSnapshots returns the changes between the previous and the new snapshot, sorted by key.
+func compareSnapshots(prev, next *snapshot) []change {
+	var changes []change
+	changes = append(changes, compareMembers(prev, next)...)
+	changes = append(changes, compareFiles(prev, next)...)
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].key < changes[j].key })
+	return changes
+}
+
+func compareMembers(prev, next *snapshot) []change {
+	var changes []change
+	for _, name := range sortedKeys(prev.members) {
+		prevMember := prev.members[name]
+		nextMember, ok := next.members[name]
+		if !ok {
+			changes = append(changes, change{
+				key:          "member-removed " + name,
+				incompatible: true,
+			})
+			continue
+		}
+		if prevMember.moduleType != nextMember.moduleType {
+			changes = append(changes, change{
+				key:          "member-type-changed " + name,
+				incompatible: true,
+				details:      []string{fmt.Sprintf("was %s, now %s", prevMember.moduleType, nextMember.moduleType)},
+			})
+		}
+		changes = append(changes, compareProperties(name, prevMember, nextMember)...)
+	}
+	for _, name := range sortedKeys(next.members) {
+		if _, ok := prev.members[name]; !ok {
+			changes = append(changes, change{key: "member-added " + name})
+		}
+	}
+	return changes
+}
+
+// compareProperties compares the properties of a member. Removing a property, changing a scalar
+// value or removing values from a list is incompatible, as users may rely on the previous values,
+// e.g. on an exported include directory or on an apex_available entry.
+func compareProperties(name string, prev, next *member) []change {
+	var changes []change
+	for _, prop := range sortedKeys(prev.properties) {
+		prevValue := prev.properties[prop]
+		key := "property-changed " + name + " " + prop
+		nextValue, ok := next.properties[prop]
+		if !ok {
+			changes = append(changes, change{
+				key:          key,
+				incompatible: true,
+				details:      []string{"removed, was " + prevValue.String()},
+			})
+			continue
+		}
+		if prevValue.list && nextValue.list {
+			removed := difference(prevValue.values, nextValue.values)
+			added := difference(nextValue.values, prevValue.values)
+			if len(removed) == 0 && len(added) == 0 {
+				continue
+			}
+			c := change{key: key, incompatible: len(removed) > 0}
+			if len(removed) > 0 {
+				c.details = append(c.details, "removed "+strings.Join(removed, ", "))
+			}
+			if len(added) > 0 {
+				c.details = append(c.details, "added "+strings.Join(added, ", "))
+			}
+			changes = append(changes, c)
+		} else if prevValue.String() != nextValue.String() {
+			changes = append(changes, change{
+				key:          key,
+				incompatible: true,
+				details:      []string{fmt.Sprintf("was %s, now %s", prevValue, nextValue)},
+			})
+		}
+	}
+	for _, prop := range sortedKeys(next.properties) {
+		if _, ok := prev.properties[prop]; !ok {
+			changes = append(changes, change{
+				key:     "property-changed " + name + " " + prop,
+				details: []string{"added " + next.properties[prop].String()},
+			})
+		}
+	}
+	return changes
+}
+
+func compareFiles(prev, next *snapshot) []change {
+	var changes []change
+	for _, name := range sortedKeys(prev.files) {
+		prevFile := prev.files[name]
+		nextFile, ok := next.files[name]
+		switch {
+		case isHeader(name) && !ok:
+			changes = append(changes, change{key: "header-removed " + name, incompatible: true})
+		case isHeader(name) && prevFile.crc32 != nextFile.crc32:
+			// The effect of changes to headers on the ABI is checked with the ABI dumps.
+			changes = append(changes, change{key: "header-changed " + name})
+		case isApiFile(name) && !ok:
+			changes = append(changes, change{key: "api-file-removed " + name, incompatible: true})
+		case isApiFile(name) && prevFile.crc32 != nextFile.crc32:
+			changes = append(changes, compareApiFiles(name, prevFile.contents, nextFile.contents))
+		case isAbiDump(name) && !ok:
+			changes = append(changes, change{key: "abi-dump-removed " + name, incompatible: true})
+		case isAbiDump(name) && prevFile.crc32 != nextFile.crc32:
+			changes = append(changes, compareAbiDumps(name, prevFile.contents, nextFile.contents))
+		}
+	}
+	for _, name := range sortedKeys(next.files) {
+		if _, ok := prev.files[name]; ok {
+			continue
+		}
+		switch {
+		case isHeader(name):
+			changes = append(changes, change{key: "header-added " + name})
+		case isApiFile(name):
+			changes = append(changes, change{key: "api-file-added " + name})
+		case isAbiDump(name):
+			changes = append(changes, change{key: "abi-dump-added " + name})
+		}
+	}
+	return changes
+}
+
+// apiLines returns the lines of an API signature file, each prefixed with the classes and package
+// that contain it so that identical lines in different classes are distinct.
+func apiLines(contents []byte) []string {
+	var lines, scopes []string
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "//"):
+		case line == "}":
+			if len(scopes) > 0 {
+				scopes = scopes[:len(scopes)-1]
+			}
+		case strings.HasSuffix(line, "{"):
+			scope := strings.TrimSpace(strings.TrimSuffix(line, "{"))
+			scopes = append(scopes, scope)
+			lines = append(lines, strings.Join(scopes, " > "))
+		default:
+			lines = append(lines, strings.Join(append(scopes, line), " > "))
+		}
+	}
+	return lines
+}
+
+// compareApiFiles compares two versions of an API signature file. Removing or changing an API is
+// incompatible, adding one is not.
+func compareApiFiles(name string, prev, next []byte) change {
+	prevLines, nextLines := apiLines(prev), apiLines(next)
+	removed := difference(prevLines, nextLines)
+	added := difference(nextLines, prevLines)
+	c := change{key: "api-file-changed " + name, incompatible: len(removed) > 0}
+	for _, line := range removed {
+		c.details = append(c.details, "- "+line)
+	}
+	for _, line := range added {
+		c.details = append(c.details, "+ "+line)
+	}
+	return c
+}
+
+type abiDump struct {
+	ElfFunctions []struct {
+		Name string `json:"name"`
+	} `json:"elf_functions"`
+	ElfObjects []struct {
+		Name string `json:"name"`
+	} `json:"elf_objects"`
+}
+
+func (d *abiDump) symbols() []string {
+	var symbols []string
+	for _, f := range d.ElfFunctions {
+		symbols = append(symbols, f.Name)
+	}
+	for _, o := range d.ElfObjects {
+		symbols = append(symbols, o.Name)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// compareAbiDumps compares two versions of an ABI dump. A changed ABI dump is always treated as
+// incompatible, as telling whether a change of a type breaks the ABI is beyond this tool. The
+// removed and added symbols are listed to help review the change.
+func compareAbiDumps(name string, prev, next []byte) change {
+	c := change{key: "abi-dump-changed " + name, incompatible: true}
+	var prevDump, nextDump abiDump
+	if err := json.Unmarshal(prev, &prevDump); err != nil {
+		c.details = append(c.details, "cannot parse the previous ABI dump: "+err.Error())
+		return c
+	}
+	if err := json.Unmarshal(next, &nextDump); err != nil {
+		c.details = append(c.details, "cannot parse the new ABI dump: "+err.Error())
+		return c
+	}
+	prevSymbols, nextSymbols := prevDump.symbols(), nextDump.symbols()
+	if removed := difference(prevSymbols, nextSymbols); len(removed) > 0 {
+		c.details = append(c.details, "removed symbols "+strings.Join(removed, ", "))
+	}
+	if added := difference(nextSymbols, prevSymbols); len(added) > 0 {
+		c.details = append(c.details, "added symbols "+strings.Join(added, ", "))
+	}
+	return c
+}
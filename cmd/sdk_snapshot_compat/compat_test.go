@@ -0,0 +1,250 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func buildSnapshot(t *testing.T, files map[string]string) *snapshot {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range sortedKeys(files) {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := readSnapshot(bytes.NewReader(buf.Bytes()), int64(buf.Len()), "snapshot.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+const previousBp = `
+package {
+    default_applicable_licenses: ["Android-Apache-2.0"],
+}
+
+cc_prebuilt_library_shared {
+    name: "libfoo",
+    prefer: false,
+    visibility: ["//visibility:public"],
+    apex_available: ["com.android.foo", "com.android.bar"],
+    min_sdk_version: "29",
+    arch: {
+        arm64: {
+            srcs: ["arm64/lib/libfoo.so"],
+            export_include_dirs: ["arm64/include/libfoo"],
+        },
+    },
+}
+
+java_sdk_library_import {
+    name: "foo-lib",
+    prefer: false,
+    public: {
+        jars: ["sdk_library/public/foo-lib-stubs.jar"],
+        current_api: "sdk_library/public/foo-lib.txt",
+    },
+}
+
+java_import {
+    name: "old-lib",
+    jars: ["java/old-lib.jar"],
+}
+
+apex_contributions_defaults {
+    name: "mysdk.contributions",
+    contents: ["prebuilt_libfoo", "prebuilt_foo-lib", "prebuilt_old-lib"],
+}
+`
+
+const currentBp = `
+package {
+    default_applicable_licenses: ["Android-Apache-2.0"],
+}
+
+cc_prebuilt_library_shared {
+    name: "libfoo",
+    prefer: false,
+    visibility: ["//visibility:public"],
+    apex_available: ["com.android.foo", "com.android.baz"],
+    min_sdk_version: "30",
+    stl: "none",
+    arch: {
+        arm64: {
+            srcs: ["arm64/lib/libfoo.so"],
+            export_include_dirs: ["arm64/include/libfoo"],
+        },
+    },
+}
+
+java_sdk_library_import {
+    name: "foo-lib",
+    prefer: false,
+    public: {
+        jars: ["sdk_library/public/foo-lib-stubs.jar"],
+        current_api: "sdk_library/public/foo-lib.txt",
+    },
+}
+
+java_import {
+    name: "new-lib",
+    jars: ["java/new-lib.jar"],
+}
+
+apex_contributions_defaults {
+    name: "mysdk.contributions",
+    contents: ["prebuilt_libfoo", "prebuilt_foo-lib", "prebuilt_new-lib"],
+}
+`
+
+const previousApi = `// Signature format: 2.0
+package android.foo {
+
+  public class Foo {
+    ctor public Foo();
+    method public void bar();
+    method public void baz();
+  }
+
+  public class Other {
+    method public void bar();
+  }
+
+}
+`
+
+const currentApi = `// Signature format: 2.0
+package android.foo {
+
+  public class Foo {
+    ctor public Foo();
+    method public void bar();
+    method public void qux();
+  }
+
+  public class Other {
+    method public void bar();
+  }
+
+}
+`
+
+func TestCompareSnapshots(t *testing.T) {
+	prev := buildSnapshot(t, map[string]string{
+		"Android.bp":                             previousBp,
+		"arm64/include/libfoo/foo.h":             "int foo();",
+		"arm64/include/libfoo/removed.h":         "int removed();",
+		"arm64/include/libfoo/same.h":            "int same();",
+		"arm64/lib/libfoo.so":                    "elf",
+		"arm64/lib/libfoo.so.lsdump":             `{"elf_functions": [{"name": "foo"}, {"name": "removed"}]}`,
+		"sdk_library/public/foo-lib.txt":         previousApi,
+		"sdk_library/public/foo-lib-removed.txt": "// Signature format: 2.0\n",
+		"snapshot-creation-build-number.txt":     "1",
+	})
+	next := buildSnapshot(t, map[string]string{
+		"Android.bp":                             currentBp,
+		"arm64/include/libfoo/foo.h":             "int foo(int);",
+		"arm64/include/libfoo/added.h":           "int added();",
+		"arm64/include/libfoo/same.h":            "int same();",
+		"arm64/lib/libfoo.so":                    "elf2",
+		"arm64/lib/libfoo.so.lsdump":             `{"elf_functions": [{"name": "foo"}, {"name": "added"}]}`,
+		"sdk_library/public/foo-lib.txt":         currentApi,
+		"sdk_library/public/foo-lib-removed.txt": "// Signature format: 2.0\n",
+		"snapshot-creation-build-number.txt":     "2",
+	})
+
+	expected := []change{
+		{
+			key:          "abi-dump-changed arm64/lib/libfoo.so.lsdump",
+			incompatible: true,
+			details:      []string{"removed symbols removed", "added symbols added"},
+		},
+		{
+			key:          "api-file-changed sdk_library/public/foo-lib.txt",
+			incompatible: true,
+			details: []string{
+				"- package android.foo > public class Foo > method public void baz();",
+				"+ package android.foo > public class Foo > method public void qux();",
+			},
+		},
+		{key: "header-added arm64/include/libfoo/added.h"},
+		{key: "header-changed arm64/include/libfoo/foo.h"},
+		{key: "header-removed arm64/include/libfoo/removed.h", incompatible: true},
+		{key: "member-added new-lib"},
+		{key: "member-removed old-lib", incompatible: true},
+		{
+			key:          "property-changed libfoo apex_available",
+			incompatible: true,
+			details:      []string{`removed "com.android.bar"`, `added "com.android.baz"`},
+		},
+		{
+			key:          "property-changed libfoo min_sdk_version",
+			incompatible: true,
+			details:      []string{`was "29", now "30"`},
+		},
+		{
+			key:     "property-changed libfoo stl",
+			details: []string{`added "none"`},
+		},
+	}
+	changes := compareSnapshots(prev, next)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes:\n%#v\ngot:\n%#v", expected, changes)
+	}
+
+	if changes := compareSnapshots(prev, prev); len(changes) != 0 {
+		t.Errorf("expected no changes between identical snapshots, got %#v", changes)
+	}
+}
+
+func TestCompareProperties(t *testing.T) {
+	prev := &member{properties: map[string]property{
+		"arch.arm64.export_include_dirs": {list: true, values: []string{`"a"`, `"b"`}},
+		"sdk_version":                    {values: []string{`"current"`}},
+		"compile_multilib":               {values: []string{`"both"`}},
+	}}
+	next := &member{properties: map[string]property{
+		"arch.arm64.export_include_dirs": {list: true, values: []string{`"b"`, `"a"`, `"c"`}},
+		"sdk_version":                    {values: []string{`"current"`}},
+	}}
+	expected := []change{
+		{
+			key:     "property-changed libfoo arch.arm64.export_include_dirs",
+			details: []string{`added "c"`},
+		},
+		{
+			key:          "property-changed libfoo compile_multilib",
+			incompatible: true,
+			details:      []string{`removed, was "both"`},
+		},
+	}
+	if changes := compareProperties("libfoo", prev, next); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes:\n%#v\ngot:\n%#v", expected, changes)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// sdk_snapshot_compat compares a new sdk snapshot zip with the previously released one and reports
+// the changes that affect the modules that use the snapshot: added and removed members, changed
+// properties in the generated Android.bp, changed exported headers, changed stub API files and
+// changed ABI dumps. It fails if there are incompatible changes that are not listed in the
+// acknowledged changes file.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	previousSnapshot = flag.String("previous", "", "the previously released snapshot zip")
+	currentSnapshot  = flag.String("current", "", "the new snapshot zip")
+	acknowledged     = flag.String("acknowledged", "", "file listing the keys of the incompatible changes that are intended")
+	reportFile       = flag.String("report", "", "file to write the report of all the changes to")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s --previous <zip> --current <zip> [--acknowledged <file>] [--report <file>]\n", os.Args[0])
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func openSnapshot(name string) (*snapshot, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return readSnapshot(f, info.Size(), name)
+}
+
+// readAcknowledged reads the keys of the acknowledged changes, one per line. Empty lines and lines
+// starting with # are ignored.
+func readAcknowledged(r io.Reader) (map[string]bool, error) {
+	keys := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys[strings.Join(strings.Fields(line), " ")] = true
+	}
+	return keys, scanner.Err()
+}
+
+// writeReport writes the changes and returns the incompatible changes that are not acknowledged.
+func writeReport(w io.Writer, changes []change, acknowledged map[string]bool) []change {
+	var unacknowledged []change
+	used := make(map[string]bool)
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No changes.")
+	}
+	for _, c := range changes {
+		status := "compatible"
+		if c.incompatible {
+			if acknowledged[c.key] {
+				status = "incompatible, acknowledged"
+				used[c.key] = true
+			} else {
+				status = "INCOMPATIBLE"
+				unacknowledged = append(unacknowledged, c)
+			}
+		}
+		fmt.Fprintf(w, "%s (%s)\n", c.key, status)
+		for _, d := range c.details {
+			fmt.Fprintf(w, "    %s\n", d)
+		}
+	}
+	for _, key := range sortedKeys(acknowledged) {
+		if !used[key] {
+			fmt.Fprintf(w, "warning: acknowledged change %q did not happen\n", key)
+		}
+	}
+	return unacknowledged
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if *previousSnapshot == "" || *currentSnapshot == "" || flag.NArg() != 0 {
+		usage()
+	}
+
+	prev, err := openSnapshot(*previousSnapshot)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	next, err := openSnapshot(*currentSnapshot)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	acknowledgedKeys := map[string]bool{}
+	if *acknowledged != "" {
+		f, err := os.Open(*acknowledged)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		acknowledgedKeys, err = readAcknowledged(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: reading %s: %s\n", *acknowledged, err)
+			os.Exit(1)
+		}
+	}
+
+	var report strings.Builder
+	unacknowledged := writeReport(&report, compareSnapshots(prev, next), acknowledgedKeys)
+	if *reportFile != "" {
+		if err := os.WriteFile(*reportFile, []byte(report.String()), 0666); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+	} else {
+		fmt.Print(report.String())
+	}
+
+	if len(unacknowledged) > 0 {
+		fmt.Fprintf(os.Stderr, "%s has incompatible changes from %s:\n", *currentSnapshot, *previousSnapshot)
+		for _, c := range unacknowledged {
+			fmt.Fprintf(os.Stderr, "  %s\n", c.key)
+			for _, d := range c.details {
+				fmt.Fprintf(os.Stderr, "      %s\n", d)
+			}
+		}
+		if *acknowledged != "" {
+			fmt.Fprintf(os.Stderr, "If the changes are intended, add their keys to %s.\n", *acknowledged)
+		} else {
+			fmt.Fprintln(os.Stderr, "If the changes are intended, list their keys in an acknowledged changes file.")
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	acknowledged, err := readAcknowledged(strings.NewReader(`
+# libbar is replaced by libbaz.
+member-removed   libbar
+
+member-removed libqux
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := []change{
+		{key: "member-added libbaz"},
+		{key: "member-removed libbar", incompatible: true},
+		{
+			key:          "property-changed libfoo min_sdk_version",
+			incompatible: true,
+			details:      []string{`was "29", now "30"`},
+		},
+	}
+
+	var report strings.Builder
+	unacknowledged := writeReport(&report, changes, acknowledged)
+	expected := `member-added libbaz (compatible)
+member-removed libbar (incompatible, acknowledged)
+property-changed libfoo min_sdk_version (INCOMPATIBLE)
+    was "29", now "30"
+warning: acknowledged change "member-removed libqux" did not happen
+`
+	if report.String() != expected {
+		t.Errorf("expected report:\n%s\ngot:\n%s", expected, report.String())
+	}
+	if !reflect.DeepEqual(unacknowledged, changes[2:]) {
+		t.Errorf("expected unacknowledged changes %#v, got %#v", changes[2:], unacknowledged)
+	}
+}
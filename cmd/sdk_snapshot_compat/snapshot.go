@@ -0,0 +1,212 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/google/blueprint/parser"
+)
+
+// Module types in the generated Android.bp that are not members of the sdk.
+var nonMemberModuleTypes = map[string]bool{
+	"package":                     true,
+	"apex_contributions_defaults": true,
+}
+
+// property is the value of a property of a module in the generated Android.bp. Scalar values are
+// stored as a single element list.
+type property struct {
+	list   bool
+	values []string
+}
+
+func (p property) String() string {
+	if p.list {
+		return "[" + strings.Join(p.values, ", ") + "]"
+	}
+	return p.values[0]
+}
+
+// member is a prebuilt module in the generated Android.bp of a snapshot.
+type member struct {
+	moduleType string
+	// The properties of the module keyed by their dotted path, e.g. arch.arm64.srcs.
+	properties map[string]property
+}
+
+// snapshotFile is a file in a snapshot zip other than the generated Android.bp.
+type snapshotFile struct {
+	crc32 uint32
+	// The contents of the file, only read for stub API files and ABI dumps.
+	contents []byte
+}
+
+type snapshot struct {
+	members map[string]*member
+	files   map[string]snapshotFile
+}
+
+// isHeader returns whether a file in a snapshot is an exported header of a native library. The
+// headers are copied to include, include_gen and <arch>/include directories.
+func isHeader(name string) bool {
+	for _, dir := range strings.Split(path.Dir(name), "/") {
+		if dir == "include" || dir == "include_gen" {
+			return true
+		}
+	}
+	return false
+}
+
+// isApiFile returns whether a file in a snapshot is an API signature file of a java_sdk_library.
+func isApiFile(name string) bool {
+	return strings.HasPrefix(name, "sdk_library/") && path.Ext(name) == ".txt"
+}
+
+// isAbiDump returns whether a file in a snapshot is an ABI dump of a native library.
+func isAbiDump(name string) bool {
+	return path.Ext(name) == ".lsdump"
+}
+
+func readSnapshot(r io.ReaderAt, size int64, name string) (*snapshot, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	s := &snapshot{
+		members: make(map[string]*member),
+		files:   make(map[string]snapshotFile),
+	}
+	foundBp := false
+	for _, f := range z.File {
+		if f.Mode().IsDir() {
+			continue
+		}
+		switch {
+		case f.Name == "Android.bp":
+			contents, err := readZipFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", name, err)
+			}
+			if err := s.parseBp(name+":Android.bp", contents); err != nil {
+				return nil, err
+			}
+			foundBp = true
+		case isApiFile(f.Name) || isAbiDump(f.Name):
+			contents, err := readZipFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", name, err)
+			}
+			s.files[f.Name] = snapshotFile{crc32: f.CRC32, contents: contents}
+		default:
+			s.files[f.Name] = snapshotFile{crc32: f.CRC32}
+		}
+	}
+	if !foundBp {
+		return nil, fmt.Errorf("%s is not an sdk snapshot: it doesn't contain an Android.bp file", name)
+	}
+	return s, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (s *snapshot) parseBp(name string, contents []byte) error {
+	file, errs := parser.Parse(name, bytes.NewReader(contents))
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	for _, def := range file.Defs {
+		module, ok := def.(*parser.Module)
+		if !ok || nonMemberModuleTypes[module.Type] {
+			continue
+		}
+		m := &member{moduleType: module.Type, properties: make(map[string]property)}
+		var memberName string
+		for _, prop := range module.Properties {
+			if prop.Name == "name" {
+				if s, ok := prop.Value.(*parser.String); ok {
+					memberName = s.Value
+				}
+				continue
+			}
+			if err := flattenProperty(prop.Name, prop.Value, m.properties); err != nil {
+				return fmt.Errorf("%s: %s: %w", name, module.Type, err)
+			}
+		}
+		if memberName == "" {
+			return fmt.Errorf("%s: %s module at %s has no name", name, module.Type, module.TypePos)
+		}
+		s.members[memberName] = m
+	}
+	return nil
+}
+
+// flattenProperty adds the property to props, or its nested properties if it is a property set,
+// keyed by their dotted path.
+func flattenProperty(name string, value parser.Expression, props map[string]property) error {
+	switch v := value.(type) {
+	case *parser.Map:
+		for _, prop := range v.Properties {
+			if err := flattenProperty(name+"."+prop.Name, prop.Value, props); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parser.List:
+		p := property{list: true}
+		for _, e := range v.Values {
+			s, err := scalarValue(e)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			p.values = append(p.values, s)
+		}
+		props[name] = p
+		return nil
+	default:
+		s, err := scalarValue(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		props[name] = property{values: []string{s}}
+		return nil
+	}
+}
+
+func scalarValue(value parser.Expression) (string, error) {
+	switch v := value.(type) {
+	case *parser.String:
+		return strconv.Quote(v.Value), nil
+	case *parser.Bool:
+		return strconv.FormatBool(v.Value), nil
+	case *parser.Int64:
+		return strconv.FormatInt(v.Value, 10), nil
+	default:
+		return "", fmt.Errorf("unsupported value at %s", value.Pos())
+	}
+}
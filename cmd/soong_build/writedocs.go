@@ -16,6 +16,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"html/template"
 	"io/ioutil"
 	"path/filepath"
@@ -50,6 +51,43 @@ var propertyRank = map[string]int{
 	"device_supported": 6,
 }
 
+// moduleTypeJsonDocs is the documentation of a module type written to soong_build.json, for tools
+// like bp_lsp that need the module types and properties in a machine readable form.
+type moduleTypeJsonDocs struct {
+	Name       string
+	Package    string
+	Text       string
+	Properties []propertyJsonDocs
+}
+
+type propertyJsonDocs struct {
+	Name       string
+	OtherNames []string `json:",omitempty"`
+	Type       string
+	Text       string
+	Default    string             `json:",omitempty"`
+	Properties []propertyJsonDocs `json:",omitempty"`
+}
+
+func propertyDocsToJson(props []bpdoc.Property) []propertyJsonDocs {
+	result := make([]propertyJsonDocs, 0, len(props))
+	for _, prop := range props {
+		text := string(prop.Text)
+		for _, otherText := range prop.OtherTexts {
+			text += string(otherText)
+		}
+		result = append(result, propertyJsonDocs{
+			Name:       prop.Name,
+			OtherNames: prop.OtherNames,
+			Type:       prop.Type,
+			Text:       text,
+			Default:    prop.Default,
+			Properties: propertyDocsToJson(prop.Properties),
+		})
+	}
+	return result
+}
+
 // For each module type, extract its documentation and convert it to the template data.
 func moduleTypeDocsToTemplates(moduleTypeList []*bpdoc.ModuleType) []moduleTypeTemplateData {
 	result := make([]moduleTypeTemplateData, 0)
@@ -119,6 +157,7 @@ func writeDocs(ctx *android.Context, filename string) error {
 	// of keywords.
 	keywordsTmpl := template.Must(template.New("file").Parse(keywordsTemplate))
 	keywordsBuf := &bytes.Buffer{}
+	jsonDocs := make([]moduleTypeJsonDocs, 0)
 	for _, pkg := range packages {
 		// We need a module name getter/setter function because I couldn't
 		// find a way to keep it in a variable defined within the template.
@@ -149,6 +188,27 @@ func writeDocs(ctx *android.Context, filename string) error {
 		if err != nil {
 			return err
 		}
+		for _, module := range modules {
+			jsonDocs = append(jsonDocs, moduleTypeJsonDocs{
+				Name:       module.Name,
+				Package:    pkg.Name,
+				Text:       string(module.Synopsis),
+				Properties: propertyDocsToJson(module.Properties),
+			})
+		}
+	}
+
+	// Write out the module types and their properties as JSON, which is used by bp_lsp to provide
+	// completion, hover docs and diagnostics for Android.bp files.
+	sort.Slice(jsonDocs, func(i, j int) bool { return jsonDocs[i].Name < jsonDocs[j].Name })
+	jsonBuf, err := json.MarshalIndent(jsonDocs, "", "  ")
+	if err != nil {
+		return err
+	}
+	jsonFilename := filepath.Join(filepath.Dir(filename), "soong_build.json")
+	err = ioutil.WriteFile(jsonFilename, jsonBuf, 0666)
+	if err != nil {
+		return err
 	}
 
 	// Write out list of keywords. This includes all module and property names, which is useful for
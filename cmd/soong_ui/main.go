@@ -30,6 +30,7 @@ import (
 	"android/soong/ui/execution_metrics"
 	"android/soong/ui/logger"
 	"android/soong/ui/metrics"
+	"android/soong/ui/query"
 	"android/soong/ui/signal"
 	"android/soong/ui/status"
 	"android/soong/ui/terminal"
@@ -85,6 +86,14 @@ var commands = []command{
 		config:       dumpVarConfig,
 		stdio:        customStdio,
 		run:          dumpVars,
+	}, {
+		flag:         "--query-mode",
+		description:  "query the Soong module graph",
+		simpleOutput: true,
+		logsPrefix:   "query-",
+		config:       queryConfig,
+		stdio:        customStdio,
+		run:          runQuery,
 	}, {
 		flag:        "--build-mode",
 		description: "build modules based on the specified build action",
@@ -276,6 +285,10 @@ func preProductConfigSetup(buildCtx build.Context, config build.Config) {
 	stat.AddOutput(status.NewErrorLog(log, filepath.Join(logsDir, logsPrefix+"error.log")))
 	stat.AddOutput(status.NewProtoErrorLog(log, buildErrorFile))
 	stat.AddOutput(status.NewCriticalPathLogger(log, buildCtx.CriticalPath))
+	if config.BuildAttributionEnabled() {
+		stat.AddOutput(status.NewBuildAttributionLogger(log,
+			filepath.Join(logsDir, logsPrefix+"build_attribution.json")))
+	}
 	stat.AddOutput(status.NewBuildProgressLog(log, filepath.Join(logsDir, logsPrefix+"build_progress.pb")))
 
 	buildCtx.Verbosef("Detected %.3v GB total RAM", float32(config.TotalRAM())/(1024*1024*1024))
@@ -436,8 +449,68 @@ func stdio() terminal.StdioInterface {
 	return terminal.StdioImpl{}
 }
 
-// dumpvar and dumpvars use stdout to output variable values, so use stderr instead of stdout when
-// reporting events to keep stdout clean from noise.
+func runQuery(ctx build.Context, config build.Config, args []string) {
+	flags := flag.NewFlagSet("query", flag.ExitOnError)
+	flags.SetOutput(ctx.Writer)
+
+	flags.Usage = func() {
+		fmt.Fprintf(ctx.Writer, "usage: %s --query-mode [--output=label|graph|json] [--graph=<file>] <query>\n\n", os.Args[0])
+		fmt.Fprintln(ctx.Writer, "In query mode, print the module variants in the Soong module graph that match")
+		fmt.Fprintln(ctx.Writer, "the query to stdout. A query is built from module names, which may contain")
+		fmt.Fprintln(ctx.Writer, "globs or a {variant}, directories (//dir, //dir:name or //dir/...), the set")
+		fmt.Fprintln(ctx.Writer, "operators + (union), - (except) and ^ (intersect), and the functions:")
+		fmt.Fprintln(ctx.Writer, "")
+		fmt.Fprintln(ctx.Writer, "  deps(expr [, depth])       transitive dependencies of expr")
+		fmt.Fprintln(ctx.Writer, "  rdeps(expr [, depth])      transitive reverse dependencies of expr")
+		fmt.Fprintln(ctx.Writer, "  somepath(from, to)         one shortest path from a module in from to one in to")
+		fmt.Fprintln(ctx.Writer, "  allpaths(from, to)         all paths from modules in from to modules in to")
+		fmt.Fprintln(ctx.Writer, "  kind(regexp, expr)         modules in expr whose module type matches regexp")
+		fmt.Fprintln(ctx.Writer, "  attr(name, regexp, expr)   modules in expr with a matching value at the dotted")
+		fmt.Fprintln(ctx.Writer, "                             path name in their module graph entry")
+		fmt.Fprintln(ctx.Writer, "  filter(field, regexp, expr)")
+		fmt.Fprintln(ctx.Writer, "                             modules in expr whose name, dir or variant matches")
+		fmt.Fprintln(ctx.Writer, "                             regexp, or with field tag, that are depended on by")
+		fmt.Fprintln(ctx.Writer, "                             another module in expr with a matching dependency tag")
+		fmt.Fprintln(ctx.Writer, "")
+		fmt.Fprintln(ctx.Writer, "The module graph is regenerated before running the query unless --graph is set.")
+		fmt.Fprintln(ctx.Writer, "")
+		flags.PrintDefaults()
+	}
+	output := flags.String("output", "label", "Output format, one of "+strings.Join(query.OutputFormats, ", "))
+	graphFile := flags.String("graph", "", "Read the module graph from this file instead of regenerating it")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		ctx.Fatalf("Invalid usage")
+	}
+
+	if *graphFile == "" {
+		build.Build(ctx, config)
+		*graphFile = config.ModuleGraphFile()
+	}
+
+	f, err := os.Open(*graphFile)
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	defer f.Close()
+
+	graph, err := query.LoadGraph(f)
+	if err != nil {
+		ctx.Fatalf("%s: %s", *graphFile, err)
+	}
+	result, err := graph.Eval(strings.Join(flags.Args(), " "))
+	if err != nil {
+		ctx.Fatalf("Invalid query: %s", err)
+	}
+	if err := query.Write(os.Stdout, *output, result); err != nil {
+		ctx.Fatal(err)
+	}
+}
+
+// dumpvar, dumpvars and query use stdout to output their results, so use stderr instead of stdout
+// when reporting events to keep stdout clean from noise.
 func customStdio() terminal.StdioInterface {
 	return terminal.NewCustomStdio(os.Stdin, os.Stderr, os.Stderr)
 }
@@ -447,6 +520,11 @@ func dumpVarConfig(ctx build.Context, args ...string) build.Config {
 	return build.NewConfig(ctx)
 }
 
+// queryConfig only generates the module graph, the query arguments are parsed by runQuery.
+func queryConfig(ctx build.Context, args ...string) build.Config {
+	return build.NewConfig(ctx, "json-module-graph")
+}
+
 func buildActionConfig(ctx build.Context, args ...string) build.Config {
 	flags := flag.NewFlagSet("build-mode", flag.ContinueOnError)
 	flags.SetOutput(ctx.Writer)
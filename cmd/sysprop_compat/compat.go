@@ -0,0 +1,268 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// property is a single property of a sysprop description, with the defaults of the sysprop
+// proto applied to fields that are not set.
+type property struct {
+	Module         string
+	ApiName        string
+	Type           string
+	Access         string
+	Scope          string
+	PropName       string
+	EnumValues     []string
+	IntegerAsBool  bool
+	LegacyPropName string
+
+	// Line is the line the property starts on in the file it was parsed from.
+	Line int
+}
+
+func (p property) key() string {
+	return p.Module + "." + p.ApiName
+}
+
+// scope returns the scope used to decide whether a change to the property is compatible.  The
+// deprecated System scope is treated as Public.
+func (p property) scope() string {
+	if p.Scope == "System" {
+		return "Public"
+	}
+	return p.Scope
+}
+
+// accessRank orders the access levels by how many operations the generated API allows.
+var accessRank = map[string]int{
+	"Readonly":  0,
+	"Writeonce": 1,
+	"ReadWrite": 2,
+}
+
+// parseProperties parses either a .sysprop file, which contains a single sysprop.Properties
+// message, or an API dump, which contains a sysprop.SyspropLibrary message.
+func parseProperties(contents string) ([]property, error) {
+	root, err := parseTextProto(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []*textMessage
+	if libProps := root.messages("props"); len(libProps) > 0 {
+		for _, p := range libProps {
+			modules = append(modules, p.msg)
+		}
+	} else if len(root.fields) > 0 {
+		modules = append(modules, root)
+	}
+
+	var ret []property
+	seen := make(map[string]int)
+	for _, module := range modules {
+		moduleName := module.scalar("module")
+		if moduleName == "" {
+			return nil, fmt.Errorf("sysprop description without a module")
+		}
+		for _, prop := range module.messages("prop") {
+			p := property{
+				Module:         moduleName,
+				ApiName:        prop.msg.scalar("api_name"),
+				Type:           prop.msg.scalar("type"),
+				Access:         prop.msg.scalar("access"),
+				Scope:          prop.msg.scalar("scope"),
+				PropName:       prop.msg.scalar("prop_name"),
+				IntegerAsBool:  prop.msg.scalar("integer_as_bool") == "true",
+				LegacyPropName: prop.msg.scalar("legacy_prop_name"),
+				Line:           prop.line,
+			}
+			if enumValues := prop.msg.scalar("enum_values"); enumValues != "" {
+				p.EnumValues = strings.Split(enumValues, "|")
+			}
+			if p.ApiName == "" {
+				p.ApiName = apiNameFromPropName(p.PropName)
+			}
+			if p.Type == "" {
+				p.Type = "Boolean"
+			}
+			if p.Access == "" {
+				p.Access = "Readonly"
+			}
+			if p.Scope == "" {
+				p.Scope = "Public"
+			}
+			if _, ok := accessRank[p.Access]; !ok {
+				return nil, fmt.Errorf("line %d: unknown access %q", p.Line, p.Access)
+			}
+			if line, ok := seen[p.key()]; ok {
+				return nil, fmt.Errorf("line %d: duplicate property %s, previously defined on line %d",
+					p.Line, p.key(), line)
+			}
+			seen[p.key()] = p.Line
+			ret = append(ret, p)
+		}
+	}
+	return ret, nil
+}
+
+// apiNameFromPropName returns the API name the sysprop code generators use for a property that
+// doesn't set api_name.
+func apiNameFromPropName(propName string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, propName)
+}
+
+// change is a difference between the old and the new version of a property.
+type change struct {
+	// Kind is the kind of change, e.g. "removed" or "type".
+	Kind string
+	// Prop is the new version of the property, or the old version if it was removed.
+	Prop property
+	// Removed is true if the line of Prop refers to the old file.
+	Removed bool
+	// Scope is the scope of the old version of the property, which decides whether the change is
+	// breaking.
+	Scope string
+	// Breaking is true if the change breaks users of the property with the given scope.
+	Breaking bool
+	Message  string
+}
+
+func (c change) String() string {
+	severity := "note"
+	if c.Breaking {
+		severity = "error"
+	}
+	return fmt.Sprintf("%s: %s (%s): %s [%s]", severity, c.Prop.key(), c.Scope, c.Message, c.Kind)
+}
+
+// compareProperties classifies the differences between the old and the new versions of a sysprop
+// description.  Public properties are a stable API used by code that is built separately from the
+// platform, so any change that could break code compiled against or behavior relied on by the old
+// version is breaking.  Internal properties are only used by code that is built together with
+// them, so changes to them are never breaking.
+func compareProperties(oldProps, newProps []property) []change {
+	newByKey := make(map[string]property, len(newProps))
+	for _, p := range newProps {
+		newByKey[p.key()] = p
+	}
+	oldByKey := make(map[string]property, len(oldProps))
+	for _, p := range oldProps {
+		oldByKey[p.key()] = p
+	}
+
+	var changes []change
+	for _, o := range oldProps {
+		public := o.scope() == "Public"
+		n, ok := newByKey[o.key()]
+		if !ok {
+			changes = append(changes, change{Kind: "removed", Prop: o, Removed: true, Scope: o.scope(), Breaking: public,
+				Message: "property was removed"})
+			continue
+		}
+
+		add := func(kind string, breaking bool, format string, args ...interface{}) {
+			changes = append(changes, change{Kind: kind, Prop: n, Scope: o.scope(), Breaking: public && breaking,
+				Message: fmt.Sprintf(format, args...)})
+		}
+
+		if o.Type != n.Type {
+			add("type", true, "type changed from %s to %s", o.Type, n.Type)
+		}
+		if o.Access != n.Access {
+			narrowed := accessRank[n.Access] < accessRank[o.Access]
+			if narrowed {
+				add("access", true, "access narrowed from %s to %s", o.Access, n.Access)
+			} else {
+				add("access", false, "access widened from %s to %s", o.Access, n.Access)
+			}
+		}
+		if o.scope() != n.scope() {
+			narrowed := n.scope() != "Public"
+			if narrowed {
+				add("scope", true, "scope narrowed from %s to %s", o.Scope, n.Scope)
+			} else {
+				add("scope", false, "scope widened from %s to %s", o.Scope, n.Scope)
+			}
+		}
+		if o.PropName != n.PropName {
+			add("prop_name", true, "prop_name changed from %q to %q", o.PropName, n.PropName)
+		}
+		if o.IntegerAsBool != n.IntegerAsBool {
+			add("integer_as_bool", true, "integer_as_bool changed from %v to %v", o.IntegerAsBool, n.IntegerAsBool)
+		}
+		if o.LegacyPropName != n.LegacyPropName {
+			if o.LegacyPropName == "" {
+				add("legacy_prop_name", false, "legacy_prop_name %q was added", n.LegacyPropName)
+			} else {
+				add("legacy_prop_name", true, "legacy_prop_name changed from %q to %q", o.LegacyPropName, n.LegacyPropName)
+			}
+		}
+		// A type change already covers changes to the enum values.
+		if o.Type == n.Type {
+			removed, added := diffValues(o.EnumValues, n.EnumValues)
+			if len(removed) > 0 {
+				add("enum_values", true, "enum values %s were removed", strings.Join(removed, ", "))
+			}
+			if len(added) > 0 {
+				add("enum_values", false, "enum values %s were added", strings.Join(added, ", "))
+			}
+		}
+	}
+
+	for _, n := range newProps {
+		if _, ok := oldByKey[n.key()]; !ok {
+			changes = append(changes, change{Kind: "added", Prop: n, Scope: n.scope(), Message: "property was added"})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Breaking != changes[j].Breaking {
+			return changes[i].Breaking
+		}
+		return changes[i].Prop.key() < changes[j].Prop.key()
+	})
+	return changes
+}
+
+// diffValues returns the values that are only in a and the values that are only in b.
+func diffValues(a, b []string) (onlyA, onlyB []string) {
+	inA := make(map[string]bool, len(a))
+	for _, v := range a {
+		inA[v] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, v := range b {
+		inB[v] = true
+		if !inA[v] {
+			onlyB = append(onlyB, v)
+		}
+	}
+	for _, v := range a {
+		if !inB[v] {
+			onlyA = append(onlyA, v)
+		}
+	}
+	return onlyA, onlyB
+}
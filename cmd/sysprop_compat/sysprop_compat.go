@@ -0,0 +1,91 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// sysprop_compat checks that a new version of a sysprop description is compatible with an older
+// version.  Both versions can be either .sysprop files or sysprop_library API dumps.  Each change
+// is classified as compatible or breaking based on the scope of the property, and breaking changes
+// are reported as errors.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s [-v] [-o <file>] <old description> <new description>\n", os.Args[0])
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+
+	verbose := flags.Bool("v", false, "also report compatible changes")
+	output := flags.String("o", "", "file to touch if there are no breaking changes")
+
+	flags.Parse(os.Args[1:])
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	oldFile, newFile := flags.Arg(0), flags.Arg(1)
+
+	oldProps, err := readProperties(oldFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", oldFile, err)
+		os.Exit(1)
+	}
+	newProps, err := readProperties(newFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", newFile, err)
+		os.Exit(1)
+	}
+
+	breaking := 0
+	for _, c := range compareProperties(oldProps, newProps) {
+		if c.Breaking {
+			breaking++
+		} else if !*verbose {
+			continue
+		}
+		file := newFile
+		if c.Removed {
+			file = oldFile
+		}
+		fmt.Fprintf(os.Stderr, "%s:%d: %s\n", file, c.Prop.Line, c)
+	}
+
+	if breaking > 0 {
+		fmt.Fprintf(os.Stderr, "%d incompatible change(s) from %s to %s\n", breaking, oldFile, newFile)
+		os.Exit(1)
+	}
+
+	if *output != "" {
+		if err := os.WriteFile(*output, nil, 0666); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	}
+}
+
+func readProperties(file string) ([]property, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseProperties(string(data))
+}
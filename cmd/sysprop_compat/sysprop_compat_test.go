@@ -0,0 +1,171 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const oldApi = `props {
+  module: "android.sysprop.FooProperties"
+  prop {
+    api_name: "enabled"
+    prop_name: "ro.foo.enabled"
+  }
+  prop {
+    api_name: "mode"
+    type: Enum
+    access: ReadWrite
+    prop_name: "persist.foo.mode"
+    enum_values: "off|on|auto"
+  }
+  prop {
+    api_name: "count"
+    type: Integer
+    prop_name: "ro.foo.count"
+  }
+  prop {
+    api_name: "internal_only"
+    type: String
+    scope: Internal
+    prop_name: "ro.foo.internal"
+  }
+  prop {
+    api_name: "gone"
+    prop_name: "ro.foo.gone"
+  }
+  prop {
+    api_name: "internal_gone"
+    scope: Internal
+    prop_name: "ro.foo.internal_gone"
+  }
+}
+`
+
+// newSysprop is a .sysprop file rather than an API dump, the checker accepts either format.
+const newSysprop = `# Foo properties.
+owner: Platform
+module: "android.sysprop.FooProperties"
+
+prop {
+    api_name: "enabled"
+    prop_name: "ro.foo.enabled"
+    access: Writeonce
+}
+prop {
+    api_name: "mode"
+    type: Enum
+    access: Readonly
+    prop_name: "persist.foo.mode"
+    enum_values: "off|on|eco"
+}
+prop {
+    api_name: "count"
+    type: Long
+    scope: Internal
+    prop_name: "ro.foo.count"
+}
+prop {
+    api_name: "internal_only"
+    type: Integer
+    prop_name: "ro.foo.internal"
+}
+prop {
+    prop_name: "ro.foo.new-prop"
+}
+`
+
+func TestParseProperties(t *testing.T) {
+	props, err := parseProperties(newSysprop)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(props) != 5 {
+		t.Fatalf("expected 5 properties, got %d", len(props))
+	}
+	want := property{
+		Module:     "android.sysprop.FooProperties",
+		ApiName:    "mode",
+		Type:       "Enum",
+		Access:     "Readonly",
+		Scope:      "Public",
+		PropName:   "persist.foo.mode",
+		EnumValues: []string{"off", "on", "eco"},
+		Line:       10,
+	}
+	if !reflect.DeepEqual(props[1], want) {
+		t.Errorf("want %+v, got %+v", want, props[1])
+	}
+	if got := props[4].ApiName; got != "ro_foo_new_prop" {
+		t.Errorf("expected api name derived from prop_name, got %q", got)
+	}
+	if got := props[0].Type; got != "Boolean" {
+		t.Errorf("expected default type Boolean, got %q", got)
+	}
+
+	empty, err := parseProperties("")
+	if err != nil || len(empty) != 0 {
+		t.Errorf("expected no properties in an empty file, got %v, %v", empty, err)
+	}
+
+	errorTests := map[string]string{
+		"unterminated message": `module: "a" prop {`,
+		"missing module":       `prop { api_name: "a" }`,
+		"duplicate property":   `module: "a" prop { api_name: "b" } prop { api_name: "b" }`,
+		"unknown access":       `module: "a" prop { api_name: "b" access: Sometimes }`,
+	}
+	for name, contents := range errorTests {
+		if _, err := parseProperties(contents); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCompareProperties(t *testing.T) {
+	oldProps, err := parseProperties(oldApi)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	newProps, err := parseProperties(newSysprop)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got []string
+	for _, c := range compareProperties(oldProps, newProps) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"error: android.sysprop.FooProperties.count (Public): type changed from Integer to Long [type]",
+		"error: android.sysprop.FooProperties.count (Public): scope narrowed from Public to Internal [scope]",
+		"error: android.sysprop.FooProperties.gone (Public): property was removed [removed]",
+		"error: android.sysprop.FooProperties.mode (Public): access narrowed from ReadWrite to Readonly [access]",
+		"error: android.sysprop.FooProperties.mode (Public): enum values auto were removed [enum_values]",
+		"note: android.sysprop.FooProperties.enabled (Public): access widened from Readonly to Writeonce [access]",
+		"note: android.sysprop.FooProperties.internal_gone (Internal): property was removed [removed]",
+		"note: android.sysprop.FooProperties.internal_only (Internal): type changed from String to Integer [type]",
+		"note: android.sysprop.FooProperties.internal_only (Internal): scope widened from Internal to Public [scope]",
+		"note: android.sysprop.FooProperties.mode (Public): enum values eco were added [enum_values]",
+		"note: android.sysprop.FooProperties.ro_foo_new_prop (Public): property was added [added]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want:\n%q\ngot:\n%q", want, got)
+	}
+
+	if changes := compareProperties(oldProps, oldProps); len(changes) != 0 {
+		t.Errorf("expected no changes comparing a description to itself, got %v", changes)
+	}
+}
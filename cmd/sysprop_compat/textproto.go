@@ -0,0 +1,194 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// textMessage is a message parsed from the protobuf text format.  Only the subset of the format
+// used by .sysprop files and sysprop API dumps is supported: scalar fields, quoted strings,
+// nested messages and # comments.
+type textMessage struct {
+	fields []textField
+}
+
+type textField struct {
+	name  string
+	line  int
+	value string
+	msg   *textMessage
+}
+
+// scalar returns the value of the last occurrence of the named scalar field, or "" if it is not
+// set.
+func (m *textMessage) scalar(name string) string {
+	ret := ""
+	for _, f := range m.fields {
+		if f.name == name && f.msg == nil {
+			ret = f.value
+		}
+	}
+	return ret
+}
+
+// messages returns every occurrence of the named message field.
+func (m *textMessage) messages(name string) []textField {
+	var ret []textField
+	for _, f := range m.fields {
+		if f.name == name && f.msg != nil {
+			ret = append(ret, f)
+		}
+	}
+	return ret
+}
+
+type textParser struct {
+	s    string
+	pos  int
+	line int
+}
+
+func parseTextProto(s string) (*textMessage, error) {
+	p := &textParser{s: s, line: 1}
+	msg, err := p.parseFields(false)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %s", p.line, err)
+	}
+	return msg, nil
+}
+
+func (p *textParser) skipSpace() {
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '\n':
+			p.line++
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.s) && p.s[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == ' ' || c == '\t' || c == '\r' || c == ',' || c == ';':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *textParser) ident() string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := rune(p.s[p.pos])
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '.' && c != '-' && c != '+' {
+			break
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *textParser) parseFields(nested bool) (*textMessage, error) {
+	msg := &textMessage{}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			if nested {
+				return nil, fmt.Errorf("unexpected end of file, expected '}'")
+			}
+			return msg, nil
+		}
+		if p.s[p.pos] == '}' {
+			if !nested {
+				return nil, fmt.Errorf("unexpected '}'")
+			}
+			p.pos++
+			return msg, nil
+		}
+
+		field := textField{line: p.line, name: p.ident()}
+		if field.name == "" {
+			return nil, fmt.Errorf("expected field name, got %q", p.s[p.pos:p.pos+1])
+		}
+		p.skipSpace()
+		hasColon := p.pos < len(p.s) && p.s[p.pos] == ':'
+		if hasColon {
+			p.pos++
+			p.skipSpace()
+		}
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("unexpected end of file, expected value of %q", field.name)
+		}
+
+		switch c := p.s[p.pos]; {
+		case c == '{':
+			p.pos++
+			sub, err := p.parseFields(true)
+			if err != nil {
+				return nil, err
+			}
+			field.msg = sub
+		case !hasColon:
+			return nil, fmt.Errorf("expected ':' or '{' after %q", field.name)
+		case c == '"' || c == '\'':
+			// Adjacent string literals are concatenated.
+			for p.pos < len(p.s) && (p.s[p.pos] == '"' || p.s[p.pos] == '\'') {
+				value, err := p.quoted()
+				if err != nil {
+					return nil, err
+				}
+				field.value += value
+				p.skipSpace()
+			}
+		default:
+			field.value = p.ident()
+			if field.value == "" {
+				return nil, fmt.Errorf("expected value of %q", field.name)
+			}
+		}
+		msg.fields = append(msg.fields, field)
+	}
+}
+
+func (p *textParser) quoted() (string, error) {
+	quote := p.s[p.pos]
+	end := p.pos + 1
+	for end < len(p.s) && p.s[end] != quote {
+		if p.s[end] == '\\' {
+			end++
+		}
+		if end < len(p.s) && p.s[end] == '\n' {
+			return "", fmt.Errorf("newline in string")
+		}
+		end++
+	}
+	if end >= len(p.s) {
+		return "", fmt.Errorf("unterminated string")
+	}
+	literal := p.s[p.pos : end+1]
+	p.pos = end + 1
+	if quote == '\'' {
+		literal = `"` + strings.ReplaceAll(literal[1:len(literal)-1], `"`, `\"`) + `"`
+	}
+	value, err := strconv.Unquote(literal)
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s: %s", literal, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// finding is a single entry in a clang-tidy baseline file.  Line numbers are deliberately not
+// part of a finding so that unrelated edits to a file don't invalidate its baseline.
+type finding struct {
+	Check   string
+	File    string
+	Message string
+}
+
+func (f finding) String() string {
+	return f.Check + "\t" + f.File + "\t" + f.Message
+}
+
+const baselineHeader = `# clang-tidy baseline.
+#
+# Each line is a known finding in the form <check>\t<file>\t<message>.  Findings listed here do
+# not fail the build even if their check is in tidy_checks_as_errors.  To regenerate this file,
+# copy the tidy/tidy-baseline.txt file from the module's intermediates directory.
+`
+
+// parseBaseline reads a baseline file written by writeBaseline.  Findings are returned as a
+// multiset so that a baseline entry only covers as many identical findings as it is listed.
+func parseBaseline(r io.Reader) (map[finding]int, error) {
+	ret := make(map[finding]int)
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected <check>\\t<file>\\t<message>, got %q", lineNum, line)
+		}
+		ret[finding{Check: fields[0], File: path.Clean(fields[1]), Message: fields[2]}]++
+	}
+	return ret, scanner.Err()
+}
+
+// writeBaseline returns the contents of a baseline file listing the given findings.
+func writeBaseline(findings []finding) []byte {
+	lines := make([]string, 0, len(findings))
+	for _, f := range findings {
+		// Messages are stored on a single line.
+		f.Message = strings.ReplaceAll(f.Message, "\n", " ")
+		lines = append(lines, f.String())
+	}
+	sort.Strings(lines)
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(baselineHeader)
+	for _, line := range lines {
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// checkGlobs matches check names against a clang-tidy check list such as the value of
+// -warnings-as-errors: a comma separated list of globs where a leading '-' removes the matching
+// checks, and later entries take precedence over earlier ones.
+type checkGlobs []string
+
+func parseCheckGlobs(s string) checkGlobs {
+	var ret checkGlobs
+	for _, glob := range strings.Split(s, ",") {
+		glob = strings.TrimSpace(glob)
+		if glob != "" {
+			ret = append(ret, glob)
+		}
+	}
+	return ret
+}
+
+func (g checkGlobs) matches(check string) bool {
+	for i := len(g) - 1; i >= 0; i-- {
+		glob := g[i]
+		negative := strings.HasPrefix(glob, "-")
+		glob = strings.TrimPrefix(glob, "-")
+		if matched, _ := path.Match(glob, check); matched {
+			return !negative
+		}
+	}
+	return false
+}
+
+// newFindings returns the diagnostics of checks treated as errors that are not covered by the
+// baseline.
+func newFindings(diags []diagnostic, baseline map[finding]int, asErrors checkGlobs) []diagnostic {
+	remaining := make(map[finding]int, len(baseline))
+	for f, n := range baseline {
+		remaining[f] = n
+	}
+
+	var ret []diagnostic
+	for _, d := range diags {
+		if !asErrors.matches(d.Name) {
+			continue
+		}
+		f := d.finding()
+		f.Message = strings.ReplaceAll(f.Message, "\n", " ")
+		if remaining[f] > 0 {
+			remaining[f]--
+			continue
+		}
+		ret = append(ret, d)
+	}
+	return ret
+}
@@ -0,0 +1,190 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// diagnostic is a single entry of the Diagnostics list of a clang-tidy --export-fixes YAML file.
+//
+// The YAML written by clang-tidy is regular enough that the diagnostics can be handled as opaque
+// blocks of text; only the fields needed to deduplicate and to compare against a baseline are
+// extracted.
+type diagnostic struct {
+	// Name is the name of the check that produced the diagnostic, e.g. bugprone-use-after-move.
+	Name string
+	// Message is the main message of the diagnostic.
+	Message string
+	// FilePath is the file the diagnostic was reported in.
+	FilePath string
+
+	// text is the YAML text of the list entry, including the leading "  - ".
+	text string
+	// key is text without fields that differ between otherwise identical diagnostics reported
+	// from different translation units.
+	key string
+}
+
+// finding returns the baseline entry for the diagnostic.
+func (d diagnostic) finding() finding {
+	return finding{Check: d.Name, File: d.FilePath, Message: d.Message}
+}
+
+// parseFixes extracts the diagnostics from a clang-tidy --export-fixes YAML file.  clang-tidy
+// doesn't write the file when there are no diagnostics, and the build touches it instead, so an
+// empty file is treated as having no diagnostics.
+func parseFixes(r io.Reader) ([]diagnostic, error) {
+	var diags []diagnostic
+	var cur []string
+	inDiagnostics := false
+
+	flush := func() error {
+		if len(cur) == 0 {
+			return nil
+		}
+		d, err := parseDiagnostic(cur)
+		if err != nil {
+			return err
+		}
+		diags = append(diags, d)
+		cur = nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "  - ") && inDiagnostics:
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			cur = append(cur, line)
+		case strings.HasPrefix(line, " ") && inDiagnostics:
+			if len(cur) == 0 {
+				return nil, fmt.Errorf("unexpected line %q before the first diagnostic", line)
+			}
+			cur = append(cur, line)
+		default:
+			// A line at indentation zero ends the diagnostics list.
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			inDiagnostics = strings.TrimSpace(line) == "Diagnostics:"
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return diags, nil
+}
+
+func parseDiagnostic(lines []string) (diagnostic, error) {
+	d := diagnostic{
+		text: strings.Join(lines, "\n") + "\n",
+	}
+	var keyLines []string
+	for i, line := range lines {
+		field, value, _ := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "  - ")), ":")
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if i == 0 {
+			indent = 4
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case indent == 4 && field == "BuildDirectory":
+			// The build directory is an absolute path that differs between builds and is
+			// identical for every diagnostic, skip it when deduplicating.
+			continue
+		case indent == 4 && field == "DiagnosticName":
+			d.Name = unquoteScalar(value)
+		case indent == 6 && field == "Message" && d.Message == "":
+			d.Message = unquoteScalar(value)
+		case indent == 6 && field == "FilePath" && d.FilePath == "":
+			d.FilePath = path.Clean(unquoteScalar(value))
+		}
+		keyLines = append(keyLines, line)
+	}
+	if d.Name == "" {
+		return d, fmt.Errorf("diagnostic without a DiagnosticName:\n%s", d.text)
+	}
+	d.key = strings.Join(keyLines, "\n")
+	return d, nil
+}
+
+// unquoteScalar returns the value of a YAML scalar as written by LLVM's YAML output, which uses
+// single quotes for strings that need quoting and double quotes for strings containing control
+// characters.
+func unquoteScalar(s string) string {
+	switch {
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// mergeDiagnostics removes duplicate diagnostics, which are common for diagnostics in headers
+// that are included by multiple translation units, and sorts the result.
+func mergeDiagnostics(diags []diagnostic) []diagnostic {
+	seen := make(map[string]bool)
+	var ret []diagnostic
+	for _, d := range diags {
+		if !seen[d.key] {
+			seen[d.key] = true
+			ret = append(ret, d)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].FilePath != ret[j].FilePath {
+			return ret[i].FilePath < ret[j].FilePath
+		}
+		return ret[i].key < ret[j].key
+	})
+	return ret
+}
+
+// writeFixes writes diagnostics as a single clang-apply-replacements compatible YAML document.
+func writeFixes(diags []diagnostic) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("---\n")
+	buf.WriteString("MainSourceFile:  ''\n")
+	if len(diags) == 0 {
+		buf.WriteString("Diagnostics:     []\n")
+	} else {
+		buf.WriteString("Diagnostics:\n")
+		for _, d := range diags {
+			buf.WriteString(d.text)
+		}
+	}
+	buf.WriteString("...\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,145 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// tidy_fixes merges the YAML files written by clang-tidy --export-fixes into a single
+// clang-apply-replacements compatible file, and optionally checks the merged diagnostics against
+// a baseline of known findings.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"android/soong/response"
+)
+
+func main() {
+	var expandedArgs []string
+	for _, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, "@") {
+			f, err := os.Open(strings.TrimPrefix(arg, "@"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			respArgs, err := response.ReadRspFile(f)
+			f.Close()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			expandedArgs = append(expandedArgs, respArgs...)
+		} else {
+			expandedArgs = append(expandedArgs, arg)
+		}
+	}
+
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s -o <merged fixes> [-baseline <file>] [-checks_as_errors <checks>] [-write_reference_baseline <file>] [-check_output <file>] [<fixes file>...]\n", os.Args[0])
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+
+	output := flags.String("o", "", "file to write the merged fixes to")
+	baselineFile := flags.String("baseline", "", "baseline of known findings that don't fail the check")
+	checksAsErrors := flags.String("checks_as_errors", "", "comma separated list of checks whose new findings are errors, in -warnings-as-errors format")
+	referenceBaseline := flags.String("write_reference_baseline", "", "file to write a baseline containing all current findings to")
+	checkOutput := flags.String("check_output", "", "file to touch if no new findings were reported")
+
+	flags.Parse(expandedArgs)
+
+	if *output == "" && *checkOutput == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	var diags []diagnostic
+	for _, input := range flags.Args() {
+		f, err := os.Open(input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		d, err := parseFixes(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", input, err)
+			os.Exit(1)
+		}
+		diags = append(diags, d...)
+	}
+	diags = mergeDiagnostics(diags)
+
+	if *output != "" {
+		if err := os.WriteFile(*output, writeFixes(diags), 0666); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if *checkOutput == "" {
+		return
+	}
+
+	asErrors := parseCheckGlobs(*checksAsErrors)
+
+	if *referenceBaseline != "" {
+		var findings []finding
+		for _, d := range diags {
+			if asErrors.matches(d.Name) {
+				findings = append(findings, d.finding())
+			}
+		}
+		if err := os.WriteFile(*referenceBaseline, writeBaseline(findings), 0666); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	}
+
+	baseline := map[finding]int{}
+	if *baselineFile != "" {
+		f, err := os.Open(*baselineFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		baseline, err = parseBaseline(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", *baselineFile, err)
+			os.Exit(1)
+		}
+	}
+
+	if found := newFindings(diags, baseline, asErrors); len(found) > 0 {
+		for _, d := range found {
+			fmt.Fprintf(os.Stderr, "%s: error: %s [%s]\n", d.FilePath, d.Message, d.Name)
+		}
+		fmt.Fprintf(os.Stderr, "%d new clang-tidy finding(s) not listed in baseline %s.\n", len(found), *baselineFile)
+		if *referenceBaseline != "" {
+			fmt.Fprintf(os.Stderr, "Fix them, or if they are intentional copy %s to the baseline.\n", *referenceBaseline)
+		}
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(*checkOutput, nil, 0666); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
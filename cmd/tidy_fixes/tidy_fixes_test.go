@@ -0,0 +1,203 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const fooFixes = `---
+MainSourceFile:  '/work/external/foo/foo.cpp'
+Diagnostics:
+  - DiagnosticName:  misc-unused-parameters
+    DiagnosticMessage:
+      Message:         'parameter ''x'' is unused'
+      FilePath:        'external/foo/foo.h'
+      FileOffset:      120
+      Replacements:
+        - FilePath:        'external/foo/foo.h'
+          Offset:          115
+          Length:          1
+          ReplacementText: '/*x*/'
+    Level:           Warning
+    BuildDirectory:  '/work'
+  - DiagnosticName:  bugprone-use-after-move
+    DiagnosticMessage:
+      Message:         '''v'' used after it was moved'
+      FilePath:        'external/foo/foo.cpp'
+      FileOffset:      300
+      Replacements:    []
+    Notes:
+      - Message:         move occurred here
+        FilePath:        'external/foo/foo.cpp'
+        FileOffset:      280
+        Replacements:    []
+    Level:           Warning
+    BuildDirectory:  '/work'
+...
+`
+
+const barFixes = `---
+MainSourceFile:  '/other/external/foo/bar.cpp'
+Diagnostics:
+  - DiagnosticName:  misc-unused-parameters
+    DiagnosticMessage:
+      Message:         'parameter ''x'' is unused'
+      FilePath:        'external/foo/foo.h'
+      FileOffset:      120
+      Replacements:
+        - FilePath:        'external/foo/foo.h'
+          Offset:          115
+          Length:          1
+          ReplacementText: '/*x*/'
+    Level:           Warning
+    BuildDirectory:  '/other'
+  - DiagnosticName:  readability-braces-around-statements
+    DiagnosticMessage:
+      Message:         statement should be inside braces
+      FilePath:        'external/foo/bar.cpp'
+      FileOffset:      10
+      Replacements:
+        - FilePath:        'external/foo/bar.cpp'
+          Offset:          10
+          Length:          0
+          ReplacementText: " {\n"
+    Level:           Warning
+    BuildDirectory:  '/other'
+...
+`
+
+func parseAll(t *testing.T, files ...string) []diagnostic {
+	t.Helper()
+	var diags []diagnostic
+	for _, file := range files {
+		d, err := parseFixes(strings.NewReader(file))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		diags = append(diags, d...)
+	}
+	return diags
+}
+
+func TestParseFixes(t *testing.T) {
+	diags := parseAll(t, fooFixes)
+
+	var got []finding
+	for _, d := range diags {
+		got = append(got, d.finding())
+	}
+	want := []finding{
+		{Check: "misc-unused-parameters", File: "external/foo/foo.h", Message: "parameter 'x' is unused"},
+		{Check: "bugprone-use-after-move", File: "external/foo/foo.cpp", Message: "'v' used after it was moved"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	empty, err := parseFixes(strings.NewReader(""))
+	if err != nil || len(empty) != 0 {
+		t.Errorf("expected no diagnostics in an empty file, got %v, %v", empty, err)
+	}
+}
+
+func TestMergeFixes(t *testing.T) {
+	merged := mergeDiagnostics(parseAll(t, fooFixes, barFixes))
+	if len(merged) != 3 {
+		t.Fatalf("expected duplicate header diagnostic to be removed, got %d diagnostics", len(merged))
+	}
+
+	var files []string
+	for _, d := range merged {
+		files = append(files, d.FilePath)
+	}
+	wantFiles := []string{"external/foo/bar.cpp", "external/foo/foo.cpp", "external/foo/foo.h"}
+	if !reflect.DeepEqual(files, wantFiles) {
+		t.Errorf("want files %q, got %q", wantFiles, files)
+	}
+
+	// The merged file must itself be parseable, so that per-module files can be merged again
+	// per directory.
+	out := writeFixes(merged)
+	reparsed, err := parseFixes(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("failed to parse merged fixes: %s\n%s", err, out)
+	}
+	if !reflect.DeepEqual(mergeDiagnostics(reparsed), merged) {
+		t.Errorf("merged fixes did not round trip:\n%s", out)
+	}
+
+	if got := writeFixes(nil); !bytes.Contains(got, []byte("Diagnostics:     []\n")) {
+		t.Errorf("expected empty diagnostics list, got:\n%s", got)
+	}
+}
+
+func TestCheckGlobs(t *testing.T) {
+	globs := parseCheckGlobs("bugprone-*,misc-*,-misc-unused-parameters")
+	tests := map[string]bool{
+		"bugprone-use-after-move":     true,
+		"misc-redundant-expression":   true,
+		"misc-unused-parameters":      false,
+		"readability-braces-around-x": false,
+	}
+	for check, want := range tests {
+		if got := globs.matches(check); got != want {
+			t.Errorf("%s: want %v, got %v", check, want, got)
+		}
+	}
+}
+
+func TestNewFindings(t *testing.T) {
+	diags := mergeDiagnostics(parseAll(t, fooFixes, barFixes))
+	asErrors := parseCheckGlobs("bugprone-*,misc-*,readability-*")
+
+	baseline, err := parseBaseline(strings.NewReader(
+		"# comment\n" +
+			"misc-unused-parameters\texternal/foo/./foo.h\tparameter 'x' is unused\n" +
+			"readability-braces-around-statements\texternal/foo/bar.cpp\tstatement should be inside braces\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	found := newFindings(diags, baseline, asErrors)
+	if len(found) != 1 || found[0].Name != "bugprone-use-after-move" {
+		t.Errorf("expected only bugprone-use-after-move to be new, got %v", found)
+	}
+
+	// Findings of checks that are not errors are never reported.
+	if found := newFindings(diags, nil, parseCheckGlobs("cert-*")); len(found) != 0 {
+		t.Errorf("expected no new findings, got %v", found)
+	}
+
+	// A reference baseline covers all current findings.
+	var findings []finding
+	for _, d := range diags {
+		findings = append(findings, d.finding())
+	}
+	reference, err := parseBaseline(bytes.NewReader(writeBaseline(findings)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if found := newFindings(diags, reference, asErrors); len(found) != 0 {
+		t.Errorf("expected reference baseline to cover all findings, got %v", found)
+	}
+
+	if _, err := parseBaseline(strings.NewReader("not a baseline line\n")); err == nil {
+		t.Errorf("expected an error for a malformed baseline")
+	}
+}
@@ -204,6 +204,10 @@ type ClassLoaderContext struct {
 
 	// Nested sub-CLC for dependencies.
 	Subcontexts []*ClassLoaderContext
+
+	// The name of the module that provides the library, if known. It is only used in reports that
+	// help debugging CLC mismatches.
+	Module string
 }
 
 // excludeLibs excludes the libraries from this ClassLoaderContext.
@@ -286,7 +290,7 @@ const AnySdkVersion int = android.FutureApiLevelInt
 
 // Add class loader context for the given library to the map entry for the given SDK version.
 func (clcMap ClassLoaderContextMap) addContext(ctx android.ModuleInstallPathContext, sdkVer int, lib string,
-	optional bool, hostPath, installPath android.Path, nestedClcMap ClassLoaderContextMap) error {
+	module string, optional bool, hostPath, installPath android.Path, nestedClcMap ClassLoaderContextMap) error {
 
 	// For prebuilts, library should have the same name as the source module.
 	lib = android.RemoveOptionalPrebuiltPrefix(lib)
@@ -344,6 +348,7 @@ func (clcMap ClassLoaderContextMap) addContext(ctx android.ModuleInstallPathCont
 		Host:        hostPath,
 		Device:      devicePath,
 		Subcontexts: subcontexts,
+		Module:      module,
 	})
 	return nil
 }
@@ -355,7 +360,15 @@ func (clcMap ClassLoaderContextMap) addContext(ctx android.ModuleInstallPathCont
 func (clcMap ClassLoaderContextMap) AddContext(ctx android.ModuleInstallPathContext, sdkVer int,
 	lib string, optional bool, hostPath, installPath android.Path, nestedClcMap ClassLoaderContextMap) {
 
-	err := clcMap.addContext(ctx, sdkVer, lib, optional, hostPath, installPath, nestedClcMap)
+	clcMap.AddContextForModule(ctx, sdkVer, lib, "", optional, hostPath, installPath, nestedClcMap)
+}
+
+// AddContextForModule is like AddContext, but also records the name of the module that provides
+// the library.
+func (clcMap ClassLoaderContextMap) AddContextForModule(ctx android.ModuleInstallPathContext, sdkVer int,
+	lib string, module string, optional bool, hostPath, installPath android.Path, nestedClcMap ClassLoaderContextMap) {
+
+	err := clcMap.addContext(ctx, sdkVer, lib, module, optional, hostPath, installPath, nestedClcMap)
 	if err != nil {
 		ctx.ModuleErrorf(err.Error())
 	}
@@ -591,6 +604,7 @@ type jsonClassLoaderContext struct {
 	Host        string
 	Device      string
 	Subcontexts []*jsonClassLoaderContext
+	Module      string `json:",omitempty"`
 }
 
 // A map from SDK version (represented with a JSON string) to JSON CLCs.
@@ -623,6 +637,7 @@ func fromJsonClassLoaderContextRec(ctx android.PathContext, jClcs []*jsonClassLo
 			Host:        constructPath(ctx, clc.Host),
 			Device:      clc.Device,
 			Subcontexts: fromJsonClassLoaderContextRec(ctx, clc.Subcontexts),
+			Module:      clc.Module,
 		})
 	}
 	return clcs
@@ -658,6 +673,7 @@ func toJsonClassLoaderContextRec(clcs []*ClassLoaderContext) []*jsonClassLoaderC
 			Host:        host,
 			Device:      clc.Device,
 			Subcontexts: toJsonClassLoaderContextRec(clc.Subcontexts),
+			Module:      clc.Module,
 		}
 	}
 	return jClcs
@@ -0,0 +1,150 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dexpreopt
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"android/soong/android"
+)
+
+// ClassLoaderContextReport describes the class loader context of a library or app, to help debug
+// mismatches between the build-time and the run-time CLC. It is read by clc_explain.
+type ClassLoaderContextReport struct {
+	// The name of the library or app.
+	Module string
+
+	// The CLC for each SDK version, in the order in which construct_context.py adds them: the
+	// conditional CLCs of the compatibility libraries in descending order of SDK version, then the
+	// unconditional CLC.
+	Contexts []*ClassLoaderContextReportSdk
+}
+
+type ClassLoaderContextReportSdk struct {
+	// The SDK version, or "any" for the unconditional CLC. The conditional CLC is only used by
+	// apps whose targetSdkVersion is lower than this.
+	SdkVersion string
+
+	Libraries []*jsonClassLoaderContext
+}
+
+// NewClassLoaderContextReport returns the report of the class loader context of a module. The
+// conditional CLC is fixed up in the same way as before it is used by dexpreopt, without
+// modifying clcMap.
+func NewClassLoaderContextReport(module string, clcMap ClassLoaderContextMap) *ClassLoaderContextReport {
+	fixedClcMap := make(ClassLoaderContextMap, len(clcMap))
+	for sdkVer, clcs := range clcMap {
+		fixedClcMap[sdkVer] = clcs
+	}
+	fixClassLoaderContext(fixedClcMap)
+
+	var sdkVers []int
+	for sdkVer, clcs := range fixedClcMap {
+		if len(clcs) > 0 {
+			sdkVers = append(sdkVers, sdkVer)
+		}
+	}
+	// AnySdkVersion is the largest, so it comes last after reversing the order.
+	sort.Sort(sort.Reverse(sort.IntSlice(sdkVers)))
+	if len(sdkVers) > 0 && sdkVers[0] == AnySdkVersion {
+		sdkVers = append(sdkVers[1:], AnySdkVersion)
+	}
+
+	report := &ClassLoaderContextReport{Module: module}
+	jsonClcMap := toJsonClassLoaderContext(fixedClcMap)
+	for _, sdkVer := range sdkVers {
+		sdkVerStr := fmt.Sprintf("%d", sdkVer)
+		if sdkVer == AnySdkVersion {
+			sdkVerStr = "any"
+		}
+		report.Contexts = append(report.Contexts, &ClassLoaderContextReportSdk{
+			SdkVersion: sdkVerStr,
+			Libraries:  jsonClcMap[sdkVerStr],
+		})
+	}
+	return report
+}
+
+func (r *ClassLoaderContextReport) JSON() string {
+	bytes, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return string(bytes)
+}
+
+// Dot returns the CLC tree as a graphviz graph. The edges from the module are labeled with the
+// position of the library in the CLC and, for compatibility libraries, with the condition on the
+// targetSdkVersion of the app. Optional libraries are dashed.
+func (r *ClassLoaderContextReport) Dot() string {
+	var b strings.Builder
+	nodes := 0
+	var writeNode func(clc *jsonClassLoaderContext) string
+	writeNode = func(clc *jsonClassLoaderContext) string {
+		id := fmt.Sprintf("n%d", nodes)
+		nodes++
+		label := clc.Name
+		if clc.Module != "" && clc.Module != clc.Name {
+			label += "\\nmodule: " + clc.Module
+		}
+		label += "\\n" + clc.Device
+		style := "solid"
+		if clc.Optional {
+			style = "dashed"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, style=%s];\n", id, dotQuote(label), style)
+		for i, sub := range clc.Subcontexts {
+			subId := writeNode(sub)
+			fmt.Fprintf(&b, "  %s -> %s [label=\"%d\"];\n", id, subId, i+1)
+		}
+		return id
+	}
+
+	fmt.Fprintf(&b, "digraph %q {\n", r.Module)
+	fmt.Fprintln(&b, "  rankdir=LR;")
+	fmt.Fprintln(&b, "  node [shape=box];")
+	fmt.Fprintf(&b, "  root [label=%q, shape=doubleoctagon];\n", r.Module)
+	position := 0
+	for _, sdk := range r.Contexts {
+		for _, clc := range sdk.Libraries {
+			position++
+			id := writeNode(clc)
+			label := fmt.Sprintf("%d", position)
+			if sdk.SdkVersion != "any" {
+				label += fmt.Sprintf(" if targetSdkVersion < %s", sdk.SdkVersion)
+			}
+			fmt.Fprintf(&b, "  root -> %s [label=%q];\n", id, label)
+		}
+	}
+	fmt.Fprintln(&b, "}")
+	return b.String()
+}
+
+// WriteClassLoaderContextReport writes the report of the class loader context of a module as JSON
+// and as a graphviz graph.
+func WriteClassLoaderContextReport(ctx android.ModuleContext, module string, clcMap ClassLoaderContextMap,
+	jsonPath, dotPath android.WritablePath) {
+	report := NewClassLoaderContextReport(module, clcMap)
+	android.WriteFileRule(ctx, jsonPath, report.JSON())
+	android.WriteFileRule(ctx, dotPath, report.Dot())
+}
+
+// dotQuote quotes a graphviz label. Unlike %q, it keeps the \n line breaks of the label.
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dexpreopt
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestClassLoaderContextReport(t *testing.T) {
+	ctx := testContext()
+	optional := false
+
+	nested := make(ClassLoaderContextMap)
+	nested.AddContext(ctx, AnySdkVersion, "c", optional, buildPath(ctx, "c"), installPath(ctx, "c"), nil)
+
+	m := make(ClassLoaderContextMap)
+	m.AddContextForModule(ctx, AnySdkVersion, "b", "b-impl", optional, buildPath(ctx, "b"), installPath(ctx, "b"), nested)
+	m.AddContext(ctx, AnySdkVersion, "d", true, buildPath(ctx, "d"), installPath(ctx, "d"), nil)
+	m.AddContext(ctx, 28, "a", optional, buildPath(ctx, "a"), installPath(ctx, "a"), nil)
+	m.AddContext(ctx, 29, AndroidHidlManager, optional, buildPath(ctx, AndroidHidlManager), nil, nil)
+	// Compatibility libraries that are also in the unconditional context are removed.
+	m.AddContext(ctx, 30, "d", true, buildPath(ctx, "d"), installPath(ctx, "d"), nil)
+
+	report := NewClassLoaderContextReport("app", m)
+
+	var sdkVersions, names []string
+	for _, sdk := range report.Contexts {
+		sdkVersions = append(sdkVersions, sdk.SdkVersion)
+		for _, lib := range sdk.Libraries {
+			names = append(names, lib.Name)
+		}
+	}
+	android.AssertArrayString(t, "SDK versions", []string{"29", "28", "any"}, sdkVersions)
+	android.AssertArrayString(t, "libraries", []string{AndroidHidlManager, "a", "b", "d"}, names)
+	android.AssertIntEquals(t, "conditional CLC is not modified", 1, len(m[30]))
+
+	b := report.Contexts[2].Libraries[0]
+	android.AssertStringEquals(t, "module", "b-impl", b.Module)
+	android.AssertStringEquals(t, "device path", "/system/b.jar", b.Device)
+	android.AssertIntEquals(t, "subcontexts", 1, len(b.Subcontexts))
+
+	dot := report.Dot()
+	android.AssertStringDoesContain(t, "dot", dot, `n0 [label="android.hidl.manager-V1.0-java\n/system/framework/android.hidl.manager-V1.0-java.jar", style=solid];`)
+	android.AssertStringDoesContain(t, "dot", dot, `root -> n0 [label="1 if targetSdkVersion < 29"];`)
+	android.AssertStringDoesContain(t, "dot", dot, `n2 [label="b\nmodule: b-impl\n/system/b.jar", style=solid];`)
+	android.AssertStringDoesContain(t, "dot", dot, `n2 -> n3 [label="1"];`)
+	android.AssertStringDoesContain(t, "dot", dot, `n4 [label="d\n/system/d.jar", style=dashed];`)
+	android.AssertStringDoesContain(t, "dot", dot, `root -> n4 [label="4"];`)
+}
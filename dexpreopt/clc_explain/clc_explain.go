@@ -0,0 +1,300 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// clc_explain shows the class loader context (CLC) that dexpreopt uses for a library or app, and
+// explains why it does not match the CLC on device, e.g. the one printed by
+// `adb shell dumpsys package dexopt`. It reads the class_loader_context.json that Soong writes in
+// the dexpreopt directory of each dexpreopted module.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+var commands = map[string]func(args []string) error{
+	"show":    showCommand,
+	"compare": compareCommand,
+}
+
+// usages is kept out of commands to avoid an initialization cycle, as the commands print their
+// own usage.
+var usages = map[string]string{
+	"show": "show [--format text|json|dot] [--target_sdk_version <version>] " +
+		"[--product_packages <file>] <class_loader_context.json>",
+	"compare": "compare --device <clc> [--target_sdk_version <version>] " +
+		"[--product_packages <file>] <class_loader_context.json>",
+}
+
+// errMismatch is returned by the compare command when the contexts differ, after the differences
+// have been printed.
+var errMismatch = errors.New("the class loader contexts do not match")
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage:\n")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", os.Args[0], usages[name])
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(1)
+	}
+	if err := cmd(os.Args[2:]); err == errMismatch {
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s\n", os.Args[0], usages[name])
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// selectionFlags are the flags that select the libraries of the CLC like construct_context.py.
+type selectionFlags struct {
+	targetSdkVersion string
+	productPackages  string
+}
+
+func (s *selectionFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&s.targetSdkVersion, "target_sdk_version", "",
+		"targetSdkVersion of the app, selects the compatibility libraries")
+	flags.StringVar(&s.productPackages, "product_packages", "",
+		"product_packages.txt, optional libraries that are not in it are dropped")
+}
+
+func (s *selectionFlags) installed() (map[string]bool, error) {
+	if s.productPackages == "" {
+		return nil, nil
+	}
+	f, err := os.Open(s.productPackages)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	installed := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			installed[line] = true
+		}
+	}
+	return installed, scanner.Err()
+}
+
+func (s *selectionFlags) selectLibraries(rep *report) ([]*library, error) {
+	installed, err := s.installed()
+	if err != nil {
+		return nil, err
+	}
+	// Without a targetSdkVersion, only the unconditional libraries are used, as for an app that
+	// targets the current SDK.
+	targetSdkVersion := s.targetSdkVersion
+	if targetSdkVersion == "" {
+		targetSdkVersion = "10000"
+	}
+	return rep.selectLibraries(targetSdkVersion, installed), nil
+}
+
+func openReport(flags *flag.FlagSet) (*report, error) {
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readReport(f)
+}
+
+func showCommand(args []string) error {
+	flags := newFlagSet("show")
+	format := flags.String("format", "text", "output format: text, json or dot")
+	var selection selectionFlags
+	selection.register(flags)
+	flags.Parse(args)
+
+	rep, err := openReport(flags)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "text":
+		writeTree(os.Stdout, rep)
+		if selection.targetSdkVersion != "" || selection.productPackages != "" {
+			libs, err := selection.selectLibraries(rep)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stdout, "\nstored context: %s\n", storedContext(libs))
+		}
+	case "json":
+		bytes, err := json.MarshalIndent(rep, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+	case "dot":
+		writeDot(os.Stdout, rep)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	return nil
+}
+
+func compareCommand(args []string) error {
+	flags := newFlagSet("compare")
+	device := flags.String("device", "",
+		"CLC on device, or a line of `dumpsys package dexopt` that contains it")
+	var selection selectionFlags
+	selection.register(flags)
+	flags.Parse(args)
+
+	if *device == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	rep, err := openReport(flags)
+	if err != nil {
+		return err
+	}
+	libs, err := selection.selectLibraries(rep)
+	if err != nil {
+		return err
+	}
+	deviceContext := extractContext(*device)
+	chain, err := parseContext(deviceContext)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("build-time context: %s\n", storedContext(libs))
+	fmt.Printf("device context:     %s\n", deviceContext)
+	problems := explain(rep, selection.targetSdkVersion, libs, chain)
+	if len(problems) == 0 {
+		fmt.Println("the class loader contexts are equivalent")
+		return nil
+	}
+	fmt.Println("differences:")
+	for _, problem := range problems {
+		fmt.Printf("  %s\n", problem)
+	}
+	return errMismatch
+}
+
+// writeTree prints the CLC of each SDK version as an indented tree.
+func writeTree(w io.Writer, rep *report) {
+	fmt.Fprintln(w, rep.Module)
+	var writeLibraries func(libs []*library, indent string)
+	writeLibraries = func(libs []*library, indent string) {
+		for _, lib := range libs {
+			var notes []string
+			if lib.Optional {
+				notes = append(notes, "optional")
+			}
+			line := fmt.Sprintf("%s%s %s", indent, describe(lib), lib.Device)
+			if len(notes) > 0 {
+				line += " [" + strings.Join(notes, ", ") + "]"
+			}
+			fmt.Fprintln(w, line)
+			writeLibraries(lib.Subcontexts, indent+"  ")
+		}
+	}
+	for _, sdk := range rep.Contexts {
+		if sdk.SdkVersion == anySdk {
+			fmt.Fprintln(w, "  for any targetSdkVersion:")
+		} else {
+			fmt.Fprintf(w, "  if targetSdkVersion < %s:\n", sdk.SdkVersion)
+		}
+		writeLibraries(sdk.Libraries, "    ")
+	}
+}
+
+// writeDot prints the CLC as a graphviz graph, in the same format as the
+// class_loader_context.dot that Soong writes.
+func writeDot(w io.Writer, rep *report) {
+	nodes := 0
+	var writeNode func(lib *library) string
+	writeNode = func(lib *library) string {
+		id := fmt.Sprintf("n%d", nodes)
+		nodes++
+		label := lib.Name
+		if lib.Module != "" && lib.Module != lib.Name {
+			label += "\\nmodule: " + lib.Module
+		}
+		label += "\\n" + lib.Device
+		style := "solid"
+		if lib.Optional {
+			style = "dashed"
+		}
+		fmt.Fprintf(w, "  %s [label=%s, style=%s];\n", id, dotQuote(label), style)
+		for i, sub := range lib.Subcontexts {
+			subId := writeNode(sub)
+			fmt.Fprintf(w, "  %s -> %s [label=\"%d\"];\n", id, subId, i+1)
+		}
+		return id
+	}
+
+	fmt.Fprintf(w, "digraph %q {\n", rep.Module)
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box];")
+	fmt.Fprintf(w, "  root [label=%q, shape=doubleoctagon];\n", rep.Module)
+	position := 0
+	for _, sdk := range rep.Contexts {
+		for _, lib := range sdk.Libraries {
+			position++
+			id := writeNode(lib)
+			label := fmt.Sprintf("%d", position)
+			if sdk.SdkVersion != anySdk {
+				label += fmt.Sprintf(" if targetSdkVersion < %s", sdk.SdkVersion)
+			}
+			fmt.Fprintf(w, "  root -> %s [label=%q];\n", id, label)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// dotQuote quotes a graphviz label. Unlike %q, it keeps the \n line breaks of the label.
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,283 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// library is a <uses-library> in the class_loader_context.json written by Soong for each
+// dexpreopted module.
+type library struct {
+	Name        string
+	Module      string
+	Optional    bool
+	Host        string
+	Device      string
+	Subcontexts []*library
+}
+
+type sdkContexts struct {
+	// The SDK version, or "any" for the unconditional context.
+	SdkVersion string
+	Libraries  []*library
+}
+
+// report is the content of class_loader_context.json.
+type report struct {
+	Module string
+	// In the order in which construct_context.py adds them.
+	Contexts []*sdkContexts
+}
+
+func readReport(r io.Reader) (*report, error) {
+	var rep report
+	if err := json.NewDecoder(r).Decode(&rep); err != nil {
+		return nil, fmt.Errorf("reading class loader context: %w", err)
+	}
+	return &rep, nil
+}
+
+const anySdk = "any"
+
+// versionGreater returns whether SDK version a is greater than b, treating codenames as greater
+// than numbered versions, like compare_version_gt in manifest.py.
+func versionGreater(a, b string) bool {
+	aInt, aErr := strconv.Atoi(a)
+	bInt, bErr := strconv.Atoi(b)
+	aIsInt, bIsInt := aErr == nil, bErr == nil
+	switch {
+	case aIsInt && bIsInt:
+		return aInt > bInt
+	case !aIsInt && !bIsInt:
+		return strings.ToUpper(a) > strings.ToUpper(b)
+	default:
+		return bIsInt
+	}
+}
+
+// selectLibraries returns the top-level libraries of the CLC of an app with the given
+// targetSdkVersion, like construct_context.py. Optional libraries that are not installed are
+// removed at every level. If installed is nil, all optional libraries are assumed to be installed.
+func (r *report) selectLibraries(targetSdkVersion string, installed map[string]bool) []*library {
+	var libs []*library
+	for _, sdk := range r.Contexts {
+		if sdk.SdkVersion == anySdk || versionGreater(sdk.SdkVersion, targetSdkVersion) {
+			libs = append(libs, sdk.Libraries...)
+		}
+	}
+	return filterInstalled(libs, installed)
+}
+
+func filterInstalled(libs []*library, installed map[string]bool) []*library {
+	var filtered []*library
+	for _, lib := range libs {
+		if lib.Optional && installed != nil && !installed[lib.Name] {
+			continue
+		}
+		copied := *lib
+		copied.Subcontexts = filterInstalled(lib.Subcontexts, installed)
+		filtered = append(filtered, &copied)
+	}
+	return filtered
+}
+
+// conditionalSdkVersion returns the lowest SDK version whose conditional CLC contains a library
+// with the given on-device path, or "" if there is none.
+func (r *report) conditionalSdkVersion(device string) string {
+	version := ""
+	for _, sdk := range r.Contexts {
+		if sdk.SdkVersion == anySdk {
+			continue
+		}
+		for _, lib := range sdk.Libraries {
+			if lib.Device == device {
+				version = sdk.SdkVersion
+			}
+		}
+	}
+	return version
+}
+
+// classLoader is an element of a class loader context in the format used by dex2oat and
+// PackageManager, e.g. PCL[a.jar:b.jar]{PCL[c.jar]#PCL[d.jar]};PCL[e.jar].
+type classLoader struct {
+	// PCL for PathClassLoader, DLC for DelegateLastClassLoader or IMC for
+	// InMemoryDexClassLoader.
+	kind      string
+	classpath []string
+	// The shared libraries of the class loader, each a chain of class loaders.
+	sharedLibraries [][]*classLoader
+}
+
+// storedContext returns the CLC that dexpreopt stores in the odex file for the libraries, which is
+// what PackageManager compares with the CLC on device.
+func storedContext(libs []*library) string {
+	return "PCL[]" + encodeSharedLibraries(libs)
+}
+
+func encodeSharedLibraries(libs []*library) string {
+	if len(libs) == 0 {
+		return ""
+	}
+	var encoded []string
+	for _, lib := range libs {
+		encoded = append(encoded, "PCL["+lib.Device+"]"+encodeSharedLibraries(lib.Subcontexts))
+	}
+	return "{" + strings.Join(encoded, "#") + "}"
+}
+
+// toClassLoaders converts the build-time libraries to the class loaders that the stored context
+// of the libraries describes.
+func toClassLoaders(libs []*library) []*classLoader {
+	root := &classLoader{kind: "PCL"}
+	root.sharedLibraries = toSharedLibraries(libs)
+	return []*classLoader{root}
+}
+
+func toSharedLibraries(libs []*library) [][]*classLoader {
+	var shared [][]*classLoader
+	for _, lib := range libs {
+		shared = append(shared, []*classLoader{{
+			kind:            "PCL",
+			classpath:       []string{lib.Device},
+			sharedLibraries: toSharedLibraries(lib.Subcontexts),
+		}})
+	}
+	return shared
+}
+
+// extractContext returns the class loader context in a line of the output of
+// `dumpsys package dexopt` or of a logcat message, or the line itself if it only contains the
+// context.
+func extractContext(line string) string {
+	start := -1
+	for _, kind := range []string{"PCL[", "DLC[", "IMC["} {
+		if i := strings.Index(line, kind); i >= 0 && (start < 0 || i < start) {
+			start = i
+		}
+	}
+	if start < 0 {
+		return strings.TrimSpace(line)
+	}
+	line = line[start:]
+	if end := strings.IndexAny(line, " \t\n"); end >= 0 {
+		line = line[:end]
+	}
+	return line
+}
+
+// parseContext parses a class loader context. Checksums of the classpath elements are dropped.
+func parseContext(s string) ([]*classLoader, error) {
+	p := &contextParser{s: s}
+	chain, err := p.chain()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos])
+	}
+	return chain, nil
+}
+
+type contextParser struct {
+	s   string
+	pos int
+}
+
+func (p *contextParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid class loader context %q at offset %d: %s", p.s, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *contextParser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+// chain parses class loaders separated by ';', each the parent of the previous one.
+func (p *contextParser) chain() ([]*classLoader, error) {
+	var chain []*classLoader
+	for {
+		cl, err := p.classLoader()
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, cl)
+		if p.peek() != ';' {
+			return chain, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *contextParser) classLoader() (*classLoader, error) {
+	open := strings.IndexByte(p.s[p.pos:], '[')
+	if open < 0 {
+		return nil, p.errorf("expected a class loader")
+	}
+	cl := &classLoader{kind: p.s[p.pos : p.pos+open]}
+	switch cl.kind {
+	case "PCL", "DLC", "IMC":
+	default:
+		return nil, p.errorf("unknown class loader type %q", cl.kind)
+	}
+	p.pos += open + 1
+	end := strings.IndexByte(p.s[p.pos:], ']')
+	if end < 0 {
+		return nil, p.errorf("unterminated classpath")
+	}
+	if classpath := p.s[p.pos : p.pos+end]; classpath != "" {
+		for _, entry := range strings.Split(classpath, ":") {
+			// Remove the checksum that PackageManager adds to the classpath entries.
+			if star := strings.IndexByte(entry, '*'); star >= 0 {
+				entry = entry[:star]
+			}
+			cl.classpath = append(cl.classpath, entry)
+		}
+	}
+	p.pos += end + 1
+
+	if p.peek() == '{' {
+		p.pos++
+		for {
+			// Shared libraries that are loaded after the dex files of the class loader are
+			// prefixed with ~. The order is not relevant for the comparison.
+			if p.peek() == '~' {
+				p.pos++
+			}
+			shared, err := p.chain()
+			if err != nil {
+				return nil, err
+			}
+			cl.sharedLibraries = append(cl.sharedLibraries, shared)
+			if p.peek() == '#' {
+				p.pos++
+				continue
+			}
+			if p.peek() != '}' {
+				return nil, p.errorf("expected '#' or '}'")
+			}
+			p.pos++
+			break
+		}
+	}
+	return cl, nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testReport = `{
+  "Module": "app",
+  "Contexts": [
+    {
+      "SdkVersion": "29",
+      "Libraries": [
+        {"Name": "android.hidl.manager-V1.0-java", "Device": "/system/framework/android.hidl.manager-V1.0-java.jar"}
+      ]
+    },
+    {
+      "SdkVersion": "28",
+      "Libraries": [
+        {"Name": "org.apache.http.legacy", "Device": "/system/framework/org.apache.http.legacy.jar"}
+      ]
+    },
+    {
+      "SdkVersion": "any",
+      "Libraries": [
+        {
+          "Name": "foo",
+          "Module": "foo-impl",
+          "Device": "/system/framework/foo.jar",
+          "Subcontexts": [
+            {"Name": "bar", "Device": "/system/framework/bar.jar"}
+          ]
+        },
+        {"Name": "baz", "Optional": true, "Device": "/product/framework/baz.jar"}
+      ]
+    }
+  ]
+}`
+
+func readTestReport(t *testing.T) *report {
+	t.Helper()
+	rep, err := readReport(strings.NewReader(testReport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rep
+}
+
+func TestStoredContext(t *testing.T) {
+	rep := readTestReport(t)
+	testCases := []struct {
+		name             string
+		targetSdkVersion string
+		installed        map[string]bool
+		expected         string
+	}{
+		{
+			name:             "current",
+			targetSdkVersion: "10000",
+			expected: "PCL[]{PCL[/system/framework/foo.jar]{PCL[/system/framework/bar.jar]}#" +
+				"PCL[/product/framework/baz.jar]}",
+		},
+		{
+			name:             "compatibility libraries",
+			targetSdkVersion: "27",
+			expected: "PCL[]{PCL[/system/framework/android.hidl.manager-V1.0-java.jar]#" +
+				"PCL[/system/framework/org.apache.http.legacy.jar]#" +
+				"PCL[/system/framework/foo.jar]{PCL[/system/framework/bar.jar]}#" +
+				"PCL[/product/framework/baz.jar]}",
+		},
+		{
+			name:             "codename",
+			targetSdkVersion: "Q",
+			expected: "PCL[]{PCL[/system/framework/foo.jar]{PCL[/system/framework/bar.jar]}#" +
+				"PCL[/product/framework/baz.jar]}",
+		},
+		{
+			name:             "optional library not installed",
+			targetSdkVersion: "28",
+			installed:        map[string]bool{"foo": true},
+			expected: "PCL[]{PCL[/system/framework/android.hidl.manager-V1.0-java.jar]#" +
+				"PCL[/system/framework/foo.jar]{PCL[/system/framework/bar.jar]}}",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := storedContext(rep.selectLibraries(tc.targetSdkVersion, tc.installed))
+			if got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseContext(t *testing.T) {
+	testCases := []struct {
+		context  string
+		expected string
+		err      string
+	}{
+		{
+			context:  "PCL[]",
+			expected: "PCL[]",
+		},
+		{
+			context:  "PCL[base.apk*1234]{PCL[/system/framework/a.jar*5678]{PCL[/system/framework/b.jar]}#~PCL[c.jar]};DLC[d.jar:e.jar]",
+			expected: "PCL[base.apk]{PCL[/system/framework/a.jar]{PCL[/system/framework/b.jar]}#PCL[c.jar]};DLC[d.jar:e.jar]",
+		},
+		{
+			context: "XYZ[a.jar]",
+			err:     `unknown class loader type "XYZ"`,
+		},
+		{
+			context: "PCL[a.jar",
+			err:     "unterminated classpath",
+		},
+		{
+			context: "PCL[]{PCL[a.jar]",
+			err:     "expected '#' or '}'",
+		},
+		{
+			context: "PCL[]x",
+			err:     `unexpected 'x'`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.context, func(t *testing.T) {
+			chain, err := parseContext(tc.context)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := encodeChain(chain); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestExtractContext(t *testing.T) {
+	line := "      arm64: [status=speed-profile] [reason=bg-dexopt] [primary-abi] " +
+		"class loader context: PCL[]{PCL[/system/framework/foo.jar]} extra"
+	if got, expected := extractContext(line), "PCL[]{PCL[/system/framework/foo.jar]}"; got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// describe returns the name of a library and of the module that provides it if it is different.
+func describe(lib *library) string {
+	if lib.Module != "" && lib.Module != lib.Name {
+		return fmt.Sprintf("%s (module %s)", lib.Name, lib.Module)
+	}
+	return lib.Name
+}
+
+// findLibrary returns a library with the given on-device path anywhere in the report.
+func (r *report) findLibrary(device string) *library {
+	var find func(libs []*library) *library
+	find = func(libs []*library) *library {
+		for _, lib := range libs {
+			if lib.Device == device {
+				return lib
+			}
+			if found := find(lib.Subcontexts); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	for _, sdk := range r.Contexts {
+		if found := find(sdk.Libraries); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+type explainer struct {
+	report *report
+	// The targetSdkVersion used to select the build-time libraries.
+	targetSdkVersion string
+	problems         []string
+}
+
+func (e *explainer) problemf(where, format string, args ...interface{}) {
+	e.problems = append(e.problems, where+": "+fmt.Sprintf(format, args...))
+}
+
+// explain compares the build-time libraries with the class loader context on device and returns a
+// description of each difference.
+func explain(rep *report, targetSdkVersion string, libs []*library, device []*classLoader) []string {
+	e := &explainer{report: rep, targetSdkVersion: targetSdkVersion}
+	where := rep.Module
+	root := device[0]
+	if root.kind != "PCL" {
+		e.problemf(where, "the app is loaded by a %s on the device, but dexpreopt assumes a PCL", root.kind)
+	}
+	// The classpath of the root class loader is not compared, as the device may list the dex files
+	// of the app itself.
+	if len(device) > 1 {
+		e.problemf(where, "the class loader of the app has parents on the device that are not in the build-time context")
+	}
+	e.compareSharedLibraries(where, libs, root.sharedLibraries)
+	return e.problems
+}
+
+func devicePath(chain []*classLoader) string {
+	if len(chain[0].classpath) == 0 {
+		return ""
+	}
+	return chain[0].classpath[0]
+}
+
+func (e *explainer) compareSharedLibraries(where string, expected []*library, actual [][]*classLoader) {
+	// Match the libraries by on-device path, then by file name to detect libraries that are
+	// installed elsewhere.
+	matches := make([]int, len(expected))
+	matched := make([]bool, len(actual))
+	for i := range matches {
+		matches[i] = -1
+	}
+	for _, samePath := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		func(a, b string) bool { return path.Base(a) == path.Base(b) },
+	} {
+		for i, lib := range expected {
+			if matches[i] >= 0 {
+				continue
+			}
+			for j, chain := range actual {
+				if !matched[j] && samePath(lib.Device, devicePath(chain)) {
+					matches[i] = j
+					matched[j] = true
+					break
+				}
+			}
+		}
+	}
+
+	for i, lib := range expected {
+		if matches[i] >= 0 {
+			continue
+		}
+		hint := ""
+		if lib.Optional {
+			hint = "; it is optional, so it may not be installed on the device"
+		} else if sdk := e.report.conditionalSdkVersion(lib.Device); sdk != "" {
+			hint = fmt.Sprintf("; it is a compatibility library that is only used if the targetSdkVersion is lower than %s", sdk)
+		}
+		e.problemf(where, "%s (%s) is in the build-time context but not on the device%s", describe(lib), lib.Device, hint)
+	}
+
+	for j, chain := range actual {
+		if matched[j] {
+			continue
+		}
+		p := devicePath(chain)
+		hint := ""
+		if sdk := e.report.conditionalSdkVersion(p); sdk != "" {
+			hint = fmt.Sprintf("; it is a compatibility library that is used if the targetSdkVersion is lower than %s, check that the targetSdkVersion is %s", sdk, e.targetSdkVersion)
+		} else if lib := e.report.findLibrary(p); lib != nil && lib.Optional {
+			hint = fmt.Sprintf("; it is the optional library %s, which was not installed at build time", describe(lib))
+		} else if lib != nil {
+			hint = fmt.Sprintf("; it is the library %s, which is used elsewhere in the build-time context", describe(lib))
+		} else {
+			hint = "; check the <uses-library> tags in the manifest"
+		}
+		e.problemf(where, "%s is on the device but not in the build-time context%s", encodeChain(chain), hint)
+	}
+
+	var expectedOrder, deviceOrder []string
+	for j := range actual {
+		for i, lib := range expected {
+			if matches[i] == j {
+				deviceOrder = append(deviceOrder, lib.Name)
+			}
+		}
+	}
+	for i, lib := range expected {
+		if matches[i] >= 0 {
+			expectedOrder = append(expectedOrder, lib.Name)
+		}
+	}
+	if strings.Join(expectedOrder, ",") != strings.Join(deviceOrder, ",") {
+		e.problemf(where, "the libraries are in a different order: %s at build time, %s on the device",
+			strings.Join(expectedOrder, ", "), strings.Join(deviceOrder, ", "))
+	}
+
+	for i, lib := range expected {
+		if matches[i] < 0 {
+			continue
+		}
+		chain := actual[matches[i]]
+		libWhere := where + " > " + lib.Name
+		if p := devicePath(chain); p != lib.Device {
+			e.problemf(libWhere, "the path is %s at build time but %s on the device", lib.Device, p)
+		}
+		if chain[0].kind != "PCL" {
+			e.problemf(libWhere, "it is loaded by a %s on the device, but by a PCL at build time", chain[0].kind)
+		}
+		if len(chain[0].classpath) > 1 {
+			e.problemf(libWhere, "its classpath on the device has more files: %s", strings.Join(chain[0].classpath[1:], ":"))
+		}
+		if len(chain) > 1 {
+			e.problemf(libWhere, "its class loader has parents on the device that are not in the build-time context")
+		}
+		e.compareSharedLibraries(libWhere, lib.Subcontexts, chain[0].sharedLibraries)
+	}
+}
+
+func encodeChain(chain []*classLoader) string {
+	var encoded []string
+	for _, cl := range chain {
+		s := cl.kind + "[" + strings.Join(cl.classpath, ":") + "]"
+		if len(cl.sharedLibraries) > 0 {
+			var shared []string
+			for _, lib := range cl.sharedLibraries {
+				shared = append(shared, encodeChain(lib))
+			}
+			s += "{" + strings.Join(shared, "#") + "}"
+		}
+		encoded = append(encoded, s)
+	}
+	return strings.Join(encoded, ";")
+}
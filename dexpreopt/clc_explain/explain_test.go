@@ -0,0 +1,81 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	rep := readTestReport(t)
+	testCases := []struct {
+		name     string
+		device   string
+		expected []string
+	}{
+		{
+			name:   "equivalent",
+			device: "PCL[base.apk*123]{PCL[/system/framework/foo.jar*1]{PCL[/system/framework/bar.jar*2]}#PCL[/product/framework/baz.jar*3]}",
+		},
+		{
+			name:   "missing optional library",
+			device: "PCL[]{PCL[/system/framework/foo.jar]{PCL[/system/framework/bar.jar]}}",
+			expected: []string{
+				"app: baz (/product/framework/baz.jar) is in the build-time context but not on the device; " +
+					"it is optional, so it may not be installed on the device",
+			},
+		},
+		{
+			name: "compatibility library",
+			device: "PCL[]{PCL[/system/framework/org.apache.http.legacy.jar]#" +
+				"PCL[/system/framework/foo.jar]{PCL[/system/framework/bar.jar]}#PCL[/product/framework/baz.jar]}",
+			expected: []string{
+				"app: PCL[/system/framework/org.apache.http.legacy.jar] is on the device but not in the build-time context; " +
+					"it is a compatibility library that is used if the targetSdkVersion is lower than 28, " +
+					"check that the targetSdkVersion is 10000",
+			},
+		},
+		{
+			name:   "order and path",
+			device: "PCL[]{PCL[/product/framework/baz.jar]#PCL[/system_ext/framework/foo.jar]{PCL[/system/framework/bar.jar]}}",
+			expected: []string{
+				"app: the libraries are in a different order: foo, baz at build time, baz, foo on the device",
+				"app > foo: the path is /system/framework/foo.jar at build time but /system_ext/framework/foo.jar on the device",
+			},
+		},
+		{
+			name:   "nested",
+			device: "PCL[]{PCL[/system/framework/foo.jar]{PCL[/system/framework/qux.jar]}#PCL[/product/framework/baz.jar]}",
+			expected: []string{
+				"app > foo: bar (/system/framework/bar.jar) is in the build-time context but not on the device",
+				"app > foo: PCL[/system/framework/qux.jar] is on the device but not in the build-time context; " +
+					"check the <uses-library> tags in the manifest",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chain, err := parseContext(tc.device)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := explain(rep, "10000", rep.selectLibraries("10000", nil), chain)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected:\n%q\ngot:\n%q", tc.expected, got)
+			}
+		})
+	}
+}
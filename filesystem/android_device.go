@@ -242,6 +242,7 @@ func (a *androidDevice) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 			ctx.ModuleErrorf("Expected super image dep to provide SuperImageProvider")
 		}
 	}
+	var validations android.Paths
 	ctx.VisitDirectDepsProxyWithTag(filesystemDepTag, func(m android.ModuleProxy) {
 		imageOutput, ok := android.OtherModuleProvider(ctx, m, android.OutputFilesProvider)
 		if !ok {
@@ -251,6 +252,9 @@ func (a *androidDevice) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 			ctx.ModuleErrorf("Partition module %s should provide exact 1 output file", m.Name())
 		}
 		deps = append(deps, imageOutput.DefaultOutputFiles[0])
+		if fsInfo, ok := android.OtherModuleProvider(ctx, m, FilesystemProvider); ok && fsInfo.ImageVerification != nil {
+			validations = append(validations, fsInfo.ImageVerification)
+		}
 	})
 
 	allImagesZip := android.PathForModuleOut(ctx, "all_images.zip")
@@ -265,7 +269,6 @@ func (a *androidDevice) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	a.allImagesZip = allImagesZip
 
 	allImagesStamp := android.PathForModuleOut(ctx, "all_images_stamp")
-	var validations android.Paths
 	if !ctx.Config().KatiEnabled() && proptools.Bool(a.deviceProps.Main_device) {
 		// In soong-only builds, build this module by default.
 		// This is the analogue to this make code:
@@ -101,6 +101,9 @@ type filesystem struct {
 	filesystemBuilder filesystemBuilder
 
 	selinuxFc android.Path
+
+	// The size report of the image, and its differences with the baseline report.
+	sizeReport android.Paths
 }
 
 type filesystemBuilder interface {
@@ -253,6 +256,16 @@ type FilesystemProperties struct {
 	// Whether this partition is not supported by flashall.
 	// If true, this partition will not be included in the `updatedpackage` dist artifact.
 	No_flashall *bool
+
+	// Options for the report of the size of each module installed in this partition. Only ext4,
+	// erofs and f2fs partitions have a size report.
+	Size_report SizeReportProperties
+
+	// If true, the built image is read back to check that it contains exactly the files of the
+	// staging directory, and that they are labeled if the partition has file contexts. Only ext4
+	// and erofs images can be verified. The check runs as a validation of the image, so it runs
+	// whenever the image is built in a checkbuild or by an android_device.
+	Verify_image *bool
 }
 
 type AndroidFilesystemDeps struct {
@@ -471,6 +484,9 @@ type FilesystemInfo struct {
 	NoFlashall       bool
 	// HasOrIsRecovery returns true for recovery and for ramdisks with a recovery partition.
 	HasOrIsRecovery bool
+	// A stamp file that is built when the image matches its staging directory, if verify_image
+	// is set. It should be used as a validation of anything that uses the image.
+	ImageVerification android.Path
 }
 
 // FullInstallPathInfo contains information about the "full install" paths of all the files
@@ -660,13 +676,17 @@ func (f *filesystem) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		buildImagePropFile, buildImagePropFileDeps = f.buildPropFile(ctx)
 		propFileForMiscInfo = f.buildPropFileForMiscInfo(ctx)
 		output := android.PathForModuleOut(ctx, f.installFileName())
-		f.buildImageUsingBuildImage(ctx, builder, buildImageParams{rootDir, buildImagePropFile, buildImagePropFileDeps, output})
+		var sizeReportSpecs map[string]android.PackagingSpec
+		if f.sizeReportEnabled() {
+			sizeReportSpecs = specs
+		}
+		f.buildImageUsingBuildImage(ctx, builder, buildImageParams{rootDir, buildImagePropFile, buildImagePropFileDeps, sizeReportSpecs, output})
 		f.output = output
 		// Create the hermetic img file using a separate rule builder so that it can be built independently
 		hermeticBuilder := android.NewRuleBuilder(pctx, ctx)
 		outputHermetic = android.PathForModuleOut(ctx, "for_target_files", f.installFileName())
 		propFileHermetic := f.propFileForHermeticImg(ctx, hermeticBuilder, buildImagePropFile)
-		f.buildImageUsingBuildImage(ctx, hermeticBuilder, buildImageParams{rootDir, propFileHermetic, buildImagePropFileDeps, outputHermetic})
+		f.buildImageUsingBuildImage(ctx, hermeticBuilder, buildImageParams{rootDir, propFileHermetic, buildImagePropFileDeps, nil, outputHermetic})
 		mapFile = f.getMapFile(ctx)
 	case compressedCpioType:
 		f.output, extraRootDirs = f.buildCpioImage(ctx, builder, rootDir, true)
@@ -679,6 +699,19 @@ func (f *filesystem) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	f.installDir = android.PathForModuleInstall(ctx, "etc")
 	ctx.InstallFile(f.installDir, f.installFileName(), f.output)
 	ctx.SetOutputFiles([]android.Path{f.output}, "")
+	if f.sizeReport != nil {
+		ctx.SetOutputFiles(f.sizeReport, ".size_report")
+		// Dist the report for the goals that dist the image.
+		var distGoals []string
+		for _, dist := range f.Dists() {
+			if proptools.String(dist.Tag) == "" {
+				distGoals = append(distGoals, dist.Targets...)
+			}
+		}
+		if len(distGoals) > 0 {
+			ctx.DistForGoals(android.FirstUniqueStrings(distGoals), f.sizeReport...)
+		}
+	}
 
 	if f.partitionName() == "recovery" {
 		rootDir = rootDir.Join(ctx, "root")
@@ -697,6 +730,15 @@ func (f *filesystem) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		partitionNameForInstalledFiles = f.partitionName()
 	}
 
+	installedFiles := buildInstalledFiles(ctx, partitionNameForInstalledFiles, rootDir, f.output)
+	var imageVerification android.Path
+	if proptools.Bool(f.properties.Verify_image) {
+		imageVerification = f.buildImageVerification(ctx, rootDir, installedFiles.Json)
+		if imageVerification != nil {
+			ctx.CheckbuildFile(imageVerification)
+		}
+	}
+
 	var erofsCompressHints android.Path
 	if f.properties.Erofs.Compress_hints != nil {
 		erofsCompressHints = android.PathForModuleSrc(ctx, *f.properties.Erofs.Compress_hints)
@@ -718,7 +760,7 @@ func (f *filesystem) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		FullInstallPaths:       fullInstallPaths,
 		InstalledFilesDepSet: depset.New(
 			depset.POSTORDER,
-			[]InstalledFilesStruct{buildInstalledFiles(ctx, partitionNameForInstalledFiles, rootDir, f.output)},
+			[]InstalledFilesStruct{installedFiles},
 			includeFilesInstalledFiles(ctx),
 		),
 		ErofsCompressHints:  erofsCompressHints,
@@ -731,6 +773,7 @@ func (f *filesystem) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		PartitionName:       f.partitionName(),
 		HasOrIsRecovery:     f.hasOrIsRecovery(ctx),
 		NoFlashall:          proptools.Bool(f.properties.No_flashall),
+		ImageVerification:   imageVerification,
 	}
 	if proptools.Bool(f.properties.Use_avb) {
 		fsInfo.UseAvb = true
@@ -1004,6 +1047,8 @@ type buildImageParams struct {
 	rootDir  android.OutputPath
 	propFile android.Path
 	toolDeps android.Paths
+	// if set, the size of the files in the image is reported
+	sizeReportSpecs map[string]android.PackagingSpec
 	// outputs
 	output android.WritablePath
 }
@@ -1026,7 +1071,7 @@ func (f *filesystem) buildImageUsingBuildImage(
 	fec := ctx.Config().HostToolPath(ctx, "fec")
 	pathToolDirs := []string{filepath.Dir(fec.String())}
 
-	builder.Command().
+	cmd := builder.Command().
 		Textf("PATH=%s:$PATH", strings.Join(pathToolDirs, ":")).
 		BuiltTool("build_image").
 		Text(params.rootDir.String()). // input directory
@@ -1041,6 +1086,15 @@ func (f *filesystem) buildImageUsingBuildImage(
 		assertMaxImageSize(builder, params.output, *f.properties.Partition_size, false)
 	}
 
+	if params.sizeReportSpecs != nil {
+		var budgetCheck android.Path
+		f.sizeReport, budgetCheck = f.buildSizeReport(ctx, params.rootDir, params.output, params.sizeReportSpecs)
+		if budgetCheck != nil {
+			// The budgets are checked whenever the image is built.
+			cmd.Validation(budgetCheck)
+		}
+	}
+
 	// rootDir is not deleted. Might be useful for quick inspection.
 	builder.Build("build_"+params.output.String(), fmt.Sprintf("Creating filesystem %s", f.BaseModuleName()))
 }
@@ -595,6 +595,207 @@ func TestF2fsPartition(t *testing.T) {
 	android.AssertStringDoesContain(t, "f2fs fs type sparse", buildImageConfig, "f2fs_sparse_flag=-S")
 }
 
+func TestSizeReport(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		fixture,
+		android.FixtureMergeMockFs(android.MockFS{
+			"baseline.json": nil,
+		}),
+	).RunTestWithBp(t, `
+		android_filesystem {
+			name: "erofs_partition",
+			type: "erofs",
+			base_dir: "system",
+			deps: ["binfoo"],
+			size_report: {
+				baseline: "baseline.json",
+				budgets: [
+					{
+						module: "binfoo",
+						max_size: 1048576,
+					},
+					{
+						dir: "system/bin/",
+						max_size: 2097152,
+					},
+				],
+			},
+		}
+
+		cc_binary {
+			name: "binfoo",
+		}
+	`)
+
+	partition := result.ModuleForTests(t, "erofs_partition", "android_common")
+	owners := android.ContentFromFileRuleForTests(t, result.TestContext, partition.Output("owners.json"))
+	android.AssertStringDoesContain(t, "owner of binfoo", owners,
+		`{"path":"system/bin/binfoo","module":"binfoo",`)
+
+	report := partition.Output("size_report/erofs_partition.size_report.json")
+	for _, flag := range []string{
+		"--root out/soong/.intermediates/erofs_partition/android_common/erofs_partition",
+		"--partition erofs_partition",
+		"--fs_type erofs",
+		"--json out/soong/.intermediates/erofs_partition/android_common/size_report/erofs_partition.size_report.json",
+		"--image out/soong/.intermediates/erofs_partition/android_common/erofs_partition.img",
+		"--simg2img ",
+		"--baseline baseline.json",
+		"--diff out/soong/.intermediates/erofs_partition/android_common/size_report/erofs_partition.size_report_diff.txt",
+	} {
+		android.AssertStringDoesContain(t, "size report flags", report.RuleParams.Command, flag)
+	}
+	android.AssertStringListContains(t, "size report inputs", android.PathsRelativeToTop(report.Implicits),
+		"out/soong/.intermediates/erofs_partition/android_common/staging_dir.timestamp")
+
+	budgets := partition.Output("size_report/budgets.stamp")
+	for _, flag := range []string{
+		"--report out/soong/.intermediates/erofs_partition/android_common/size_report/erofs_partition.size_report.json",
+		"--budget module:binfoo=1048576",
+		"--budget dir:system/bin=2097152",
+	} {
+		android.AssertStringDoesContain(t, "size budget flags", budgets.RuleParams.Command, flag)
+	}
+
+	image := partition.Output("erofs_partition.img")
+	android.AssertStringDoesNotContain(t, "image command", image.RuleParams.Command, "partition_size_report")
+	android.AssertPathsRelativeToTopEquals(t, "image validations",
+		[]string{"out/soong/.intermediates/erofs_partition/android_common/size_report/budgets.stamp"},
+		image.Validations)
+
+	// The hermetic image is the same, so it doesn't need another check.
+	hermetic := partition.Output("for_target_files/erofs_partition.img")
+	android.AssertIntEquals(t, "hermetic image validations", 0, len(hermetic.Validations))
+}
+
+func TestSizeReportEnabled(t *testing.T) {
+	result := fixture.RunTestWithBp(t, `
+		android_filesystem {
+			name: "no_report",
+			type: "ext4",
+		}
+
+		android_filesystem {
+			name: "disted",
+			type: "ext4",
+			dist: {
+				targets: ["droidcore"],
+			},
+		}
+
+		android_filesystem {
+			name: "enabled",
+			type: "ext4",
+			size_report: {
+				enabled: true,
+			},
+		}
+	`)
+
+	for _, test := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"no_report", false},
+		{"disted", true},
+		{"enabled", true},
+	} {
+		partition := result.ModuleForTests(t, test.name, "android_common")
+		report := partition.MaybeOutput("size_report/" + test.name + ".size_report.json")
+		android.AssertBoolEquals(t, test.name+" size report", test.enabled, report.Rule != nil)
+		if test.enabled {
+			android.AssertStringListContains(t, test.name+" size report inputs", android.PathsRelativeToTop(report.Implicits),
+				"out/soong/.intermediates/"+test.name+"/android_common/"+test.name+".img")
+		}
+		android.AssertBoolEquals(t, test.name+" budget check", false,
+			partition.MaybeOutput("size_report/budgets.stamp").Rule != nil)
+	}
+}
+
+func TestSizeReportBudgetErrors(t *testing.T) {
+	fixture.ExtendWithErrorHandler(android.FixtureExpectsAllErrorsToMatchAPattern([]string{
+		`size_report.budgets\[0\]: module and dir cannot both be set`,
+		`size_report.budgets\[1\]: either module or dir must be set`,
+		`size_report.budgets\[2\]: max_size must be set`,
+	})).RunTestWithBp(t, `
+		android_filesystem {
+			name: "myfilesystem",
+			size_report: {
+				budgets: [
+					{
+						module: "foo",
+						dir: "bin",
+						max_size: 1,
+					},
+					{
+						max_size: 1,
+					},
+					{
+						module: "foo",
+					},
+				],
+			},
+		}
+	`)
+}
+
+func TestVerifyImage(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		fixture,
+		android.FixtureMergeMockFs(android.MockFS{
+			"file_contexts.bin": nil,
+		}),
+	).RunTestWithBp(t, `
+		android_filesystem {
+			name: "myfilesystem",
+			deps: ["binfoo"],
+			precompiled_file_contexts: "file_contexts.bin",
+			verify_image: true,
+		}
+
+		android_filesystem {
+			name: "unverified",
+			deps: ["binfoo"],
+		}
+
+		cc_binary {
+			name: "binfoo",
+		}
+	`)
+
+	partition := result.ModuleForTests(t, "myfilesystem", "android_common")
+	verification := partition.Output("image_verification/verified.stamp")
+	cmd := verification.RuleParams.Command
+	for _, flag := range []string{
+		"fsimage verify",
+		"--installed_files out/soong/.intermediates/myfilesystem/android_common/installed-files-myfilesystem.json",
+		"--prefix /myfilesystem",
+		"--require_selinux_labels",
+		"out/soong/.intermediates/myfilesystem/android_common/myfilesystem.img",
+	} {
+		android.AssertStringDoesContain(t, "image verification flags", cmd, flag)
+	}
+
+	fsInfo, _ := android.OtherModuleProvider(result.TestContext.OtherModuleProviderAdaptor(), partition.Module(), FilesystemProvider)
+	android.AssertPathRelativeToTopEquals(t, "image verification", "out/soong/.intermediates/myfilesystem/android_common/image_verification/verified.stamp", fsInfo.ImageVerification)
+
+	unverified := result.ModuleForTests(t, "unverified", "android_common")
+	unverifiedInfo, _ := android.OtherModuleProvider(result.TestContext.OtherModuleProviderAdaptor(), unverified.Module(), FilesystemProvider)
+	android.AssertPathRelativeToTopEquals(t, "no image verification", "", unverifiedInfo.ImageVerification)
+}
+
+func TestVerifyImageUnsupportedType(t *testing.T) {
+	fixture.ExtendWithErrorHandler(android.FixtureExpectsOneErrorPattern(
+		`verify_image: only ext4 and erofs images can be verified`)).
+		RunTestWithBp(t, `
+		android_filesystem {
+			name: "myramdisk",
+			type: "compressed_cpio",
+			verify_image: true,
+		}
+	`)
+}
+
 func TestFsTypesPropertyError(t *testing.T) {
 	fixture.ExtendWithErrorHandler(android.FixtureExpectsOneErrorPattern(
 		"erofs: erofs is non-empty, but FS type is f2fs\n. Please delete erofs properties if this partition should use f2fs\n")).
@@ -0,0 +1,49 @@
+// Copyright (C) 2026 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"android/soong/android"
+)
+
+// buildImageVerification checks that the built image contains exactly the files of the staging
+// directory listed in installedFiles, with the same sizes and contents, by reading the image with
+// fsimage. If the partition has file contexts, every file in the image must also have an SELinux
+// label. It returns a stamp file that is written when the image is valid, which is meant to be
+// used as a validation of the image.
+func (f *filesystem) buildImageVerification(ctx android.ModuleContext, rootDir android.Path, installedFiles android.Path) android.Path {
+	switch f.fsType(ctx) {
+	case ext4Type, erofsType:
+	default:
+		ctx.PropertyErrorf("verify_image", "only ext4 and erofs images can be verified")
+		return nil
+	}
+
+	stamp := android.PathForModuleOut(ctx, "image_verification", "verified.stamp")
+	builder := android.NewRuleBuilder(pctx, ctx)
+	cmd := builder.Command().
+		BuiltTool("fsimage").
+		Text("verify").
+		FlagWithInput("--installed_files ", installedFiles).
+		// fileslist names the files in the staging directory after its base name.
+		FlagWithArg("--prefix ", "/"+rootDir.Base()).
+		FlagWithOutput("--stamp ", stamp)
+	if f.selinuxFc != nil {
+		cmd.Flag("--require_selinux_labels")
+	}
+	cmd.Input(f.output)
+	builder.Build("verify_image", "Verifying filesystem image "+f.BaseModuleName())
+	return stamp
+}
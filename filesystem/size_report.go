@@ -0,0 +1,175 @@
+// Copyright (C) 2026 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"android/soong/android"
+
+	"github.com/google/blueprint/proptools"
+)
+
+type SizeReportProperties struct {
+	// Whether to build the size report. Defaults to true if baseline or budgets are set, or if the
+	// image is disted.
+	Enabled *bool
+
+	// A size report from a previous build of this partition, e.g. <name>.size_report.json from
+	// the dist directory. The changes in the size of each module are written to
+	// <name>.size_report_diff.txt.
+	Baseline *string `android:"path"`
+
+	// Maximum sizes of modules installed in this partition and of directories in it. It is a
+	// build error if the image exceeds any of them.
+	Budgets []SizeBudget
+}
+
+// SizeBudget is the maximum size of a module or of a directory in a partition. Exactly one of
+// module and dir must be set.
+type SizeBudget struct {
+	// Name of a module installed in this partition.
+	Module *string
+
+	// Path of a directory in this partition, relative to its root, e.g. "system/app".
+	Dir *string
+
+	// The maximum size in bytes. For erofs images the size of the compressed files is counted,
+	// otherwise their size rounded up to whole blocks.
+	Max_size *int64
+}
+
+// sizeReportOwner is an entry of the file that tells partition_size_report which module installs
+// each file.
+type sizeReportOwner struct {
+	Path      string `json:"path"`
+	Module    string `json:"module"`
+	Variation string `json:"variation,omitempty"`
+}
+
+func (f *filesystem) sizeReportBudgetFlags(ctx android.ModuleContext) []string {
+	var flags []string
+	for i, budget := range f.properties.Size_report.Budgets {
+		prop := fmt.Sprintf("size_report.budgets[%d]", i)
+		if budget.Max_size == nil {
+			ctx.PropertyErrorf(prop, "max_size must be set")
+			continue
+		}
+		switch {
+		case budget.Module != nil && budget.Dir != nil:
+			ctx.PropertyErrorf(prop, "module and dir cannot both be set")
+		case budget.Module != nil:
+			flags = append(flags, fmt.Sprintf("module:%s=%d", *budget.Module, *budget.Max_size))
+		case budget.Dir != nil:
+			flags = append(flags, fmt.Sprintf("dir:%s=%d", filepath.Clean(*budget.Dir), *budget.Max_size))
+		default:
+			ctx.PropertyErrorf(prop, "either module or dir must be set")
+		}
+	}
+	return flags
+}
+
+// writeSizeReportOwners writes the module that installs each file, keyed by the path of the file
+// relative to the root of the partition.
+func (f *filesystem) writeSizeReportOwners(ctx android.ModuleContext, specs map[string]android.PackagingSpec) android.Path {
+	var owners []sizeReportOwner
+	for _, rel := range android.SortedKeys(specs) {
+		spec := specs[rel]
+		path := rel
+		if spec.Partition() != "root" && f.properties.Base_dir != nil {
+			path = filepath.Join(*f.properties.Base_dir, rel)
+		}
+		owners = append(owners, sizeReportOwner{
+			Path:      path,
+			Module:    spec.Owner(),
+			Variation: spec.Variation(),
+		})
+	}
+	content, err := json.Marshal(owners)
+	if err != nil {
+		ctx.ModuleErrorf("failed to write the owners of the files: %s", err)
+		return nil
+	}
+	ownersFile := android.PathForModuleOut(ctx, "size_report", "owners.json")
+	android.WriteFileRule(ctx, ownersFile, string(content))
+	return ownersFile
+}
+
+// sizeReportEnabled returns true if the size report of the image should be built.
+func (f *filesystem) sizeReportEnabled() bool {
+	props := f.properties.Size_report
+	if props.Enabled != nil {
+		return *props.Enabled
+	}
+	return props.Baseline != nil || len(props.Budgets) > 0 || len(f.Dists()) > 0
+}
+
+// buildSizeReport adds a rule that writes the size of each file in the image and the module that
+// installs it. It returns the report as JSON and CSV, and the differences with the baseline report
+// if there is one, and the stamp file of the check of the size budgets if there are any.
+func (f *filesystem) buildSizeReport(ctx android.ModuleContext, rootDir android.Path, image android.Path, specs map[string]android.PackagingSpec) (android.Paths, android.Path) {
+	ownersFile := f.writeSizeReportOwners(ctx, specs)
+	if ownersFile == nil {
+		return nil, nil
+	}
+	jsonReport := android.PathForModuleOut(ctx, "size_report", f.BaseModuleName()+".size_report.json")
+	csvReport := android.PathForModuleOut(ctx, "size_report", f.BaseModuleName()+".size_report.csv")
+	outputs := android.Paths{jsonReport, csvReport}
+
+	builder := android.NewRuleBuilder(pctx, ctx)
+	fsType := f.fsType(ctx)
+	cmd := builder.Command().
+		BuiltTool("partition_size_report").
+		FlagWithArg("--root ", rootDir.String()).
+		Implicit(f.fileystemStagingDirTimestamp(ctx)).
+		FlagWithInput("--owners ", ownersFile).
+		FlagWithArg("--partition ", f.partitionName()).
+		FlagWithArg("--fs_type ", fsType.String()).
+		FlagWithOutput("--json ", jsonReport).
+		FlagWithOutput("--csv ", csvReport)
+	if fsType == erofsType {
+		cmd.FlagWithInput("--image ", image).
+			FlagWithInput("--dump_erofs ", ctx.Config().HostToolPath(ctx, "dump.erofs"))
+		if proptools.BoolDefault(f.properties.Erofs.Sparse, true) {
+			cmd.FlagWithInput("--simg2img ", ctx.Config().HostToolPath(ctx, "simg2img"))
+		}
+	} else {
+		// The sizes are read from the staging directory, but the report describes the image.
+		cmd.Implicit(image)
+	}
+	if baseline := f.properties.Size_report.Baseline; baseline != nil {
+		diff := android.PathForModuleOut(ctx, "size_report", f.BaseModuleName()+".size_report_diff.txt")
+		cmd.FlagWithInput("--baseline ", android.PathForModuleSrc(ctx, *baseline)).
+			FlagWithOutput("--diff ", diff)
+		outputs = append(outputs, diff)
+	}
+	builder.Build("size_report", fmt.Sprintf("Creating size report of %s", f.BaseModuleName()))
+
+	budgetFlags := f.sizeReportBudgetFlags(ctx)
+	if len(budgetFlags) == 0 {
+		return outputs, nil
+	}
+	stamp := android.PathForModuleOut(ctx, "size_report", "budgets.stamp")
+	builder = android.NewRuleBuilder(pctx, ctx)
+	builder.Command().
+		BuiltTool("partition_size_report").
+		FlagWithInput("--report ", jsonReport).
+		FlagForEachArg("--budget ", budgetFlags).
+		FlagWithOutput("--stamp ", stamp)
+	builder.Build("size_budgets", fmt.Sprintf("Checking size budgets of %s", f.BaseModuleName()))
+	return outputs, stamp
+}
@@ -0,0 +1,390 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// The on-disk format of erofs is defined in fs/erofs/erofs_fs.h in the kernel.
+const (
+	erofsSuperblockOffset = 1024
+	erofsSuperblockSize   = 128
+	erofsMagic            = 0xe0f5e1e2
+
+	erofsIncompatZeroPadding  = 0x1
+	erofsIncompatBigPcluster  = 0x2
+	erofsIncompatChunkedFile  = 0x4
+	erofsIncompatDeviceTable  = 0x8
+	erofsIncompatZtailpacking = 0x10
+	erofsIncompatFragments    = 0x20
+	erofsIncompatXattrPrefix  = 0x40
+
+	erofsSupportedIncompat = erofsIncompatZeroPadding | erofsIncompatBigPcluster |
+		erofsIncompatChunkedFile | erofsIncompatDeviceTable | erofsIncompatZtailpacking |
+		erofsIncompatFragments | erofsIncompatXattrPrefix
+
+	erofsSlotSize          = 32
+	erofsCompactInodeSize  = 32
+	erofsExtendedInodeSize = 64
+	erofsDirentSize        = 12
+
+	erofsLayoutFlatPlain         = 0
+	erofsLayoutCompressedFull    = 1
+	erofsLayoutFlatInline        = 2
+	erofsLayoutCompressedCompact = 3
+	erofsLayoutChunkBased        = 4
+
+	erofsChunkFormatBlkbits = 0x1f
+	erofsChunkFormatIndexes = 0x20
+	erofsNullAddr           = 0xffffffff
+	erofsLongXattrPrefix    = 0x80
+)
+
+// The prefixes of the names of extended attributes, by their index.
+var erofsXattrPrefixes = map[uint8]string{
+	1: "user.",
+	2: "system.posix_acl_access",
+	3: "system.posix_acl_default",
+	4: "trusted.",
+	6: "security.",
+}
+
+type erofs struct {
+	r            io.ReaderAt
+	blkBits      uint
+	blockSize    int64
+	dirBlockSize int64
+	rootNid      uint64
+	metaBlkAddr  int64
+	xattrBlkAddr int64
+	incompat     uint32
+}
+
+type erofsInode struct {
+	nid uint64
+	// The offset of the inode in the image.
+	offset int64
+	// The size of the inode and of its inline extended attributes.
+	inodeSize, xattrSize int64
+	layout               int
+	mode                 uint32
+	uid, gid             uint32
+	size                 int64
+	// The start block, the number of compressed blocks or the chunk format, depending on the
+	// layout.
+	u uint32
+}
+
+// dataOffset returns the offset of the data that follows the inode and its extended attributes.
+func (ino *erofsInode) dataOffset() int64 {
+	return ino.offset + ino.inodeSize + ino.xattrSize
+}
+
+func isErofs(r io.ReaderAt) bool {
+	var buf [4]byte
+	if err := readAt(r, buf[:], erofsSuperblockOffset); err != nil {
+		return false
+	}
+	return binary.LittleEndian.Uint32(buf[:]) == erofsMagic
+}
+
+func newErofs(r io.ReaderAt) (*erofs, error) {
+	sb := make([]byte, erofsSuperblockSize)
+	if err := readAt(r, sb, erofsSuperblockOffset); err != nil {
+		return nil, fmt.Errorf("reading erofs superblock: %w", err)
+	}
+	if binary.LittleEndian.Uint32(sb) != erofsMagic {
+		return nil, fmt.Errorf("not an erofs image")
+	}
+	fs := &erofs{
+		r:            r,
+		blkBits:      uint(sb[12]),
+		rootNid:      uint64(binary.LittleEndian.Uint16(sb[14:])),
+		metaBlkAddr:  int64(binary.LittleEndian.Uint32(sb[40:])),
+		xattrBlkAddr: int64(binary.LittleEndian.Uint32(sb[44:])),
+		incompat:     binary.LittleEndian.Uint32(sb[80:]),
+	}
+	if fs.blkBits < 9 || fs.blkBits > 16 || sb[90] > 4 {
+		return nil, fmt.Errorf("invalid erofs superblock")
+	}
+	if unsupported := fs.incompat &^ erofsSupportedIncompat; unsupported != 0 {
+		return nil, fmt.Errorf("unsupported erofs features %#x", unsupported)
+	}
+	fs.blockSize = 1 << fs.blkBits
+	fs.dirBlockSize = fs.blockSize << sb[90]
+	return fs, nil
+}
+
+func (fs *erofs) rootIno() uint64 {
+	return fs.rootNid
+}
+
+func (fs *erofs) readInode(nid uint64) (*erofsInode, error) {
+	ino := &erofsInode{
+		nid:    nid,
+		offset: fs.metaBlkAddr*fs.blockSize + int64(nid)*erofsSlotSize,
+	}
+	buf := make([]byte, erofsExtendedInodeSize)
+	if err := readAt(fs.r, buf[:erofsCompactInodeSize], ino.offset); err != nil {
+		return nil, fmt.Errorf("reading inode %d: %w", nid, err)
+	}
+	format := binary.LittleEndian.Uint16(buf[0:])
+	ino.layout = int(format>>1) & 0x7
+	xattrCount := int64(binary.LittleEndian.Uint16(buf[2:]))
+	if xattrCount > 0 {
+		// The header of the inline extended attributes counts as 12 bytes.
+		ino.xattrSize = 12 + (xattrCount-1)*4
+	}
+	ino.mode = uint32(binary.LittleEndian.Uint16(buf[4:]))
+	ino.u = binary.LittleEndian.Uint32(buf[16:])
+	if format&1 == 0 {
+		ino.inodeSize = erofsCompactInodeSize
+		ino.size = int64(binary.LittleEndian.Uint32(buf[8:]))
+		ino.uid = uint32(binary.LittleEndian.Uint16(buf[24:]))
+		ino.gid = uint32(binary.LittleEndian.Uint16(buf[26:]))
+	} else {
+		if err := readAt(fs.r, buf, ino.offset); err != nil {
+			return nil, fmt.Errorf("reading inode %d: %w", nid, err)
+		}
+		ino.inodeSize = erofsExtendedInodeSize
+		ino.size = int64(binary.LittleEndian.Uint64(buf[8:]))
+		ino.uid = binary.LittleEndian.Uint32(buf[24:])
+		ino.gid = binary.LittleEndian.Uint32(buf[28:])
+	}
+	if ino.size < 0 {
+		return nil, fmt.Errorf("invalid size of inode %d", nid)
+	}
+	return ino, nil
+}
+
+func (fs *erofs) stat(nid uint64, e *Entry) error {
+	ino, err := fs.readInode(nid)
+	if err != nil {
+		return err
+	}
+	e.Mode = ino.mode
+	e.Uid = ino.uid
+	e.Gid = ino.gid
+	e.Size = ino.size
+	if ino.xattrSize > 0 {
+		if e.Xattrs, err = fs.xattrs(ino); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fs *erofs) xattrs(ino *erofsInode) (map[string][]byte, error) {
+	buf := make([]byte, ino.xattrSize)
+	if err := readAt(fs.r, buf, ino.offset+ino.inodeSize); err != nil {
+		return nil, fmt.Errorf("reading xattrs: %w", err)
+	}
+	xattrs := map[string][]byte{}
+	sharedCount := int(buf[4])
+	entries := buf[12:]
+	if len(entries) < sharedCount*4 {
+		return nil, fmt.Errorf("invalid xattr header")
+	}
+	for i := 0; i < sharedCount; i++ {
+		// Shared attributes are referenced by their offset in 4 byte units from the start of the
+		// xattr blocks.
+		id := int64(binary.LittleEndian.Uint32(entries[i*4:]))
+		header := make([]byte, 4)
+		offset := fs.xattrBlkAddr*fs.blockSize + id*4
+		if err := readAt(fs.r, header, offset); err != nil {
+			return nil, fmt.Errorf("reading shared xattr: %w", err)
+		}
+		entry := make([]byte, 4+int(header[0])+int(binary.LittleEndian.Uint16(header[2:])))
+		if err := readAt(fs.r, entry, offset); err != nil {
+			return nil, fmt.Errorf("reading shared xattr: %w", err)
+		}
+		if _, err := parseErofsXattr(entry, xattrs); err != nil {
+			return nil, err
+		}
+	}
+	entries = entries[sharedCount*4:]
+	for len(entries) >= 4 {
+		n, err := parseErofsXattr(entries, xattrs)
+		if err != nil {
+			return nil, err
+		}
+		// Entries are aligned to 4 bytes.
+		n = (n + 3) &^ 3
+		if n > len(entries) {
+			break
+		}
+		entries = entries[n:]
+	}
+	return xattrs, nil
+}
+
+// parseErofsXattr adds the extended attribute at the start of buf to xattrs, and returns the
+// size of its entry.
+func parseErofsXattr(buf []byte, xattrs map[string][]byte) (int, error) {
+	nameLen := int(buf[0])
+	index := buf[1]
+	valueSize := int(binary.LittleEndian.Uint16(buf[2:]))
+	if 4+nameLen+valueSize > len(buf) {
+		return 0, fmt.Errorf("truncated xattr entry")
+	}
+	if index&erofsLongXattrPrefix != 0 {
+		return 0, fmt.Errorf("long xattr name prefixes are not supported")
+	}
+	prefix, ok := erofsXattrPrefixes[index]
+	if !ok && index != 0 {
+		return 0, fmt.Errorf("unknown xattr name index %d", index)
+	}
+	name := prefix + string(buf[4:4+nameLen])
+	xattrs[name] = append([]byte(nil), buf[4+nameLen:4+nameLen+valueSize]...)
+	return 4 + nameLen + valueSize, nil
+}
+
+func (fs *erofs) copyData(nid uint64, w io.Writer) error {
+	ino, err := fs.readInode(nid)
+	if err != nil {
+		return err
+	}
+	switch ino.layout {
+	case erofsLayoutFlatPlain:
+		return copyRange(w, fs.r, int64(ino.u)*fs.blockSize, ino.size)
+	case erofsLayoutFlatInline:
+		// All blocks but the last one are stored from the start block, and the last one right
+		// after the inode.
+		blocks := (ino.size+fs.blockSize-1)/fs.blockSize - 1
+		if blocks < 0 {
+			blocks = 0
+		}
+		if err := copyRange(w, fs.r, int64(ino.u)*fs.blockSize, blocks*fs.blockSize); err != nil {
+			return err
+		}
+		tail := ino.size - blocks*fs.blockSize
+		if ino.dataOffset()%fs.blockSize+tail > fs.blockSize {
+			return fmt.Errorf("inline data crosses a block boundary")
+		}
+		return copyRange(w, fs.r, ino.dataOffset(), tail)
+	case erofsLayoutChunkBased:
+		return fs.copyChunks(ino, w)
+	case erofsLayoutCompressedFull, erofsLayoutCompressedCompact:
+		return fs.copyCompressed(ino, w)
+	}
+	return fmt.Errorf("unknown data layout %d", ino.layout)
+}
+
+// copyChunks copies the data of a file that is split in chunks of a fixed number of blocks,
+// which are used to deduplicate data.
+func (fs *erofs) copyChunks(ino *erofsInode, w io.Writer) error {
+	chunkSize := fs.blockSize << (ino.u & erofsChunkFormatBlkbits)
+	chunks := (ino.size + chunkSize - 1) / chunkSize
+	indexSize := int64(4)
+	if ino.u&erofsChunkFormatIndexes != 0 {
+		indexSize = 8
+	}
+	indexes := make([]byte, chunks*indexSize)
+	start := (ino.dataOffset() + indexSize - 1) &^ (indexSize - 1)
+	if err := readAt(fs.r, indexes, start); err != nil {
+		return fmt.Errorf("reading chunk indexes: %w", err)
+	}
+	for i := int64(0); i < chunks; i++ {
+		var blkAddr uint32
+		if indexSize == 8 {
+			if device := binary.LittleEndian.Uint16(indexes[i*8+2:]); device != 0 {
+				return fmt.Errorf("chunks on extra devices are not supported")
+			}
+			blkAddr = binary.LittleEndian.Uint32(indexes[i*8+4:])
+		} else {
+			blkAddr = binary.LittleEndian.Uint32(indexes[i*4:])
+		}
+		length := chunkSize
+		if (i+1)*chunkSize > ino.size {
+			length = ino.size - i*chunkSize
+		}
+		var err error
+		if blkAddr == erofsNullAddr {
+			err = writeZeros(w, length)
+		} else {
+			err = copyRange(w, fs.r, int64(blkAddr)*fs.blockSize, length)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fs *erofs) readDir(nid uint64) ([]dirent, error) {
+	ino, err := fs.readInode(nid)
+	if err != nil {
+		return nil, err
+	}
+	if ino.mode&ModeType != ModeDir {
+		return nil, fmt.Errorf("not a directory")
+	}
+	buf := &bytes.Buffer{}
+	if err := fs.copyData(nid, buf); err != nil {
+		return nil, err
+	}
+	var entries []dirent
+	data := buf.Bytes()
+	for len(data) > 0 {
+		block := data
+		if int64(len(block)) > fs.dirBlockSize {
+			block = block[:fs.dirBlockSize]
+		}
+		more, err := parseErofsDirents(block)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, more...)
+		data = data[len(block):]
+	}
+	return entries, nil
+}
+
+// parseErofsDirents parses a directory block, which starts with an array of entries followed by
+// their names. The offset of the first name gives the number of entries.
+func parseErofsDirents(block []byte) ([]dirent, error) {
+	if len(block) < erofsDirentSize {
+		return nil, fmt.Errorf("invalid directory block")
+	}
+	count := int(binary.LittleEndian.Uint16(block[8:])) / erofsDirentSize
+	if count == 0 || count*erofsDirentSize > len(block) {
+		return nil, fmt.Errorf("invalid directory block")
+	}
+	entries := make([]dirent, 0, count)
+	for i := 0; i < count; i++ {
+		entry := block[i*erofsDirentSize:]
+		nameStart := int(binary.LittleEndian.Uint16(entry[8:]))
+		nameEnd := len(block)
+		if i+1 < count {
+			nameEnd = int(binary.LittleEndian.Uint16(entry[erofsDirentSize+8:]))
+		}
+		if nameStart > nameEnd || nameEnd > len(block) {
+			return nil, fmt.Errorf("invalid directory entry")
+		}
+		name := block[nameStart:nameEnd]
+		if i+1 == count {
+			// The last name is terminated by the end of the block or by a NUL.
+			if n := bytes.IndexByte(name, 0); n >= 0 {
+				name = name[:n]
+			}
+		}
+		entries = append(entries, dirent{name: string(name), ino: binary.LittleEndian.Uint64(entry)})
+	}
+	return entries, nil
+}
@@ -0,0 +1,357 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsimage
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Compressed files are split in logical clusters (lclusters) of a fixed size. Each lcluster has
+// an index that says whether a physical cluster (pcluster) of compressed data starts in it, and
+// where. The decompressed data of a pcluster continues until the start of the next one. See
+// fs/erofs/zmap.c in the kernel.
+const (
+	erofsLclusterPlain   = 0
+	erofsLclusterHead1   = 1
+	erofsLclusterNonhead = 2
+	erofsLclusterHead2   = 3
+
+	erofsAdviseCompacted2B        = 0x1
+	erofsAdviseBigPcluster1       = 0x2
+	erofsAdviseBigPcluster2       = 0x4
+	erofsAdviseInlinePcluster     = 0x8
+	erofsAdviseInterlacedPcluster = 0x10
+	erofsAdviseFragmentPcluster   = 0x20
+	erofsFragmentInodeBit         = 0x80
+
+	// The flag in the first delta of the lcluster after the head of a big pcluster that says it
+	// holds the number of compressed blocks.
+	erofsD0Cblkcnt = 1 << 11
+
+	erofsCompressionLz4     = 0
+	erofsCompressionLzma    = 1
+	erofsCompressionDeflate = 2
+	erofsCompressionZstd    = 3
+)
+
+type erofsLcluster struct {
+	typ        int
+	clusterofs int64
+	// The start block of the pcluster for head lclusters.
+	pblk int64
+	// The number of compressed blocks of the pcluster, if it is set in this lcluster.
+	compressedBlocks int64
+}
+
+type erofsZmap struct {
+	fs           *erofs
+	ino          *erofsInode
+	advise       uint16
+	algorithms   [2]int
+	lclusterBits uint
+	lclusters    int64
+	// The offset of the map header, which is followed by the lcluster indexes.
+	headerOffset int64
+}
+
+func (fs *erofs) newZmap(ino *erofsInode) (*erofsZmap, error) {
+	z := &erofsZmap{
+		fs:           fs,
+		ino:          ino,
+		headerOffset: (ino.dataOffset() + 7) &^ 7,
+	}
+	header := make([]byte, 8)
+	if err := readAt(fs.r, header, z.headerOffset); err != nil {
+		return nil, fmt.Errorf("reading compression header: %w", err)
+	}
+	z.advise = binary.LittleEndian.Uint16(header[4:])
+	z.algorithms = [2]int{int(header[6] & 0xf), int(header[6] >> 4)}
+	z.lclusterBits = fs.blkBits + uint(header[7]&0x7)
+	if header[7]&erofsFragmentInodeBit != 0 || z.advise&erofsAdviseFragmentPcluster != 0 {
+		return nil, fmt.Errorf("compressed fragments are not supported")
+	}
+	if z.advise&erofsAdviseInlinePcluster != 0 {
+		return nil, fmt.Errorf("tail-packed compressed data is not supported")
+	}
+	if ino.layout == erofsLayoutCompressedCompact && z.lclusterBits != fs.blkBits {
+		return nil, fmt.Errorf("compacted indexes with lclusters larger than a block are not supported")
+	}
+	z.lclusters = (ino.size + 1<<z.lclusterBits - 1) >> z.lclusterBits
+	return z, nil
+}
+
+func (z *erofsZmap) bigPcluster(typ int) bool {
+	switch typ {
+	case erofsLclusterHead1:
+		return z.advise&erofsAdviseBigPcluster1 != 0
+	case erofsLclusterHead2:
+		return z.advise&erofsAdviseBigPcluster2 != 0
+	}
+	return false
+}
+
+func (z *erofsZmap) load(lcn int64) (erofsLcluster, error) {
+	if z.ino.layout == erofsLayoutCompressedFull {
+		return z.loadFull(lcn)
+	}
+	return z.loadCompacted(lcn)
+}
+
+// loadFull reads an lcluster index of the legacy format, which uses 8 bytes per lcluster.
+func (z *erofsZmap) loadFull(lcn int64) (erofsLcluster, error) {
+	buf := make([]byte, 8)
+	if err := readAt(z.fs.r, buf, z.headerOffset+16+lcn*8); err != nil {
+		return erofsLcluster{}, fmt.Errorf("reading lcluster %d: %w", lcn, err)
+	}
+	lc := erofsLcluster{typ: int(binary.LittleEndian.Uint16(buf) & 0x3)}
+	if lc.typ == erofsLclusterNonhead {
+		if delta0 := int64(binary.LittleEndian.Uint16(buf[4:])); delta0&erofsD0Cblkcnt != 0 {
+			lc.compressedBlocks = delta0 &^ erofsD0Cblkcnt
+		}
+		return lc, nil
+	}
+	lc.clusterofs = int64(binary.LittleEndian.Uint16(buf[2:]))
+	lc.pblk = int64(binary.LittleEndian.Uint32(buf[4:]))
+	return lc, nil
+}
+
+// loadCompacted reads an lcluster index of the compacted format, which packs the indexes of 2
+// lclusters in 8 bytes or of 16 lclusters in 32 bytes. Each pack ends with the start block of
+// its first pcluster, and the start blocks of the others are derived from it.
+func (z *erofsZmap) loadCompacted(lcn int64) (erofsLcluster, error) {
+	if lcn >= z.lclusters {
+		return erofsLcluster{}, fmt.Errorf("lcluster %d out of range", lcn)
+	}
+	base := z.headerOffset + 8
+	// The first 4 byte packs align the 2 byte packs to 32 bytes.
+	initial4B := (32 - base%32) / 4
+	if initial4B == 8 {
+		initial4B = 0
+	}
+	var compacted2B int64
+	if z.advise&erofsAdviseCompacted2B != 0 && initial4B < z.lclusters {
+		compacted2B = (z.lclusters - initial4B) &^ 15
+	}
+
+	pos := base
+	shift := uint(2)
+	switch {
+	case lcn < initial4B:
+	case lcn < initial4B+compacted2B:
+		pos += initial4B * 4
+		lcn -= initial4B
+		shift = 1
+	default:
+		pos += initial4B*4 + compacted2B*2
+		lcn -= initial4B + compacted2B
+	}
+	pos += lcn << shift
+
+	vcnt := int64(2)
+	if shift == 1 {
+		vcnt = 16
+	}
+	packSize := vcnt << shift
+	pack := make([]byte, packSize)
+	packStart := pos &^ (packSize - 1)
+	if err := readAt(z.fs.r, pack, packStart); err != nil {
+		return erofsLcluster{}, fmt.Errorf("reading lcluster %d: %w", lcn, err)
+	}
+	encodeBits := (packSize - 4) * 8 / vcnt
+	loBits := z.lclusterBits
+	if loBits < 12 {
+		loBits = 12
+	}
+	decode := func(i int64) (int64, int) {
+		bit := encodeBits * i
+		v := binary.LittleEndian.Uint32(pack[bit/8:]) >> (bit % 8)
+		return int64(v & (1<<loBits - 1)), int(v>>loBits) & 3
+	}
+
+	i := (pos - packStart) >> shift
+	lo, typ := decode(i)
+	lc := erofsLcluster{typ: typ}
+	if typ == erofsLclusterNonhead {
+		if lo&erofsD0Cblkcnt != 0 {
+			lc.compressedBlocks = lo &^ erofsD0Cblkcnt
+		}
+		return lc, nil
+	}
+	lc.clusterofs = lo
+
+	// Count the blocks of the pclusters before this one in the pack.
+	var blocks int64
+	if z.advise&(erofsAdviseBigPcluster1|erofsAdviseBigPcluster2) == 0 {
+		for i > 0 {
+			i--
+			lo, typ := decode(i)
+			if typ == erofsLclusterNonhead {
+				i -= lo
+			}
+			if i >= 0 {
+				blocks++
+			}
+		}
+	} else {
+		for i > 0 {
+			i--
+			lo, typ := decode(i)
+			if typ == erofsLclusterNonhead {
+				if lo&erofsD0Cblkcnt != 0 {
+					i--
+					blocks += lo &^ erofsD0Cblkcnt
+					continue
+				}
+				if lo <= 1 {
+					return erofsLcluster{}, fmt.Errorf("invalid lcluster %d", lcn)
+				}
+				i -= lo - 2
+				continue
+			}
+			blocks++
+		}
+	}
+	lc.pblk = int64(binary.LittleEndian.Uint32(pack[packSize-4:])) + blocks
+	return lc, nil
+}
+
+// erofsPcluster is the compressed data of a range of a file.
+type erofsPcluster struct {
+	// The offset of the decompressed data in the file.
+	offset int64
+	typ    int
+	pblk   int64
+	blocks int64
+}
+
+// pclusters returns the pclusters of the file in order.
+func (z *erofsZmap) pclusters() ([]erofsPcluster, error) {
+	var pclusters []erofsPcluster
+	for lcn := int64(0); lcn < z.lclusters; lcn++ {
+		lc, err := z.load(lcn)
+		if err != nil {
+			return nil, err
+		}
+		if lc.typ == erofsLclusterNonhead {
+			if lcn == 0 {
+				return nil, fmt.Errorf("the first lcluster isn't a head")
+			}
+			continue
+		}
+		p := erofsPcluster{
+			offset: lcn<<z.lclusterBits + lc.clusterofs,
+			typ:    lc.typ,
+			pblk:   lc.pblk,
+			blocks: 1,
+		}
+		if p.offset >= z.ino.size {
+			break
+		}
+		if z.bigPcluster(lc.typ) && lcn+1 < z.lclusters {
+			next, err := z.load(lcn + 1)
+			if err != nil {
+				return nil, err
+			}
+			if next.typ == erofsLclusterNonhead && next.compressedBlocks > 0 {
+				p.blocks = next.compressedBlocks
+			}
+		}
+		pclusters = append(pclusters, p)
+	}
+	if len(pclusters) == 0 && z.ino.size > 0 {
+		return nil, fmt.Errorf("no compressed data")
+	}
+	return pclusters, nil
+}
+
+func (fs *erofs) copyCompressed(ino *erofsInode, w io.Writer) error {
+	if ino.size == 0 {
+		return nil
+	}
+	z, err := fs.newZmap(ino)
+	if err != nil {
+		return err
+	}
+	pclusters, err := z.pclusters()
+	if err != nil {
+		return err
+	}
+	for i, p := range pclusters {
+		end := ino.size
+		if i+1 < len(pclusters) {
+			end = pclusters[i+1].offset
+		}
+		if end < p.offset {
+			return fmt.Errorf("pclusters out of order")
+		}
+		data, err := z.decompress(p, end-p.offset)
+		if err != nil {
+			return fmt.Errorf("pcluster at %d: %w", p.offset, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decompress returns the first length bytes of the decompressed data of a pcluster.
+func (z *erofsZmap) decompress(p erofsPcluster, length int64) ([]byte, error) {
+	raw := make([]byte, p.blocks*z.fs.blockSize)
+	if err := readAt(z.fs.r, raw, p.pblk*z.fs.blockSize); err != nil {
+		return nil, err
+	}
+	if p.typ == erofsLclusterPlain {
+		if length > int64(len(raw)) {
+			return nil, fmt.Errorf("uncompressed data is too long")
+		}
+		if z.advise&erofsAdviseInterlacedPcluster == 0 {
+			return raw[:length], nil
+		}
+		// Interlaced uncompressed data starts at the offset of the data in its block, and wraps
+		// around to the start of the pcluster.
+		shift := p.offset % int64(len(raw))
+		return append(append([]byte(nil), raw[shift:]...), raw[:shift]...)[:length], nil
+	}
+
+	algorithm := z.algorithms[0]
+	if p.typ == erofsLclusterHead2 {
+		algorithm = z.algorithms[1]
+	}
+	// With zero padding, the compressed data is at the end of the pcluster. It is always used
+	// by algorithms other than LZ4.
+	if z.fs.incompat&erofsIncompatZeroPadding != 0 || algorithm != erofsCompressionLz4 {
+		raw = bytes.TrimLeft(raw, "\x00")
+	}
+	switch algorithm {
+	case erofsCompressionLz4:
+		return lz4Decompress(raw, int(length))
+	case erofsCompressionDeflate:
+		data := make([]byte, length)
+		if _, err := io.ReadFull(flate.NewReader(bytes.NewReader(raw)), data); err != nil {
+			return nil, fmt.Errorf("deflate: %w", err)
+		}
+		return data, nil
+	case erofsCompressionLzma:
+		return nil, fmt.Errorf("LZMA compression is not supported")
+	case erofsCompressionZstd:
+		return nil, fmt.Errorf("zstd compression is not supported")
+	}
+	return nil, fmt.Errorf("unknown compression algorithm %d", algorithm)
+}
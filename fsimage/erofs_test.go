@@ -0,0 +1,557 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsimage
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const (
+	testErofsBlockSize  = 4096
+	testErofsXattrBlock = 1
+	testErofsMetaBlock  = 2
+	testErofsDataBlock  = 16
+)
+
+// erofsBuilder writes an erofs image with 4K blocks. The shared extended attributes are in block
+// 1, the inodes in blocks 2 to 15 and the data from block 16.
+type erofsBuilder struct {
+	t        *testing.T
+	incompat uint32
+	// Whether SELinux labels are shared between inodes.
+	shareLabels bool
+	// Whether files larger than a block are split in chunks of one block.
+	chunked bool
+	// Compressed files by path, which are written instead of the data of the test files.
+	compressed map[string]erofsCompressedFile
+
+	meta, data, shared []byte
+	sharedIds          map[string]uint32
+}
+
+// erofsCompressedFile is a compressed file whose data has already been added to the builder.
+type erofsCompressedFile struct {
+	layout int
+	// indexes returns the map header and the lcluster indexes that follow the inode and its
+	// extended attributes at offset.
+	indexes func(offset int64) []byte
+}
+
+func (b *erofsBuilder) addData(data []byte) uint32 {
+	block := testErofsDataBlock + uint32(len(b.data)/testErofsBlockSize)
+	b.data = append(b.data, data...)
+	b.data = append(b.data, make([]byte, -len(b.data)&(testErofsBlockSize-1))...)
+	return block
+}
+
+func erofsXattrEntry(name, value string) []byte {
+	var index uint8
+	for i, prefix := range erofsXattrPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			index, name = i, strings.TrimPrefix(name, prefix)
+			break
+		}
+	}
+	entry := []byte{uint8(len(name)), index, 0, 0}
+	binary.LittleEndian.PutUint16(entry[2:], uint16(len(value)))
+	entry = append(append(entry, name...), value...)
+	return append(entry, make([]byte, -len(entry)&3)...)
+}
+
+// encodeXattrs returns the extended attributes that follow an inode.
+func (b *erofsBuilder) encodeXattrs(xattrs map[string]string) []byte {
+	if len(xattrs) == 0 {
+		return nil
+	}
+	var names []string
+	for name := range xattrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var ids, entries []byte
+	for _, name := range names {
+		if b.shareLabels && name == selinuxXattr {
+			key := name + "=" + xattrs[name]
+			id, ok := b.sharedIds[key]
+			if !ok {
+				id = uint32(len(b.shared) / 4)
+				b.shared = append(b.shared, erofsXattrEntry(name, xattrs[name])...)
+				b.sharedIds[key] = id
+			}
+			ids = binary.LittleEndian.AppendUint32(ids, id)
+			continue
+		}
+		entries = append(entries, erofsXattrEntry(name, xattrs[name])...)
+	}
+	header := make([]byte, 12)
+	header[4] = uint8(len(ids) / 4)
+	return append(append(header, ids...), entries...)
+}
+
+func encodeErofsInode(f testFile, size int64, layout int, u uint32, xattrs []byte) []byte {
+	var inode []byte
+	icount := 0
+	if len(xattrs) > 0 {
+		icount = (len(xattrs)-12)/4 + 1
+	}
+	if f.uid > 0xffff || f.gid > 0xffff {
+		inode = make([]byte, erofsExtendedInodeSize)
+		binary.LittleEndian.PutUint16(inode[0:], uint16(layout<<1|1))
+		binary.LittleEndian.PutUint64(inode[8:], uint64(size))
+		binary.LittleEndian.PutUint32(inode[24:], f.uid)
+		binary.LittleEndian.PutUint32(inode[28:], f.gid)
+		binary.LittleEndian.PutUint32(inode[44:], 1)
+	} else {
+		inode = make([]byte, erofsCompactInodeSize)
+		binary.LittleEndian.PutUint16(inode[0:], uint16(layout<<1))
+		binary.LittleEndian.PutUint16(inode[6:], 1)
+		binary.LittleEndian.PutUint32(inode[8:], uint32(size))
+		binary.LittleEndian.PutUint16(inode[24:], uint16(f.uid))
+		binary.LittleEndian.PutUint16(inode[26:], uint16(f.gid))
+	}
+	binary.LittleEndian.PutUint16(inode[2:], uint16(icount))
+	binary.LittleEndian.PutUint16(inode[4:], uint16(f.mode))
+	binary.LittleEndian.PutUint32(inode[16:], u)
+	return append(inode, xattrs...)
+}
+
+// erofsDirBlocks encodes the entries of a directory in blocks, each with an array of entries
+// followed by their names.
+func erofsDirBlocks(names []string, nids map[string]uint64, modes map[string]uint32) []byte {
+	var data []byte
+	for len(names) > 0 {
+		n, size := 0, 0
+		for n < len(names) && size+erofsDirentSize+len(names[n]) <= testErofsBlockSize {
+			size += erofsDirentSize + len(names[n])
+			n++
+		}
+		block := make([]byte, n*erofsDirentSize)
+		for i, name := range names[:n] {
+			entry := block[i*erofsDirentSize:]
+			binary.LittleEndian.PutUint64(entry[0:], nids[name])
+			binary.LittleEndian.PutUint16(entry[8:], uint16(len(block)))
+			switch modes[name] & ModeType {
+			case ModeRegular:
+				entry[10] = 1
+			case ModeDir:
+				entry[10] = 2
+			case ModeSymlink:
+				entry[10] = 7
+			}
+			block = append(block, name...)
+		}
+		names = names[n:]
+		if len(names) > 0 {
+			block = append(block, make([]byte, testErofsBlockSize-len(block))...)
+		}
+		data = append(data, block...)
+	}
+	return data
+}
+
+// erofsNode is an inode whose size in the metadata is known before its data is written.
+type erofsNode struct {
+	f      testFile
+	nid    uint64
+	xattrs []byte
+	// The inline data for flat inline layouts.
+	tail []byte
+}
+
+func (b *erofsBuilder) build(files []testFile) []byte {
+	b.sharedIds = map[string]uint32{}
+	tree := testTree(files)
+	nodes := map[string]*erofsNode{".": {f: testFile{path: ".", mode: ModeDir | 0755}}}
+	for _, f := range files {
+		nodes[f.path] = &erofsNode{f: f}
+	}
+	paths := make([]string, 0, len(nodes))
+	for p := range nodes {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	// Reserve the space of each inode in the metadata. Inodes are aligned to 32 bytes, and
+	// don't cross blocks with their inline data.
+	place := func(size int) uint64 {
+		b.meta = append(b.meta, make([]byte, -len(b.meta)&(erofsSlotSize-1))...)
+		if len(b.meta)%testErofsBlockSize+size > testErofsBlockSize {
+			b.meta = append(b.meta, make([]byte, -len(b.meta)&(testErofsBlockSize-1))...)
+		}
+		nid := uint64(len(b.meta) / erofsSlotSize)
+		b.meta = append(b.meta, make([]byte, size)...)
+		return nid
+	}
+	for _, p := range paths {
+		n := nodes[p]
+		n.xattrs = b.encodeXattrs(n.f.xattrs)
+		data := []byte(n.f.data)
+		if n.f.mode&ModeType == ModeSymlink {
+			data = []byte(n.f.link)
+		}
+		inodeSize := len(encodeErofsInode(n.f, 0, 0, 0, n.xattrs))
+		if c, ok := b.compressed[p]; ok {
+			n.nid = place(inodeSize + len(c.indexes(0)) + 8)
+			continue
+		}
+		if _, ok := tree[p]; !ok && !b.chunked && len(data)%testErofsBlockSize != 0 {
+			n.tail = data[len(data)&^(testErofsBlockSize-1):]
+		}
+		if _, ok := tree[p]; !ok && b.chunked && len(data) > testErofsBlockSize {
+			// Leave space for the chunk indexes.
+			inodeSize += 4 + (len(data)+testErofsBlockSize-1)/testErofsBlockSize*4
+		}
+		n.nid = place(inodeSize + len(n.tail))
+	}
+
+	for _, p := range paths {
+		n := nodes[p]
+		offset := int64(testErofsMetaBlock*testErofsBlockSize) + int64(n.nid)*erofsSlotSize
+		data := []byte(n.f.data)
+		if n.f.mode&ModeType == ModeSymlink {
+			data = []byte(n.f.link)
+		}
+		var inode []byte
+		if children, ok := tree[p]; ok {
+			names := append([]string{".", ".."}, children...)
+			sort.Strings(names)
+			nids := map[string]uint64{".": n.nid, "..": nodes[path.Dir(p)].nid}
+			modes := map[string]uint32{".": ModeDir, "..": ModeDir}
+			for _, child := range children {
+				nids[child] = nodes[path.Join(p, child)].nid
+				modes[child] = nodes[path.Join(p, child)].f.mode
+			}
+			data = erofsDirBlocks(names, nids, modes)
+			inode = encodeErofsInode(n.f, int64(len(data)), erofsLayoutFlatPlain, b.addData(data), n.xattrs)
+		} else if c, ok := b.compressed[p]; ok {
+			inode = encodeErofsInode(n.f, int64(len(data)), c.layout, 0, n.xattrs)
+			inode = append(inode, c.indexes(offset+int64(len(inode)))...)
+		} else if b.chunked && len(data) > testErofsBlockSize {
+			inode = encodeErofsInode(n.f, int64(len(data)), erofsLayoutChunkBased, 0, n.xattrs)
+			inode = append(inode, make([]byte, -(offset+int64(len(inode)))&3)...)
+			for i := 0; i < len(data); i += testErofsBlockSize {
+				block := data[i:]
+				if len(block) > testErofsBlockSize {
+					block = block[:testErofsBlockSize]
+				}
+				addr := uint32(erofsNullAddr)
+				if bytes.Count(block, []byte{0}) != len(block) {
+					addr = b.addData(block)
+				}
+				inode = binary.LittleEndian.AppendUint32(inode, addr)
+			}
+		} else {
+			layout, u := erofsLayoutFlatPlain, uint32(0)
+			if n.tail != nil {
+				layout = erofsLayoutFlatInline
+			}
+			if full := len(data) - len(n.tail); full > 0 {
+				u = b.addData(data[:full])
+			}
+			inode = append(encodeErofsInode(n.f, int64(len(data)), layout, u, n.xattrs), n.tail...)
+		}
+		copy(b.meta[n.nid*erofsSlotSize:], inode)
+	}
+
+	if len(b.meta) > (testErofsDataBlock-testErofsMetaBlock)*testErofsBlockSize ||
+		len(b.shared) > testErofsBlockSize {
+		b.t.Fatalf("too many files")
+	}
+	img := make([]byte, testErofsDataBlock*testErofsBlockSize)
+	copy(img[testErofsXattrBlock*testErofsBlockSize:], b.shared)
+	copy(img[testErofsMetaBlock*testErofsBlockSize:], b.meta)
+	img = append(img, b.data...)
+
+	sb := img[erofsSuperblockOffset:]
+	binary.LittleEndian.PutUint32(sb[0:], erofsMagic)
+	sb[12] = 12
+	binary.LittleEndian.PutUint16(sb[14:], uint16(nodes["."].nid))
+	binary.LittleEndian.PutUint32(sb[36:], uint32(len(img)/testErofsBlockSize))
+	binary.LittleEndian.PutUint32(sb[40:], testErofsMetaBlock)
+	binary.LittleEndian.PutUint32(sb[44:], testErofsXattrBlock)
+	binary.LittleEndian.PutUint32(sb[80:], b.incompat)
+	return img
+}
+
+func TestErofs(t *testing.T) {
+	testCases := []struct {
+		name    string
+		builder erofsBuilder
+	}{
+		{name: "flat"},
+		{name: "shared xattrs", builder: erofsBuilder{shareLabels: true}},
+		{name: "chunks", builder: erofsBuilder{chunked: true, incompat: erofsIncompatChunkedFile}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.builder.t = t
+			img, err := Open(bytes.NewReader(tc.builder.build(testFiles)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img.Type != "erofs" || img.Sparse {
+				t.Errorf("expected a raw erofs image, got %s", img.Type)
+			}
+			checkImage(t, img, testFiles)
+			checkLookup(t, img)
+		})
+	}
+}
+
+// lz4Sequence encodes literals followed by a match in the LZ4 block format. The last sequence
+// of a block has no match.
+func lz4Sequence(literals []byte, offset, matchLength int) []byte {
+	length := func(l int) []byte {
+		var ext []byte
+		for l -= 15; l >= 255; l -= 255 {
+			ext = append(ext, 255)
+		}
+		return append(ext, byte(l))
+	}
+	var token byte
+	var litExt, matchExt []byte
+	if len(literals) >= 15 {
+		token, litExt = 15<<4, length(len(literals))
+	} else {
+		token = byte(len(literals)) << 4
+	}
+	seq := append(append([]byte{0}, litExt...), literals...)
+	if matchLength > 0 {
+		if matchLength-4 >= 15 {
+			token, matchExt = token|15, length(matchLength-4)
+		} else {
+			token |= byte(matchLength - 4)
+		}
+		seq = append(seq, byte(offset), byte(offset>>8))
+		seq = append(seq, matchExt...)
+	}
+	seq[0] = token
+	return seq
+}
+
+// lz4Repeat encodes data that repeats a pattern in the LZ4 block format.
+func lz4Repeat(data []byte, pattern int) []byte {
+	end := len(data) - 5
+	return append(lz4Sequence(data[:pattern], pattern, end-pattern), lz4Sequence(data[end:], 0, 0)...)
+}
+
+// zeroPadded pads compressed data at the start to fill its blocks.
+func zeroPadded(data []byte, blocks int) []byte {
+	return append(make([]byte, blocks*testErofsBlockSize-len(data)), data...)
+}
+
+func erofsMapHeader(offset int64, advise uint16, algorithms byte) []byte {
+	header := make([]byte, -offset&7+8)
+	binary.LittleEndian.PutUint16(header[len(header)-4:], advise)
+	header[len(header)-2] = algorithms
+	return header
+}
+
+func TestErofsCompressedFull(t *testing.T) {
+	// Three pclusters: LZ4, DEFLATE and uncompressed.
+	data := append([]byte(strings.Repeat("abcd", 1500)), strings.Repeat("deflate ", 375)...)
+	data = append(data, strings.Repeat("p", 1000)...)
+
+	var deflated bytes.Buffer
+	w, _ := flate.NewWriter(&deflated, flate.BestCompression)
+	w.Write(data[6000:9000])
+	w.Close()
+
+	b := erofsBuilder{t: t, incompat: erofsIncompatZeroPadding}
+	lz4Block := b.addData(zeroPadded(lz4Repeat(data[:6000], 4), 1))
+	deflateBlock := b.addData(zeroPadded(deflated.Bytes(), 1))
+	plainBlock := b.addData(data[9000:])
+	b.compressed = map[string]erofsCompressedFile{
+		"lib/libbar.so": {
+			layout: erofsLayoutCompressedFull,
+			indexes: func(offset int64) []byte {
+				indexes := erofsMapHeader(offset, 0, erofsCompressionDeflate<<4|erofsCompressionLz4)
+				indexes = append(indexes, make([]byte, 8)...)
+				for _, lc := range []struct {
+					typ        int
+					clusterofs uint16
+					blkaddr    uint32
+				}{
+					{erofsLclusterHead1, 0, lz4Block},
+					{erofsLclusterHead2, 6000 - 4096, deflateBlock},
+					{erofsLclusterPlain, 9000 - 8192, plainBlock},
+				} {
+					index := make([]byte, 8)
+					binary.LittleEndian.PutUint16(index[0:], uint16(lc.typ))
+					binary.LittleEndian.PutUint16(index[2:], lc.clusterofs)
+					binary.LittleEndian.PutUint32(index[4:], lc.blkaddr)
+					indexes = append(indexes, index...)
+				}
+				return indexes
+			},
+		},
+	}
+	files := []testFile{
+		{path: "lib", mode: ModeDir | 0755},
+		{path: "lib/libbar.so", mode: ModeRegular | 0644, data: string(data),
+			xattrs: map[string]string{selinuxXattr: "u:object_r:system_lib_file:s0"}},
+	}
+	img, err := Open(bytes.NewReader(b.build(files)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkImage(t, img, files)
+}
+
+// compactedIndexes encodes lcluster indexes in the compacted format. lo is the cluster offset of
+// head lclusters and the distance to the head or to the next head of non-head lclusters, and
+// blkaddrs is the start block of each head lcluster.
+func compactedIndexes(offset int64, advise uint16, types []int, lo []uint32, blkaddrs []uint32) []byte {
+	indexes := erofsMapHeader(offset, advise, erofsCompressionLz4)
+	base := offset + int64(len(indexes))
+	n := len(types)
+	initial4B := int((32 - base%32) / 4 % 8)
+	compacted2B := 0
+	if advise&erofsAdviseCompacted2B != 0 && initial4B < n {
+		compacted2B = (n - initial4B) &^ 15
+	}
+	pack := func(first, vcnt, packSize int) {
+		buf := make([]byte, packSize)
+		encodeBits := (packSize - 4) * 8 / vcnt
+		var blkaddr uint32
+		found := false
+		for i := 0; i < vcnt && first+i < n; i++ {
+			lcn := first + i
+			v := lo[lcn] | uint32(types[lcn])<<12
+			bit := encodeBits * i
+			word := binary.LittleEndian.Uint32(buf[bit/8:]) | v<<(bit%8)
+			binary.LittleEndian.PutUint32(buf[bit/8:], word)
+			if !found && types[lcn] != erofsLclusterNonhead {
+				blkaddr, found = blkaddrs[lcn], true
+			}
+		}
+		binary.LittleEndian.PutUint32(buf[packSize-4:], blkaddr)
+		indexes = append(indexes, buf...)
+	}
+	lcn := 0
+	for ; lcn < initial4B && lcn < n; lcn += 2 {
+		pack(lcn, 2, 8)
+	}
+	for ; lcn < initial4B+compacted2B; lcn += 16 {
+		pack(lcn, 16, 32)
+	}
+	for ; lcn < n; lcn += 2 {
+		pack(lcn, 2, 8)
+	}
+	return indexes
+}
+
+func TestErofsCompressedCompacted(t *testing.T) {
+	t.Run("big pcluster", func(t *testing.T) {
+		data := append([]byte(strings.Repeat("0123456789abcdef", 512)), strings.Repeat("z", 500)...)
+		b := erofsBuilder{t: t, incompat: erofsIncompatZeroPadding | erofsIncompatBigPcluster}
+		big := b.addData(zeroPadded(lz4Repeat(data[:8192], 16), 2))
+		small := b.addData(zeroPadded(lz4Repeat(data[8192:], 1), 1))
+		b.compressed = map[string]erofsCompressedFile{
+			"app.apk": {
+				layout: erofsLayoutCompressedCompact,
+				indexes: func(offset int64) []byte {
+					return compactedIndexes(offset, erofsAdviseBigPcluster1,
+						[]int{erofsLclusterHead1, erofsLclusterNonhead, erofsLclusterHead1},
+						[]uint32{0, erofsD0Cblkcnt | 2, 0},
+						[]uint32{big, 0, small})
+				},
+			},
+		}
+		files := []testFile{{path: "app.apk", mode: ModeRegular | 0644, data: string(data)}}
+		img, err := Open(bytes.NewReader(b.build(files)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkImage(t, img, files)
+	})
+
+	t.Run("2B indexes", func(t *testing.T) {
+		// Every fifth lcluster continues the pcluster of the previous one, and the other
+		// pclusters start at varying offsets in their lclusters.
+		const lclusters = 41
+		types := make([]int, lclusters)
+		lo := make([]uint32, lclusters)
+		blkaddrs := make([]uint32, lclusters)
+		var starts []int
+		for i := range types {
+			if i%5 == 4 {
+				types[i] = erofsLclusterNonhead
+				lo[i] = 1
+				continue
+			}
+			types[i] = erofsLclusterHead1
+			lo[i] = uint32(i * 100 % 4000)
+			starts = append(starts, i*testErofsBlockSize+int(lo[i]))
+		}
+		size := (lclusters-1)*testErofsBlockSize + 50
+		data := make([]byte, size)
+		b := erofsBuilder{t: t, incompat: erofsIncompatZeroPadding}
+		head := 0
+		for i, start := range starts {
+			end := size
+			if i+1 < len(starts) {
+				end = starts[i+1]
+			}
+			for j := start; j < end; j++ {
+				data[j] = byte('a' + i%26)
+			}
+			for types[head] == erofsLclusterNonhead {
+				head++
+			}
+			blkaddrs[head] = b.addData(zeroPadded(lz4Repeat(data[start:end], 1), 1))
+			head++
+		}
+		b.compressed = map[string]erofsCompressedFile{
+			"framework.jar": {
+				layout: erofsLayoutCompressedCompact,
+				indexes: func(offset int64) []byte {
+					return compactedIndexes(offset, erofsAdviseCompacted2B, types, lo, blkaddrs)
+				},
+			},
+		}
+		files := []testFile{{path: "framework.jar", mode: ModeRegular | 0644, data: string(data)}}
+		img, err := Open(bytes.NewReader(b.build(files)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkImage(t, img, files)
+	})
+}
+
+func TestErofsUnsupportedCompression(t *testing.T) {
+	b := erofsBuilder{t: t}
+	b.compressed = map[string]erofsCompressedFile{
+		"foo": {
+			layout: erofsLayoutCompressedFull,
+			indexes: func(offset int64) []byte {
+				return erofsMapHeader(offset, erofsAdviseInlinePcluster, erofsCompressionLz4)
+			},
+		},
+	}
+	img, err := Open(bytes.NewReader(b.build([]testFile{{path: "foo", mode: ModeRegular | 0644, data: "foo"}})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := img.ReadFile("foo"); err == nil || !strings.Contains(err.Error(), "tail-packed") {
+		t.Errorf("expected an error for tail-packed data, got %v", err)
+	}
+}
@@ -0,0 +1,528 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsimage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// The on-disk format of ext4 is described in
+// https://www.kernel.org/doc/html/latest/filesystems/ext4/index.html.
+const (
+	ext4SuperblockOffset = 1024
+	ext4SuperblockSize   = 1024
+	ext4Magic            = 0xef53
+	ext4RootIno          = 2
+
+	ext4IncompatFiletype   = 0x2
+	ext4IncompatRecover    = 0x4
+	ext4IncompatExtents    = 0x40
+	ext4Incompat64bit      = 0x80
+	ext4IncompatMMP        = 0x100
+	ext4IncompatFlexBg     = 0x200
+	ext4IncompatCsumSeed   = 0x2000
+	ext4IncompatLargedir   = 0x4000
+	ext4IncompatInlineData = 0x8000
+	ext4IncompatCasefold   = 0x20000
+
+	ext4SupportedIncompat = ext4IncompatFiletype | ext4IncompatExtents | ext4Incompat64bit |
+		ext4IncompatMMP | ext4IncompatFlexBg | ext4IncompatCsumSeed | ext4IncompatLargedir |
+		ext4IncompatInlineData | ext4IncompatCasefold
+
+	ext4ExtentsFlag    = 0x80000
+	ext4InlineDataFlag = 0x10000000
+
+	ext4GoodOldInodeSize = 128
+	ext4InlineBlockSize  = 60
+	ext4ExtentMagic      = 0xf30a
+	ext4MaxInitExtentLen = 32768
+	ext4XattrMagic       = 0xea020000
+	ext4InlineDataXattr  = "system.data"
+)
+
+// The prefixes of the names of extended attributes, by their index.
+var ext4XattrPrefixes = map[uint8]string{
+	1: "user.",
+	2: "system.posix_acl_access",
+	3: "system.posix_acl_default",
+	4: "trusted.",
+	6: "security.",
+	7: "system.",
+	8: "system.richacl",
+}
+
+type ext4 struct {
+	r              io.ReaderAt
+	blockSize      int64
+	inodeSize      int64
+	inodesCount    uint32
+	inodesPerGroup uint32
+	firstDataBlock int64
+	blocksCount    int64
+	descSize       int64
+	incompat       uint32
+}
+
+func isExt4(r io.ReaderAt) bool {
+	var buf [2]byte
+	if err := readAt(r, buf[:], ext4SuperblockOffset+0x38); err != nil {
+		return false
+	}
+	return binary.LittleEndian.Uint16(buf[:]) == ext4Magic
+}
+
+func newExt4(r io.ReaderAt) (*ext4, error) {
+	sb := make([]byte, ext4SuperblockSize)
+	if err := readAt(r, sb, ext4SuperblockOffset); err != nil {
+		return nil, fmt.Errorf("reading ext4 superblock: %w", err)
+	}
+	if binary.LittleEndian.Uint16(sb[0x38:]) != ext4Magic {
+		return nil, fmt.Errorf("not an ext4 image")
+	}
+	fs := &ext4{
+		r:              r,
+		blockSize:      1024 << binary.LittleEndian.Uint32(sb[0x18:]),
+		inodeSize:      ext4GoodOldInodeSize,
+		inodesCount:    binary.LittleEndian.Uint32(sb[0x0:]),
+		firstDataBlock: int64(binary.LittleEndian.Uint32(sb[0x14:])),
+		inodesPerGroup: binary.LittleEndian.Uint32(sb[0x28:]),
+		descSize:       32,
+	}
+	if binary.LittleEndian.Uint32(sb[0x4c:]) >= 1 {
+		fs.inodeSize = int64(binary.LittleEndian.Uint16(sb[0x58:]))
+		fs.incompat = binary.LittleEndian.Uint32(sb[0x60:])
+	}
+	if unsupported := fs.incompat &^ ext4SupportedIncompat; unsupported != 0 {
+		return nil, fmt.Errorf("unsupported ext4 features %#x", unsupported)
+	}
+	fs.blocksCount = int64(binary.LittleEndian.Uint32(sb[0x4:]))
+	if fs.incompat&ext4Incompat64bit != 0 {
+		fs.descSize = int64(binary.LittleEndian.Uint16(sb[0xfe:]))
+		fs.blocksCount |= int64(binary.LittleEndian.Uint32(sb[0x150:])) << 32
+	}
+	if fs.blockSize > 65536 || fs.inodeSize < ext4GoodOldInodeSize || fs.inodesPerGroup == 0 || fs.descSize < 32 {
+		return nil, fmt.Errorf("invalid ext4 superblock")
+	}
+	return fs, nil
+}
+
+func (fs *ext4) rootIno() uint64 {
+	return ext4RootIno
+}
+
+// readInode returns the raw inode.
+func (fs *ext4) readInode(ino uint64) ([]byte, error) {
+	if ino == 0 || ino > uint64(fs.inodesCount) {
+		return nil, fmt.Errorf("invalid inode %d", ino)
+	}
+	group := int64(ino-1) / int64(fs.inodesPerGroup)
+	index := int64(ino-1) % int64(fs.inodesPerGroup)
+
+	desc := make([]byte, fs.descSize)
+	if err := readAt(fs.r, desc, (fs.firstDataBlock+1)*fs.blockSize+group*fs.descSize); err != nil {
+		return nil, fmt.Errorf("reading group descriptor %d: %w", group, err)
+	}
+	inodeTable := int64(binary.LittleEndian.Uint32(desc[0x8:]))
+	if fs.descSize >= 64 {
+		inodeTable |= int64(binary.LittleEndian.Uint32(desc[0x28:])) << 32
+	}
+
+	inode := make([]byte, fs.inodeSize)
+	if err := readAt(fs.r, inode, inodeTable*fs.blockSize+index*fs.inodeSize); err != nil {
+		return nil, fmt.Errorf("reading inode %d: %w", ino, err)
+	}
+	return inode, nil
+}
+
+func ext4Size(inode []byte) int64 {
+	return int64(binary.LittleEndian.Uint32(inode[0x4:])) | int64(binary.LittleEndian.Uint32(inode[0x6c:]))<<32
+}
+
+func ext4Flags(inode []byte) uint32 {
+	return binary.LittleEndian.Uint32(inode[0x20:])
+}
+
+func (fs *ext4) stat(ino uint64, e *Entry) error {
+	inode, err := fs.readInode(ino)
+	if err != nil {
+		return err
+	}
+	e.Mode = uint32(binary.LittleEndian.Uint16(inode[0x0:]))
+	e.Uid = uint32(binary.LittleEndian.Uint16(inode[0x2:])) | uint32(binary.LittleEndian.Uint16(inode[0x78:]))<<16
+	e.Gid = uint32(binary.LittleEndian.Uint16(inode[0x18:])) | uint32(binary.LittleEndian.Uint16(inode[0x7a:]))<<16
+	e.Size = ext4Size(inode)
+	xattrs, err := fs.xattrs(inode)
+	if err != nil {
+		return err
+	}
+	// The inline data is exposed as the content of the file, not as an attribute.
+	delete(xattrs, ext4InlineDataXattr)
+	if len(xattrs) > 0 {
+		e.Xattrs = xattrs
+	}
+	return nil
+}
+
+// xattrs returns the extended attributes stored in the inode and in its attribute block.
+func (fs *ext4) xattrs(inode []byte) (map[string][]byte, error) {
+	xattrs := map[string][]byte{}
+	if fs.inodeSize > ext4GoodOldInodeSize {
+		start := ext4GoodOldInodeSize + int64(binary.LittleEndian.Uint16(inode[0x80:]))
+		if start+4 <= fs.inodeSize && binary.LittleEndian.Uint32(inode[start:]) == ext4XattrMagic {
+			// The offsets of the values are relative to the first entry.
+			entries := inode[start+4:]
+			if err := parseExt4Xattrs(entries, entries, xattrs); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	block := int64(binary.LittleEndian.Uint32(inode[0x68:])) | int64(binary.LittleEndian.Uint16(inode[0x76:]))<<32
+	if block != 0 {
+		buf := make([]byte, fs.blockSize)
+		if err := readAt(fs.r, buf, block*fs.blockSize); err != nil {
+			return nil, fmt.Errorf("reading xattr block: %w", err)
+		}
+		if binary.LittleEndian.Uint32(buf) != ext4XattrMagic {
+			return nil, fmt.Errorf("invalid xattr block %d", block)
+		}
+		// The offsets of the values are relative to the start of the block, and the entries
+		// start after the 32 byte header.
+		if err := parseExt4Xattrs(buf[32:], buf, xattrs); err != nil {
+			return nil, err
+		}
+	}
+	return xattrs, nil
+}
+
+func parseExt4Xattrs(entries, values []byte, xattrs map[string][]byte) error {
+	for len(entries) >= 4 && binary.LittleEndian.Uint32(entries) != 0 {
+		if len(entries) < 16 {
+			return fmt.Errorf("truncated xattr entry")
+		}
+		nameLen := int(entries[0])
+		index := entries[1]
+		valueOffset := int(binary.LittleEndian.Uint16(entries[2:]))
+		valueInum := binary.LittleEndian.Uint32(entries[4:])
+		valueSize := int(binary.LittleEndian.Uint32(entries[8:]))
+		entryLen := (16 + nameLen + 3) &^ 3
+		if len(entries) < entryLen {
+			return fmt.Errorf("truncated xattr entry")
+		}
+		prefix, ok := ext4XattrPrefixes[index]
+		if !ok && index != 0 {
+			return fmt.Errorf("unknown xattr name index %d", index)
+		}
+		name := prefix + string(entries[16:16+nameLen])
+		if valueInum != 0 {
+			return fmt.Errorf("xattr %s: values in inodes are not supported", name)
+		}
+		if valueOffset+valueSize > len(values) {
+			return fmt.Errorf("xattr %s: value out of bounds", name)
+		}
+		xattrs[name] = append([]byte(nil), values[valueOffset:valueOffset+valueSize]...)
+		entries = entries[entryLen:]
+	}
+	return nil
+}
+
+// ext4Extent maps length blocks of a file starting at logical to the blocks starting at physical.
+type ext4Extent struct {
+	logical, physical, length int64
+	// Uninitialized extents are allocated but read as zeros.
+	uninitialized bool
+}
+
+// extents returns the extents of a file that doesn't have inline data, sorted by logical block.
+func (fs *ext4) extents(inode []byte) ([]ext4Extent, error) {
+	var extents []ext4Extent
+	var err error
+	if ext4Flags(inode)&ext4ExtentsFlag != 0 {
+		extents, err = fs.extentTree(inode[0x28:0x28+ext4InlineBlockSize], 0, extents)
+	} else {
+		extents, err = fs.blockMap(inode[0x28 : 0x28+ext4InlineBlockSize])
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(extents, func(i, j int) bool { return extents[i].logical < extents[j].logical })
+	return extents, nil
+}
+
+func (fs *ext4) extentTree(node []byte, depth int, extents []ext4Extent) ([]ext4Extent, error) {
+	if len(node) < 12 || binary.LittleEndian.Uint16(node) != ext4ExtentMagic {
+		return nil, fmt.Errorf("invalid extent header")
+	}
+	entries := int(binary.LittleEndian.Uint16(node[2:]))
+	nodeDepth := int(binary.LittleEndian.Uint16(node[6:]))
+	if depth > 5 || 12+entries*12 > len(node) {
+		return nil, fmt.Errorf("invalid extent tree")
+	}
+	for i := 0; i < entries; i++ {
+		entry := node[12+i*12:]
+		if nodeDepth == 0 {
+			length := int64(binary.LittleEndian.Uint16(entry[4:]))
+			uninitialized := length > ext4MaxInitExtentLen
+			if uninitialized {
+				length -= ext4MaxInitExtentLen
+			}
+			extents = append(extents, ext4Extent{
+				logical:       int64(binary.LittleEndian.Uint32(entry[0:])),
+				physical:      int64(binary.LittleEndian.Uint16(entry[6:]))<<32 | int64(binary.LittleEndian.Uint32(entry[8:])),
+				length:        length,
+				uninitialized: uninitialized,
+			})
+			continue
+		}
+		leaf := int64(binary.LittleEndian.Uint32(entry[4:])) | int64(binary.LittleEndian.Uint16(entry[8:]))<<32
+		child := make([]byte, fs.blockSize)
+		if err := readAt(fs.r, child, leaf*fs.blockSize); err != nil {
+			return nil, fmt.Errorf("reading extent block %d: %w", leaf, err)
+		}
+		var err error
+		if extents, err = fs.extentTree(child, depth+1, extents); err != nil {
+			return nil, err
+		}
+	}
+	return extents, nil
+}
+
+// blockMap returns the extents of a file that uses the indirect block map of ext2 and ext3.
+func (fs *ext4) blockMap(iblock []byte) ([]ext4Extent, error) {
+	var extents []ext4Extent
+	var logical int64
+	add := func(block int64) {
+		if block != 0 {
+			if n := len(extents); n > 0 && extents[n-1].logical+extents[n-1].length == logical &&
+				extents[n-1].physical+extents[n-1].length == block {
+				extents[n-1].length++
+			} else {
+				extents = append(extents, ext4Extent{logical: logical, physical: block, length: 1})
+			}
+		}
+		logical++
+	}
+	perBlock := fs.blockSize / 4
+	// walk adds the blocks referenced by an indirect block of the given level, or skips them if
+	// the indirect block is a hole.
+	var walk func(block int64, level int) error
+	walk = func(block int64, level int) error {
+		if level == 0 {
+			add(block)
+			return nil
+		}
+		if block == 0 {
+			skip := int64(1)
+			for i := 0; i < level; i++ {
+				skip *= perBlock
+			}
+			logical += skip
+			return nil
+		}
+		buf := make([]byte, fs.blockSize)
+		if err := readAt(fs.r, buf, block*fs.blockSize); err != nil {
+			return fmt.Errorf("reading indirect block %d: %w", block, err)
+		}
+		for i := int64(0); i < perBlock; i++ {
+			if err := walk(int64(binary.LittleEndian.Uint32(buf[i*4:])), level-1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i := 0; i < 15; i++ {
+		level := 0
+		if i >= 12 {
+			level = i - 11
+		}
+		if err := walk(int64(binary.LittleEndian.Uint32(iblock[i*4:])), level); err != nil {
+			return nil, err
+		}
+	}
+	return extents, nil
+}
+
+// isFastSymlink returns true if the target of a symlink is stored in the inode itself.
+func isFastSymlink(inode []byte) bool {
+	return uint32(binary.LittleEndian.Uint16(inode[0x0:]))&ModeType == ModeSymlink &&
+		ext4Size(inode) < ext4InlineBlockSize &&
+		ext4Flags(inode)&(ext4ExtentsFlag|ext4InlineDataFlag) == 0
+}
+
+func (fs *ext4) copyData(ino uint64, w io.Writer) error {
+	inode, err := fs.readInode(ino)
+	if err != nil {
+		return err
+	}
+	size := ext4Size(inode)
+	if isFastSymlink(inode) {
+		_, err := w.Write(inode[0x28 : 0x28+size])
+		return err
+	}
+	if ext4Flags(inode)&ext4InlineDataFlag != 0 {
+		data, err := fs.inlineData(inode)
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) < size {
+			return fmt.Errorf("inline data is shorter than the file")
+		}
+		_, err = w.Write(data[:size])
+		return err
+	}
+
+	extents, err := fs.extents(inode)
+	if err != nil {
+		return err
+	}
+	var written int64
+	for _, extent := range extents {
+		start := extent.logical * fs.blockSize
+		if start >= size {
+			break
+		}
+		if start < written {
+			return fmt.Errorf("overlapping extents")
+		}
+		if err := writeZeros(w, start-written); err != nil {
+			return err
+		}
+		length := extent.length * fs.blockSize
+		if start+length > size {
+			length = size - start
+		}
+		if extent.uninitialized {
+			err = writeZeros(w, length)
+		} else {
+			err = copyRange(w, fs.r, extent.physical*fs.blockSize, length)
+		}
+		if err != nil {
+			return err
+		}
+		written = start + length
+	}
+	return writeZeros(w, size-written)
+}
+
+// inlineData returns the data stored in the inode, which is the content of i_block followed by
+// the value of the system.data attribute.
+func (fs *ext4) inlineData(inode []byte) ([]byte, error) {
+	xattrs, err := fs.xattrs(inode)
+	if err != nil {
+		return nil, err
+	}
+	data := append([]byte(nil), inode[0x28:0x28+ext4InlineBlockSize]...)
+	return append(data, xattrs[ext4InlineDataXattr]...), nil
+}
+
+func (fs *ext4) readDir(ino uint64) ([]dirent, error) {
+	inode, err := fs.readInode(ino)
+	if err != nil {
+		return nil, err
+	}
+	if uint32(binary.LittleEndian.Uint16(inode[0x0:]))&ModeType != ModeDir {
+		return nil, fmt.Errorf("not a directory")
+	}
+	if ext4Flags(inode)&ext4InlineDataFlag != 0 {
+		// Inline directories start with the inode of the parent directory instead of the "."
+		// and ".." entries, and continue in the system.data attribute.
+		xattrs, err := fs.xattrs(inode)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := fs.parseDirents(inode[0x28+4 : 0x28+ext4InlineBlockSize])
+		if err != nil {
+			return nil, err
+		}
+		more, err := fs.parseDirents(xattrs[ext4InlineDataXattr])
+		if err != nil {
+			return nil, err
+		}
+		return append(entries, more...), nil
+	}
+
+	// The size is checked against the blocks the directory maps, so that a corrupt size can't make
+	// the directory look larger than the image.
+	size := ext4Size(inode)
+	extents, err := fs.extents(inode)
+	if err != nil {
+		return nil, err
+	}
+	var mapped int64
+	for _, extent := range extents {
+		if extent.physical+extent.length > fs.blocksCount {
+			return nil, fmt.Errorf("directory block %d is outside of the image", extent.physical+extent.length-1)
+		}
+		mapped = max(mapped, (extent.logical+extent.length)*fs.blockSize)
+	}
+	if size > mapped {
+		return nil, fmt.Errorf("directory size %d is larger than its %d bytes of blocks", size, mapped)
+	}
+
+	// Entries don't span blocks, so the blocks are parsed one at a time. Indexed directories are
+	// read linearly, as the blocks of the hash tree look like empty directory entries.
+	var entries []dirent
+	var next int64
+	block := make([]byte, fs.blockSize)
+	for _, extent := range extents {
+		if extent.logical*fs.blockSize >= size {
+			break
+		}
+		if extent.logical != next {
+			return nil, fmt.Errorf("hole in directory at block %d", next)
+		}
+		for i := int64(0); i < extent.length && (extent.logical+i)*fs.blockSize < size; i++ {
+			if extent.uninitialized {
+				return nil, fmt.Errorf("uninitialized directory block %d", extent.logical+i)
+			}
+			if err := readAt(fs.r, block, (extent.physical+i)*fs.blockSize); err != nil {
+				return nil, fmt.Errorf("reading directory block %d: %w", extent.physical+i, err)
+			}
+			more, err := fs.parseDirents(block[:min(fs.blockSize, size-(extent.logical+i)*fs.blockSize)])
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, more...)
+		}
+		next = extent.logical + extent.length
+	}
+	return entries, nil
+}
+
+func (fs *ext4) parseDirents(buf []byte) ([]dirent, error) {
+	var entries []dirent
+	for len(buf) >= 8 {
+		ino := binary.LittleEndian.Uint32(buf)
+		recLen := int(binary.LittleEndian.Uint16(buf[4:]))
+		if recLen == 0 || recLen == 65535 {
+			recLen = 65536
+		}
+		nameLen := int(binary.LittleEndian.Uint16(buf[6:]))
+		if fs.incompat&ext4IncompatFiletype != 0 {
+			nameLen = int(buf[6])
+		}
+		if recLen < 8 || recLen > len(buf) || 8+nameLen > recLen {
+			return nil, fmt.Errorf("invalid directory entry")
+		}
+		if ino != 0 {
+			entries = append(entries, dirent{name: string(buf[8 : 8+nameLen]), ino: uint64(ino)})
+		}
+		buf = buf[recLen:]
+	}
+	return entries, nil
+}
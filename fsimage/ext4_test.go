@@ -0,0 +1,367 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const (
+	testExt4BlockSize  = 1024
+	testExt4InodeSize  = 512
+	testExt4Inodes     = 64
+	testExt4InodeTable = 3
+)
+
+// ext4Builder writes a minimal ext4 image with a single block group and 1K blocks.
+type ext4Builder struct {
+	t   *testing.T
+	img []byte
+	// Whether files use the indirect block map instead of extents.
+	blockMap bool
+	// Whether extended attributes are stored in a block instead of in the inode.
+	xattrBlocks bool
+}
+
+func (b *ext4Builder) allocBlock(data []byte) uint32 {
+	block := uint32(len(b.img) / testExt4BlockSize)
+	buf := make([]byte, testExt4BlockSize)
+	copy(buf, data)
+	b.img = append(b.img, buf...)
+	return block
+}
+
+func (b *ext4Builder) inode(ino uint32) []byte {
+	offset := testExt4InodeTable*testExt4BlockSize + int(ino-1)*testExt4InodeSize
+	return b.img[offset : offset+testExt4InodeSize]
+}
+
+// writeData writes the data of a file, leaving holes for blocks of zeros, and sets i_block.
+func (b *ext4Builder) writeData(data []byte) ([]byte, uint32) {
+	type extent struct{ logical, physical, length uint32 }
+	var extents []extent
+	for logical := 0; logical*testExt4BlockSize < len(data); logical++ {
+		block := data[logical*testExt4BlockSize:]
+		if len(block) > testExt4BlockSize {
+			block = block[:testExt4BlockSize]
+		}
+		if bytes.Count(block, []byte{0}) == len(block) {
+			continue
+		}
+		physical := b.allocBlock(block)
+		if n := len(extents); n > 0 && extents[n-1].logical+extents[n-1].length == uint32(logical) {
+			extents[n-1].length++
+		} else {
+			extents = append(extents, extent{uint32(logical), physical, 1})
+		}
+	}
+
+	iblock := make([]byte, 60)
+	if b.blockMap {
+		for _, e := range extents {
+			for i := uint32(0); i < e.length; i++ {
+				if e.logical+i >= 12 {
+					b.t.Fatalf("file too large for direct blocks")
+				}
+				binary.LittleEndian.PutUint32(iblock[(e.logical+i)*4:], e.physical+i)
+			}
+		}
+		return iblock, 0
+	}
+	if len(extents) > 4 {
+		b.t.Fatalf("too many extents")
+	}
+	binary.LittleEndian.PutUint16(iblock[0:], ext4ExtentMagic)
+	binary.LittleEndian.PutUint16(iblock[2:], uint16(len(extents)))
+	binary.LittleEndian.PutUint16(iblock[4:], 4)
+	for i, e := range extents {
+		entry := iblock[12+i*12:]
+		binary.LittleEndian.PutUint32(entry[0:], e.logical)
+		binary.LittleEndian.PutUint16(entry[4:], uint16(e.length))
+		binary.LittleEndian.PutUint32(entry[8:], e.physical)
+	}
+	return iblock, ext4ExtentsFlag
+}
+
+// xattrEntries encodes extended attributes in the format shared by inodes and xattr blocks.
+// Values are stored after the entries, and their offsets are relative to base.
+func xattrEntries(xattrs map[string]string, base int) []byte {
+	var names []string
+	for name := range xattrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var entries, values []byte
+	for _, name := range names {
+		var index uint8
+		suffix := name
+		for i, prefix := range ext4XattrPrefixes {
+			if strings.HasPrefix(name, prefix) && len(prefix) > len(name)-len(suffix) {
+				index, suffix = i, strings.TrimPrefix(name, prefix)
+			}
+		}
+		entry := make([]byte, (16+len(suffix)+3)&^3)
+		entry[0] = uint8(len(suffix))
+		entry[1] = index
+		// The offset of the value is fixed up below, when the size of the entries is known.
+		binary.LittleEndian.PutUint16(entry[2:], uint16(len(values)))
+		binary.LittleEndian.PutUint32(entry[8:], uint32(len(xattrs[name])))
+		copy(entry[16:], suffix)
+		entries = append(entries, entry...)
+		values = append(values, xattrs[name]...)
+		for len(values)%4 != 0 {
+			values = append(values, 0)
+		}
+	}
+	entries = append(entries, 0, 0, 0, 0)
+	for e := entries; binary.LittleEndian.Uint32(e) != 0; e = e[(16+int(e[0])+3)&^3:] {
+		offset := binary.LittleEndian.Uint16(e[2:])
+		binary.LittleEndian.PutUint16(e[2:], offset+uint16(base+len(entries)))
+	}
+	return append(entries, values...)
+}
+
+func (b *ext4Builder) writeInode(ino uint32, f testFile, data []byte) {
+	// Allocate the blocks first, as they may move the inode table.
+	iblock := make([]byte, 60)
+	var flags uint32
+	if f.mode&ModeType == ModeSymlink && len(data) < 60 {
+		copy(iblock, data)
+	} else {
+		iblock, flags = b.writeData(data)
+	}
+	var xattrBlock uint32
+	if len(f.xattrs) > 0 && b.xattrBlocks {
+		block := make([]byte, 32)
+		binary.LittleEndian.PutUint32(block[0:], ext4XattrMagic)
+		binary.LittleEndian.PutUint32(block[4:], 1)
+		binary.LittleEndian.PutUint32(block[8:], 1)
+		xattrBlock = b.allocBlock(append(block, xattrEntries(f.xattrs, 32)...))
+	}
+
+	inode := b.inode(ino)
+	binary.LittleEndian.PutUint16(inode[0x0:], uint16(f.mode))
+	binary.LittleEndian.PutUint16(inode[0x2:], uint16(f.uid))
+	binary.LittleEndian.PutUint16(inode[0x78:], uint16(f.uid>>16))
+	binary.LittleEndian.PutUint16(inode[0x18:], uint16(f.gid))
+	binary.LittleEndian.PutUint16(inode[0x7a:], uint16(f.gid>>16))
+	binary.LittleEndian.PutUint32(inode[0x4:], uint32(len(data)))
+	binary.LittleEndian.PutUint16(inode[0x1a:], 1)
+	binary.LittleEndian.PutUint32(inode[0x20:], flags)
+	copy(inode[0x28:], iblock)
+	binary.LittleEndian.PutUint32(inode[0x68:], xattrBlock)
+	binary.LittleEndian.PutUint16(inode[0x80:], 32)
+
+	if len(f.xattrs) > 0 && !b.xattrBlocks {
+		xattrs := append([]byte{0, 0, 0, 0}, xattrEntries(f.xattrs, 0)...)
+		binary.LittleEndian.PutUint32(xattrs, ext4XattrMagic)
+		if 160+len(xattrs) > testExt4InodeSize {
+			b.t.Fatalf("%s: xattrs don't fit in the inode", f.path)
+		}
+		copy(inode[160:], xattrs)
+	}
+}
+
+// dirBlocks encodes the entries of a directory in blocks. The last entry of each block extends
+// to its end.
+func dirBlocks(names []string, inos map[string]uint32, modes map[string]uint32) []byte {
+	var data, block []byte
+	last := 0
+	flush := func() {
+		if len(block) > 0 {
+			binary.LittleEndian.PutUint16(block[last+4:], uint16(testExt4BlockSize-last))
+			block = append(block, make([]byte, testExt4BlockSize-len(block))...)
+			data = append(data, block...)
+			block = nil
+		}
+	}
+	for _, name := range names {
+		entry := make([]byte, (8+len(name)+3)&^3)
+		if len(block)+len(entry) > testExt4BlockSize {
+			flush()
+		}
+		binary.LittleEndian.PutUint32(entry[0:], inos[name])
+		binary.LittleEndian.PutUint16(entry[4:], uint16(len(entry)))
+		entry[6] = uint8(len(name))
+		switch modes[name] & ModeType {
+		case ModeRegular:
+			entry[7] = 1
+		case ModeDir:
+			entry[7] = 2
+		case ModeSymlink:
+			entry[7] = 7
+		}
+		copy(entry[8:], name)
+		last = len(block)
+		block = append(block, entry...)
+	}
+	flush()
+	return data
+}
+
+func (b *ext4Builder) build(files []testFile) []byte {
+	tree := testTree(files)
+	inos := map[string]uint32{".": ext4RootIno}
+	byPath := map[string]testFile{".": {path: ".", mode: ModeDir | 0755}}
+	next := uint32(11)
+	for _, f := range files {
+		inos[f.path] = next
+		byPath[f.path] = f
+		next++
+	}
+	if next > testExt4Inodes {
+		b.t.Fatalf("too many files")
+	}
+
+	// Boot block, superblock, group descriptor and inode table.
+	b.img = make([]byte, (testExt4InodeTable+testExt4Inodes*testExt4InodeSize/testExt4BlockSize)*testExt4BlockSize)
+	for p, f := range byPath {
+		data := []byte(f.data)
+		if f.mode&ModeType == ModeSymlink {
+			data = []byte(f.link)
+		}
+		if children, ok := tree[p]; ok {
+			names := append([]string{".", ".."}, children...)
+			dirInos := map[string]uint32{".": inos[p], "..": inos[path.Dir(p)]}
+			modes := map[string]uint32{".": ModeDir, "..": ModeDir}
+			for _, child := range children {
+				dirInos[child] = inos[path.Join(p, child)]
+				modes[child] = byPath[path.Join(p, child)].mode
+			}
+			data = dirBlocks(names, dirInos, modes)
+		}
+		b.writeInode(inos[p], f, data)
+	}
+
+	sb := b.img[ext4SuperblockOffset:]
+	binary.LittleEndian.PutUint32(sb[0x0:], testExt4Inodes)
+	binary.LittleEndian.PutUint32(sb[0x4:], uint32(len(b.img)/testExt4BlockSize))
+	binary.LittleEndian.PutUint32(sb[0x14:], 1)
+	binary.LittleEndian.PutUint32(sb[0x20:], 8192)
+	binary.LittleEndian.PutUint32(sb[0x28:], testExt4Inodes)
+	binary.LittleEndian.PutUint16(sb[0x38:], ext4Magic)
+	binary.LittleEndian.PutUint32(sb[0x4c:], 1)
+	binary.LittleEndian.PutUint32(sb[0x54:], 11)
+	binary.LittleEndian.PutUint16(sb[0x58:], testExt4InodeSize)
+	binary.LittleEndian.PutUint32(sb[0x60:], ext4IncompatFiletype|ext4IncompatExtents)
+	binary.LittleEndian.PutUint32(b.img[2*testExt4BlockSize+0x8:], testExt4InodeTable)
+	return b.img
+}
+
+func TestExt4(t *testing.T) {
+	testCases := []struct {
+		name    string
+		builder ext4Builder
+	}{
+		{name: "extents"},
+		{name: "block map", builder: ext4Builder{blockMap: true}},
+		{name: "xattr blocks", builder: ext4Builder{xattrBlocks: true}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.builder.t = t
+			img, err := Open(bytes.NewReader(tc.builder.build(testFiles)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img.Type != "ext4" || img.Sparse {
+				t.Errorf("expected a raw ext4 image, got %s", img.Type)
+			}
+			checkImage(t, img, testFiles)
+			checkLookup(t, img)
+		})
+	}
+}
+
+func TestExt4LargeDirectory(t *testing.T) {
+	files := []testFile{{path: "etc", mode: ModeDir | 0755}}
+	for i := 0; i < 50; i++ {
+		files = append(files, testFile{
+			path: path.Join("etc", strings.Repeat("x", i)+"_file"),
+			mode: ModeRegular | 0644,
+			data: strings.Repeat("y", i),
+		})
+	}
+	b := ext4Builder{t: t}
+	img, err := Open(bytes.NewReader(b.build(files)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkImage(t, img, files)
+}
+
+func TestExt4UnsupportedFeatures(t *testing.T) {
+	b := ext4Builder{t: t}
+	data := b.build(testFiles)
+	// META_BG changes the location of the group descriptors.
+	binary.LittleEndian.PutUint32(data[ext4SuperblockOffset+0x60:], ext4IncompatFiletype|0x10)
+	if _, err := Open(bytes.NewReader(data)); err == nil || !strings.Contains(err.Error(), "unsupported ext4 features 0x10") {
+		t.Errorf("expected an error for unsupported features, got %v", err)
+	}
+}
+
+func TestExt4CorruptDirectory(t *testing.T) {
+	testCases := []struct {
+		name    string
+		corrupt func(img []byte) []byte
+		err     string
+	}{
+		{
+			name: "directory size",
+			corrupt: func(img []byte) []byte {
+				// A size of about 1.6GB used to be read into memory.
+				root := img[testExt4InodeTable*testExt4BlockSize+(ext4RootIno-1)*testExt4InodeSize:]
+				binary.LittleEndian.PutUint32(root[0x4:], 0x60000000)
+				return img
+			},
+			err: "directory size 1610612736 is larger than its 1024 bytes of blocks",
+		},
+		{
+			name: "directory block",
+			corrupt: func(img []byte) []byte {
+				root := img[testExt4InodeTable*testExt4BlockSize+(ext4RootIno-1)*testExt4InodeSize:]
+				// The physical block of the first extent.
+				binary.LittleEndian.PutUint32(root[0x28+12+8:], 1<<20)
+				return img
+			},
+			err: "directory block 1048576 is outside of the image",
+		},
+		{
+			name: "truncated",
+			corrupt: func(img []byte) []byte {
+				// Keep the superblock and the inode table, but none of the data blocks.
+				return img[:(testExt4InodeTable+testExt4Inodes*testExt4InodeSize/testExt4BlockSize)*testExt4BlockSize]
+			},
+			err: "reading directory block",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := ext4Builder{t: t}
+			img, err := Open(bytes.NewReader(tc.corrupt(b.build(testFiles))))
+			if err == nil {
+				err = img.Walk(func(e *Entry) error { return nil })
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
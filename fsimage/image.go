@@ -0,0 +1,345 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fsimage reads the files in ext4 and erofs images, sparse or not, without mounting them
+// or running any of the host tools of the filesystems.
+package fsimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// The file type bits of Entry.Mode, as in st_mode.
+const (
+	ModeType    = 0170000
+	ModeSocket  = 0140000
+	ModeSymlink = 0120000
+	ModeRegular = 0100000
+	ModeBlock   = 0060000
+	ModeDir     = 0040000
+	ModeChar    = 0020000
+	ModeFifo    = 0010000
+)
+
+const (
+	selinuxXattr    = "security.selinux"
+	capabilityXattr = "security.capability"
+)
+
+// Entry is a file, directory or other inode in an image.
+type Entry struct {
+	// Path of the entry relative to the root of the image, using slashes. The root directory is
+	// ".".
+	Path string
+	// The type and permission bits, as in st_mode.
+	Mode uint32
+	Uid  uint32
+	Gid  uint32
+	Size int64
+	// The target of symlinks.
+	Link string
+	// The extended attributes, keyed by their full name, e.g. "security.selinux".
+	Xattrs map[string][]byte
+
+	ino uint64
+}
+
+func (e *Entry) IsDir() bool {
+	return e.Mode&ModeType == ModeDir
+}
+
+func (e *Entry) IsRegular() bool {
+	return e.Mode&ModeType == ModeRegular
+}
+
+func (e *Entry) IsSymlink() bool {
+	return e.Mode&ModeType == ModeSymlink
+}
+
+// SELinuxContext returns the SELinux label of the entry, or an empty string if it doesn't have
+// one.
+func (e *Entry) SELinuxContext() string {
+	return strings.TrimRight(string(e.Xattrs[selinuxXattr]), "\x00")
+}
+
+// Capabilities returns the permitted file capabilities of the entry as a bit mask, or 0 if it
+// doesn't have any.
+func (e *Entry) Capabilities() (uint64, error) {
+	data, ok := e.Xattrs[capabilityXattr]
+	if !ok {
+		return 0, nil
+	}
+	// struct vfs_cap_data from linux/capability.h.
+	if len(data) < 4 {
+		return 0, fmt.Errorf("%s: invalid %s", e.Path, capabilityXattr)
+	}
+	var size int
+	switch revision := binary.LittleEndian.Uint32(data) & 0xff000000; revision {
+	case 0x01000000:
+		size = 12
+	case 0x02000000:
+		size = 20
+	case 0x03000000:
+		size = 24
+	default:
+		return 0, fmt.Errorf("%s: unknown capability revision %#x", e.Path, revision)
+	}
+	if len(data) < size {
+		return 0, fmt.Errorf("%s: invalid %s", e.Path, capabilityXattr)
+	}
+	permitted := uint64(binary.LittleEndian.Uint32(data[4:]))
+	if size > 12 {
+		permitted |= uint64(binary.LittleEndian.Uint32(data[12:])) << 32
+	}
+	return permitted, nil
+}
+
+// filesystem is implemented by the readers of each filesystem type. Inodes are identified by
+// numbers that are only meaningful to the filesystem.
+type filesystem interface {
+	rootIno() uint64
+	// stat sets the mode, owner, size and extended attributes of an inode in e.
+	stat(ino uint64, e *Entry) error
+	readDir(ino uint64) ([]dirent, error)
+	// copyData writes the content of a regular file or the target of a symlink to w.
+	copyData(ino uint64, w io.Writer) error
+}
+
+type dirent struct {
+	name string
+	ino  uint64
+}
+
+// Image is an ext4 or erofs image.
+type Image struct {
+	// The type of the filesystem, "ext4" or "erofs".
+	Type string
+	// Whether the image is an Android sparse image.
+	Sparse bool
+
+	fs filesystem
+}
+
+// Open detects the type of the image read by r and returns a reader for its files.
+func Open(r io.ReaderAt) (*Image, error) {
+	img := &Image{}
+	if isSparse(r) {
+		s, err := newSparseReader(r)
+		if err != nil {
+			return nil, err
+		}
+		r = s
+		img.Sparse = true
+	}
+	switch {
+	case isExt4(r):
+		fs, err := newExt4(r)
+		if err != nil {
+			return nil, err
+		}
+		img.Type, img.fs = "ext4", fs
+	case isErofs(r):
+		fs, err := newErofs(r)
+		if err != nil {
+			return nil, err
+		}
+		img.Type, img.fs = "erofs", fs
+	default:
+		return nil, fmt.Errorf("not an ext4 or erofs image")
+	}
+	return img, nil
+}
+
+// OpenFile opens the image in a file. The file is closed with the returned io.Closer.
+func OpenFile(name string) (*Image, io.Closer, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	img, err := Open(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return img, f, nil
+}
+
+func (img *Image) entry(ino uint64, p string) (*Entry, error) {
+	e := &Entry{Path: p, ino: ino}
+	if err := img.fs.stat(ino, e); err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	if e.IsSymlink() {
+		buf := &bytes.Buffer{}
+		if err := img.fs.copyData(ino, buf); err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		e.Link = buf.String()
+	}
+	return e, nil
+}
+
+func (img *Image) readDir(dir *Entry) ([]dirent, error) {
+	entries, err := img.fs.readDir(dir.ino)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dir.Path, err)
+	}
+	ret := entries[:0]
+	for _, d := range entries {
+		if d.name != "." && d.name != ".." {
+			ret = append(ret, d)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].name < ret[j].name })
+	return ret, nil
+}
+
+// Walk calls fn for every entry in the image, starting with the root directory. Directories are
+// visited before their contents, and the entries of a directory are visited in the order of their
+// names.
+func (img *Image) Walk(fn func(e *Entry) error) error {
+	root, err := img.entry(img.fs.rootIno(), ".")
+	if err != nil {
+		return err
+	}
+	return img.walk(root, fn, map[uint64]bool{})
+}
+
+func (img *Image) walk(e *Entry, fn func(e *Entry) error, visiting map[uint64]bool) error {
+	if err := fn(e); err != nil {
+		return err
+	}
+	if !e.IsDir() {
+		return nil
+	}
+	if visiting[e.ino] {
+		return fmt.Errorf("%s: directory loop", e.Path)
+	}
+	visiting[e.ino] = true
+	defer delete(visiting, e.ino)
+
+	entries, err := img.readDir(e)
+	if err != nil {
+		return err
+	}
+	for _, d := range entries {
+		child, err := img.entry(d.ino, path.Join(e.Path, d.name))
+		if err != nil {
+			return err
+		}
+		if err := img.walk(child, fn, visiting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lookup returns the entry at a path relative to the root of the image. Symlinks are not
+// followed.
+func (img *Image) Lookup(p string) (*Entry, error) {
+	e, err := img.entry(img.fs.rootIno(), ".")
+	if err != nil {
+		return nil, err
+	}
+	p = path.Clean(strings.TrimPrefix(p, "/"))
+	if p == "." {
+		return e, nil
+	}
+	for _, name := range strings.Split(p, "/") {
+		if !e.IsDir() {
+			return nil, fmt.Errorf("%s: not a directory", e.Path)
+		}
+		entries, err := img.readDir(e)
+		if err != nil {
+			return nil, err
+		}
+		i := sort.Search(len(entries), func(i int) bool { return entries[i].name >= name })
+		if i == len(entries) || entries[i].name != name {
+			return nil, fmt.Errorf("%s: %w", path.Join(e.Path, name), os.ErrNotExist)
+		}
+		if e, err = img.entry(entries[i].ino, path.Join(e.Path, name)); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Copy writes the content of a regular file to w.
+func (img *Image) Copy(w io.Writer, e *Entry) error {
+	if !e.IsRegular() {
+		return fmt.Errorf("%s: not a regular file", e.Path)
+	}
+	if err := img.fs.copyData(e.ino, w); err != nil {
+		return fmt.Errorf("%s: %w", e.Path, err)
+	}
+	return nil
+}
+
+// ReadFile returns the content of the regular file at a path relative to the root of the image.
+func (img *Image) ReadFile(p string) ([]byte, error) {
+	e, err := img.Lookup(p)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := img.Copy(buf, e); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// copyRange copies length bytes at off in r to w.
+func copyRange(w io.Writer, r io.ReaderAt, off, length int64) error {
+	n, err := io.Copy(w, io.NewSectionReader(r, off, length))
+	if err == nil && n != length {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+var zeros [4096]byte
+
+// writeZeros writes n zero bytes to w, for holes in files.
+func writeZeros(w io.Writer, n int64) error {
+	for n > 0 {
+		l := int64(len(zeros))
+		if l > n {
+			l = n
+		}
+		if _, err := w.Write(zeros[:l]); err != nil {
+			return err
+		}
+		n -= l
+	}
+	return nil
+}
+
+// readAt reads exactly len(buf) bytes at off.
+func readAt(r io.ReaderAt, buf []byte, off int64) error {
+	n, err := r.ReadAt(buf, off)
+	if n == len(buf) {
+		return nil
+	}
+	if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
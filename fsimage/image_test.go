@@ -0,0 +1,185 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsimage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testFile is a file that the tests write to an image with the builders of each filesystem.
+type testFile struct {
+	path     string
+	mode     uint32
+	uid, gid uint32
+	data     string
+	link     string
+	xattrs   map[string]string
+}
+
+func (f testFile) size() int64 {
+	if f.mode&ModeType == ModeSymlink {
+		return int64(len(f.link))
+	}
+	return int64(len(f.data))
+}
+
+// vfs_cap_data with CAP_NET_ADMIN, CAP_NET_RAW and CAP_SYSLOG permitted.
+var testCapabilities = "\x01\x00\x00\x02" + "\x00\x30\x00\x00" + "\x00\x00\x00\x00" + "\x04\x00\x00\x00" + "\x00\x00\x00\x00"
+
+var testFiles = []testFile{
+	{path: "system", mode: ModeDir | 0755},
+	{path: "system/bin", mode: ModeDir | 0751, gid: 2000,
+		xattrs: map[string]string{selinuxXattr: "u:object_r:system_file:s0"}},
+	{path: "system/bin/sh", mode: ModeRegular | 0755, gid: 2000, data: "#!/bin/sh\n",
+		xattrs: map[string]string{selinuxXattr: "u:object_r:shell_exec:s0"}},
+	{path: "system/bin/netd", mode: ModeRegular | 0750, uid: 100000, gid: 3003,
+		data: strings.Repeat("netd", 1500) + "end",
+		xattrs: map[string]string{
+			selinuxXattr:    "u:object_r:netd_exec:s0",
+			capabilityXattr: testCapabilities,
+		}},
+	{path: "system/bin/toybox", mode: ModeSymlink | 0777, link: "sh"},
+	{path: "system/etc", mode: ModeDir | 0755},
+	{path: "system/etc/empty", mode: ModeRegular | 0644},
+	{path: "system/etc/long_link", mode: ModeSymlink | 0777, link: strings.Repeat("a/", 40) + "target"},
+	{path: "system/lib64", mode: ModeDir | 0755},
+	{path: "system/lib64/libfoo.so", mode: ModeRegular | 0644,
+		data: "head" + strings.Repeat("\x00", 9000) + "tail" + strings.Repeat("\x00", 3000)},
+}
+
+// checkImage checks that the image contains exactly the given files, in addition to the root
+// directory and lost+found.
+func checkImage(t *testing.T, img *Image, files []testFile) {
+	t.Helper()
+	entries := map[string]*Entry{}
+	var paths []string
+	err := img.Walk(func(e *Entry) error {
+		entries[e.Path] = e
+		if e.Path != "." && e.Path != "lost+found" {
+			paths = append(paths, e.Path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expectedPaths []string
+	for _, f := range files {
+		expectedPaths = append(expectedPaths, f.path)
+	}
+	sort.Strings(expectedPaths)
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("expected entries %q, got %q", expectedPaths, paths)
+	}
+
+	for _, f := range files {
+		e := entries[f.path]
+		if e == nil {
+			continue
+		}
+		if e.Mode != f.mode || e.Uid != f.uid || e.Gid != f.gid {
+			t.Errorf("%s: expected mode %o and owner %d:%d, got %o and %d:%d",
+				f.path, f.mode, f.uid, f.gid, e.Mode, e.Uid, e.Gid)
+		}
+		// The size of directories depends on the filesystem.
+		if !e.IsDir() && e.Size != f.size() {
+			t.Errorf("%s: expected size %d, got %d", f.path, f.size(), e.Size)
+		}
+		if e.Link != f.link {
+			t.Errorf("%s: expected link %q, got %q", f.path, f.link, e.Link)
+		}
+		xattrs := map[string]string{}
+		for name, value := range e.Xattrs {
+			xattrs[name] = string(value)
+		}
+		if len(xattrs) > 0 || len(f.xattrs) > 0 {
+			if !reflect.DeepEqual(xattrs, f.xattrs) {
+				t.Errorf("%s: expected xattrs %q, got %q", f.path, f.xattrs, xattrs)
+			}
+		}
+		if e.IsRegular() {
+			data, err := img.ReadFile(f.path)
+			if err != nil {
+				t.Errorf("%s: %s", f.path, err)
+			} else if string(data) != f.data {
+				t.Errorf("%s: content differs", f.path)
+			}
+		}
+	}
+
+	if netd := entries["system/bin/netd"]; netd != nil {
+		if label := netd.SELinuxContext(); label != "u:object_r:netd_exec:s0" {
+			t.Errorf("unexpected SELinux label %q", label)
+		}
+		caps, err := netd.Capabilities()
+		if err != nil {
+			t.Error(err)
+		} else if caps != 0x400003000 {
+			t.Errorf("unexpected capabilities %#x", caps)
+		}
+	}
+
+}
+
+func checkLookup(t *testing.T, img *Image) {
+	t.Helper()
+	if _, err := img.Lookup("system/bin/missing"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a not exist error for a missing file, got %v", err)
+	}
+	if _, err := img.Lookup("system/bin/sh/foo"); err == nil {
+		t.Errorf("expected an error for a path under a file")
+	}
+	if e, err := img.Lookup("/system//lib64/"); err != nil || e.Path != "system/lib64" {
+		t.Errorf("expected system/lib64, got %v, %v", e, err)
+	}
+	if e, err := img.Lookup("/"); err != nil || !e.IsDir() || e.Path != "." {
+		t.Errorf("expected the root directory, got %v, %v", e, err)
+	}
+}
+
+// testTree returns the directories of the files by path, with the names of their children.
+func testTree(files []testFile) map[string][]string {
+	tree := map[string][]string{".": nil}
+	for _, f := range files {
+		dir := path.Dir(f.path)
+		tree[dir] = append(tree[dir], path.Base(f.path))
+		if f.mode&ModeType == ModeDir {
+			if _, ok := tree[f.path]; !ok {
+				tree[f.path] = nil
+			}
+		}
+	}
+	for _, children := range tree {
+		sort.Strings(children)
+	}
+	return tree
+}
+
+func TestOpenInvalid(t *testing.T) {
+	if _, err := Open(bytes.NewReader(make([]byte, 8192))); err == nil {
+		t.Errorf("expected an error for an empty image")
+	}
+	if _, err := Open(bytes.NewReader(nil)); err == nil {
+		t.Errorf("expected an error for a truncated image")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsimage
+
+import (
+	"fmt"
+)
+
+// lz4Decompress decodes an LZ4 block, as described in
+// https://github.com/lz4/lz4/blob/dev/doc/lz4_Block_format.md, until it has produced length
+// bytes. erofs doesn't store the size of the compressed data, so the input may be followed by
+// padding.
+func lz4Decompress(src []byte, length int) ([]byte, error) {
+	dst := make([]byte, 0, length)
+	readLength := func(l int) (int, error) {
+		if l != 15 {
+			return l, nil
+		}
+		for {
+			if len(src) == 0 {
+				return 0, fmt.Errorf("lz4: truncated length")
+			}
+			b := src[0]
+			src = src[1:]
+			l += int(b)
+			if b != 255 {
+				return l, nil
+			}
+		}
+	}
+	for len(dst) < length {
+		if len(src) == 0 {
+			return nil, fmt.Errorf("lz4: truncated input")
+		}
+		token := src[0]
+		src = src[1:]
+
+		literals, err := readLength(int(token >> 4))
+		if err != nil {
+			return nil, err
+		}
+		if literals > len(src) {
+			return nil, fmt.Errorf("lz4: truncated literals")
+		}
+		dst = append(dst, src[:literals]...)
+		src = src[literals:]
+		if len(dst) >= length {
+			break
+		}
+
+		if len(src) < 2 {
+			return nil, fmt.Errorf("lz4: truncated match")
+		}
+		offset := int(src[0]) | int(src[1])<<8
+		src = src[2:]
+		if offset == 0 || offset > len(dst) {
+			return nil, fmt.Errorf("lz4: invalid match offset %d", offset)
+		}
+		matchLength, err := readLength(int(token & 0xf))
+		if err != nil {
+			return nil, err
+		}
+		matchLength += 4
+		// Matches may overlap the bytes they produce, so copy them one at a time.
+		start := len(dst) - offset
+		for i := 0; i < matchLength; i++ {
+			dst = append(dst, dst[start+i])
+		}
+	}
+	return dst[:length], nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsimage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// The Android sparse image format, as written by img2simg and libsparse.
+const (
+	sparseMagic          = 0xed26ff3a
+	sparseHeaderSize     = 28
+	sparseChunkHeaderLen = 12
+
+	sparseChunkRaw      = 0xcac1
+	sparseChunkFill     = 0xcac2
+	sparseChunkDontCare = 0xcac3
+	sparseChunkCRC32    = 0xcac4
+)
+
+type sparseChunk struct {
+	// The offset and length of the chunk in the expanded image.
+	start, length int64
+	typ           uint16
+	// The offset of the data of raw chunks in the sparse image.
+	dataOffset int64
+	// The value that fill chunks are filled with.
+	fill [4]byte
+}
+
+// sparseReader expands a sparse image on the fly.
+type sparseReader struct {
+	r      io.ReaderAt
+	size   int64
+	chunks []sparseChunk
+}
+
+// isSparse returns true if the image starts with the magic number of sparse images.
+func isSparse(r io.ReaderAt) bool {
+	var buf [4]byte
+	if _, err := r.ReadAt(buf[:], 0); err != nil {
+		return false
+	}
+	return binary.LittleEndian.Uint32(buf[:]) == sparseMagic
+}
+
+func newSparseReader(r io.ReaderAt) (*sparseReader, error) {
+	header := make([]byte, sparseHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("reading sparse header: %w", err)
+	}
+	if binary.LittleEndian.Uint32(header[0:]) != sparseMagic {
+		return nil, fmt.Errorf("not a sparse image")
+	}
+	if major := binary.LittleEndian.Uint16(header[4:]); major != 1 {
+		return nil, fmt.Errorf("unsupported sparse image version %d", major)
+	}
+	fileHeaderSize := int64(binary.LittleEndian.Uint16(header[8:]))
+	chunkHeaderSize := int64(binary.LittleEndian.Uint16(header[10:]))
+	blockSize := int64(binary.LittleEndian.Uint32(header[12:]))
+	totalBlocks := int64(binary.LittleEndian.Uint32(header[16:]))
+	totalChunks := binary.LittleEndian.Uint32(header[20:])
+	if fileHeaderSize < sparseHeaderSize || chunkHeaderSize < sparseChunkHeaderLen || blockSize == 0 {
+		return nil, fmt.Errorf("invalid sparse header")
+	}
+
+	s := &sparseReader{r: r, size: totalBlocks * blockSize}
+	offset := fileHeaderSize
+	var start int64
+	chunkHeader := make([]byte, sparseChunkHeaderLen)
+	for i := uint32(0); i < totalChunks; i++ {
+		if _, err := r.ReadAt(chunkHeader, offset); err != nil {
+			return nil, fmt.Errorf("reading sparse chunk %d: %w", i, err)
+		}
+		chunk := sparseChunk{
+			start:  start,
+			length: int64(binary.LittleEndian.Uint32(chunkHeader[4:])) * blockSize,
+			typ:    binary.LittleEndian.Uint16(chunkHeader[0:]),
+		}
+		totalSize := int64(binary.LittleEndian.Uint32(chunkHeader[8:]))
+		dataOffset := offset + chunkHeaderSize
+		switch chunk.typ {
+		case sparseChunkRaw:
+			if totalSize != chunkHeaderSize+chunk.length {
+				return nil, fmt.Errorf("sparse chunk %d: invalid raw chunk size", i)
+			}
+			chunk.dataOffset = dataOffset
+		case sparseChunkFill:
+			if _, err := r.ReadAt(chunk.fill[:], dataOffset); err != nil {
+				return nil, fmt.Errorf("reading sparse chunk %d: %w", i, err)
+			}
+		case sparseChunkDontCare:
+		case sparseChunkCRC32:
+			// The checksum doesn't map to any data in the image.
+			offset += totalSize
+			continue
+		default:
+			return nil, fmt.Errorf("sparse chunk %d: unknown type %#x", i, chunk.typ)
+		}
+		s.chunks = append(s.chunks, chunk)
+		start += chunk.length
+		offset += totalSize
+	}
+	if start != s.size {
+		return nil, fmt.Errorf("sparse chunks cover %d bytes, expected %d", start, s.size)
+	}
+	return s, nil
+}
+
+func (s *sparseReader) Size() int64 {
+	return s.size
+}
+
+func (s *sparseReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+	n := 0
+	// Find the first chunk that ends after off.
+	i := sort.Search(len(s.chunks), func(i int) bool {
+		return s.chunks[i].start+s.chunks[i].length > off
+	})
+	for ; n < len(p) && i < len(s.chunks); i++ {
+		chunk := &s.chunks[i]
+		chunkOff := off + int64(n) - chunk.start
+		l := chunk.length - chunkOff
+		if l > int64(len(p)-n) {
+			l = int64(len(p) - n)
+		}
+		dst := p[n : n+int(l)]
+		switch chunk.typ {
+		case sparseChunkRaw:
+			if _, err := s.r.ReadAt(dst, chunk.dataOffset+chunkOff); err != nil {
+				return n, err
+			}
+		case sparseChunkFill:
+			for j := range dst {
+				dst[j] = chunk.fill[(chunkOff+int64(j))%4]
+			}
+		case sparseChunkDontCare:
+			for j := range dst {
+				dst[j] = 0
+			}
+		}
+		n += len(dst)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
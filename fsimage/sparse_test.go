@@ -0,0 +1,129 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+const testSparseBlockSize = 4096
+
+// sparseImage converts a raw image to the sparse format, using don't care chunks for blocks of
+// zeros, fill chunks for blocks that repeat 4 bytes and raw chunks for the others.
+func sparseImage(raw []byte) []byte {
+	raw = append(raw, make([]byte, -len(raw)&(testSparseBlockSize-1))...)
+	var chunks []byte
+	count := 0
+	addChunk := func(typ uint16, blocks int, data []byte) {
+		header := make([]byte, sparseChunkHeaderLen)
+		binary.LittleEndian.PutUint16(header[0:], typ)
+		binary.LittleEndian.PutUint32(header[4:], uint32(blocks))
+		binary.LittleEndian.PutUint32(header[8:], uint32(sparseChunkHeaderLen+len(data)))
+		chunks = append(append(chunks, header...), data...)
+		count++
+	}
+	for off := 0; off < len(raw); off += testSparseBlockSize {
+		block := raw[off : off+testSparseBlockSize]
+		if bytes.Equal(block, bytes.Repeat(block[:4], testSparseBlockSize/4)) {
+			if bytes.Count(block, []byte{0}) == len(block) {
+				addChunk(sparseChunkDontCare, 1, nil)
+			} else {
+				addChunk(sparseChunkFill, 1, block[:4])
+			}
+		} else {
+			addChunk(sparseChunkRaw, 1, block)
+		}
+	}
+	addChunk(sparseChunkCRC32, 0, []byte{1, 2, 3, 4})
+
+	header := make([]byte, sparseHeaderSize)
+	binary.LittleEndian.PutUint32(header[0:], sparseMagic)
+	binary.LittleEndian.PutUint16(header[4:], 1)
+	binary.LittleEndian.PutUint16(header[8:], sparseHeaderSize)
+	binary.LittleEndian.PutUint16(header[10:], sparseChunkHeaderLen)
+	binary.LittleEndian.PutUint32(header[12:], testSparseBlockSize)
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(raw)/testSparseBlockSize))
+	binary.LittleEndian.PutUint32(header[20:], uint32(count))
+	return append(header, chunks...)
+}
+
+func TestSparse(t *testing.T) {
+	raw := make([]byte, 5*testSparseBlockSize+100)
+	copy(raw, "start")
+	for i := testSparseBlockSize; i < 2*testSparseBlockSize; i += 4 {
+		copy(raw[i:], "fill")
+	}
+	copy(raw[4*testSparseBlockSize-2:], "across blocks")
+	copy(raw[len(raw)-3:], "end")
+	padded := append(raw, make([]byte, -len(raw)&(testSparseBlockSize-1))...)
+
+	r, err := newSparseReader(bytes.NewReader(sparseImage(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Size() != int64(len(padded)) {
+		t.Errorf("expected size %d, got %d", len(padded), r.Size())
+	}
+	for _, read := range []struct{ off, length int }{
+		{0, len(padded)},
+		{3, 10},
+		{testSparseBlockSize - 1, 6},
+		{4*testSparseBlockSize - 5, 20},
+		{2 * testSparseBlockSize, testSparseBlockSize},
+	} {
+		buf := make([]byte, read.length)
+		if _, err := r.ReadAt(buf, int64(read.off)); err != nil {
+			t.Errorf("reading %d bytes at %d: %s", read.length, read.off, err)
+		} else if !bytes.Equal(buf, padded[read.off:read.off+read.length]) {
+			t.Errorf("reading %d bytes at %d: got %q", read.length, read.off, buf)
+		}
+	}
+	if n, err := r.ReadAt(make([]byte, 10), int64(len(padded)-5)); n != 5 || err == nil {
+		t.Errorf("expected to read 5 bytes and an error at the end of the image, got %d and %v", n, err)
+	}
+}
+
+func TestSparseImages(t *testing.T) {
+	ext4 := ext4Builder{t: t}
+	img, err := Open(bytes.NewReader(sparseImage(ext4.build(testFiles))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Type != "ext4" || !img.Sparse {
+		t.Errorf("expected a sparse ext4 image, got %s", img.Type)
+	}
+	checkImage(t, img, testFiles)
+
+	erofs := erofsBuilder{t: t}
+	img, err = Open(bytes.NewReader(sparseImage(erofs.build(testFiles))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Type != "erofs" || !img.Sparse {
+		t.Errorf("expected a sparse erofs image, got %s", img.Type)
+	}
+	checkImage(t, img, testFiles)
+}
+
+func TestSparseInvalid(t *testing.T) {
+	image := sparseImage(make([]byte, testSparseBlockSize))
+	// Claim one more block than the chunks cover.
+	binary.LittleEndian.PutUint32(image[16:], 2)
+	if _, err := newSparseReader(bytes.NewReader(image)); err == nil {
+		t.Errorf("expected an error for chunks that don't cover the image")
+	}
+}
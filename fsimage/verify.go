@@ -0,0 +1,103 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsimage
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// InstalledFile is an entry of the installed-files JSON written by fileslist for the staging
+// directory of a partition.
+type InstalledFile struct {
+	SHA256 string
+	// The path of the file on the device, e.g. /system/bin/foo.
+	Name string
+	Size int64
+}
+
+// ReadInstalledFiles parses an installed-files JSON file.
+func ReadInstalledFiles(r io.Reader) ([]InstalledFile, error) {
+	var files []InstalledFile
+	if err := json.NewDecoder(r).Decode(&files); err != nil {
+		return nil, fmt.Errorf("reading installed files: %w", err)
+	}
+	return files, nil
+}
+
+type VerifyOptions struct {
+	// The prefix of the names in the installed files list that is the root of the image, e.g.
+	// "/system".
+	Prefix string
+	// Whether every entry in the image must have an SELinux label.
+	RequireSELinuxLabels bool
+}
+
+// Verify checks that the image contains exactly the files in the installed files list, with the
+// same sizes and contents. It returns a description of each difference.
+func Verify(img *Image, installed []InstalledFile, opts VerifyOptions) ([]string, error) {
+	prefix := strings.TrimSuffix(opts.Prefix, "/") + "/"
+	expected := make(map[string]InstalledFile, len(installed))
+	for _, f := range installed {
+		if !strings.HasPrefix(f.Name, prefix) {
+			return nil, fmt.Errorf("installed file %s is not in %s", f.Name, prefix)
+		}
+		expected[path.Clean(strings.TrimPrefix(f.Name, prefix))] = f
+	}
+
+	var problems []string
+	err := img.Walk(func(e *Entry) error {
+		if opts.RequireSELinuxLabels && e.SELinuxContext() == "" {
+			problems = append(problems, fmt.Sprintf("%s: no SELinux label", e.Path))
+		}
+		if e.IsDir() {
+			return nil
+		}
+		want, ok := expected[e.Path]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: not in the installed files", e.Path))
+			return nil
+		}
+		delete(expected, e.Path)
+
+		h := sha256.New()
+		if e.IsSymlink() {
+			io.WriteString(h, e.Link)
+		} else if e.IsRegular() {
+			if err := img.Copy(h, e); err != nil {
+				return err
+			}
+		}
+		if e.Size != want.Size {
+			problems = append(problems, fmt.Sprintf("%s: size is %d, expected %d", e.Path, e.Size, want.Size))
+		} else if sum := fmt.Sprintf("%x", h.Sum(nil)); sum != want.SHA256 {
+			problems = append(problems, fmt.Sprintf("%s: SHA-256 is %s, expected %s", e.Path, sum, want.SHA256))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range installed {
+		if p := path.Clean(strings.TrimPrefix(f.Name, prefix)); expected[p] == f {
+			problems = append(problems, fmt.Sprintf("%s: missing from the image", p))
+		}
+	}
+	return problems, nil
+}
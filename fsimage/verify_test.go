@@ -0,0 +1,111 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsimage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// installedFiles returns the installed files list that fileslist writes for the test files in a
+// staging directory named root.
+func installedFiles(files []testFile) []InstalledFile {
+	var installed []InstalledFile
+	for _, f := range files {
+		if f.mode&ModeType == ModeDir {
+			continue
+		}
+		content := f.data
+		if f.mode&ModeType == ModeSymlink {
+			content = f.link
+		}
+		installed = append(installed, InstalledFile{
+			SHA256: fmt.Sprintf("%x", sha256.Sum256([]byte(content))),
+			Name:   "/root/" + f.path,
+			Size:   f.size(),
+		})
+	}
+	return installed
+}
+
+func TestVerify(t *testing.T) {
+	b := ext4Builder{t: t}
+	img, err := Open(bytes.NewReader(b.build(testFiles)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installed := installedFiles(testFiles)
+	// Round trip through JSON to check the format of the file.
+	content, err := json.Marshal(installed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	installed, err = ReadInstalledFiles(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, err := Verify(img, installed, VerifyOptions{Prefix: "/root"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) > 0 {
+		t.Errorf("expected no problems, got %q", problems)
+	}
+
+	var modified []InstalledFile
+	for _, f := range installed {
+		switch f.Name {
+		case "/root/system/bin/sh":
+			f.Size++
+		case "/root/system/bin/toybox":
+			f.SHA256 = fmt.Sprintf("%x", sha256.Sum256([]byte("toybox")))
+		case "/root/system/etc/empty":
+			continue
+		}
+		modified = append(modified, f)
+	}
+	modified = append(modified, InstalledFile{Name: "/root/system/bin/missing"})
+	problems, err = Verify(img, modified, VerifyOptions{Prefix: "/root/", RequireSELinuxLabels: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		".: no SELinux label",
+		"system: no SELinux label",
+		"system/bin/sh: size is 10, expected 11",
+		"system/bin/toybox: no SELinux label",
+		"system/bin/toybox: SHA-256 is " + fmt.Sprintf("%x", sha256.Sum256([]byte("sh"))) +
+			", expected " + fmt.Sprintf("%x", sha256.Sum256([]byte("toybox"))),
+		"system/etc: no SELinux label",
+		"system/etc/empty: no SELinux label",
+		"system/etc/empty: not in the installed files",
+		"system/etc/long_link: no SELinux label",
+		"system/lib64: no SELinux label",
+		"system/lib64/libfoo.so: no SELinux label",
+		"system/bin/missing: missing from the image",
+	}
+	if !reflect.DeepEqual(problems, expected) {
+		t.Errorf("expected problems:\n%q\ngot:\n%q", expected, problems)
+	}
+
+	if _, err := Verify(img, []InstalledFile{{Name: "/vendor/foo"}}, VerifyOptions{Prefix: "/root"}); err == nil {
+		t.Errorf("expected an error for a file outside of the prefix")
+	}
+}
@@ -450,6 +450,8 @@ type FuzzPackagedModule struct {
 	Corpus         android.Paths
 	Config         android.Path
 	Data           android.Paths
+	Lang           Lang
+	Sanitizers     []string
 }
 
 type FuzzConfigInfo struct {
@@ -473,6 +475,14 @@ type FuzzConfigInfo struct {
 	// Specifies whether fuzz target should check presubmitted code changes for crashes.
 	// Defaults to false.
 	UseForPresubmit bool
+	// Email address of people to CC on bugs or contact about this fuzz target.
+	Cc []string
+	// A brief description of what the fuzzed code does.
+	Description string
+	// Component in Google's bug tracking system that bugs should be filed to.
+	Componentid *int64
+	// Hotlist(s) in Google's bug tracking system that bugs should be marked with.
+	Hotlists []string
 }
 type FuzzPackagedModuleInfo struct {
 	FuzzConfig *FuzzConfigInfo
@@ -480,6 +490,10 @@ type FuzzPackagedModuleInfo struct {
 	Corpus     android.Paths
 	Config     android.Path
 	Data       android.Paths
+	// The language of the fuzz target.
+	Lang Lang
+	// The sanitizers the fuzz target is built with, as in SANITIZE_HOST and SANITIZE_TARGET.
+	Sanitizers []string
 }
 
 var FuzzPackagedModuleInfoProvider = blueprint.NewProvider[FuzzPackagedModuleInfo]()
@@ -490,6 +504,8 @@ func SetFuzzPackagedModuleInfo(ctx android.ModuleContext, fm *FuzzPackagedModule
 		Config:     fm.Config,
 		Corpus:     fm.Corpus,
 		Data:       fm.Data,
+		Lang:       fm.Lang,
+		Sanitizers: fm.Sanitizers,
 	}
 	if fm.FuzzProperties.Fuzz_config != nil {
 		info.FuzzConfig = &FuzzConfigInfo{
@@ -502,6 +518,10 @@ func SetFuzzPackagedModuleInfo(ctx android.ModuleContext, fm *FuzzPackagedModule
 			FuzzOnHaikuHost:      BoolDefault(fm.FuzzProperties.Fuzz_config.Fuzz_on_haiku_host, true),
 			UsePlatformLibs:      fm.FuzzProperties.Fuzz_config.Use_platform_libs,
 			UseForPresubmit:      BoolDefault(fm.FuzzProperties.Fuzz_config.Use_for_presubmit, false),
+			Cc:                   fm.FuzzProperties.Fuzz_config.Cc,
+			Description:          fm.FuzzProperties.Fuzz_config.Description,
+			Componentid:          fm.FuzzProperties.Fuzz_config.Componentid,
+			Hotlists:             fm.FuzzProperties.Fuzz_config.Hotlists,
 		}
 	}
 
@@ -0,0 +1,120 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fuzz
+
+// This file contains the inventory of the C/C++, Rust and Java fuzz targets.
+
+import (
+	"encoding/json"
+	"sort"
+
+	"android/soong/android"
+)
+
+func init() {
+	RegisterFuzzBuildComponents(android.InitRegistrationContext)
+}
+
+func RegisterFuzzBuildComponents(ctx android.RegistrationContext) {
+	ctx.RegisterParallelSingletonType("fuzz_inventory", fuzzInventoryFactory)
+}
+
+// FuzzTargetInventoryEntry describes a fuzz target in fuzz_targets.json.
+type FuzzTargetInventoryEntry struct {
+	Name         string   `json:"name"`
+	Lang         Lang     `json:"lang"`
+	ModuleDir    string   `json:"module_dir"`
+	HostOrTarget string   `json:"host_or_target"`
+	Arch         string   `json:"arch"`
+	Sanitizers   []string `json:"sanitizers,omitempty"`
+	CorpusFiles  int      `json:"corpus_files"`
+	Dictionary   string   `json:"dictionary,omitempty"`
+	Owners       []string `json:"owners,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Componentid  *int64   `json:"componentid,omitempty"`
+	Hotlists     []string `json:"hotlists,omitempty"`
+	// Whether the target is fuzzed continuously on host or device, depending on HostOrTarget.
+	ContinuouslyFuzzed bool `json:"continuously_fuzzed"`
+	UseForPresubmit    bool `json:"use_for_presubmit"`
+}
+
+func fuzzInventoryFactory() android.Singleton {
+	return &fuzzInventorySingleton{}
+}
+
+// fuzzInventorySingleton writes fuzz_targets.json, the inventory of the fuzz targets.
+type fuzzInventorySingleton struct{}
+
+func (s *fuzzInventorySingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	var entries []FuzzTargetInventoryEntry
+	ctx.VisitAllModuleProxies(func(module android.ModuleProxy) {
+		fuzzInfo, ok := android.OtherModuleProvider(ctx, module, FuzzPackagedModuleInfoProvider)
+		if !ok {
+			return
+		}
+		commonInfo := android.OtherModulePointerProviderOrDefault(ctx, module, android.CommonModuleInfoProvider)
+		hostOrTarget := "target"
+		if commonInfo.Target.HostCross {
+			hostOrTarget = "host_cross"
+		} else if commonInfo.Target.Os.Class == android.Host {
+			hostOrTarget = "host"
+		}
+
+		entry := FuzzTargetInventoryEntry{
+			Name:               ctx.ModuleName(module),
+			Lang:               fuzzInfo.Lang,
+			ModuleDir:          ctx.ModuleDir(module),
+			HostOrTarget:       hostOrTarget,
+			Arch:               commonInfo.Target.Arch.ArchType.String(),
+			Sanitizers:         fuzzInfo.Sanitizers,
+			CorpusFiles:        len(fuzzInfo.Corpus),
+			ContinuouslyFuzzed: true,
+		}
+		if fuzzInfo.Dictionary != nil {
+			entry.Dictionary = fuzzInfo.Dictionary.String()
+		}
+		if config := fuzzInfo.FuzzConfig; config != nil {
+			entry.Owners = config.Cc
+			entry.Description = config.Description
+			entry.Componentid = config.Componentid
+			entry.Hotlists = config.Hotlists
+			if hostOrTarget == "target" {
+				entry.ContinuouslyFuzzed = config.FuzzOnHaikuDevice
+			} else {
+				entry.ContinuouslyFuzzed = config.FuzzOnHaikuHost
+			}
+			entry.UseForPresubmit = config.UseForPresubmit
+		}
+		entries = append(entries, entry)
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		if entries[i].HostOrTarget != entries[j].HostOrTarget {
+			return entries[i].HostOrTarget < entries[j].HostOrTarget
+		}
+		return entries[i].Arch < entries[j].Arch
+	})
+	inventory, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to marshal the fuzz target inventory: %s", err)
+		return
+	}
+	inventoryFile := android.PathForOutput(ctx, "fuzz", "fuzz_targets.json")
+	android.WriteFileRule(ctx, inventoryFile, string(inventory))
+	ctx.Phony("fuzz_inventory", inventoryFile)
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fuzz
+
+// This file contains the smoke tests of the host libFuzzer targets, which tradefed runs over the
+// corpus installed next to the fuzz target.
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+	"android/soong/tradefed"
+)
+
+// The default number of inputs each fuzz target runs in its smoke test, which can be overridden
+// with FUZZ_SMOKE_RUNS.
+const defaultFuzzSmokeRuns = 1000
+
+// smokeTestSuites are the test suites the smoke tests are part of.
+var smokeTestSuites = []string{"general-tests"}
+
+// SmokeTest is the host test that runs a libFuzzer target over its corpus, which fails if the
+// fuzz target crashes.
+type SmokeTest struct {
+	// The autogenerated tradefed config of the test.
+	Config android.Path
+	// The test suites the test is part of.
+	TestSuites []string
+}
+
+// AndroidMkEntries adds the test config and the test suites of the smoke test to the Make
+// entries of the fuzz target.
+func (t *SmokeTest) AndroidMkEntries(entries *android.AndroidMkEntries) {
+	if t == nil {
+		return
+	}
+	entries.AddCompatibilityTestSuites(t.TestSuites...)
+	entries.SetString("LOCAL_FULL_TEST_CONFIG", t.Config.String())
+}
+
+// AndroidMkInfo is like AndroidMkEntries for the modules that provide an android.AndroidMkInfo.
+func (t *SmokeTest) AndroidMkInfo(entries *android.AndroidMkInfo) {
+	if t == nil {
+		return
+	}
+	entries.AddCompatibilityTestSuites(t.TestSuites...)
+	entries.SetString("LOCAL_FULL_TEST_CONFIG", t.Config.String())
+}
+
+// ModuleInfoJSON adds the test config and the test suites of the smoke test to module-info.json.
+func (t *SmokeTest) ModuleInfoJSON(moduleInfoJSON *android.ModuleInfoJSON) {
+	if t == nil {
+		return
+	}
+	moduleInfoJSON.AutoTestConfig = []string{"true"}
+	moduleInfoJSON.TestConfig = append(moduleInfoJSON.TestConfig, t.Config.String())
+	moduleInfoJSON.CompatibilitySuites = append(moduleInfoJSON.CompatibilitySuites, t.TestSuites...)
+}
+
+// BuildSmokeTest generates the tradefed config of the smoke test of a libFuzzer target, which
+// must install its corpus to the corpus directory next to it.  It returns nil for the fuzz
+// targets that aren't built for the host or that have no corpus.
+func BuildSmokeTest(ctx android.ModuleContext, fm FuzzPackagedModule) *SmokeTest {
+	if !ctx.Host() || ctx.Target().HostCross || len(fm.Corpus) == 0 {
+		return nil
+	}
+
+	runs := defaultFuzzSmokeRuns
+	if env := ctx.Config().Getenv("FUZZ_SMOKE_RUNS"); env != "" {
+		var err error
+		if runs, err = strconv.Atoi(env); err != nil || runs <= 0 {
+			ctx.ModuleErrorf("FUZZ_SMOKE_RUNS must be a positive number, got %q", env)
+			return nil
+		}
+	}
+
+	// libFuzzer adds the inputs it finds to the first corpus directory, so give it a new one to
+	// keep the installed corpus unchanged.
+	command := []string{ctx.ModuleName(), "-runs=" + strconv.Itoa(runs), "-seed=1", "-create_missing_dirs=1"}
+	if fm.Dictionary != nil {
+		command = append(command, "-dict="+fm.Dictionary.Base())
+	}
+	command = append(command, "smoke_corpus", "corpus")
+
+	config := tradefed.AutoGenTestConfig(ctx, tradefed.AutoGenTestConfigOptions{
+		TestSuites:    smokeTestSuites,
+		AutoGenConfig: proptools.BoolPtr(true),
+		TestRunnerOptions: []tradefed.Option{{
+			Name:  "test-command-line",
+			Key:   ctx.ModuleName(),
+			Value: strings.Join(command, " "),
+		}},
+		HostTemplate: "${FuzzSmokeTestConfigTemplate}",
+	})
+
+	android.SetProvider(ctx, android.TestSuiteInfoProvider, android.TestSuiteInfo{
+		TestSuites: smokeTestSuites,
+	})
+	return &SmokeTest{Config: config, TestSuites: smokeTestSuites}
+}
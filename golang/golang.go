@@ -36,6 +36,7 @@ func init() {
 func RegisterGoModuleTypes(ctx android.RegistrationContext) {
 	ctx.RegisterModuleType("bootstrap_go_package", goPackageModuleFactory)
 	ctx.RegisterModuleType("blueprint_go_binary", goBinaryModuleFactory)
+	ctx.RegisterModuleType("go_test_host", GoTestHostFactory)
 }
 
 // A GoPackage is a module for building Go packages.
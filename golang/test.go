@@ -0,0 +1,316 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/bootstrap"
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+	"android/soong/tradefed"
+)
+
+var (
+	pctx = android.NewPackageContext("android/soong/golang")
+
+	goToolDir = filepath.Join("$goRoot", "pkg", "tool", runtime.GOOS+"_"+runtime.GOARCH)
+
+	// The rules below build a test binary the same way blueprint bootstrap does when it runs the
+	// tests of the Go packages.
+	goCompile = pctx.AndroidStaticRule("goCompile",
+		blueprint.RuleParams{
+			Command:     "GOROOT='$goRoot' $goCompileCmd -o $out -p $pkgPath -complete $incFlags -pack $in",
+			CommandDeps: []string{"$goCompileCmd"},
+		},
+		"pkgPath", "incFlags")
+
+	goLink = pctx.AndroidStaticRule("goLink",
+		blueprint.RuleParams{
+			Command:     "GOROOT='$goRoot' $goLinkCmd -o $out $libDirFlags $in",
+			CommandDeps: []string{"$goLinkCmd"},
+		},
+		"libDirFlags")
+
+	goTestMain = pctx.AndroidStaticRule("goTestMain",
+		blueprint.RuleParams{
+			Command:     "$goTestMainCmd -o $out -pkg $pkgPath $in",
+			CommandDeps: []string{"$goTestMainCmd"},
+		},
+		"pkgPath")
+)
+
+func init() {
+	pctx.VariableFunc("goRoot", func(ctx android.PackageVarContext) string {
+		return ctx.Config().GoRoot()
+	})
+	pctx.StaticVariable("goCompileCmd", filepath.Join(goToolDir, "compile"))
+	pctx.StaticVariable("goLinkCmd", filepath.Join(goToolDir, "link"))
+	pctx.HostBinToolVariable("goTestMainCmd", "gotestmain")
+}
+
+type goTestDependencyTag struct {
+	blueprint.BaseDependencyTag
+}
+
+var goTestDepTag goTestDependencyTag
+
+type goTestProperties struct {
+	// the import path of the package under test, as in bootstrap_go_package.
+	PkgPath *string
+
+	// the Go sources of the package under test.
+	Srcs []string `android:"path"`
+
+	// the Go test sources of the package under test. They are compiled together with srcs, so
+	// they must be in the same package rather than in an external _test package.
+	TestSrcs []string `android:"path"`
+
+	// list of bootstrap_go_package modules imported by srcs and testSrcs.
+	Deps []string
+
+	// list of files or filegroup modules that provide data that should be installed alongside
+	// the test.
+	Data []string `android:"path"`
+
+	// list of compatibility suites (for example "general-tests") that the module should be
+	// installed into.
+	Test_suites []string
+
+	// the name of the test configuration (for example "AndroidTest.xml") that should be
+	// installed with the module.
+	Test_config *string `android:"path"`
+
+	// the name of the test configuration template (for example "AndroidTestTemplate.xml") that
+	// should be installed with the module.
+	Test_config_template *string `android:"path"`
+
+	// Flag to indicate whether or not to create test config automatically. If AndroidTest.xml
+	// doesn't exist next to the Android.bp, this attribute doesn't need to be set to true
+	// explicitly.
+	Auto_gen_config *bool
+
+	// Test options.
+	Test_options android.CommonTestOptions
+}
+
+// A GoTest is a module that builds the tests of a Go package into a test binary that runs as a
+// host test.
+type GoTest struct {
+	android.ModuleBase
+
+	properties goTestProperties
+
+	outputFile android.Path
+	installDir android.InstallPath
+	testConfig android.Path
+}
+
+// go_test_host builds the tests of a Go package into a binary that is installed into test suites
+// and run by tradefed, like cc_test_host. The pkgPath, srcs, testSrcs and deps properties are the
+// same as those of a bootstrap_go_package, whose tests otherwise only run when bootstrapping with
+// -t.
+func GoTestHostFactory() android.Module {
+	module := &GoTest{}
+	module.AddProperties(&module.properties)
+	if module.properties.Test_options.Unit_test == nil {
+		module.properties.Test_options.Unit_test = proptools.BoolPtr(true)
+	}
+	android.InitAndroidArchModule(module, android.HostSupported, android.MultilibFirst)
+	return module
+}
+
+func (g *GoTest) DepsMutator(ctx android.BottomUpMutatorContext) {
+	ctx.AddVariationDependencies(nil, goTestDepTag, g.properties.Deps...)
+}
+
+func (g *GoTest) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	pkgPath := proptools.String(g.properties.PkgPath)
+	if pkgPath == "" {
+		ctx.PropertyErrorf("pkgPath", "missing import path of the package under test")
+		return
+	}
+	if len(g.properties.TestSrcs) == 0 {
+		ctx.PropertyErrorf("testSrcs", "no test sources")
+		return
+	}
+	srcs := android.PathsForModuleSrc(ctx, g.properties.Srcs)
+	testSrcs := android.PathsForModuleSrc(ctx, g.properties.TestSrcs)
+
+	// The archives of the imported packages and of their transitive dependencies.
+	var incFlags, libDirFlags []string
+	var archives android.Paths
+	ctx.WalkDepsProxy(func(child, _ android.ModuleProxy) bool {
+		info, ok := android.OtherModuleProvider(ctx, child, bootstrap.PackageProvider)
+		if !ok {
+			if ctx.OtherModuleDependencyTag(child) == goTestDepTag {
+				ctx.PropertyErrorf("deps", "%q is not a bootstrap_go_package", ctx.OtherModuleName(child))
+			}
+			return false
+		}
+		incFlags = append(incFlags, "-I "+info.PkgRoot)
+		libDirFlags = append(libDirFlags, "-L "+info.PkgRoot)
+		archives = append(archives, outputPath(ctx, info.PackageTarget))
+		return true
+	})
+	incFlags = android.FirstUniqueStrings(incFlags)
+	libDirFlags = android.FirstUniqueStrings(libDirFlags)
+	archives = android.FirstUniquePaths(archives)
+
+	testRoot := android.PathForModuleOut(ctx, "test")
+	testPkgArchive := testRoot.Join(ctx, pkgPath+".a")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        goCompile,
+		Description: "compile " + pkgPath + " tests",
+		Output:      testPkgArchive,
+		Inputs:      append(srcs, testSrcs...),
+		Implicits:   archives,
+		Args: map[string]string{
+			"pkgPath":  pkgPath,
+			"incFlags": strings.Join(incFlags, " "),
+		},
+	})
+
+	mainFile := testRoot.Join(ctx, "test.go")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        goTestMain,
+		Description: "gotestmain " + pkgPath,
+		Output:      mainFile,
+		Inputs:      testSrcs,
+		Args: map[string]string{
+			"pkgPath": pkgPath,
+		},
+	})
+
+	mainArchive := testRoot.Join(ctx, "test.a")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        goCompile,
+		Description: "compile " + pkgPath + " test main",
+		Output:      mainArchive,
+		Input:       mainFile,
+		Implicit:    testPkgArchive,
+		Args: map[string]string{
+			"pkgPath":  "main",
+			"incFlags": strings.Join(append([]string{"-I " + testRoot.String()}, incFlags...), " "),
+		},
+	})
+
+	outputFile := android.PathForModuleOut(ctx, ctx.ModuleName())
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        goLink,
+		Description: "link " + ctx.ModuleName(),
+		Output:      outputFile,
+		Input:       mainArchive,
+		Implicits:   append(android.Paths{testPkgArchive}, archives...),
+		Args: map[string]string{
+			"libDirFlags": strings.Join(append([]string{"-L " + testRoot.String()}, libDirFlags...), " "),
+		},
+	})
+	g.outputFile = outputFile
+
+	// A Go test binary exits with a non-zero status if any test fails, which is what the shell
+	// test runner checks.
+	g.testConfig = tradefed.AutoGenTestConfig(ctx, tradefed.AutoGenTestConfigOptions{
+		TestConfigProp:         g.properties.Test_config,
+		TestConfigTemplateProp: g.properties.Test_config_template,
+		TestSuites:             g.properties.Test_suites,
+		AutoGenConfig:          g.properties.Auto_gen_config,
+		OutputFileName:         outputFile.Base(),
+		DeviceTemplate:         "${ShellTestConfigTemplate}",
+		HostTemplate:           "${ShellTestConfigTemplate}",
+	})
+
+	var data []android.DataPath
+	for _, path := range android.PathsForModuleSrc(ctx, g.properties.Data) {
+		data = append(data, android.DataPath{SrcPath: path})
+	}
+	g.installDir = android.PathForModuleInstall(ctx, "nativetest64", ctx.ModuleName())
+	installedData := ctx.InstallTestData(g.installDir, data)
+	ctx.InstallFile(g.installDir, outputFile.Base(), outputFile, installedData...)
+
+	android.SetProvider(ctx, tradefed.BaseTestProviderKey, tradefed.BaseTestProviderData{
+		TestcaseRelDataFiles: addArch(ctx.Arch().ArchType.String(), installedData.Paths()),
+		OutputFile:           outputFile,
+		TestConfig:           g.testConfig,
+		TestSuites:           g.properties.Test_suites,
+		IsHost:               true,
+		IsUnitTest:           proptools.Bool(g.properties.Test_options.Unit_test),
+		MkInclude:            "$(BUILD_SYSTEM)/soong_cc_rust_prebuilt.mk",
+		MkAppClass:           "NATIVE_TESTS",
+		InstallDir:           g.installDir,
+	})
+	android.SetProvider(ctx, android.TestSuiteInfoProvider, android.TestSuiteInfo{
+		TestSuites: g.properties.Test_suites,
+	})
+
+	moduleInfoJSON := ctx.ModuleInfoJSON()
+	moduleInfoJSON.Class = []string{"NATIVE_TESTS"}
+	if len(g.properties.Test_suites) > 0 {
+		moduleInfoJSON.CompatibilitySuites = append(moduleInfoJSON.CompatibilitySuites, g.properties.Test_suites...)
+	} else {
+		moduleInfoJSON.CompatibilitySuites = append(moduleInfoJSON.CompatibilitySuites, "null-suite")
+	}
+	if proptools.Bool(g.properties.Test_options.Unit_test) {
+		moduleInfoJSON.IsUnitTest = "true"
+		moduleInfoJSON.CompatibilitySuites = append(moduleInfoJSON.CompatibilitySuites, "host-unit-tests")
+	}
+	if g.testConfig != nil {
+		if _, ok := g.testConfig.(android.WritablePath); ok {
+			moduleInfoJSON.AutoTestConfig = []string{"true"}
+		}
+		moduleInfoJSON.TestConfig = append(moduleInfoJSON.TestConfig, g.testConfig.String())
+	}
+
+	ctx.SetOutputFiles(android.Paths{outputFile}, "")
+}
+
+// outputPath translates a path in the output directory from a blueprint bootstrap module into a
+// Path.
+func outputPath(ctx android.ModuleContext, path string) android.Path {
+	return android.PathForArbitraryOutput(ctx, android.Rel(ctx, ctx.Config().OutDir(), path)).WithoutRel()
+}
+
+func addArch(archType string, paths android.Paths) []string {
+	var archRelPaths []string
+	for _, p := range paths {
+		archRelPaths = append(archRelPaths, filepath.Join(archType, p.Rel()))
+	}
+	return archRelPaths
+}
+
+func (g *GoTest) AndroidMkEntries() []android.AndroidMkEntries {
+	return []android.AndroidMkEntries{{
+		Class:      "NATIVE_TESTS",
+		OutputFile: android.OptionalPathForPath(g.outputFile),
+		Include:    "$(BUILD_SYSTEM)/soong_cc_rust_prebuilt.mk",
+		ExtraEntries: []android.AndroidMkExtraEntriesFunc{
+			func(ctx android.AndroidMkExtraEntriesContext, entries *android.AndroidMkEntries) {
+				entries.SetString("LOCAL_MODULE_SUFFIX", "")
+				entries.SetPath("LOCAL_MODULE_PATH", g.installDir)
+				entries.AddCompatibilityTestSuites(g.properties.Test_suites...)
+				if g.testConfig != nil {
+					entries.SetPath("LOCAL_FULL_TEST_CONFIG", g.testConfig)
+				}
+				entries.SetBoolIfTrue("LOCAL_DISABLE_AUTO_GENERATE_TEST_CONFIG",
+					!proptools.BoolDefault(g.properties.Auto_gen_config, true))
+				g.properties.Test_options.SetAndroidMkEntries(entries)
+			},
+		},
+	}}
+}
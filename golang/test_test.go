@@ -0,0 +1,91 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+import (
+	"testing"
+
+	"android/soong/android"
+
+	"github.com/google/blueprint/bootstrap"
+)
+
+var prepareForGoTest = android.GroupFixturePreparers(
+	android.PrepareForTestWithArchMutator,
+	android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+		RegisterGoModuleTypes(ctx)
+		ctx.PreDepsMutators(func(ctx android.RegisterMutatorsContext) {
+			ctx.BottomUpBlueprint("bootstrap_deps", bootstrap.BootstrapDeps).UsesReverseDependencies()
+		})
+	}),
+	android.FixtureMergeMockFs(android.MockFS{
+		"foo/foo.go":      nil,
+		"foo/foo_test.go": nil,
+		"foo/testdata/in": nil,
+	}),
+)
+
+func TestGoTestHost(t *testing.T) {
+	result := prepareForGoTest.RunTestWithBp(t, `
+		bootstrap_go_package {
+			name: "gopkg",
+			pkgPath: "test/pkg",
+		}
+
+		go_test_host {
+			name: "foo_test",
+			pkgPath: "test/foo",
+			srcs: ["foo/foo.go"],
+			testSrcs: ["foo/foo_test.go"],
+			deps: ["gopkg"],
+			data: ["foo/testdata/in"],
+			test_suites: ["general-tests"],
+		}
+	`)
+
+	foo := result.ModuleForTests(t, "foo_test", result.Config.BuildOSTarget.String())
+
+	compile := foo.Output("test/test/foo.a")
+	android.AssertPathsRelativeToTopEquals(t, "compiled sources",
+		[]string{"foo/foo.go", "foo/foo_test.go"}, compile.Inputs)
+	android.AssertStringEquals(t, "pkgPath", "test/foo", compile.Args["pkgPath"])
+	android.AssertStringDoesContain(t, "imports of the test package", compile.Args["incFlags"], "-I ")
+
+	testMain := foo.Output("test/test.go")
+	android.AssertPathsRelativeToTopEquals(t, "gotestmain inputs", []string{"foo/foo_test.go"}, testMain.Inputs)
+
+	link := foo.Output("foo_test")
+	android.AssertStringEquals(t, "linked archive", "test/test.a", link.Input.Rel())
+
+	android.AssertPathsRelativeToTopEquals(t, "output files",
+		[]string{android.PathRelativeToTop(link.Output)}, foo.OutputFiles(result.TestContext, t, ""))
+
+	testInfo, _ := android.OtherModuleProvider(result, foo.Module(), android.TestSuiteInfoProvider)
+	android.AssertDeepEquals(t, "test suites", []string{"general-tests"}, testInfo.TestSuites)
+
+	entries := android.AndroidMkEntriesForTest(t, result.TestContext, foo.Module())[0]
+	android.AssertStringEquals(t, "LOCAL_IS_UNIT_TEST", "true", entries.EntryMap["LOCAL_IS_UNIT_TEST"][0])
+}
+
+func TestGoTestHostErrors(t *testing.T) {
+	prepareForGoTest.ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`testSrcs: no test sources`)).RunTestWithBp(t, `
+		go_test_host {
+			name: "foo_test",
+			pkgPath: "test/foo",
+			srcs: ["foo/foo.go"],
+		}
+	`)
+}
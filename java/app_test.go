@@ -3530,6 +3530,13 @@ func TestUsesLibraries(t *testing.T) {
 	android.AssertStringDoesContain(t, "dexpreopt app cmd context", cmd, "--context-json=")
 	android.AssertStringDoesContain(t, "dexpreopt app cmd product_packages", cmd,
 		"--product-packages=out/soong/.intermediates/app/android_common/dexpreopt/app/product_packages.txt")
+
+	// Test that the CLC is described for clc_explain, with the modules that provide the libraries.
+	clcReport := android.ContentFromFileRuleForTests(t, result.TestContext,
+		app.Output("dexpreopt/app/class_loader_context.json"))
+	android.AssertStringDoesContain(t, "CLC report library", clcReport, `"Name": "com.non.sdk.lib"`)
+	android.AssertStringDoesContain(t, "CLC report module", clcReport, `"Module": "non-sdk-lib"`)
+	app.Output("dexpreopt/app/class_loader_context.dot")
 }
 
 func TestDexpreoptBcp(t *testing.T) {
@@ -91,6 +91,62 @@ var (
 		}, []string{"javacFlags", "bootClasspath", "classpath", "processorpath", "processor", "srcJars", "srcJarDir",
 			"outDir", "annoDir", "annoSrcJar", "javaVersion"}, nil)
 
+	// javacIncremental is the javac rule used with the use_incremental_javac partial compile flag.
+	// When SOONG_USE_PARTIAL_COMPILE is set, javac_incremental recompiles only the sources that
+	// changed since the previous build and the sources that depend on them, on top of the classes
+	// of the previous jar, and compiles all the sources when an ABI-relevant input changed.
+	// Otherwise it compiles all the sources like the javac rule, and removes the state of the
+	// incremental compiles.
+	javacIncremental = pctx.AndroidStaticRule("javac-incremental",
+		blueprint.RuleParams{
+			Command: `rm -rf "$outDir" "$annoDir" "$annoSrcJar.tmp" "$srcJarDir" "$out.tmp" && ` +
+				`mkdir -p "$outDir" "$annoDir" "$srcJarDir" && ` +
+				`${config.ZipSyncCmd} -d $srcJarDir -l $srcJarDir/list -f "*.java" $srcJars && ` +
+				`(if [ -s $srcJarDir/list ] || [ -s $out.rsp ] ; then ` +
+				`if [ -n "$${SOONG_USE_PARTIAL_COMPILE}" ]; then ` +
+				`${config.JavacIncrementalCmd} --target "$out" --classes_dir "$outDir" --inputs_file "$out.rsp" ` +
+				`--srcjar_list "$srcJarDir/list" --srcjars "$srcJars" --deps_file "$incrementalDepsFile" -- ` +
+				`${config.SoongJavacWrapper} ${config.JavacCmd} ` +
+				`${config.JavacHeapFlags} ${config.JavacVmFlags} ${config.CommonJdkFlags} ` +
+				`$processorpath $processor $javacFlags $bootClasspath $classpath ` +
+				`-source $javaVersion -target $javaVersion ` +
+				`-d $outDir -s $annoDir ; ` +
+				`else ` +
+				`rm -f "$out.javac_state" && ` +
+				`${config.FindInputDeltaCmd} --template '' --target "$out" --inputs_file "$out.rsp" && ` +
+				`${config.SoongJavacWrapper} ${config.JavacCmd} ` +
+				`${config.JavacHeapFlags} ${config.JavacVmFlags} ${config.CommonJdkFlags} ` +
+				`$processorpath $processor $javacFlags $bootClasspath $classpath ` +
+				`-source $javaVersion -target $javaVersion ` +
+				`-d $outDir -s $annoDir @$out.rsp @$srcJarDir/list ; fi ; fi ) && ` +
+				`${config.SoongZipCmd} -jar -o $annoSrcJar.tmp -C $annoDir -D $annoDir && ` +
+				`${config.SoongZipCmd} -jar -o $out.tmp -C $outDir -D $outDir && ` +
+				`if ! cmp -s "$out.tmp" "$out"; then mv "$out.tmp" "$out"; fi && ` +
+				`if ! cmp -s "$annoSrcJar.tmp" "$annoSrcJar"; then mv "$annoSrcJar.tmp" "$annoSrcJar"; fi && ` +
+				`if [ -f "$out.pc_state.new" ]; then mv "$out.pc_state.new" "$out.pc_state"; fi && ` +
+				`if [ -f "$out.javac_state.new" ]; then mv "$out.javac_state.new" "$out.javac_state"; fi && ` +
+				`rm -rf "$srcJarDir" "$outDir"`,
+			CommandDeps: []string{
+				"${config.FindInputDeltaCmd}",
+				"${config.JavacIncrementalCmd}",
+				"${config.JavacCmd}",
+				"${config.SoongZipCmd}",
+				"${config.ZipSyncCmd}",
+			},
+			CommandOrderOnly: []string{"${config.SoongJavacWrapper}"},
+			Restat:           true,
+			Rspfile:          "$out.rsp",
+			RspfileContent:   "$in",
+		}, "javacFlags", "bootClasspath", "classpath", "processorpath", "processor", "srcJars", "srcJarDir",
+		"outDir", "annoDir", "annoSrcJar", "javaVersion", "incrementalDepsFile")
+
+	// Remove the classes jar and the state of the incremental compiles when
+	// SOONG_USE_PARTIAL_COMPILE is turned off, so that the jar is compiled from scratch.
+	javacIncrementalClean = pctx.AndroidStaticRule("javac-incremental-partialcompileclean",
+		blueprint.RuleParams{
+			Command: `rm -f "${builtOut}" "${builtOut}.pc_state" "${builtOut}.javac_state"`,
+		}, "builtOut")
+
 	_ = pctx.VariableFunc("kytheCorpus",
 		func(ctx android.PackageVarContext) string { return ctx.Config().XrefCorpusName() })
 	_ = pctx.VariableFunc("kytheCuEncoding",
@@ -652,9 +708,33 @@ func transformJavaToClasses(ctx android.ModuleContext, outputFile android.Writab
 		outDir = filepath.Join(shardDir, outDir)
 		annoDir = filepath.Join(shardDir, annoDir)
 	}
+	args := map[string]string{
+		"javacFlags":    flags.javacFlags,
+		"bootClasspath": bootClasspath,
+		"classpath":     classpathArg,
+		"processorpath": flags.processorPath.FormJavaClassPath("-processorpath"),
+		"processor":     processor,
+		"srcJars":       strings.Join(srcJars.Strings(), " "),
+		"srcJarDir":     android.PathForModuleOut(ctx, intermediatesDir, srcJarDir).String(),
+		"outDir":        android.PathForModuleOut(ctx, intermediatesDir, outDir).String(),
+		"annoDir":       android.PathForModuleOut(ctx, intermediatesDir, annoDir).String(),
+		"annoSrcJar":    annoSrcJar.String(),
+		"javaVersion":   flags.javaVersion.String(),
+	}
 	rule := javac
 	if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_JAVAC") {
 		rule = javacRE
+	} else if ctx.Config().PartialCompileFlags().Use_incremental_javac && len(flags.processors) == 0 {
+		// Annotation processors may generate sources from any of the sources, so only
+		// compiles without them can be incremental.
+		rule = javacIncremental
+		// javac_incremental tracks the sources and srcjars separately from the other
+		// dependencies, which are listed in a file as they may not fit on the command line.
+		incrementalDepsFile := outputFile.ReplaceExtension(ctx, "incremental_deps")
+		android.WriteFileRule(ctx, incrementalDepsFile, strings.Join(android.SortedUniqueStrings(
+			android.RemoveListFromList(deps.Strings(), srcJars.Strings())), "\n"))
+		deps = append(deps, incrementalDepsFile)
+		args["incrementalDepsFile"] = incrementalDepsFile.String()
 	}
 	ctx.Build(pctx, android.BuildParams{
 		Rule:           rule,
@@ -663,20 +743,22 @@ func transformJavaToClasses(ctx android.ModuleContext, outputFile android.Writab
 		ImplicitOutput: annoSrcJar,
 		Inputs:         srcFiles,
 		Implicits:      deps,
-		Args: map[string]string{
-			"javacFlags":    flags.javacFlags,
-			"bootClasspath": bootClasspath,
-			"classpath":     classpathArg,
-			"processorpath": flags.processorPath.FormJavaClassPath("-processorpath"),
-			"processor":     processor,
-			"srcJars":       strings.Join(srcJars.Strings(), " "),
-			"srcJarDir":     android.PathForModuleOut(ctx, intermediatesDir, srcJarDir).String(),
-			"outDir":        android.PathForModuleOut(ctx, intermediatesDir, outDir).String(),
-			"annoDir":       android.PathForModuleOut(ctx, intermediatesDir, annoDir).String(),
-			"annoSrcJar":    annoSrcJar.String(),
-			"javaVersion":   flags.javaVersion.String(),
-		},
+		Args:           args,
 	})
+
+	if rule == javacIncremental {
+		cleanPhonyPath := android.PathForModuleOut(ctx, intermediatesDir, outDir+"-partialcompileclean")
+		ctx.Build(pctx, android.BuildParams{
+			Rule:        javacIncrementalClean,
+			Description: "javacIncrementalClean",
+			Output:      cleanPhonyPath,
+			Args: map[string]string{
+				"builtOut": outputFile.String(),
+			},
+			PhonyOutput: true,
+		})
+		ctx.Phony("partialcompileclean", cleanPhonyPath)
+	}
 }
 
 func TransformResourcesToJar(ctx android.ModuleContext, outputFile android.WritablePath,
@@ -160,6 +160,7 @@ func init() {
 
 	pctx.HostBinToolVariable("GenKotlinBuildFileCmd", "gen-kotlin-build-file")
 	pctx.HostBinToolVariable("FindInputDeltaCmd", "find_input_delta")
+	pctx.HostBinToolVariable("JavacIncrementalCmd", "javac_incremental")
 
 	pctx.SourcePathVariable("JarArgsCmd", "build/soong/scripts/jar-args.sh")
 	pctx.SourcePathVariable("PackageCheckCmd", "build/soong/scripts/package-check.sh")
@@ -458,6 +458,13 @@ func (d *dexpreopter) dexpreopt(ctx android.ModuleContext, libName string, dexJa
 		return
 	}
 
+	// Describe the class loader context for clc_explain, to debug mismatches with the context on
+	// device.
+	clcReportJson := android.PathForModuleOut(ctx, "dexpreopt", dexJarStem, "class_loader_context.json")
+	clcReportDot := android.PathForModuleOut(ctx, "dexpreopt", dexJarStem, "class_loader_context.dot")
+	dexpreopt.WriteClassLoaderContextReport(ctx, libName, d.classLoaderContexts, clcReportJson, clcReportDot)
+	ctx.CheckbuildFile(clcReportJson, clcReportDot)
+
 	globalSoong := dexpreopt.GetGlobalSoongConfig(ctx)
 
 	// The root "product_packages.txt" is generated by `build/make/core/Makefile`. It contains a list
@@ -56,6 +56,7 @@ type JavaFuzzTest struct {
 // fuzzing in Android Runtime (ART: Android OS on emulator or device)
 func JavaFuzzFactory() android.Module {
 	module := &JavaFuzzTest{}
+	module.fuzzPackagedModule.Lang = fuzz.Java
 
 	module.addHostAndDeviceProperties()
 	module.AddProperties(&module.testProperties)
@@ -3733,7 +3733,7 @@ func addCLCFromDep(ctx android.ModuleContext, depModule android.ModuleProxy,
 				optional = true
 			}
 		}
-		clcMap.AddContext(ctx, dexpreopt.AnySdkVersion, *sdkLib, optional,
+		clcMap.AddContextForModule(ctx, dexpreopt.AnySdkVersion, *sdkLib, depName, optional,
 			dep.DexJarBuildPath.PathOrNil(),
 			dep.UsesLibraryDependencyInfo.DexJarInstallPath, dep.UsesLibraryDependencyInfo.ClassLoaderContexts)
 	} else {
@@ -107,11 +107,11 @@ type linter struct {
 }
 
 type LintDepSets struct {
-	HTML, Text, XML, Baseline depset.DepSet[android.Path]
+	HTML, Text, XML, SARIF, Baseline depset.DepSet[android.Path]
 }
 
 type LintDepSetsBuilder struct {
-	HTML, Text, XML, Baseline *depset.Builder[android.Path]
+	HTML, Text, XML, SARIF, Baseline *depset.Builder[android.Path]
 }
 
 func NewLintDepSetBuilder() LintDepSetsBuilder {
@@ -119,14 +119,16 @@ func NewLintDepSetBuilder() LintDepSetsBuilder {
 		HTML:     depset.NewBuilder[android.Path](depset.POSTORDER),
 		Text:     depset.NewBuilder[android.Path](depset.POSTORDER),
 		XML:      depset.NewBuilder[android.Path](depset.POSTORDER),
+		SARIF:    depset.NewBuilder[android.Path](depset.POSTORDER),
 		Baseline: depset.NewBuilder[android.Path](depset.POSTORDER),
 	}
 }
 
-func (l LintDepSetsBuilder) Direct(html, text, xml android.Path, baseline android.OptionalPath) LintDepSetsBuilder {
+func (l LintDepSetsBuilder) Direct(html, text, xml, sarif android.Path, baseline android.OptionalPath) LintDepSetsBuilder {
 	l.HTML.Direct(html)
 	l.Text.Direct(text)
 	l.XML.Direct(xml)
+	l.SARIF.Direct(sarif)
 	if baseline.Valid() {
 		l.Baseline.Direct(baseline.Path())
 	}
@@ -137,6 +139,7 @@ func (l LintDepSetsBuilder) Transitive(info *LintInfo) LintDepSetsBuilder {
 	l.HTML.Transitive(info.TransitiveHTML)
 	l.Text.Transitive(info.TransitiveText)
 	l.XML.Transitive(info.TransitiveXML)
+	l.SARIF.Transitive(info.TransitiveSARIF)
 	l.Baseline.Transitive(info.TransitiveBaseline)
 	return l
 }
@@ -146,6 +149,7 @@ func (l LintDepSetsBuilder) Build() LintDepSets {
 		HTML:     l.HTML.Build(),
 		Text:     l.Text.Build(),
 		XML:      l.XML.Build(),
+		SARIF:    l.SARIF.Build(),
 		Baseline: l.Baseline.Build(),
 	}
 }
@@ -200,11 +204,13 @@ type LintInfo struct {
 	HTML              android.Path
 	Text              android.Path
 	XML               android.Path
+	SARIF             android.Path
 	ReferenceBaseline android.Path
 
 	TransitiveHTML     depset.DepSet[android.Path]
 	TransitiveText     depset.DepSet[android.Path]
 	TransitiveXML      depset.DepSet[android.Path]
+	TransitiveSARIF    depset.DepSet[android.Path]
 	TransitiveBaseline depset.DepSet[android.Path]
 }
 
@@ -419,9 +425,13 @@ func (l *linter) lint(ctx android.ModuleContext) {
 	html := android.PathForModuleOut(ctx, "lint", "lint-report.html")
 	text := android.PathForModuleOut(ctx, "lint", "lint-report.txt")
 	xml := android.PathForModuleOut(ctx, "lint", "lint-report.xml")
+	sarif := android.PathForModuleOut(ctx, "lint", "lint-report.sarif")
+	// The SARIF file written by lint contains paths into the sandbox, it is rewritten after lint
+	// runs.
+	rawSarif := android.PathForModuleOut(ctx, "lint", "lint-report.raw.sarif")
 	referenceBaseline := android.PathForModuleOut(ctx, "lint", "lint-baseline.xml")
 
-	depSetsBuilder := NewLintDepSetBuilder().Direct(html, text, xml, baseline)
+	depSetsBuilder := NewLintDepSetBuilder().Direct(html, text, xml, sarif, baseline)
 
 	ctx.VisitDirectDepsProxyWithTag(staticLibTag, func(dep android.ModuleProxy) {
 		if info, ok := android.OtherModuleProvider(ctx, dep, LintProvider); ok {
@@ -463,7 +473,7 @@ func (l *linter) lint(ctx android.ModuleContext) {
 
 	rule.Command().Text("rm -rf").Flag(lintPaths.cacheDir.String()).Flag(lintPaths.homeDir.String())
 	rule.Command().Text("mkdir -p").Flag(lintPaths.cacheDir.String()).Flag(lintPaths.homeDir.String())
-	rule.Command().Text("rm -f").Output(html).Output(text).Output(xml)
+	rule.Command().Text("rm -f").Output(html).Output(text).Output(xml).Output(rawSarif).Output(sarif)
 
 	files, ok := allLintDatabasefiles[l.compileSdkKind]
 	if !ok {
@@ -493,6 +503,7 @@ func (l *linter) lint(ctx android.ModuleContext) {
 		FlagWithOutput("--html ", html).
 		FlagWithOutput("--text ", text).
 		FlagWithOutput("--xml ", xml).
+		FlagWithOutput("--sarif ", rawSarif).
 		FlagWithArg("--compile-sdk-version ", l.compileSdkVersion.String()).
 		FlagWithArg("--java-language-level ", l.javaLanguageLevel).
 		FlagWithArg("--kotlin-language-level ", l.kotlinLanguageLevel).
@@ -504,6 +515,7 @@ func (l *linter) lint(ctx android.ModuleContext) {
 
 	rule.Temporary(lintPaths.projectXML)
 	rule.Temporary(lintPaths.configXML)
+	rule.Temporary(rawSarif)
 
 	suppressExitCode := BoolDefault(l.properties.Lint.Suppress_exit_code, false)
 	if exitCode := ctx.Config().Getenv("ANDROID_LINT_SUPPRESS_EXIT_CODE"); exitCode == "" && !suppressExitCode {
@@ -537,17 +549,28 @@ func (l *linter) lint(ctx android.ModuleContext) {
 	// The HTML output contains a date, remove it to make the output deterministic.
 	rule.Command().Text(`sed -i.tmp -e 's|Check performed at .*\(</nav>\)|\1|'`).Output(html)
 
+	// Make the locations in the SARIF output relative to the top of the source tree and attribute
+	// the results to the module.
+	rule.Command().BuiltTool("lint_sarif").
+		FlagWithOutput("-o ", sarif).
+		FlagWithArg("-root ", "$PWD").
+		FlagWithArg("-module ", ctx.ModuleName()).
+		FlagWithArg("-module_dir ", ctx.ModuleDir()).
+		Input(rawSarif)
+
 	rule.Build("lint", "lint")
 
 	android.SetProvider(ctx, LintProvider, &LintInfo{
 		HTML:              html,
 		Text:              text,
 		XML:               xml,
+		SARIF:             sarif,
 		ReferenceBaseline: referenceBaseline,
 
 		TransitiveHTML:     depSets.HTML,
 		TransitiveText:     depSets.Text,
 		TransitiveXML:      depSets.XML,
+		TransitiveSARIF:    depSets.SARIF,
 		TransitiveBaseline: depSets.Baseline,
 	})
 
@@ -566,8 +589,9 @@ func BuildModuleLintReportZips(ctx android.ModuleContext, depSets LintDepSets, v
 	htmlList := android.SortedUniquePaths(depSets.HTML.ToList())
 	textList := android.SortedUniquePaths(depSets.Text.ToList())
 	xmlList := android.SortedUniquePaths(depSets.XML.ToList())
+	sarifList := android.SortedUniquePaths(depSets.SARIF.ToList())
 
-	if len(htmlList) == 0 && len(textList) == 0 && len(xmlList) == 0 {
+	if len(htmlList) == 0 && len(textList) == 0 && len(xmlList) == 0 && len(sarifList) == 0 {
 		return nil
 	}
 
@@ -580,13 +604,18 @@ func BuildModuleLintReportZips(ctx android.ModuleContext, depSets LintDepSets, v
 	xmlZip := android.PathForModuleOut(ctx, "lint-report-xml.zip")
 	lintZip(ctx, xmlList, xmlZip, validations)
 
-	return android.Paths{htmlZip, textZip, xmlZip}
+	sarifZip := android.PathForModuleOut(ctx, "lint-report-sarif.zip")
+	lintZip(ctx, sarifList, sarifZip, validations)
+
+	return android.Paths{htmlZip, textZip, xmlZip, sarifZip}
 }
 
 type lintSingleton struct {
 	htmlZip              android.WritablePath
 	textZip              android.WritablePath
 	xmlZip               android.WritablePath
+	sarifZip             android.WritablePath
+	sarif                android.WritablePath
 	referenceBaselineZip android.WritablePath
 }
 
@@ -701,14 +730,39 @@ func (l *lintSingleton) generateLintReportZips(ctx android.SingletonContext) {
 	l.xmlZip = android.PathForOutput(ctx, "lint-report-xml.zip")
 	zip(l.xmlZip, func(l *LintInfo) android.Path { return l.XML })
 
+	l.sarifZip = android.PathForOutput(ctx, "lint-report-sarif.zip")
+	zip(l.sarifZip, func(l *LintInfo) android.Path { return l.SARIF })
+
+	l.sarif = android.PathForOutput(ctx, "lint-report.sarif")
+	l.mergeSarif(ctx, outputs)
+
 	l.referenceBaselineZip = android.PathForOutput(ctx, "lint-report-reference-baselines.zip")
 	zip(l.referenceBaselineZip, func(l *LintInfo) android.Path { return l.ReferenceBaseline })
 
-	ctx.Phony("lint-check", l.htmlZip, l.textZip, l.xmlZip, l.referenceBaselineZip)
+	ctx.Phony("lint-check", l.htmlZip, l.textZip, l.xmlZip, l.sarifZip, l.sarif, l.referenceBaselineZip)
 
 	if !ctx.Config().UnbundledBuild() {
-		ctx.DistForGoal("lint-check", l.htmlZip, l.textZip, l.xmlZip, l.referenceBaselineZip)
+		ctx.DistForGoal("lint-check", l.htmlZip, l.textZip, l.xmlZip, l.sarifZip, l.sarif, l.referenceBaselineZip)
+	}
+}
+
+// mergeSarif merges the SARIF reports of all modules into a single file that can be uploaded to
+// code scanning tools, attributing the results of each module to its team.
+func (l *lintSingleton) mergeSarif(ctx android.SingletonContext, outputs []*LintInfo) {
+	var paths android.Paths
+	for _, output := range outputs {
+		if output.SARIF != nil {
+			paths = append(paths, output.SARIF)
+		}
 	}
+	paths = android.SortedUniquePaths(paths)
+
+	rule := android.NewRuleBuilder(pctx, ctx)
+	rule.Command().BuiltTool("lint_sarif").
+		FlagWithOutput("-o ", l.sarif).
+		FlagWithInput("-teams ", android.AllTeamsFilePath(ctx)).
+		FlagWithRspFileInputList("@", l.sarif.ReplaceExtension(ctx, "rsp"), paths)
+	rule.Build("lint_sarif", "merge lint SARIF reports")
 }
 
 func init() {
@@ -329,3 +329,31 @@ func TestNotTestViaDefault(t *testing.T) {
 		t.Fatalf("Expected command to contain --test")
 	}
 }
+
+func TestJavaLintSarif(t *testing.T) {
+	t.Parallel()
+	result := PrepareForTestWithJavaDefaultModules.RunTestWithBp(t, `
+		java_library {
+			name: "foo",
+			srcs: [
+				"a.java",
+			],
+			min_sdk_version: "29",
+			sdk_version: "current",
+		}
+	`)
+
+	foo := result.ModuleForTests(t, "foo", "android_common")
+	sboxProto := android.RuleBuilderSboxProtoForTests(t, result.TestContext, foo.Output("lint.sbox.textproto"))
+	command := *sboxProto.Commands[0].Command
+
+	if !strings.Contains(command, "--sarif __SBOX_SANDBOX_DIR__/out/soong/.intermediates/foo/android_common/lint/lint-report.raw.sarif") {
+		t.Errorf("Expected lint to write a SARIF report, got %q", command)
+	}
+	if !strings.Contains(command, "lint_sarif -o __SBOX_SANDBOX_DIR__/out/soong/.intermediates/foo/android_common/lint/lint-report.sarif -root $PWD -module foo -module_dir . ") {
+		t.Errorf("Expected the SARIF report to be rewritten with lint_sarif, got %q", command)
+	}
+
+	lintInfo, _ := android.OtherModuleProvider(result.TestContext.OtherModuleProviderAdaptor(), foo.Module(), LintProvider)
+	android.AssertPathRelativeToTopEquals(t, "SARIF", "out/soong/.intermediates/foo/android_common/lint/lint-report.sarif", lintInfo.SARIF)
+}
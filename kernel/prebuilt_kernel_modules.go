@@ -71,6 +71,15 @@ type prebuiltKernelModulesProperties struct {
 	// Whether debug symbols should be stripped from the *.ko files.
 	// Defaults to true.
 	Strip_debug_symbols *bool
+
+	// Module.symvers of the kernel the modules are loaded into. If this or kernel_image is set,
+	// the build checks that the symbols needed by the modules are exported by the kernel, by srcs
+	// or by system_deps with matching CRCs, and that the vermagic of the modules matches the
+	// kernel.
+	Kernel_symvers *string `android:"path,arch_variant"`
+
+	// vmlinux of the kernel the modules are loaded into. See kernel_symvers.
+	Kernel_image *string `android:"path,arch_variant"`
 }
 
 // prebuilt_kernel_modules installs a set of prebuilt kernel module files to the correct directory.
@@ -103,7 +112,8 @@ func (pkm *prebuiltKernelModules) GenerateAndroidBuildActions(ctx android.Module
 	modules := android.PathsForModuleSrc(ctx, pkm.properties.Srcs)
 	systemModules := android.PathsForModuleSrc(ctx, pkm.properties.System_deps)
 
-	depmodOut := pkm.runDepmod(ctx, modules, systemModules)
+	validations := pkm.checkKernelModules(ctx, modules, systemModules)
+	depmodOut := pkm.runDepmod(ctx, modules, systemModules, validations)
 	if proptools.BoolDefault(pkm.properties.Strip_debug_symbols, true) {
 		modules = stripDebugSymbols(ctx, modules)
 	}
@@ -255,7 +265,34 @@ func modulesDirForAndroidDlkm(ctx android.ModuleContext, modulesDir android.Outp
 	}
 }
 
-func (pkm *prebuiltKernelModules) runDepmod(ctx android.ModuleContext, modules android.Paths, systemModules android.Paths) depmodOutputs {
+// checkKernelModules checks that the modules can be loaded into the kernel set in kernel_symvers or
+// kernel_image, and returns the outputs of the check, to be used as validations.
+func (pkm *prebuiltKernelModules) checkKernelModules(ctx android.ModuleContext, modules android.Paths, systemModules android.Paths) android.Paths {
+	if pkm.properties.Kernel_symvers == nil && pkm.properties.Kernel_image == nil {
+		return nil
+	}
+	out := android.PathForModuleOut(ctx, "kernel_module_check.stamp")
+
+	builder := android.NewRuleBuilder(pctx, ctx)
+	cmd := builder.Command().BuiltTool("kernel_module_check").FlagWithOutput("-o ", out)
+	if pkm.properties.Kernel_symvers != nil {
+		cmd.FlagWithInput("-symvers ", android.PathForModuleSrc(ctx, *pkm.properties.Kernel_symvers))
+	}
+	if pkm.properties.Kernel_image != nil {
+		cmd.FlagWithInput("-kernel ", android.PathForModuleSrc(ctx, *pkm.properties.Kernel_image))
+	}
+	if pkm.KernelVersion() != "" {
+		cmd.FlagWithArg("-kernel_release ", pkm.KernelVersion())
+	}
+	cmd.FlagForEachInput("-system_module ", systemModules)
+	cmd.Inputs(modules)
+	builder.Build("kernel_module_check", fmt.Sprintf("kernel_module_check %s", ctx.ModuleName()))
+
+	ctx.CheckbuildFile(out)
+	return android.Paths{out}
+}
+
+func (pkm *prebuiltKernelModules) runDepmod(ctx android.ModuleContext, modules android.Paths, systemModules android.Paths, validations android.Paths) depmodOutputs {
 	baseDir := android.PathForModuleOut(ctx, "depmod").OutputPath
 	fakeVer := "0.0" // depmod demands this anyway
 	modulesDir := baseDir.Join(ctx, "lib", "modules", fakeVer)
@@ -304,6 +341,7 @@ func (pkm *prebuiltKernelModules) runDepmod(ctx android.ModuleContext, modules a
 		BuiltTool("depmod").
 		FlagWithArg("-b ", baseDir.String()).
 		Text(fakeVer).
+		Validations(validations).
 		ImplicitOutput(modulesDep).
 		ImplicitOutput(modulesSoftdep).
 		ImplicitOutput(modulesAlias)
@@ -58,6 +58,56 @@ func TestKernelModulesFilelist(t *testing.T) {
 	android.AssertDeepEquals(t, "foo packaging specs", expected, actual)
 }
 
+func TestKernelModulesCheck(t *testing.T) {
+	ctx := android.GroupFixturePreparers(
+		cc.PrepareForTestWithCcDefaultModules,
+		android.FixtureRegisterWithContext(registerKernelBuildComponents),
+		android.MockFS{
+			"mod1.ko":               nil,
+			"mod2.ko":               nil,
+			"system/core.ko":        nil,
+			"kernel/Module.symvers": nil,
+			"kernel/vmlinux":        nil,
+		}.AddToFixture(),
+	).RunTestWithBp(t, `
+		prebuilt_kernel_modules {
+			name: "foo",
+			srcs: ["mod1.ko", "mod2.ko"],
+			system_deps: ["system/core.ko"],
+			kernel_version: "6.1",
+			kernel_symvers: "kernel/Module.symvers",
+			kernel_image: "kernel/vmlinux",
+		}
+		prebuilt_kernel_modules {
+			name: "bar",
+			srcs: ["mod1.ko"],
+		}
+	`)
+
+	foo := ctx.ModuleForTests(t, "foo", "android_arm64_armv8-a")
+	check := foo.Output("kernel_module_check.stamp")
+	cmd := check.RuleParams.Command
+	for _, expected := range []string{
+		"-symvers kernel/Module.symvers",
+		"-kernel kernel/vmlinux",
+		"-kernel_release 6.1",
+		"-system_module system/core.ko",
+		"mod1.ko mod2.ko",
+	} {
+		android.AssertStringDoesContain(t, "kernel_module_check command", cmd, expected)
+	}
+
+	depmod := foo.Rule("depmod")
+	android.AssertPathsRelativeToTopEquals(t, "depmod validations",
+		[]string{android.PathRelativeToTop(check.Output)}, depmod.Validations)
+
+	// The check only runs if the kernel is set.
+	bar := ctx.ModuleForTests(t, "bar", "android_arm64_armv8-a")
+	if bar.MaybeOutput("kernel_module_check.stamp").Rule != nil {
+		t.Errorf("kernel_module_check should not run without kernel_symvers or kernel_image")
+	}
+}
+
 func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
@@ -0,0 +1,372 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mk2rbc
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// The execution tests convert each test/execution/*/product.mk.test, run the result with the
+// minimal product configuration runtime in test/execution/product_config.rbc.test and compare
+// the variables it prints with the ones in make_output.txt, which capture_make_output.sh
+// captures by running make on the same makefile.  The shell commands run against a mock file
+// system with the contents of the test's tree/ directory.
+func TestExecution(t *testing.T) {
+	for _, v := range known_variables {
+		KnownVariables.NewVariable(v.name, v.class, v.starlarkType)
+	}
+	runtime, err := os.ReadFile(filepath.Join(getTestDirectory(), "execution", "product_config.rbc.test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests, err := filepath.Glob(filepath.Join(getTestDirectory(), "execution", "*", "product.mk.test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tests) == 0 {
+		t.Fatal("no execution tests found")
+	}
+	for _, mkFile := range tests {
+		testDir := filepath.Dir(mkFile)
+		t.Run(filepath.Base(testDir), func(t *testing.T) {
+			expected, err := readMakeOutput(filepath.Join(testDir, "make_output.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			shell, err := newShellEmulator(filepath.Join(testDir, "tree"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			in, err := os.Open(mkFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer in.Close()
+			ss, err := Convert(Request{
+				MkFile:         "product.mk",
+				Reader:         in,
+				OutputSuffix:   ".star",
+				SourceFS:       shell.fs,
+				MakefileFinder: &testMakefileFinder{fs: shell.fs},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			script := ss.String()
+			r := &productConfigRunner{
+				scripts: map[string]string{
+					baseUri:                 string(runtime),
+					":product.star":         script,
+					":input_variables.star": "def init(g, handle):\n  pass\n",
+				},
+				shell: shell,
+			}
+			got, err := r.run(Launcher(":product.star", ":input_variables.star", "product"))
+			if err != nil {
+				t.Fatalf("%s\nGenerated script:\n%s", err, script)
+			}
+			for name, want := range expected {
+				if value, ok := got[name]; !ok {
+					t.Errorf("%s: expected %q, but it is not set\nGenerated script:\n%s", name, want, script)
+				} else if value != want {
+					t.Errorf("%s: expected %q, got %q\nGenerated script:\n%s", name, want, value, script)
+				}
+			}
+		})
+	}
+}
+
+// readMakeOutput reads the `NAME := value` lines make prints.
+func readMakeOutput(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseMakeAssignments(strings.Split(string(data), "\n")), nil
+}
+
+func parseMakeAssignments(lines []string) map[string]string {
+	result := make(map[string]string)
+	for _, line := range lines {
+		if name, value, ok := strings.Cut(line, " := "); ok {
+			result[name] = strings.TrimSpace(value)
+		} else if name, ok := strings.CutSuffix(line, " :="); ok {
+			result[name] = ""
+		}
+	}
+	return result
+}
+
+// productConfigRunner runs the product configuration launcher the way rbcrun does, with the
+// modules loaded from memory and $(shell) commands run by the shell emulator.
+type productConfigRunner struct {
+	scripts map[string]string
+	shell   *shellEmulator
+	loaded  map[string]starlark.StringDict
+}
+
+func (r *productConfigRunner) run(launcher string) (map[string]string, error) {
+	r.loaded = make(map[string]starlark.StringDict)
+	var printed []string
+	thread := &starlark.Thread{
+		Name: "mk2rbc",
+		Print: func(_ *starlark.Thread, msg string) {
+			printed = append(printed, msg)
+		},
+		Load: r.load,
+	}
+	if _, err := starlark.ExecFile(thread, "launcher.star", launcher, r.builtins()); err != nil {
+		if evalErr, ok := err.(*starlark.EvalError); ok {
+			return nil, fmt.Errorf("%s", evalErr.Backtrace())
+		}
+		return nil, err
+	}
+	return parseMakeAssignments(printed), nil
+}
+
+func (r *productConfigRunner) load(thread *starlark.Thread, module string) (starlark.StringDict, error) {
+	if globals, ok := r.loaded[module]; ok {
+		return globals, nil
+	}
+	script, ok := r.scripts[module]
+	if !ok {
+		return nil, fmt.Errorf("cannot load %s", module)
+	}
+	globals, err := starlark.ExecFile(thread, module, script, r.builtins())
+	if err != nil {
+		return nil, err
+	}
+	r.loaded[module] = globals
+	return globals, nil
+}
+
+func (r *productConfigRunner) builtins() starlark.StringDict {
+	return starlark.StringDict{
+		"struct":     starlark.NewBuiltin("struct", starlarkstruct.Make),
+		"rblf_shell": starlark.NewBuiltin("rblf_shell", r.shell.builtin),
+	}
+}
+
+// shellEmulator runs the commands that $(shell ...) allows against a mock file system, so that
+// the results don't depend on the host.
+type shellEmulator struct {
+	fs       FindMockFS
+	contents map[string]string
+}
+
+// newShellEmulator creates a shellEmulator with the files in dir, which may not exist.
+func newShellEmulator(dir string) (*shellEmulator, error) {
+	e := &shellEmulator{contents: make(map[string]string)}
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		e.contents[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	e.fs = NewFindMockFS(files)
+	return e, nil
+}
+
+func (e *shellEmulator) builtin(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var command string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &command); err != nil {
+		return nil, err
+	}
+	output, err := e.run(command)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", command, err)
+	}
+	return starlark.String(output), nil
+}
+
+// run returns the output of the command with the newlines replaced by spaces, like make does.
+func (e *shellEmulator) run(command string) (string, error) {
+	var lines []string
+	for _, segment := range strings.Split(command, "|") {
+		words := strings.Fields(segment)
+		if len(words) == 0 {
+			return "", fmt.Errorf("empty command")
+		}
+		args, err := e.expandArgs(words[1:])
+		if err != nil {
+			return "", err
+		}
+		switch words[0] {
+		case "cat":
+			lines, err = e.cat(args)
+		case "echo":
+			lines = []string{strings.Join(args, " ")}
+		case "find":
+			lines, err = e.find(args)
+		case "ls":
+			lines, err = e.ls(args)
+		case "sort":
+			if len(args) > 0 {
+				lines, err = e.cat(args)
+			}
+			sort.Strings(lines)
+		default:
+			err = fmt.Errorf("%s is not emulated", words[0])
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(lines, " "), nil
+}
+
+// expandArgs removes the quotes from the arguments and expands the unquoted glob patterns.
+func (e *shellEmulator) expandArgs(words []string) ([]string, error) {
+	var args []string
+	for _, word := range words {
+		if unquoted := unquoteShellWord(word); unquoted != word {
+			args = append(args, unquoted)
+			continue
+		}
+		if !strings.ContainsAny(word, "*?[") {
+			args = append(args, word)
+			continue
+		}
+		matches, err := fs.Glob(e.fs, word)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			matches = []string{word}
+		}
+		// FindMockFS doesn't sort directory entries, but the shell sorts the expansion.
+		sort.Strings(matches)
+		args = append(args, matches...)
+	}
+	return args, nil
+}
+
+func (e *shellEmulator) cat(args []string) ([]string, error) {
+	var lines []string
+	for _, arg := range args {
+		content, ok := e.contents[filepath.Clean(arg)]
+		if !ok {
+			return nil, fmt.Errorf("%s: %s", arg, os.ErrNotExist)
+		}
+		lines = append(lines, strings.Split(strings.TrimSuffix(content, "\n"), "\n")...)
+	}
+	return lines, nil
+}
+
+func (e *shellEmulator) ls(args []string) ([]string, error) {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	var lines []string
+	for _, arg := range args {
+		fi, err := e.fs.Stat(filepath.Clean(arg))
+		if err != nil {
+			return nil, fmt.Errorf("cannot access %s: %s", arg, err)
+		}
+		if !fi.IsDir() {
+			lines = append(lines, arg)
+			continue
+		}
+		entries, err := fs.ReadDir(e.fs, filepath.Clean(arg))
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		sort.Strings(names)
+		lines = append(lines, names...)
+	}
+	return lines, nil
+}
+
+func (e *shellEmulator) find(args []string) ([]string, error) {
+	var roots []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		roots, args = append(roots, args[0]), args[1:]
+	}
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+	var namePattern, fileType string
+	for len(args) > 0 {
+		if len(args) < 2 {
+			return nil, fmt.Errorf("missing argument to %s", args[0])
+		}
+		switch args[0] {
+		case "-name":
+			namePattern = args[1]
+		case "-type":
+			fileType = args[1]
+		default:
+			return nil, fmt.Errorf("%s is not emulated", args[0])
+		}
+		args = args[2:]
+	}
+
+	var lines []string
+	for _, root := range roots {
+		err := fs.WalkDir(e.fs, filepath.Clean(root), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if fileType == "d" && !d.IsDir() || fileType == "f" && d.IsDir() {
+				return nil
+			}
+			if namePattern != "" {
+				if matched, _ := filepath.Match(namePattern, d.Name()); !matched {
+					return nil
+				}
+			}
+			lines = append(lines, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
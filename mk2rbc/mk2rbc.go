@@ -1443,22 +1443,6 @@ func (p *makeControlFuncParser) parse(ctx *parseContext, node mkparser.Node, arg
 	}
 }
 
-type shellCallParser struct{}
-
-func (p *shellCallParser) parse(ctx *parseContext, node mkparser.Node, args *mkparser.MakeString) starlarkExpr {
-	// Shell functions need special treatment as everything
-	// after the name is a single text argument
-	x := ctx.parseMakeString(node, args)
-	if xBad, ok := x.(*badExpr); ok {
-		return xBad
-	}
-	return &callExpr{
-		name:       baseName + ".shell",
-		args:       []starlarkExpr{x},
-		returnType: starlarkTypeUnknown,
-	}
-}
-
 type myDirCallParser struct{}
 
 func (p *myDirCallParser) parse(ctx *parseContext, node mkparser.Node, args *mkparser.MakeString) starlarkExpr {
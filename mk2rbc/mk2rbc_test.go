@@ -312,6 +312,51 @@ def init(g, handle):
   rblf.mkinfo("product.mk", "this is the info")
   rblf.mkerror("product.mk", "this is the error")
   cfg["PRODUCT_NAME"] = rblf.shell("echo *")
+`,
+	},
+	{
+		desc:   "Shell commands",
+		mkname: "product.mk",
+		in: `
+PRODUCT_PACKAGES := $(shell ls vendor/foo1)
+PRODUCT_PACKAGES += $(shell find vendor -name '*.mk' | sort)
+PRODUCT_NAME := $(shell date +%Y%m%d)
+PRODUCT_MODEL := $(shell sort vendor/foo1/list.txt)
+PRODUCT_MODEL := $(shell find -L vendor -name '*.mk')
+PRODUCT_MODEL := $(shell cat /etc/hostname)
+PRODUCT_MODEL := $(shell find . -name '*.mk' -delete)
+PRODUCT_MODEL := $(shell find -L /etc -name '*.mk')
+PRODUCT_MODEL := $(shell find -H -O2 vendor .. -name '*.mk')
+PRODUCT_MODEL := $(shell rm -rf out)
+PRODUCT_MODEL := $(shell ls vendor; ls ..)
+PRODUCT_MODEL := $(shell $(MY_TOOL) foo)
+PRODUCT_MODEL := $(shell sort -o vendor/x.mk vendor/y.mk)
+PRODUCT_MODEL := $(shell sort -ro vendor/x.mk vendor/y.mk)
+PRODUCT_MODEL := $(shell sort --output=vendor/x.mk vendor/y.mk)
+PRODUCT_MODEL := $(shell cat $(FOO))
+PRODUCT_MODEL := $(shell ls $(TOP)/..)
+`,
+		expected: `load("//build/make/core:product_config.rbc", "rblf")
+
+def init(g, handle):
+  cfg = rblf.cfg(handle)
+  cfg["PRODUCT_PACKAGES"] = [rblf.shell("ls vendor/foo1")]
+  cfg["PRODUCT_PACKAGES"] += [rblf.shell("find vendor -name '*.mk' | sort")]
+  cfg["PRODUCT_NAME"] = rblf.shell("TZ=UTC LC_ALL=C date +%Y%m%d")
+  cfg["PRODUCT_MODEL"] = rblf.shell("sort vendor/foo1/list.txt")
+  cfg["PRODUCT_MODEL"] = rblf.shell("find -L vendor -name '*.mk'")
+  cfg["PRODUCT_MODEL"] = rblf.mk2rbc_error("product.mk:7", "$(shell cat /etc/hostname) is not supported: cat: /etc/hostname is outside of the source tree")
+  cfg["PRODUCT_MODEL"] = rblf.mk2rbc_error("product.mk:8", "$(shell find . -name '*.mk' -delete) is not supported: find: -delete is not allowed")
+  cfg["PRODUCT_MODEL"] = rblf.mk2rbc_error("product.mk:9", "$(shell find -L /etc -name '*.mk') is not supported: find: /etc is outside of the source tree")
+  cfg["PRODUCT_MODEL"] = rblf.mk2rbc_error("product.mk:10", "$(shell find -H -O2 vendor .. -name '*.mk') is not supported: find: .. is outside of the source tree")
+  cfg["PRODUCT_MODEL"] = rblf.mk2rbc_error("product.mk:11", "$(shell rm -rf out) is not supported: rm is not an allowed command")
+  cfg["PRODUCT_MODEL"] = rblf.mk2rbc_error("product.mk:12", "$(shell ls vendor; ls ..) is not supported: ';' is not allowed")
+  cfg["PRODUCT_MODEL"] = rblf.mk2rbc_error("product.mk:13", "$(shell $(MY_TOOL) foo) is not supported: the command name cannot be a variable")
+  cfg["PRODUCT_MODEL"] = rblf.mk2rbc_error("product.mk:14", "$(shell sort -o vendor/x.mk vendor/y.mk) is not supported: sort: -o is not allowed")
+  cfg["PRODUCT_MODEL"] = rblf.mk2rbc_error("product.mk:15", "$(shell sort -ro vendor/x.mk vendor/y.mk) is not supported: sort: -o is not allowed")
+  cfg["PRODUCT_MODEL"] = rblf.mk2rbc_error("product.mk:16", "$(shell sort --output=vendor/x.mk vendor/y.mk) is not supported: sort: --output is not allowed")
+  cfg["PRODUCT_MODEL"] = rblf.mk2rbc_error("product.mk:17", "$(shell cat $(FOO)) is not supported: cat: the arguments cannot reference variables")
+  cfg["PRODUCT_MODEL"] = rblf.mk2rbc_error("product.mk:18", "$(shell ls $(TOP)/..) is not supported: ls: the arguments cannot reference variables")
 `,
 	},
 	{
@@ -0,0 +1,240 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mk2rbc
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	mkparser "android/soong/androidmk/parser"
+)
+
+// Characters that make a shell command do more than run a pipeline of simple commands:
+// command separators, redirections, subshells and expansions.
+const shellMetaCharacters = ";&<>`$()\n"
+
+// The environment `date` runs in, so that its output doesn't depend on the host settings.
+const shellDateEnv = "TZ=UTC LC_ALL=C "
+
+// shellCommands are the commands that $(shell ...) may run in product configuration, with
+// the checks for their arguments.  They only read the source tree, so running them from
+// Starlark gives the same result as running them from make.
+var shellCommands = map[string]func(args []string) error{
+	"cat":  checkShellPathArgs,
+	"date": checkDateArgs,
+	"echo": func([]string) error { return nil },
+	"find": checkFindArgs,
+	"ls":   checkShellPathArgs,
+	"sort": checkSortArgs,
+}
+
+// Actions that make find run commands or write files, and options that read the starting
+// points from a file.
+var findForbiddenActions = map[string]bool{
+	"-delete":      true,
+	"-exec":        true,
+	"-execdir":     true,
+	"-files0-from": true,
+	"-fls":         true,
+	"-fprint":      true,
+	"-fprint0":     true,
+	"-fprintf":     true,
+	"-ok":          true,
+	"-okdir":       true,
+}
+
+type shellCallParser struct{}
+
+func (p *shellCallParser) parse(ctx *parseContext, node mkparser.Node, args *mkparser.MakeString) starlarkExpr {
+	// Shell functions need special treatment as everything
+	// after the name is a single text argument
+	command, err := checkShellCommand(args)
+	if err != nil {
+		return ctx.newBadExpr(node, "$(shell %s) is not supported: %s", strings.TrimSpace(args.Dump()), err)
+	}
+	x := ctx.parseMakeString(node, command)
+	if xBad, ok := x.(*badExpr); ok {
+		return xBad
+	}
+	return &callExpr{
+		name:       baseName + ".shell",
+		args:       []starlarkExpr{x},
+		returnType: starlarkTypeUnknown,
+	}
+}
+
+// checkShellCommand verifies that the command is a pipeline of allowed commands and returns
+// the command to run, which has a fixed environment for `date`.
+func checkShellCommand(command *mkparser.MakeString) (*mkparser.MakeString, error) {
+	for _, s := range command.Strings {
+		if i := strings.IndexAny(s, shellMetaCharacters); i >= 0 {
+			return nil, fmt.Errorf("%q is not allowed", s[i])
+		}
+	}
+
+	segments := command.Split("|")
+	rewritten := false
+	for i, segment := range segments {
+		words := segment.Words()
+		if len(words) == 0 {
+			return nil, fmt.Errorf("empty command")
+		}
+		if !words[0].Const() {
+			return nil, fmt.Errorf("the command name cannot be a variable")
+		}
+		name := words[0].Dump()
+		check, ok := shellCommands[name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not an allowed command", name)
+		}
+		// Arguments that reference variables can't be checked until they are expanded.
+		var args []string
+		for _, word := range words[1:] {
+			if !word.Const() {
+				return nil, fmt.Errorf("%s: the arguments cannot reference variables", name)
+			}
+			args = append(args, unquoteShellWord(word.Dump()))
+		}
+		if err := check(args); err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		if name == "date" {
+			segments[i] = prependMakeString(segment, shellDateEnv)
+			rewritten = true
+		}
+	}
+	if !rewritten {
+		return command, nil
+	}
+	return joinMakeStrings(segments, "|"), nil
+}
+
+func unquoteShellWord(word string) string {
+	if len(word) >= 2 && (word[0] == '\'' || word[0] == '"') && word[len(word)-1] == word[0] {
+		return word[1 : len(word)-1]
+	}
+	return word
+}
+
+// checkShellPathArgs verifies that the paths a command reads are in the source tree.
+func checkShellPathArgs(args []string) error {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if err := checkSourcePath(arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkSourcePath(path string) error {
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "~") {
+		return fmt.Errorf("%s is outside of the source tree", path)
+	}
+	for _, element := range strings.Split(path, "/") {
+		if element == ".." {
+			return fmt.Errorf("%s is outside of the source tree", path)
+		}
+	}
+	return nil
+}
+
+// Options that come before the starting points of find.  -D takes an argument.
+var findLeadingOptions = map[string]bool{
+	"-D": true,
+	"-H": true,
+	"-L": true,
+	"-P": true,
+}
+
+func checkFindArgs(args []string) error {
+	i := 0
+	for ; i < len(args); i++ {
+		if args[i] == "-D" {
+			i++
+		} else if !findLeadingOptions[args[i]] && !strings.HasPrefix(args[i], "-O") {
+			break
+		}
+	}
+	// The starting points end at the first expression token.
+	for ; i < len(args) && !strings.HasPrefix(args[i], "-") && args[i] != "!"; i++ {
+		if err := checkSourcePath(args[i]); err != nil {
+			return err
+		}
+	}
+	for ; i < len(args); i++ {
+		if findForbiddenActions[args[i]] {
+			return fmt.Errorf("%s is not allowed", args[i])
+		}
+	}
+	return nil
+}
+
+// Long options that make sort write files or run commands.
+var sortForbiddenOptions = []string{"--compress-program", "--output", "--temporary-directory"}
+
+// checkSortArgs verifies that sort only reads files in the source tree and writes nothing
+// but its standard output.
+func checkSortArgs(args []string) error {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--") {
+			for _, option := range sortForbiddenOptions {
+				if arg == option || strings.HasPrefix(arg, option+"=") {
+					return fmt.Errorf("%s is not allowed", option)
+				}
+			}
+		} else if strings.HasPrefix(arg, "-") {
+			if i := strings.IndexAny(arg, "oT"); i >= 0 {
+				return fmt.Errorf("-%c is not allowed", arg[i])
+			}
+		}
+	}
+	return checkShellPathArgs(args)
+}
+
+func checkDateArgs(args []string) error {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "+") && arg != "-u" && arg != "--utc" {
+			return fmt.Errorf("only +FORMAT and -u arguments are allowed, not %s", arg)
+		}
+	}
+	return nil
+}
+
+// prependMakeString returns a copy of the MakeString with the text inserted after its
+// leading whitespace.
+func prependMakeString(ms *mkparser.MakeString, text string) *mkparser.MakeString {
+	r := cloneMakeString(ms)
+	trimmed := strings.TrimLeft(r.Strings[0], " \t")
+	r.Strings[0] = r.Strings[0][:len(r.Strings[0])-len(trimmed)] + text + trimmed
+	return r
+}
+
+// joinMakeStrings is the opposite of MakeString.Split.
+func joinMakeStrings(parts []*mkparser.MakeString, sep string) *mkparser.MakeString {
+	r := &mkparser.MakeString{StringPos: parts[0].StringPos, Strings: []string{""}}
+	for i, part := range parts {
+		if i > 0 {
+			r.Strings[len(r.Strings)-1] += sep
+		}
+		r.Strings[len(r.Strings)-1] += part.Strings[0]
+		r.Strings = append(r.Strings, part.Strings[1:]...)
+		r.Variables = append(r.Variables, part.Variables...)
+	}
+	return r
+}
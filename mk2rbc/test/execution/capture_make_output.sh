@@ -0,0 +1,17 @@
+#!/bin/bash -e
+# Regenerates the make_output.txt files that TestExecution compares the
+# converted product configurations against, by running make on each
+# product.mk.test. Each test runs its shell commands in its tree/ directory,
+# which TestExecution loads into a mock file system.
+
+cd "$(dirname "$0")"
+for test in */; do
+  test="${test%/}"
+  dir="$test"
+  if [[ -d "$test/tree" ]]; then
+    dir="$test/tree"
+  fi
+  make -s --no-print-directory -C "$dir" \
+    -f "$PWD/$test/product.mk.test" -f "$PWD/print_vars.mk" print_vars \
+    > "$test/make_output.txt"
+done
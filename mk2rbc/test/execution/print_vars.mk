@@ -0,0 +1,7 @@
+# Prints the product variables the way the Starlark product configuration
+# prints them, after the product makefile has been read.
+$(foreach v,$(sort $(filter PRODUCT_%,$(.VARIABLES))),$(info $(v) := $(strip $($(v)))))
+
+.PHONY: print_vars
+print_vars:
+	@true
@@ -156,7 +156,7 @@ func (p *PythonBinaryModule) buildBinary(ctx android.ModuleContext) {
 		srcsZips = append(srcsZips, sharedLibZip)
 	}
 	p.installSource = registerBuildActionForParFile(ctx, embeddedLauncher, launcherPath,
-		"python3", main, p.getStem(ctx), srcsZips)
+		"python3", main, p.getStem(ctx), srcsZips, p.staticCheckValidations)
 
 	var sharedLibs []string
 	// if embedded launcher is enabled, we need to collect the shared library dependencies of the
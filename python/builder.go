@@ -93,7 +93,7 @@ func init() {
 
 func registerBuildActionForParFile(ctx android.ModuleContext, embeddedLauncher bool,
 	launcherPath android.OptionalPath, interpreter, main, binName string,
-	srcsZips android.Paths) android.Path {
+	srcsZips, validations android.Paths) android.Path {
 
 	// .intermediate output path for bin executable.
 	binFile := android.PathForModuleOut(ctx, binName)
@@ -107,6 +107,7 @@ func registerBuildActionForParFile(ctx android.ModuleContext, embeddedLauncher b
 			Description: "host python archive",
 			Output:      binFile,
 			Implicits:   implicits,
+			Validations: validations,
 			Args: map[string]string{
 				"interp":   strings.Replace(interpreter, "/", `\/`, -1),
 				"main":     strings.Replace(strings.TrimSuffix(main, pyExt), "/", ".", -1),
@@ -123,6 +124,7 @@ func registerBuildActionForParFile(ctx android.ModuleContext, embeddedLauncher b
 				Description: "embedded python archive",
 				Output:      binFile,
 				Implicits:   implicits,
+				Validations: validations,
 				Args: map[string]string{
 					"srcsZips": strings.Join(srcsZips.Strings(), " "),
 					"launcher": launcherPath.String(),
@@ -134,6 +136,7 @@ func registerBuildActionForParFile(ctx android.ModuleContext, embeddedLauncher b
 				Description: "embedded python archive",
 				Output:      binFile,
 				Implicits:   implicits,
+				Validations: validations,
 				Args: map[string]string{
 					"main":     strings.Replace(strings.TrimSuffix(main, pyExt), "/", ".", -1),
 					"srcsZips": strings.Join(srcsZips.Strings(), " "),
@@ -0,0 +1,79 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package python
+
+import (
+	"android/soong/android"
+)
+
+type GeneratedPythonLibraryModule struct {
+	PythonLibraryModule
+	callbacks  GeneratedPythonLibraryCallbacks
+	moduleName string
+}
+
+type GeneratedPythonLibraryCallbacks interface {
+	// Called from inside DepsMutator, gives a chance to AddDependencies
+	DepsMutator(module *GeneratedPythonLibraryModule, ctx android.BottomUpMutatorContext)
+
+	// Called from inside GenerateAndroidBuildActions. Add the build rules to generate the
+	// python sources, and return the paths to them.  The generated sources are packaged relative
+	// to the module's gen directory.
+	GenerateSourceBuildActions(module *GeneratedPythonLibraryModule, ctx android.ModuleContext) android.Paths
+}
+
+// GeneratedPythonLibraryModuleFactory provides a utility for modules that are generated
+// source code, including ones outside the python package, to build python libraries
+// from that generated source.  The resulting module provides PythonLibraryInfo, so it
+// can be listed in the libs of other python modules.
+//
+// These modules will have some properties blocked, and it will be an error if
+// modules attempt to set them. See the list of property names in GenerateAndroidBuildActions
+// for the list of those properties.
+func GeneratedPythonLibraryModuleFactory(moduleName string, callbacks GeneratedPythonLibraryCallbacks, properties interface{}) android.Module {
+	module := &GeneratedPythonLibraryModule{
+		PythonLibraryModule: PythonLibraryModule{
+			hod:      android.HostAndDeviceSupported,
+			multilib: android.MultilibBoth,
+		},
+		callbacks:  callbacks,
+		moduleName: moduleName,
+	}
+	module.AddProperties(&module.properties, &module.protoProperties, &module.sourceProperties)
+	if properties != nil {
+		module.AddProperties(properties)
+	}
+	android.InitAndroidArchModule(module, module.hod, module.multilib)
+	android.InitDefaultableModule(module)
+	return module
+}
+
+func (module *GeneratedPythonLibraryModule) DepsMutator(ctx android.BottomUpMutatorContext) {
+	module.callbacks.DepsMutator(module, ctx)
+	module.PythonLibraryModule.DepsMutator(ctx)
+}
+
+func (module *GeneratedPythonLibraryModule) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	// These modules are all-generated, so disallow these properties to keep it simple.
+	if len(module.properties.Srcs) != 0 {
+		ctx.PropertyErrorf("srcs", "srcs not allowed on %s", module.moduleName)
+	}
+	if len(module.properties.Exclude_srcs) != 0 {
+		ctx.PropertyErrorf("exclude_srcs", "exclude_srcs not allowed on %s", module.moduleName)
+	}
+
+	module.generatedSrcs = module.callbacks.GenerateSourceBuildActions(module, ctx)
+	module.PythonLibraryModule.GenerateAndroidBuildActions(ctx)
+}
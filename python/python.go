@@ -130,6 +130,9 @@ type BaseProperties struct {
 	// true. This allows taking the resulting binary outside of the build and running it on machines
 	// that don't have python installed or may have an older version of python.
 	Embedded_launcher *bool
+
+	// mypy and pylint checks of the sources of host modules, run as validations of the module.
+	Static_checks StaticChecksProperties
 }
 
 // Used to store files of current module after expanding dependencies
@@ -169,6 +172,13 @@ type PythonLibraryModule struct {
 	// The shared libraries that should be bundled with the python code for
 	// any standalone python binaries that depend on this module.
 	bundleSharedLibs android.Paths
+
+	// Python sources generated by the module itself, see GeneratedPythonLibraryModule.
+	generatedSrcs android.Paths
+
+	// The outputs of the static checks of the module's sources, added as validations of the
+	// module's outputs.
+	staticCheckValidations android.Paths
 }
 
 // newModule generates new Python base module
@@ -311,6 +321,8 @@ func (p *PythonLibraryModule) DepsMutator(ctx android.BottomUpMutatorContext) {
 	}
 
 	p.AddDepsOnPythonLauncherAndStdlib(ctx, hostStdLibTag, hostLauncherTag, hostlauncherSharedLibTag, false, ctx.Config().BuildOSTarget)
+
+	p.staticChecksDeps(ctx)
 }
 
 // AddDepsOnPythonLauncherAndStdlib will make the current module depend on the python stdlib,
@@ -383,6 +395,7 @@ func (p *PythonLibraryModule) GenerateAndroidBuildActions(ctx android.ModuleCont
 		ctx.PropertyErrorf("version.py2.enabled", "Python 2 is no longer supported, please convert to python 3.")
 	}
 	expandedSrcs := android.PathsForModuleSrcExcludes(ctx, p.properties.Srcs, p.properties.Exclude_srcs)
+	expandedSrcs = append(expandedSrcs, p.generatedSrcs...)
 	// Keep before any early returns.
 	android.SetProvider(ctx, android.TestOnlyProviderKey, android.TestModuleInformation{
 		TestOnly:       Bool(p.sourceProperties.Test_only),
@@ -441,6 +454,7 @@ func (p *PythonLibraryModule) GenerateAndroidBuildActions(ctx android.ModuleCont
 
 	// generate the zipfile of all source and data files
 	p.srcsZip = p.createSrcsZip(ctx, pkgPath)
+	p.staticCheckValidations = p.buildStaticChecks(ctx)
 	p.precompiledSrcsZip = p.precompileSrcs(ctx)
 
 	android.SetProvider(ctx, PythonLibraryInfoProvider, PythonLibraryInfo{
@@ -655,6 +669,7 @@ func (p *PythonLibraryModule) precompileSrcs(ctx android.ModuleContext) android.
 		Input:       p.srcsZip,
 		Output:      out,
 		Implicits:   launcherSharedLibs,
+		Validations: p.staticCheckValidations,
 		Description: "Precompile the python sources of " + ctx.ModuleName(),
 		Args: map[string]string{
 			"stdlibZip":     stdLib.String(),
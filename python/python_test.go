@@ -330,6 +330,74 @@ func expectModule(t *testing.T, ctx *android.TestContext, name, variant, expecte
 	android.AssertPathRelativeToTopEquals(t, "srcsZip", expectedSrcsZip, base.srcsZip)
 }
 
+func TestPythonStaticChecks(t *testing.T) {
+	t.Parallel()
+	ctx := android.GroupFixturePreparers(
+		android.PrepareForTestWithDefaults,
+		android.PrepareForTestWithArchMutator,
+		android.PrepareForTestWithAllowMissingDependencies,
+		cc.PrepareForTestWithCcDefaultModules,
+		PrepareForTestWithPythonBuildComponents,
+	).RunTestWithBp(t, `
+		python_binary_host {
+			name: "mypy",
+			srcs: ["mypy.py"],
+		}
+		python_binary_host {
+			name: "pylint",
+			srcs: ["pylint.py"],
+		}
+		python_library_host {
+			name: "symbolfile",
+			pkg_path: "symbolfile",
+			srcs: ["symbolfile.py"],
+		}
+		python_binary_host {
+			name: "ndkstubgen",
+			pkg_path: "ndkstubgen",
+			main: "ndkstubgen.py",
+			srcs: ["ndkstubgen.py"],
+			libs: ["symbolfile"],
+			embedded_launcher: false,
+			static_checks: {
+				mypy: true,
+				mypy_config: "mypy.ini",
+				pylint: true,
+			},
+		}
+	`)
+
+	module := ctx.ModuleForTests(t, "ndkstubgen", "linux_glibc_x86_64")
+	mypy := module.Rule("mypy")
+	android.AssertStringDoesContain(t, "mypy flags", mypy.Args["flags"], "--config-file=mypy.ini")
+	android.AssertStringDoesContain(t, "mypy srcs", mypy.Args["srcs"], "static_checks/mypy/ndkstubgen/ndkstubgen.py")
+	android.AssertStringDoesContain(t, "mypy srcs zips", mypy.Args["srcsZips"], "symbolfile.py.srcszip")
+	android.AssertStringDoesNotContain(t, "mypy srcs", mypy.Args["srcs"], "symbolfile.py")
+	pylint := module.Rule("pylint")
+	android.AssertStringEquals(t, "pylint flags", "", pylint.Args["flags"])
+
+	// The checks are validations of the binary, so that they don't delay its dependents.
+	binary := module.Output("ndkstubgen")
+	android.AssertPathsRelativeToTopEquals(t, "binary validations",
+		[]string{mypy.Output.String(), pylint.Output.String()}, binary.Validations)
+
+	// Device modules can't enable static checks.
+	android.GroupFixturePreparers(
+		PrepareForTestWithPythonBuildComponents,
+		android.PrepareForTestWithAllowMissingDependencies,
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		"static checks are only supported for host modules")).
+		RunTestWithBp(t, `
+			python_library {
+				name: "lib",
+				srcs: ["lib.py"],
+				static_checks: {
+					mypy: true,
+				},
+			}
+		`)
+}
+
 func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
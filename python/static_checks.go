@@ -0,0 +1,176 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package python
+
+// This file contains the mypy and pylint checks of the sources of Python host modules.
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+var (
+	mypyTag   = dependencyTag{name: "mypy"}
+	pylintTag = dependencyTag{name: "pylint"}
+
+	// The sources of the module and of its transitive libs are extracted from their srcs zips, so
+	// that they are laid out according to their pkg_path as at runtime.
+	mypy = pctx.AndroidStaticRule("mypy", blueprint.RuleParams{
+		Command: `rm -rf $root && mkdir -p $root && ` +
+			`for z in $srcsZips; do unzip -qo -d $root $$z || exit 1; done && ` +
+			`MYPYPATH=$root $mypyCmd --explicit-package-bases --no-error-summary --cache-dir=/dev/null $flags $srcs && ` +
+			`rm -rf $root && touch $out`,
+		CommandDeps: []string{"$mypyCmd"},
+	}, "root", "srcsZips", "mypyCmd", "flags", "srcs")
+
+	pylint = pctx.AndroidStaticRule("pylint", blueprint.RuleParams{
+		Command: `rm -rf $root && mkdir -p $root && ` +
+			`for z in $srcsZips; do unzip -qo -d $root $$z || exit 1; done && ` +
+			`PYTHONPATH=$root $pylintCmd --score=n $flags $srcs && ` +
+			`rm -rf $root && touch $out`,
+		CommandDeps: []string{"$pylintCmd"},
+	}, "root", "srcsZips", "pylintCmd", "flags", "srcs")
+)
+
+// properties of the static checks of the Python sources of host modules
+type StaticChecksProperties struct {
+	// whether to type check the sources of this module with mypy. The sources of the transitive
+	// libs are visible to mypy but not checked. Defaults to false.
+	Mypy *bool
+
+	// the mypy configuration file, e.g. mypy.ini.
+	Mypy_config *string `android:"path"`
+
+	// whether to lint the sources of this module with pylint. Defaults to false.
+	Pylint *bool
+
+	// the pylint configuration file, e.g. pylintrc.
+	Pylint_config *string `android:"path"`
+}
+
+// staticChecksDeps adds the dependencies on the mypy and pylint host tools.
+func (p *PythonLibraryModule) staticChecksDeps(ctx android.BottomUpMutatorContext) {
+	props := &p.properties.Static_checks
+	if !ctx.Host() {
+		if Bool(props.Mypy) || Bool(props.Pylint) {
+			ctx.PropertyErrorf("static_checks", "static checks are only supported for host modules")
+		}
+		return
+	}
+	variations := ctx.Config().BuildOSTarget.Variations()
+	if Bool(props.Mypy) {
+		ctx.AddFarVariationDependencies(variations, mypyTag, "mypy")
+	}
+	if Bool(props.Pylint) {
+		ctx.AddFarVariationDependencies(variations, pylintTag, "pylint")
+	}
+}
+
+// collectTransitiveSrcsZips returns the srcs zips of the transitive libs of the module, without
+// the checks of collectPathsFromTransitiveDeps that are only needed when building a binary.
+func (p *PythonLibraryModule) collectTransitiveSrcsZips(ctx android.ModuleContext) android.Paths {
+	seen := make(map[android.Module]bool)
+	var result android.Paths
+	ctx.WalkDepsProxy(func(child, _ android.ModuleProxy) bool {
+		if ctx.OtherModuleDependencyTag(child) != pythonLibTag || seen[child] {
+			return false
+		}
+		seen[child] = true
+		if dep, ok := android.OtherModuleProvider(ctx, child, PythonLibraryInfoProvider); ok {
+			result = append(result, dep.SrcsZip)
+		}
+		return true
+	})
+	return result
+}
+
+func hostToolPath(ctx android.ModuleContext, tag blueprint.DependencyTag) android.OptionalPath {
+	var path android.OptionalPath
+	ctx.VisitDirectDepsProxyWithTag(tag, func(m android.ModuleProxy) {
+		path = android.OtherModuleProviderOrDefault(ctx, m, android.HostToolProviderInfoProvider).HostToolPath
+	})
+	return path
+}
+
+// buildStaticChecks registers the enabled static checks and returns their outputs, which must be
+// added as validations of the outputs of the module.
+func (p *PythonLibraryModule) buildStaticChecks(ctx android.ModuleContext) android.Paths {
+	props := &p.properties.Static_checks
+	if !ctx.Host() || !(Bool(props.Mypy) || Bool(props.Pylint)) {
+		return nil
+	}
+
+	var srcs []string
+	for _, path := range p.srcsPathMappings {
+		if path.src.Ext() == pyExt {
+			srcs = append(srcs, path.dest)
+		}
+	}
+	if len(srcs) == 0 {
+		return nil
+	}
+	srcsZips := append(android.Paths{p.srcsZip}, p.collectTransitiveSrcsZips(ctx)...)
+
+	var validations android.Paths
+	check := func(name string, rule blueprint.Rule, tag blueprint.DependencyTag, config *string, configFlag string) {
+		tool := hostToolPath(ctx, tag)
+		if !tool.Valid() {
+			// The dependency on the tool is missing, which was already reported unless missing
+			// dependencies are allowed.
+			return
+		}
+		root := android.PathForModuleOut(ctx, "static_checks", name)
+		out := android.PathForModuleOut(ctx, "static_checks", name+".stamp")
+		implicits := append(android.Paths(nil), srcsZips...)
+		var flags []string
+		if config != nil {
+			configPath := android.PathForModuleSrc(ctx, *config)
+			implicits = append(implicits, configPath)
+			flags = append(flags, configFlag+configPath.String())
+		}
+		var rootedSrcs []string
+		for _, src := range srcs {
+			rootedSrcs = append(rootedSrcs, filepath.Join(root.String(), src))
+		}
+		ctx.Build(pctx, android.BuildParams{
+			Rule:        rule,
+			Description: name + " " + ctx.ModuleName(),
+			Output:      out,
+			Implicits:   implicits,
+			Args: map[string]string{
+				"root":       root.String(),
+				"srcsZips":   strings.Join(srcsZips.Strings(), " "),
+				name + "Cmd": tool.Path().String(),
+				"flags":      strings.Join(flags, " "),
+				"srcs":       strings.Join(proptools.ShellEscapeList(rootedSrcs), " "),
+			},
+		})
+		validations = append(validations, out)
+	}
+
+	if Bool(props.Mypy) {
+		check("mypy", mypy, mypyTag, props.Mypy_config, "--config-file=")
+	}
+	if Bool(props.Pylint) {
+		check("pylint", pylint, pylintTag, props.Pylint_config, "--rcfile=")
+	}
+	ctx.CheckbuildFile(validations...)
+	return validations
+}
@@ -558,6 +558,7 @@ func Rustdoc(ctx ModuleContext, main android.Path, deps PathDeps,
 	// shared pieces like the index and search data itself.
 	// https://github.com/rust-lang/rust/blob/master/src/librustdoc/html/render/write_shared.rs#L144-L146
 	docDir := android.PathForOutput(ctx, "rustdoc")
+	envVars := rustEnvVars(ctx, deps, crateName, ctx.RustModule().compiler.cargoOutDir())
 
 	ctx.Build(pctx, android.BuildParams{
 		Rule:        rustdoc,
@@ -568,9 +569,24 @@ func Rustdoc(ctx ModuleContext, main android.Path, deps PathDeps,
 		Args: map[string]string{
 			"rustdocFlags": strings.Join(rustdocFlags, " "),
 			"outDir":       docDir.String(),
-			"envVars":      strings.Join(rustEnvVars(ctx, deps, crateName, ctx.RustModule().compiler.cargoOutDir()), " "),
+			"envVars":      strings.Join(envVars, " "),
 		},
 	})
 
+	var depCrates []string
+	for _, libs := range []RustLibraries{deps.RLibs, deps.DyLibs, deps.ProcMacros} {
+		for _, lib := range libs {
+			depCrates = append(depCrates, lib.CrateName)
+		}
+	}
+	ctx.RustModule().rustdocInfo = &RustdocInfo{
+		CrateName: crateName,
+		Main:      main,
+		Flags:     rustdocFlags,
+		EnvVars:   envVars,
+		Implicit:  ctx.RustModule().UnstrippedOutputFile(),
+		DepCrates: android.SortedUniqueStrings(depCrates),
+	}
+
 	return docTimestampFile
 }
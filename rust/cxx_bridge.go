@@ -0,0 +1,168 @@
+// Copyright 2026 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"strings"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+var (
+	_ = pctx.HostBinToolVariable("cxxbridgeCmd", "cxxbridge")
+
+	cxxBridge = pctx.AndroidStaticRule("cxxBridge",
+		blueprint.RuleParams{
+			Command: "$cxxbridgeCmd $flags $in --header -o $header && " +
+				"$cxxbridgeCmd $flags $in -o $out",
+			CommandDeps: []string{"$cxxbridgeCmd"},
+		},
+		"flags", "header")
+)
+
+func init() {
+	android.RegisterModuleType("rust_cxx_bridge", RustCxxBridgeFactory)
+	android.RegisterModuleType("rust_cxx_bridge_host", RustCxxBridgeHostFactory)
+}
+
+var _ SourceProvider = (*cxxBridgeDecorator)(nil)
+var _ android.SourceFileGenerator = (*cxxBridgeDecorator)(nil)
+
+type CxxBridgeProperties struct {
+	// The Rust source file containing the #[cxx::bridge] module. It is the entry point of the
+	// generated crate, and the input of cxxbridge for the C++ side.
+	Bridge_src *string `android:"path,arch_variant"`
+
+	// list of cxxbridge-specific flags and options, e.g. --cxx-impl-annotations.
+	Cxxbridge_flags []string `android:"arch_variant"`
+
+	// module name of the cc_library_static that compiles the generated C++ source, together with
+	// the C++ implementation of the functions declared in the bridge. It is linked into the Rust
+	// variants of this module.
+	Cc_library *string
+}
+
+type cxxBridgeDecorator struct {
+	*BaseSourceProvider
+
+	Properties CxxBridgeProperties
+
+	cxxHeader    android.WritablePath
+	cxxSource    android.WritablePath
+	cxxHeaderDir android.Path
+}
+
+func (b *cxxBridgeDecorator) GenerateSource(ctx ModuleContext, deps PathDeps) android.Path {
+	bridgeFile := android.OptionalPathForModuleSrc(ctx, b.Properties.Bridge_src)
+	if !bridgeFile.Valid() {
+		ctx.PropertyErrorf("bridge_src", "invalid path to bridge source")
+		return nil
+	}
+	stem := b.BaseSourceProvider.getStem(ctx)
+
+	// The Rust side is the bridge source itself, expanded by the cxx::bridge procedural macro
+	// when the crate is compiled.
+	outputFile := android.PathForModuleOut(ctx, stem+".rs")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        android.Cp,
+		Description: "cxx bridge " + bridgeFile.Path().Rel(),
+		Output:      outputFile,
+		Input:       bridgeFile.Path(),
+	})
+
+	// The C++ side is generated by cxxbridge. The header is generated in its own directory so that
+	// cc modules can include it as "<source_stem>.rs.h".
+	b.cxxHeaderDir = android.PathForModuleOut(ctx, "cxx_include")
+	b.cxxHeader = android.PathForModuleOut(ctx, "cxx_include", stem+".rs.h")
+	b.cxxSource = android.PathForModuleOut(ctx, "cxx_src", stem+".rs.cc")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:           cxxBridge,
+		Description:    "cxxbridge " + bridgeFile.Path().Rel(),
+		Output:         b.cxxSource,
+		ImplicitOutput: b.cxxHeader,
+		Input:          bridgeFile.Path(),
+		Args: map[string]string{
+			"flags":  strings.Join(proptools.NinjaAndShellEscapeList(b.Properties.Cxxbridge_flags), " "),
+			"header": b.cxxHeader.String(),
+		},
+	})
+
+	b.BaseSourceProvider.OutputFiles = android.Paths{outputFile}
+	return outputFile
+}
+
+// GeneratedSourceFiles returns the generated C++ source, for cc modules that list this module in
+// generated_sources.
+func (b *cxxBridgeDecorator) GeneratedSourceFiles() android.Paths {
+	return android.PathsIfNonNil(b.cxxSource)
+}
+
+// GeneratedHeaderDirs returns the directory of the generated C++ header, for cc modules that list
+// this module in generated_headers or generated_sources.
+func (b *cxxBridgeDecorator) GeneratedHeaderDirs() android.Paths {
+	return android.PathsIfNonNil(b.cxxHeaderDir)
+}
+
+func (b *cxxBridgeDecorator) GeneratedDeps() android.Paths {
+	return android.PathsIfNonNil(b.cxxHeader, b.cxxSource)
+}
+
+func (b *cxxBridgeDecorator) SourceProviderProps() []interface{} {
+	return append(b.BaseSourceProvider.SourceProviderProps(), &b.Properties)
+}
+
+func (b *cxxBridgeDecorator) SourceProviderDeps(ctx DepsContext, deps Deps) Deps {
+	deps = b.BaseSourceProvider.SourceProviderDeps(ctx, deps)
+	deps.Rustlibs = append(deps.Rustlibs, "libcxx")
+
+	if !ctx.RustModule().Source() && b.Properties.Cc_library != nil {
+		// This is not the source variant, so add the C++ side as a dependency.
+		//
+		// This is necessary to avoid a circular dependency between the source variant and the
+		// dependent cc module, as for the static inline library of rust_bindgen.
+		deps.WholeStaticLibs = append(deps.WholeStaticLibs, String(b.Properties.Cc_library))
+	}
+	return deps
+}
+
+// rust_cxx_bridge generates both sides of a cxx bridge from a Rust source containing a
+// #[cxx::bridge] module. The Rust side is a crate that can be added as a dependency in the rustlibs
+// property. The C++ side can be compiled by adding this module to the generated_headers and
+// generated_sources properties of a cc_library_static, which should also depend on the
+// header library that provides rust/cxx.h. That cc_library_static is set in cc_library so that
+// it is linked into the Rust variants.
+func RustCxxBridgeFactory() android.Module {
+	module, _ := NewRustCxxBridge(android.HostAndDeviceSupported)
+	return module.Init()
+}
+
+func RustCxxBridgeHostFactory() android.Module {
+	module, _ := NewRustCxxBridge(android.HostSupported)
+	return module.Init()
+}
+
+func NewRustCxxBridge(hod android.HostOrDeviceSupported) (*Module, *cxxBridgeDecorator) {
+	bridge := &cxxBridgeDecorator{
+		BaseSourceProvider: NewSourceProvider(),
+		Properties:         CxxBridgeProperties{},
+	}
+
+	module := NewSourceProviderModule(hod, bridge, false, false)
+
+	return module, bridge
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"strings"
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestRustCxxBridge(t *testing.T) {
+	ctx := testRust(t, `
+		rust_cxx_bridge {
+			name: "libfoo_bridge",
+			bridge_src: "src/bridge.rs",
+			crate_name: "foo_bridge",
+			source_stem: "bridge",
+			cxxbridge_flags: ["--cxx-impl-annotations=FOO_EXPORT"],
+			cc_library: "libfoo_bridge_cc",
+		}
+		cc_library_static {
+			name: "libfoo_bridge_cc",
+			srcs: ["foo.cpp"],
+			generated_headers: ["libfoo_bridge"],
+			generated_sources: ["libfoo_bridge"],
+		}
+	`)
+
+	source := ctx.ModuleForTests(t, "libfoo_bridge", "android_arm64_armv8-a_source")
+	rustSrc := source.Output("bridge.rs")
+	if rustSrc.Rule != android.Cp || rustSrc.Input.String() != "src/bridge.rs" {
+		t.Errorf("expected bridge.rs to be copied from src/bridge.rs, got rule %v from %v", rustSrc.Rule, rustSrc.Input)
+	}
+	cxxSrc := source.Output("cxx_src/bridge.rs.cc")
+	if !strings.Contains(cxxSrc.Args["flags"], "--cxx-impl-annotations=FOO_EXPORT") {
+		t.Errorf("missing cxxbridge flags in rust_cxx_bridge rule: flags %#v", cxxSrc.Args["flags"])
+	}
+	if !strings.HasSuffix(cxxSrc.Args["header"], "cxx_include/bridge.rs.h") {
+		t.Errorf("unexpected header path %q", cxxSrc.Args["header"])
+	}
+
+	rlib := ctx.ModuleForTests(t, "libfoo_bridge", "android_arm64_armv8-a_rlib_dylib-std").Module().(*Module)
+	if !android.InList("libcxx.rlib-std", rlib.Properties.AndroidMkRlibs) && !android.InList("libcxx", rlib.Properties.AndroidMkDylibs) {
+		t.Errorf("rust_cxx_bridge should depend on libcxx, rlibs: %q dylibs: %q",
+			rlib.Properties.AndroidMkRlibs, rlib.Properties.AndroidMkDylibs)
+	}
+	if !android.InList("libfoo_bridge_cc", rlib.Properties.AndroidMkStaticLibs) {
+		t.Errorf("rust_cxx_bridge should link the C++ side, static libs: %q", rlib.Properties.AndroidMkStaticLibs)
+	}
+
+	// The generated C++ source is compiled by the cc module, with the generated header directory in
+	// its include path.
+	compile := ctx.ModuleForTests(t, "libfoo_bridge_cc", "android_arm64_armv8-a_static").Output("obj/cxx_src/bridge.rs.o")
+	headerDir := strings.TrimSuffix(cxxSrc.Args["header"], "/bridge.rs.h")
+	if !strings.Contains(compile.Args["cFlags"], "-I"+headerDir) {
+		t.Errorf("missing include path of the generated header %q in cFlags %q", headerDir, compile.Args["cFlags"])
+	}
+}
+
+func TestRustCxxBridgeMissingSource(t *testing.T) {
+	testRustError(t, "invalid path to bridge source", `
+		rust_cxx_bridge {
+			name: "libfoo_bridge",
+			crate_name: "foo_bridge",
+			source_stem: "bridge",
+		}
+	`)
+}
@@ -15,13 +15,32 @@
 package rust
 
 import (
+	"strings"
+
 	"android/soong/android"
+	"android/soong/rust/config"
 )
 
 func init() {
 	android.RegisterParallelSingletonType("rustdoc", RustdocSingleton)
+	android.RegisterParallelSingletonType("rustdoc_site", RustdocSiteSingleton)
+}
+
+// RustdocInfo holds the rustdoc invocation of a crate, so that it can be documented again as part
+// of the rustdoc site.
+type RustdocInfo struct {
+	CrateName string
+	Main      android.Path
+	Flags     []string
+	EnvVars   []string
+	Implicit  android.Path
+	// The names of the crates the crate depends on.
+	DepCrates []string
 }
 
+// The crates of the Rust sysroot, which are documented on doc.rust-lang.org.
+var sysrootCrates = []string{"alloc", "core", "proc_macro", "std", "test"}
+
 func RustdocSingleton() android.Singleton {
 	return &rustdocSingleton{}
 }
@@ -51,3 +70,124 @@ func (n *rustdocSingleton) GenerateBuildActions(ctx android.SingletonContext) {
 	rule.Build("rustdoc-zip", "Zipping all built Rust documentation...")
 	ctx.Phony("rustdoc", docZip)
 }
+
+func RustdocSiteSingleton() android.Singleton {
+	return &rustdocSiteSingleton{}
+}
+
+// rustdocSiteSingleton documents the Rust libraries into a single site, where the docs of a crate
+// link to the docs of the crates it depends on and the search covers all the crates. The crates
+// are all the Rust libraries, or the ones listed in RUSTDOC_SITE_CRATES and their dependencies.
+type rustdocSiteSingleton struct{}
+
+func (n *rustdocSiteSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	// The docs are written to the same directory, like the rustdoc singleton does, so that
+	// rustdoc merges the search index and the crate list of the index page.
+	siteDir := android.PathForOutput(ctx, "rustdoc_site", "site")
+	siteZip := android.PathForOutput(ctx, "rustdoc_site.zip")
+
+	infos := make(map[string]*RustdocInfo)
+	moduleCrates := make(map[string]string)
+	ctx.VisitAllModuleProxies(func(module android.ModuleProxy) {
+		if !android.OtherModulePointerProviderOrDefault(ctx, module, android.CommonModuleInfoProvider).Enabled {
+			return
+		}
+		m, ok := android.OtherModuleProvider(ctx, module, RustInfoProvider)
+		if !ok || m.RustdocInfo == nil {
+			return
+		}
+		// Different modules, e.g. for vendor and system, may build the same crate. The site
+		// only has one page per crate.
+		if _, exists := infos[m.RustdocInfo.CrateName]; !exists {
+			infos[m.RustdocInfo.CrateName] = m.RustdocInfo
+		}
+		moduleCrates[ctx.ModuleName(module)] = m.RustdocInfo.CrateName
+	})
+
+	crates := android.SortedKeys(infos)
+	if selected := ctx.Config().Getenv("RUSTDOC_SITE_CRATES"); selected != "" {
+		var queue []string
+		for _, name := range strings.FieldsFunc(selected, func(r rune) bool { return r == ',' || r == ' ' }) {
+			crate, ok := moduleCrates[name]
+			if !ok {
+				ctx.Errorf("RUSTDOC_SITE_CRATES: %q is not a documented Rust library", name)
+				continue
+			}
+			queue = append(queue, crate)
+		}
+		inSite := make(map[string]bool)
+		for len(queue) > 0 {
+			crate := queue[0]
+			queue = queue[1:]
+			if inSite[crate] {
+				continue
+			}
+			inSite[crate] = true
+			for _, dep := range infos[crate].DepCrates {
+				if _, ok := infos[dep]; ok {
+					queue = append(queue, dep)
+				}
+			}
+		}
+		crates = android.SortedKeys(inSite)
+	}
+
+	// rustdoc doesn't remove the docs of the crates that are no longer in the site from the site
+	// directory, nor from the search index it merges, so the directory is cleaned whenever the
+	// crates of the site change. The names of these files can't clash with the timestamps of the
+	// crates, as crate names can't contain a '-'.
+	siteCrates := android.PathForOutput(ctx, "rustdoc_site", "site-crates.txt")
+	android.WriteFileRule(ctx, siteCrates, strings.Join(crates, "\n"))
+	cleanTimestamp := android.PathForOutput(ctx, "rustdoc_site", "site-clean.timestamp")
+	cleanRule := android.NewRuleBuilder(pctx, ctx)
+	cleanRule.Command().Text("rm -rf").Text(siteDir.String()).Implicit(siteCrates)
+	cleanRule.Command().Text("touch").Output(cleanTimestamp)
+	cleanRule.Build("rustdoc-site-clean", "Cleaning the Rust documentation site")
+
+	timestamps := make(map[string]android.WritablePath)
+	for _, crate := range crates {
+		timestamps[crate] = android.PathForOutput(ctx, "rustdoc_site", crate+".timestamp")
+	}
+	docsURL := "https://doc.rust-lang.org/" + config.GetRustVersion(ctx) + "/"
+
+	var allTimestamps android.Paths
+	for _, crate := range crates {
+		info := infos[crate]
+		flags := append([]string{}, info.Flags...)
+		implicits := android.Paths{info.Implicit, cleanTimestamp}
+		for _, dep := range info.DepCrates {
+			if timestamp, ok := timestamps[dep]; ok {
+				// rustdoc links to the docs of a dependency with relative links when they are
+				// already in the output directory.
+				implicits = append(implicits, timestamp)
+			} else if android.InList(dep, sysrootCrates) {
+				flags = append(flags, "--extern-html-root-url "+dep+"="+docsURL)
+			}
+		}
+
+		ctx.Build(pctx, android.BuildParams{
+			Rule:        rustdoc,
+			Description: "rustdoc site " + crate,
+			Output:      timestamps[crate],
+			Input:       info.Main,
+			Implicits:   implicits,
+			Args: map[string]string{
+				"rustdocFlags": strings.Join(flags, " "),
+				"outDir":       siteDir.String(),
+				"envVars":      strings.Join(info.EnvVars, " "),
+			},
+		})
+		allTimestamps = append(allTimestamps, timestamps[crate])
+	}
+
+	rule := android.NewRuleBuilder(pctx, ctx)
+	rule.Command().BuiltTool("soong_zip").
+		FlagWithOutput("-o ", siteZip).
+		FlagWithArg("-C ", siteDir.String()).
+		FlagWithArg("-D ", siteDir.String()).
+		Implicits(allTimestamps)
+	rule.Build("rustdoc-site-zip", "Zipping the Rust documentation site")
+
+	ctx.Phony("rustdoc_site", siteZip)
+	ctx.DistForGoal("rustdoc_site", siteZip)
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+const rustdocSiteBp = `
+	rust_library {
+		name: "libfoo",
+		crate_name: "foo",
+		srcs: ["foo.rs"],
+		rustlibs: ["libbar"],
+	}
+	rust_library {
+		name: "libbar",
+		crate_name: "bar",
+		srcs: ["src/bar.rs"],
+	}
+	rust_library {
+		name: "libbaz",
+		crate_name: "baz",
+		srcs: ["foo.rs"],
+	}
+`
+
+var prepareForRustdocSiteTest = android.GroupFixturePreparers(
+	prepareForRustTest,
+	rustMockedFiles.AddToFixture(),
+	android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+		ctx.RegisterParallelSingletonType("rustdoc_site", RustdocSiteSingleton)
+	}),
+)
+
+func TestRustdocSite(t *testing.T) {
+	skipTestIfOsNotSupported(t)
+	result := android.GroupFixturePreparers(
+		prepareForRustdocSiteTest,
+		android.FixtureMergeEnv(map[string]string{"RUSTDOC_SITE_CRATES": "libfoo"}),
+	).RunTestWithBp(t, rustdocSiteBp)
+
+	site := result.SingletonForTests(t, "rustdoc_site")
+
+	// The dependencies of the selected crates are documented first, so that their docs are
+	// linked to.
+	foo := site.Output("rustdoc_site/foo.timestamp")
+	android.AssertStringEquals(t, "out dir", "out/soong/rustdoc_site/site",
+		android.StringRelativeToTop(result.Config, foo.Args["outDir"]))
+	android.AssertStringDoesContain(t, "rustdoc flags", foo.Args["rustdocFlags"], "--crate-name foo")
+	android.AssertStringListContains(t, "implicits", android.PathsRelativeToTop(foo.Implicits),
+		"out/soong/rustdoc_site/bar.timestamp")
+	site.Output("rustdoc_site/bar.timestamp")
+	if site.MaybeOutput("rustdoc_site/baz.timestamp").Rule != nil {
+		t.Errorf("libbaz is documented in the site, but it isn't selected")
+	}
+
+	zip := site.Output("rustdoc_site.zip")
+	android.AssertStringListContains(t, "zip implicits", android.PathsRelativeToTop(zip.Implicits),
+		"out/soong/rustdoc_site/foo.timestamp")
+
+	// The site directory is cleaned when the crates of the site change.
+	siteCrates := site.Output("rustdoc_site/site-crates.txt")
+	android.AssertStringEquals(t, "site crates", "bar\nfoo",
+		android.ContentFromFileRuleForTests(t, result.TestContext, siteCrates))
+	clean := site.Output("rustdoc_site/site-clean.timestamp")
+	android.AssertStringDoesContain(t, "clean command", android.StringRelativeToTop(result.Config, clean.RuleParams.Command),
+		"rm -rf out/soong/rustdoc_site/site")
+	android.AssertStringListContains(t, "clean implicits", android.PathsRelativeToTop(clean.Implicits),
+		"out/soong/rustdoc_site/site-crates.txt")
+	android.AssertStringListContains(t, "implicits", android.PathsRelativeToTop(foo.Implicits),
+		"out/soong/rustdoc_site/site-clean.timestamp")
+}
+
+func TestRustdocSiteAllCrates(t *testing.T) {
+	skipTestIfOsNotSupported(t)
+	result := prepareForRustdocSiteTest.RunTestWithBp(t, rustdocSiteBp)
+
+	site := result.SingletonForTests(t, "rustdoc_site")
+	for _, crate := range []string{"foo", "bar", "baz"} {
+		site.Output("rustdoc_site/" + crate + ".timestamp")
+	}
+}
+
+func TestRustdocSiteUnknownCrate(t *testing.T) {
+	skipTestIfOsNotSupported(t)
+	android.GroupFixturePreparers(
+		prepareForRustdocSiteTest,
+		android.FixtureMergeEnv(map[string]string{"RUSTDOC_SITE_CRATES": "libfoo,libmissing"}),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`RUSTDOC_SITE_CRATES: "libmissing" is not a documented Rust library`)).
+		RunTestWithBp(t, rustdocSiteBp)
+}
@@ -33,6 +33,7 @@ type fuzzDecorator struct {
 	fuzzPackagedModule  fuzz.FuzzPackagedModule
 	sharedLibraries     android.RuleBuilderInstalls
 	installedSharedDeps []string
+	smokeTest           *fuzz.SmokeTest
 }
 
 var _ compiler = (*fuzzDecorator)(nil)
@@ -51,7 +52,8 @@ func RustFuzzHostFactory() android.Module {
 func NewRustFuzz(hod android.HostOrDeviceSupported) (*Module, *fuzzDecorator) {
 	module, binary := NewRustBinary(hod)
 	fuzz := &fuzzDecorator{
-		binaryDecorator: binary,
+		binaryDecorator:    binary,
+		fuzzPackagedModule: fuzz.FuzzPackagedModule{Lang: fuzz.Rust},
 	}
 
 	// Change the defaults for the binaryDecorator's baseCompiler
@@ -129,51 +131,58 @@ func (fuzzer *fuzzDecorator) autoDep(ctx android.BottomUpMutatorContext) autoDep
 	return rlibAutoDep
 }
 
-func (fuzz *fuzzDecorator) install(ctx ModuleContext) {
-	fuzz.fuzzPackagedModule = cc.PackageFuzzModule(ctx, fuzz.fuzzPackagedModule)
+func (fuzzer *fuzzDecorator) moduleInfoJSON(ctx ModuleContext, moduleInfoJSON *android.ModuleInfoJSON) {
+	fuzzer.binaryDecorator.moduleInfoJSON(ctx, moduleInfoJSON)
+	fuzzer.smokeTest.ModuleInfoJSON(moduleInfoJSON)
+}
+
+func (fuzzer *fuzzDecorator) install(ctx ModuleContext) {
+	fuzzer.fuzzPackagedModule = cc.PackageFuzzModule(ctx, fuzzer.fuzzPackagedModule)
+	fuzzer.fuzzPackagedModule.Sanitizers = cc.FuzzSanitizers(ctx.RustModule())
+	fuzzer.smokeTest = fuzz.BuildSmokeTest(ctx, fuzzer.fuzzPackagedModule)
 
 	installBase := "fuzz"
 
 	// Grab the list of required shared libraries.
-	fuzz.sharedLibraries, _ = cc.CollectAllSharedDependencies(ctx)
+	fuzzer.sharedLibraries, _ = cc.CollectAllSharedDependencies(ctx)
 
-	for _, ruleBuilderInstall := range fuzz.sharedLibraries {
+	for _, ruleBuilderInstall := range fuzzer.sharedLibraries {
 		install := ruleBuilderInstall.To
 
-		fuzz.installedSharedDeps = append(fuzz.installedSharedDeps,
+		fuzzer.installedSharedDeps = append(fuzzer.installedSharedDeps,
 			cc.SharedLibraryInstallLocation(
 				install, ctx.Host(), ctx.InstallInVendor(), installBase, ctx.Arch().ArchType.String()))
 
 		// Also add the dependency on the shared library symbols dir.
 		if !ctx.Host() {
-			fuzz.installedSharedDeps = append(fuzz.installedSharedDeps,
+			fuzzer.installedSharedDeps = append(fuzzer.installedSharedDeps,
 				cc.SharedLibrarySymbolsInstallLocation(install, ctx.InstallInVendor(), installBase, ctx.Arch().ArchType.String()))
 		}
 	}
 
 	var fuzzData []android.DataPath
-	for _, d := range fuzz.fuzzPackagedModule.Corpus {
+	for _, d := range fuzzer.fuzzPackagedModule.Corpus {
 		fuzzData = append(fuzzData, android.DataPath{SrcPath: d, RelativeInstallPath: "corpus", WithoutRel: true})
 	}
 
-	for _, d := range fuzz.fuzzPackagedModule.Data {
+	for _, d := range fuzzer.fuzzPackagedModule.Data {
 		fuzzData = append(fuzzData, android.DataPath{SrcPath: d, RelativeInstallPath: "data"})
 	}
 
-	if d := fuzz.fuzzPackagedModule.Dictionary; d != nil {
+	if d := fuzzer.fuzzPackagedModule.Dictionary; d != nil {
 		fuzzData = append(fuzzData, android.DataPath{SrcPath: d, WithoutRel: true})
 	}
 
-	if d := fuzz.fuzzPackagedModule.Config; d != nil {
+	if d := fuzzer.fuzzPackagedModule.Config; d != nil {
 		fuzzData = append(fuzzData, android.DataPath{SrcPath: d, WithoutRel: true})
 	}
 
-	fuzz.binaryDecorator.baseCompiler.dir = filepath.Join(
+	fuzzer.binaryDecorator.baseCompiler.dir = filepath.Join(
 		"fuzz", ctx.Target().Arch.ArchType.String(), ctx.ModuleName())
-	fuzz.binaryDecorator.baseCompiler.dir64 = filepath.Join(
+	fuzzer.binaryDecorator.baseCompiler.dir64 = filepath.Join(
 		"fuzz", ctx.Target().Arch.ArchType.String(), ctx.ModuleName())
-	fuzz.binaryDecorator.baseCompiler.installTestData(ctx, fuzzData)
+	fuzzer.binaryDecorator.baseCompiler.installTestData(ctx, fuzzData)
 
-	fuzz.binaryDecorator.baseCompiler.install(ctx)
+	fuzzer.binaryDecorator.baseCompiler.install(ctx)
 
 }
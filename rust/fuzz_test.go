@@ -15,11 +15,13 @@
 package rust
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
 	"android/soong/android"
 	"android/soong/cc"
+	"android/soong/fuzz"
 )
 
 func TestRustFuzz(t *testing.T) {
@@ -155,3 +157,86 @@ func TestCCFuzzDepBundling(t *testing.T) {
 		t.Errorf("cc_fuzz does not contain the expected bundled transitive shared libs from rust_ffi_static ('libcc_transitive_dep'): %#v", libs)
 	}
 }
+
+func TestFuzzInventory(t *testing.T) {
+	skipTestIfOsNotSupported(t)
+	result := android.GroupFixturePreparers(
+		prepareForRustTest,
+		rustMockedFiles.AddToFixture(),
+		android.FixtureRegisterWithContext(fuzz.RegisterFuzzBuildComponents),
+		android.FixtureMergeMockFs(android.MockFS{
+			"corpus/a/seed": nil,
+			"corpus/b/seed": nil,
+			"fuzzer.dict":   nil,
+			"no_corpus.rs":  nil,
+		}),
+	).RunTestWithBp(t, `
+			rust_fuzz_host {
+				name: "host_fuzzer",
+				srcs: ["foo.rs"],
+				corpus: ["corpus/*/seed"],
+				dictionary: "fuzzer.dict",
+				fuzz_config: {
+					cc: ["owner@example.com"],
+					componentid: 1234,
+					fuzz_on_haiku_host: false,
+				},
+			}
+			rust_fuzz_host {
+				name: "host_fuzzer_without_corpus",
+				srcs: ["no_corpus.rs"],
+			}
+			rust_fuzz {
+				name: "device_fuzzer",
+				srcs: ["foo.rs"],
+				corpus: ["corpus/*/seed"],
+			}
+	`)
+
+	inventory := result.SingletonForTests(t, "fuzz_inventory")
+	var entries []fuzz.FuzzTargetInventoryEntry
+	content := android.ContentFromFileRuleForTests(t, result.TestContext, inventory.Output("fuzz/fuzz_targets.json"))
+	if err := json.Unmarshal([]byte(content), &entries); err != nil {
+		t.Fatalf("invalid fuzz_targets.json: %s\n%s", err, content)
+	}
+
+	byName := make(map[string]fuzz.FuzzTargetInventoryEntry)
+	for _, e := range entries {
+		byName[e.Name+" "+e.HostOrTarget] = e
+	}
+	host, ok := byName["host_fuzzer host"]
+	if !ok {
+		t.Fatalf("host_fuzzer missing from fuzz_targets.json:\n%s", content)
+	}
+	android.AssertStringEquals(t, "lang", string(fuzz.Rust), string(host.Lang))
+	android.AssertIntEquals(t, "corpus files", 2, host.CorpusFiles)
+	android.AssertStringEquals(t, "dictionary", "fuzzer.dict", host.Dictionary)
+	android.AssertDeepEquals(t, "owners", []string{"owner@example.com"}, host.Owners)
+	android.AssertBoolEquals(t, "continuously fuzzed", false, host.ContinuouslyFuzzed)
+	if host.Componentid == nil || *host.Componentid != 1234 {
+		t.Errorf("expected componentid 1234, got %v", host.Componentid)
+	}
+	device, ok := byName["device_fuzzer target"]
+	if !ok {
+		t.Fatalf("device_fuzzer missing from fuzz_targets.json:\n%s", content)
+	}
+	android.AssertBoolEquals(t, "device continuously fuzzed", true, device.ContinuouslyFuzzed)
+
+	android.AssertDeepEquals(t, "sanitizers", []string{"fuzzer"}, host.Sanitizers)
+
+	// Only the host fuzz targets with a corpus have a smoke test.
+	hostModule := result.ModuleForTests(t, "host_fuzzer", "linux_glibc_x86_64_fuzzer")
+	config := hostModule.Output("host_fuzzer.config")
+	android.AssertStringDoesContain(t, "smoke test command", config.Args["extraTestRunnerConfigs"],
+		"host_fuzzer -runs=1000 -seed=1 -create_missing_dirs=1 -dict=fuzzer.dict smoke_corpus corpus")
+	entries := android.AndroidMkEntriesForTest(t, result.TestContext, hostModule.Module())[0]
+	android.AssertDeepEquals(t, "test suites", []string{"general-tests"}, entries.EntryMap["LOCAL_COMPATIBILITY_SUITE"])
+	android.AssertStringPathRelativeToTopEquals(t, "test config", result.Config,
+		"out/soong/.intermediates/host_fuzzer/linux_glibc_x86_64_fuzzer/host_fuzzer.config",
+		entries.EntryMap["LOCAL_FULL_TEST_CONFIG"][0])
+
+	withoutCorpus := result.ModuleForTests(t, "host_fuzzer_without_corpus", "linux_glibc_x86_64_fuzzer")
+	if withoutCorpus.MaybeOutput("host_fuzzer_without_corpus.config").Rule != nil {
+		t.Errorf("unexpected smoke test of a fuzz target without corpus")
+	}
+}
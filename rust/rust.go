@@ -62,6 +62,7 @@ type RustInfo struct {
 	SourceProviderInfo            *SourceProviderInfo
 	XrefRustFiles                 android.Paths
 	DocTimestampFile              android.OptionalPath
+	RustdocInfo                   *RustdocInfo
 }
 
 var RustInfoProvider = blueprint.NewProvider[*RustInfo]()
@@ -232,6 +233,7 @@ type Module struct {
 	kytheFiles android.Paths
 
 	docTimestampFile android.OptionalPath
+	rustdocInfo      *RustdocInfo
 
 	hideApexVariantFromMake bool
 
@@ -1102,6 +1104,16 @@ func (mod *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 		if mod.compiler.(libraryInterface).source() {
 			mod.sourceProvider.GenerateSource(ctx, deps)
 			mod.sourceProvider.setSubName(ctx.ModuleSubDir())
+			// Source providers that also generate C or C++ sources (e.g. rust_cxx_bridge) can be
+			// used in the generated_headers and generated_sources of cc modules, which depend on
+			// the source variant.
+			if gen, ok := mod.sourceProvider.(android.SourceFileGenerator); ok {
+				android.SetProvider(ctx, android.GeneratedSourceInfoProvider, android.GeneratedSourceInfo{
+					GeneratedSourceFiles: gen.GeneratedSourceFiles(),
+					GeneratedHeaderDirs:  gen.GeneratedHeaderDirs(),
+					GeneratedDeps:        gen.GeneratedDeps(),
+				})
+			}
 		} else {
 			sourceMod := actx.GetDirectDepProxyWithTag(mod.Name(), sourceDepTag)
 			sourceLib := android.OtherModuleProviderOrDefault(ctx, sourceMod, RustInfoProvider).SourceProviderInfo
@@ -1175,6 +1187,7 @@ func (mod *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 		TransitiveAndroidMkSharedLibs: mod.transitiveAndroidMkSharedLibs,
 		XrefRustFiles:                 mod.XrefRustFiles(),
 		DocTimestampFile:              mod.docTimestampFile,
+		RustdocInfo:                   mod.rustdocInfo,
 	}
 	if mod.compiler != nil {
 		rustInfo.CompilerInfo = &CompilerInfo{
@@ -147,6 +147,12 @@ func GatherRequiredDepsForTest() string {
 			srcs: ["foo.rs"],
 			host_supported: true,
 		}
+		rust_library {
+			name: "libcxx",
+			crate_name: "cxx",
+			srcs: ["foo.rs"],
+			host_supported: true,
+		}
 		rust_library {
 			name: "libgrpcio",
 			crate_name: "grpcio",
@@ -182,6 +188,8 @@ func registerRequiredBuildComponentsForTest(ctx android.RegistrationContext) {
 	ctx.RegisterModuleType("rust_binary_host", RustBinaryHostFactory)
 	ctx.RegisterModuleType("rust_bindgen", RustBindgenFactory)
 	ctx.RegisterModuleType("rust_bindgen_host", RustBindgenHostFactory)
+	ctx.RegisterModuleType("rust_cxx_bridge", RustCxxBridgeFactory)
+	ctx.RegisterModuleType("rust_cxx_bridge_host", RustCxxBridgeHostFactory)
 	ctx.RegisterModuleType("rust_test", RustTestFactory)
 	ctx.RegisterModuleType("rust_test_host", RustTestHostFactory)
 	ctx.RegisterModuleType("rust_library", RustLibraryFactory)
@@ -0,0 +1,59 @@
+// Copyright (C) 2026 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"fmt"
+
+	"android/soong/android"
+)
+
+type compatCheckProperties struct {
+	// The previously released snapshot zip of this sdk. If set, the new snapshot is compared with
+	// it and the build fails if the new snapshot has incompatible changes for the modules that use
+	// it, e.g. removed members, removed APIs, narrowed apex_available or changed ABI dumps.
+	Previous_snapshot *string `android:"path"`
+
+	// A file that lists the incompatible changes that are intended, one per line, using the keys
+	// of the changes in the compatibility report, e.g. "member-removed libfoo". Lines starting
+	// with # are comments.
+	Acknowledged_changes *string `android:"path"`
+}
+
+// buildCompatCheck creates a rule that compares the snapshot zip with the previously released
+// snapshot, if there is one. It returns the report of the changes, which fails to build if there
+// are unacknowledged incompatible changes.
+func (s *sdk) buildCompatCheck(ctx android.ModuleContext, snapshotZip android.Path, snapshotFileSuffix string) android.Path {
+	props := s.properties.Compat_check
+	if props.Previous_snapshot == nil {
+		if props.Acknowledged_changes != nil {
+			ctx.PropertyErrorf("compat_check.acknowledged_changes", "requires compat_check.previous_snapshot")
+		}
+		return nil
+	}
+
+	report := android.PathForModuleOut(ctx, fmt.Sprintf("%s%s.compat_report.txt", ctx.ModuleName(), snapshotFileSuffix))
+	builder := android.NewRuleBuilder(pctx, ctx)
+	cmd := builder.Command().
+		BuiltTool("sdk_snapshot_compat").
+		FlagWithInput("--previous ", android.PathForModuleSrc(ctx, *props.Previous_snapshot)).
+		FlagWithInput("--current ", snapshotZip).
+		FlagWithOutput("--report ", report)
+	if props.Acknowledged_changes != nil {
+		cmd.FlagWithInput("--acknowledged ", android.PathForModuleSrc(ctx, *props.Acknowledged_changes))
+	}
+	builder.Build("sdk_compat_check", "Checking the compatibility of the snapshot of "+ctx.ModuleName())
+	return report
+}
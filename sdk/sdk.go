@@ -84,6 +84,9 @@ type sdkProperties struct {
 
 	// True if this is a module_exports (or module_exports_snapshot) module type.
 	Module_exports bool `blueprint:"mutated"`
+
+	// Options for checking the compatibility of the snapshot with a previously released one.
+	Compat_check compatCheckProperties
 }
 
 // sdk defines an SDK which is a logical group of modules (e.g. native libs, headers, java libs, etc.)
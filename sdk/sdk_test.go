@@ -176,6 +176,49 @@ func TestSdkInstall(t *testing.T) {
 `))
 }
 
+func TestSdkCompatCheck(t *testing.T) {
+	t.Parallel()
+	result := testSdkWithFs(t, `
+		sdk {
+			name: "mysdk",
+			compat_check: {
+				previous_snapshot: "prebuilts/mysdk-current.zip",
+				acknowledged_changes: "mysdk_acknowledged_changes.txt",
+			},
+		}
+	`, android.MockFS{
+		"prebuilts/mysdk-current.zip":    nil,
+		"mysdk_acknowledged_changes.txt": nil,
+	})
+
+	sdk := result.ModuleForTests(t, "mysdk", "common_os")
+	cmd := sdk.Rule("sdk_compat_check").RuleParams.Command
+	for _, flag := range []string{
+		"--previous prebuilts/mysdk-current.zip",
+		"--current out/soong/.intermediates/mysdk/common_os/mysdk-current.zip",
+		"--report out/soong/.intermediates/mysdk/common_os/mysdk-current.compat_report.txt",
+		"--acknowledged mysdk_acknowledged_changes.txt",
+	} {
+		android.AssertStringDoesContain(t, "compat check flags", cmd, flag)
+	}
+
+	zip := sdk.Output("mysdk-current.zip")
+	android.AssertPathsRelativeToTopEquals(t, "snapshot zip validations",
+		[]string{"out/soong/.intermediates/mysdk/common_os/mysdk-current.compat_report.txt"}, zip.Validations)
+}
+
+func TestSdkCompatCheckRequiresPreviousSnapshot(t *testing.T) {
+	t.Parallel()
+	testSdkError(t, `compat_check.acknowledged_changes: requires compat_check.previous_snapshot`, `
+		sdk {
+			name: "mysdk",
+			compat_check: {
+				acknowledged_changes: "Test.java",
+			},
+		}
+	`)
+}
+
 type EmbeddedPropertiesStruct struct {
 	S_Embedded_Common    string `android:"arch_variant"`
 	S_Embedded_Different string `android:"arch_variant"`
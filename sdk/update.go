@@ -532,7 +532,13 @@ be unnecessary as every module in the sdk already has its own licenses property.
 		desc = "Building intermediate snapshot for " + ctx.ModuleName()
 	}
 
-	ctx.Build(pctx, android.BuildParams{
+	// Check the compatibility of the snapshot whenever it is built.
+	var validations android.Paths
+	if compatReport := s.buildCompatCheck(ctx, outputZipFile, snapshotFileSuffix); compatReport != nil {
+		validations = append(validations, compatReport)
+	}
+
+	zipParams := android.BuildParams{
 		Description: desc,
 		Rule:        zipFiles,
 		Inputs:      filesToZip,
@@ -540,7 +546,11 @@ be unnecessary as every module in the sdk already has its own licenses property.
 		Args: map[string]string{
 			"basedir": builder.snapshotDir.String(),
 		},
-	})
+	}
+	if len(builder.zipsToMerge) == 0 {
+		zipParams.Validations = validations
+	}
+	ctx.Build(pctx, zipParams)
 
 	if len(builder.zipsToMerge) != 0 {
 		ctx.Build(pctx, android.BuildParams{
@@ -549,6 +559,7 @@ be unnecessary as every module in the sdk already has its own licenses property.
 			Input:       zipFile,
 			Inputs:      android.SortedUniquePaths(builder.zipsToMerge),
 			Output:      outputZipFile,
+			Validations: validations,
 		})
 	}
 
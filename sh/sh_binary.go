@@ -108,6 +108,9 @@ type shBinaryProperties struct {
 	// Make this module available when building for recovery.
 	Recovery_available *bool
 
+	// Checks of the script that run as part of the build.
+	Shellcheck ShellcheckProperties
+
 	// The name of the image this module is built for
 	ImageVariation string `blueprint:"mutated"`
 
@@ -297,7 +300,7 @@ func (s *ShBinary) InstallInRecovery() bool {
 		(proptools.Bool(s.properties.Recovery_available) && s.properties.ImageVariation == android.RecoveryVariation)
 }
 
-func (s *ShBinary) generateAndroidBuildActions(ctx android.ModuleContext) {
+func (s *ShBinary) generateAndroidBuildActions(ctx android.ModuleContext, dataBins []string) {
 	if s.properties.Src == nil {
 		ctx.PropertyErrorf("src", "missing prebuilt source file")
 	}
@@ -320,9 +323,10 @@ func (s *ShBinary) generateAndroidBuildActions(ctx android.ModuleContext) {
 	// This ensures that outputFilePath has the correct name for others to
 	// use, as the source file may have a different name.
 	ctx.Build(pctx, android.BuildParams{
-		Rule:   android.CpExecutable,
-		Output: s.outputFilePath,
-		Input:  s.sourceFilePath,
+		Rule:        android.CpExecutable,
+		Output:      s.outputFilePath,
+		Input:       s.sourceFilePath,
+		Validations: s.buildScriptChecks(ctx, dataBins),
 	})
 
 	s.properties.SubName = s.GetSubname(ctx)
@@ -347,7 +351,7 @@ func (s *ShBinary) GetSubname(ctx android.ModuleContext) string {
 }
 
 func (s *ShBinary) GenerateAndroidBuildActions(ctx android.ModuleContext) {
-	s.generateAndroidBuildActions(ctx)
+	s.generateAndroidBuildActions(ctx, nil)
 	installDir := android.PathForModuleInstall(ctx, "bin", proptools.String(s.properties.Sub_dir))
 	if !s.Installable() {
 		s.SkipInstall()
@@ -438,7 +442,7 @@ func (s *ShTest) addToDataModules(ctx android.ModuleContext, relPath string, pat
 }
 
 func (s *ShTest) GenerateAndroidBuildActions(ctx android.ModuleContext) {
-	s.ShBinary.generateAndroidBuildActions(ctx)
+	s.ShBinary.generateAndroidBuildActions(ctx, s.testProperties.Data_bins)
 
 	expandedData := android.PathsForModuleSrc(ctx, s.testProperties.Data)
 	expandedData = append(expandedData, android.PathsForModuleSrc(ctx, s.testProperties.Device_common_data)...)
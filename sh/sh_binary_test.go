@@ -399,3 +399,84 @@ func TestDefaultsForBinaries(t *testing.T) {
 	mod = ctx.ModuleForTests(t, "the-binary", "android_arm64_armv8-a").Module().(*ShBinary)
 	android.AssertStringEquals(t, "Filename", expectedFilename, *mod.properties.Filename)
 }
+
+func TestShBinaryShellcheck(t *testing.T) {
+	result := prepareForShTest.RunTestWithBp(t, `
+		sh_binary_host {
+			name: "foo",
+			src: "test.sh",
+			required: ["bar"],
+			shellcheck: {
+				enabled: true,
+				severity: "warning",
+				exclude: ["SC2086", "1090"],
+			},
+		}
+		sh_binary_host {
+			name: "bar",
+			src: "test.sh",
+		}
+	`)
+
+	buildOS := result.Config.BuildOS.String()
+	foo := result.ModuleForTests(t, "foo", buildOS+"_x86_64")
+
+	shellcheck := foo.Output("shellcheck.stamp")
+	android.AssertStringEquals(t, "shellcheck severity", "warning", shellcheck.Args["severity"])
+	android.AssertStringEquals(t, "shellcheck flags", "--exclude=SC2086,1090", shellcheck.Args["flags"])
+
+	hostTools := foo.Output("host_tools.stamp")
+	android.AssertStringEquals(t, "declared host tools", "bar", hostTools.Args["declared"])
+
+	cp := foo.Output("foo")
+	android.AssertPathsRelativeToTopEquals(t, "validations of the script",
+		[]string{android.PathRelativeToTop(shellcheck.Output), android.PathRelativeToTop(hostTools.Output)},
+		cp.Validations)
+
+	// The checks are opt-in.
+	bar := result.ModuleForTests(t, "bar", buildOS+"_x86_64")
+	android.AssertDeepEquals(t, "validations of an unchecked script", 0, len(bar.Output("bar").Validations))
+}
+
+func TestShTestShellcheckHostToolsOnly(t *testing.T) {
+	result := prepareForShTest.RunTestWithBp(t, `
+		sh_test_host {
+			name: "foo",
+			src: "test.sh",
+			data_bins: ["bar"],
+			shellcheck: {
+				check_host_tools: true,
+			},
+		}
+		sh_binary_host {
+			name: "bar",
+			src: "test.sh",
+		}
+	`)
+
+	buildOS := result.Config.BuildOS.String()
+	foo := result.ModuleForTests(t, "foo", buildOS+"_x86_64")
+	if foo.MaybeOutput("shellcheck.stamp").Rule != nil {
+		t.Errorf("shellcheck should only run when enabled")
+	}
+	hostTools := foo.Output("host_tools.stamp")
+	android.AssertStringEquals(t, "declared host tools", "bar", hostTools.Args["declared"])
+	android.AssertStringDoesContain(t, "properties", hostTools.Args["properties"], "data_bins")
+}
+
+func TestShBinaryShellcheckErrors(t *testing.T) {
+	prepareForShTest.ExtendWithErrorHandler(android.FixtureExpectsAllErrorsToMatchAPattern([]string{
+		`shellcheck.severity: must be one of error, warning, info, style, got "fatal"`,
+		`shellcheck.exclude: invalid shellcheck code "2086x"`,
+	})).RunTestWithBp(t, `
+		sh_binary_host {
+			name: "foo",
+			src: "test.sh",
+			shellcheck: {
+				enabled: true,
+				severity: "fatal",
+				exclude: ["2086x"],
+			},
+		}
+	`)
+}
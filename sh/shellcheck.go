@@ -0,0 +1,144 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sh
+
+// This file contains the opt-in shellcheck and host tool declaration checks of the scripts of
+// sh_binary and sh_test modules.
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+var (
+	shellcheck = pctx.AndroidStaticRule("shellcheck",
+		blueprint.RuleParams{
+			Command:     `$shellcheckCmd --severity=$severity $flags $in && touch $out`,
+			CommandDeps: []string{"$shellcheckCmd"},
+		},
+		"severity", "flags")
+
+	// Lists the commands that the script invokes from the bin directory of the host out dir, and
+	// fails if any of them is not in $declared.
+	shHostTools = pctx.AndroidStaticRule("shHostTools",
+		blueprint.RuleParams{
+			Command: `grep -ohE '(\$$\{?(ANDROID_HOST_OUT|HOST_OUT)\}?|out/host/[^/]+)/bin/[A-Za-z0-9_.+-]+' $in | ` +
+				`sed -e 's|.*/||' | sort -u > $out.used && ` +
+				`printf '%s\n' $declared | sort -u > $out.declared && ` +
+				`missing=$$(comm -23 $out.used $out.declared) && rm -f $out.used $out.declared && ` +
+				`if [ -n "$$missing" ]; then ` +
+				`echo "$in: commands invoked from the host out dir must be declared in $properties:" $$missing >&2; ` +
+				`exit 1; fi && touch $out`,
+		},
+		"declared", "properties")
+
+	shellcheckSeverities = []string{"error", "warning", "info", "style"}
+	shellcheckCodeRegexp = regexp.MustCompile(`^(SC)?[0-9]+$`)
+)
+
+func init() {
+	pctx.HostBinToolVariable("shellcheckCmd", "shellcheck")
+}
+
+type ShellcheckProperties struct {
+	// whether to check the script with shellcheck. Defaults to false.
+	Enabled *bool
+
+	// the minimum severity of the issues that fail the build: error, warning, info or style.
+	// Defaults to style, which reports all issues.
+	Severity *string
+
+	// list of shellcheck codes to ignore, e.g. SC2086.
+	Exclude []string
+
+	// whether to check that the commands the script invokes from the bin directory of the host out
+	// dir, e.g. $ANDROID_HOST_OUT/bin/aapt2, are declared in data_bins, required or host_required,
+	// so that they are in the sandbox and the test zips. Defaults to the value of enabled.
+	Check_host_tools *bool
+}
+
+// buildScriptChecks registers the enabled checks of the script and returns their outputs, which
+// must be added as validations of the script output. dataBins are the data_bins of an sh_test.
+func (s *ShBinary) buildScriptChecks(ctx android.ModuleContext, dataBins []string) android.Paths {
+	props := &s.properties.Shellcheck
+	var validations android.Paths
+
+	if Bool(props.Enabled) {
+		severity := proptools.StringDefault(props.Severity, "style")
+		if !android.InList(severity, shellcheckSeverities) {
+			ctx.PropertyErrorf("shellcheck.severity", "must be one of %s, got %q",
+				strings.Join(shellcheckSeverities, ", "), severity)
+		}
+		var flags []string
+		for _, code := range props.Exclude {
+			if !shellcheckCodeRegexp.MatchString(code) {
+				ctx.PropertyErrorf("shellcheck.exclude", "invalid shellcheck code %q, expected e.g. SC2086", code)
+			}
+		}
+		if len(props.Exclude) > 0 {
+			flags = append(flags, "--exclude="+strings.Join(props.Exclude, ","))
+		}
+
+		out := android.PathForModuleOut(ctx, "shellcheck.stamp")
+		ctx.Build(pctx, android.BuildParams{
+			Rule:        shellcheck,
+			Description: "shellcheck " + ctx.ModuleName(),
+			Output:      out,
+			Input:       s.sourceFilePath,
+			Args: map[string]string{
+				"severity": severity,
+				"flags":    strings.Join(flags, " "),
+			},
+		})
+		validations = append(validations, out)
+	}
+
+	if proptools.BoolDefault(props.Check_host_tools, Bool(props.Enabled)) {
+		var declared []string
+		for _, name := range dataBins {
+			if module := android.SrcIsModule(name); module != "" {
+				name = module
+			}
+			declared = append(declared, name)
+		}
+		declared = append(declared, s.RequiredModuleNames(ctx)...)
+		declared = append(declared, s.HostRequiredModuleNames()...)
+
+		properties := "required or host_required"
+		if _, isTest := ctx.Module().(*ShTest); isTest {
+			properties = "data_bins, " + properties
+		}
+		out := android.PathForModuleOut(ctx, "host_tools.stamp")
+		ctx.Build(pctx, android.BuildParams{
+			Rule:        shHostTools,
+			Description: "check host tools " + ctx.ModuleName(),
+			Output:      out,
+			Input:       s.sourceFilePath,
+			Args: map[string]string{
+				"declared":   strings.Join(proptools.ShellEscapeList(android.SortedUniqueStrings(declared)), " "),
+				"properties": properties,
+			},
+		})
+		validations = append(validations, out)
+	}
+
+	ctx.CheckbuildFile(validations...)
+	return validations
+}
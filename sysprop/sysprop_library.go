@@ -424,6 +424,23 @@ func (m *syspropLibrary) GenerateAndroidBuildActions(ctx android.ModuleContext)
 		Text("; exit 38) )").
 		Implicits(apiFileList)
 
+	// 3. checks the semantics of the changes from latest.txt to current.txt
+	// sysprop_compat reports each incompatible change, e.g. a narrowed enum or a public property
+	// whose access was reduced.
+	msg = fmt.Sprintf(`\n******************************\n`+
+		`API of sysprop_library %s is incompatible with latest version\n`+
+		`Please fix the breakage listed above and rebuild.\n`+
+		`******************************\n`, baseModuleName)
+
+	rule.Command().
+		Text("( ").
+		BuiltTool("sysprop_compat").
+		Text(latestApiArgument).
+		Text(currentApiArgument).
+		Text(" || ( echo").Flag("-e").
+		Flag(`"` + msg + `"`).
+		Text("; exit 38) )")
+
 	m.checkApiFileTimeStamp = android.PathForModuleOut(ctx, "check_api.timestamp")
 
 	rule.Command().
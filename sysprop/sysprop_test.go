@@ -402,3 +402,22 @@ func TestMinSdkVersionIsForwarded(t *testing.T) {
 	propFromRust := proptools.String(rustModule.Properties.Min_sdk_version)
 	android.AssertStringEquals(t, "min_sdk_version forwarding to rust module", "29", propFromRust)
 }
+
+func TestSyspropCompatibilityCheck(t *testing.T) {
+	result := test(t, `
+		sysprop_library {
+			name: "sysprop-platform",
+			srcs: ["android/sysprop/PlatformProperties.sysprop"],
+			api_packages: ["android.sysprop"],
+			property_owner: "Platform",
+		}
+	`)
+
+	checkApi := result.ModuleForTests(t, "sysprop-platform", "").Output("check_api.timestamp")
+	android.AssertStringDoesContain(t, "check api command", checkApi.RuleParams.Command,
+		"sysprop_api_checker api/sysprop-platform-latest.txt api/sysprop-platform-current.txt")
+	android.AssertStringDoesContain(t, "check api command", checkApi.RuleParams.Command,
+		"sysprop_compat api/sysprop-platform-latest.txt api/sysprop-platform-current.txt")
+	android.AssertStringListContains(t, "check api implicits", checkApi.Implicits.Strings(),
+		"api/sysprop-platform-latest.txt")
+}
@@ -24,6 +24,7 @@ var (
 
 func init() {
 	pctx.SourcePathVariable("AutoGenTestConfigScript", "build/make/tools/auto_gen_test_config.py")
+	pctx.SourcePathVariable("FuzzSmokeTestConfigTemplate", "build/soong/fuzz/fuzz_smoke_test_config_template.xml")
 	pctx.SourcePathVariable("InstrumentationTestConfigTemplate", "build/make/core/instrumentation_test_config_template.xml")
 	pctx.SourcePathVariable("JavaTestConfigTemplate", "build/make/core/java_test_config_template.xml")
 	pctx.SourcePathVariable("JavaHostTestConfigTemplate", "build/make/core/java_host_test_config_template.xml")
@@ -1159,6 +1159,12 @@ func (c *configImpl) ModuleActionsFile() string {
 	return shared.JoinPath(c.SoongOutDir(), "module-actions.json")
 }
 
+// BuildAttributionEnabled returns true if soong_build should tag each ninja build statement with
+// the module that owns it so that a per-module build time report can be written after the build.
+func (c *configImpl) BuildAttributionEnabled() bool {
+	return c.environ.IsEnvTrue("SOONG_BUILD_ATTRIBUTION")
+}
+
 func (c *configImpl) TempDir() string {
 	return shared.TempDirForOutDir(c.SoongOutDir())
 }
@@ -0,0 +1,423 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Eval parses and evaluates a query over the graph.
+func (g *Graph) Eval(query string) (Set, error) {
+	e, err := Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	return e.eval(g)
+}
+
+func (e *patternExpr) eval(g *Graph) (Set, error) {
+	return g.matchPattern(e.pattern)
+}
+
+// matchPattern returns the modules matching a pattern, which is one of:
+//   - a module name, which may contain glob characters, e.g. libfoo or libfoo*
+//   - a module label including the variant, e.g. libfoo{android_arm64_armv8-a_shared}
+//   - a directory, optionally followed by a module name, e.g. //external/foo:libfoo
+//   - a directory and all of its subdirectories, e.g. //vendor/...
+func (g *Graph) matchPattern(pattern string) (Set, error) {
+	ret := make(Set)
+	switch {
+	case strings.HasPrefix(pattern, "//"):
+		dir, name, hasName := strings.Cut(strings.TrimPrefix(pattern, "//"), ":")
+		if !hasName {
+			name = "*"
+		}
+		recursive := dir == "..." || strings.HasSuffix(dir, "/...")
+		dir = strings.TrimSuffix(strings.TrimSuffix(dir, "..."), "/")
+		if dir == "" {
+			dir = "."
+		}
+		for _, m := range g.Modules {
+			moduleDir := m.Dir()
+			dirMatches := moduleDir == dir ||
+				recursive && (dir == "." || strings.HasPrefix(moduleDir, dir+"/"))
+			if matched, _ := path.Match(name, m.Name); dirMatches && matched {
+				ret.add(m)
+			}
+		}
+	case strings.Contains(pattern, "{"):
+		for _, m := range g.Modules {
+			if matched, _ := path.Match(pattern, m.Label()); matched {
+				ret.add(m)
+			}
+		}
+	case strings.ContainsAny(pattern, `*?[\`):
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		for _, m := range g.Modules {
+			if matched, _ := path.Match(pattern, m.Name); matched {
+				ret.add(m)
+			}
+		}
+	default:
+		modules, ok := g.byName[pattern]
+		if !ok {
+			return nil, fmt.Errorf("no module named %q", pattern)
+		}
+		for _, m := range modules {
+			ret.add(m)
+		}
+	}
+	return ret, nil
+}
+
+func (e *setExpr) eval(g *Graph) (Set, error) {
+	left, err := e.left.eval(g)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(g)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(Set)
+	switch e.op {
+	case "union":
+		for m := range left {
+			ret.add(m)
+		}
+		for m := range right {
+			ret.add(m)
+		}
+	case "except":
+		for m := range left {
+			if !right[m] {
+				ret.add(m)
+			}
+		}
+	case "intersect":
+		for m := range left {
+			if right[m] {
+				ret.add(m)
+			}
+		}
+	}
+	return ret, nil
+}
+
+type function struct {
+	// minArgs and maxArgs are the number of arguments the function accepts.
+	minArgs, maxArgs int
+	// usage is the signature of the function, for error messages.
+	usage string
+	eval  func(g *Graph, args []Expr) (Set, error)
+}
+
+// functions are the functions that can be called in a query.
+var functions = map[string]function{
+	"deps":     {1, 2, "deps(expr [, depth])", evalDeps},
+	"rdeps":    {1, 2, "rdeps(expr [, depth])", evalRdeps},
+	"somepath": {2, 2, "somepath(from, to)", evalSomepath},
+	"allpaths": {2, 2, "allpaths(from, to)", evalAllpaths},
+	"kind":     {2, 2, "kind(regexp, expr)", evalKind},
+	"attr":     {3, 3, "attr(name, regexp, expr)", evalAttr},
+	"filter":   {3, 3, "filter(name|dir|variant|tag, regexp, expr)", evalFilter},
+}
+
+func (e *callExpr) eval(g *Graph) (Set, error) {
+	f := functions[e.name]
+	if len(e.args) < f.minArgs || len(e.args) > f.maxArgs {
+		return nil, fmt.Errorf("wrong number of arguments to %s, usage: %s", e.name, f.usage)
+	}
+	ret, err := f.eval(g, e.args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", e.name, err)
+	}
+	return ret, nil
+}
+
+// literal returns the text of an argument that must be a word or a string rather than an
+// expression.
+func literal(arg Expr) (string, error) {
+	if p, ok := arg.(*patternExpr); ok {
+		return p.pattern, nil
+	}
+	return "", fmt.Errorf("expected a word or a string, got %s", arg)
+}
+
+func regexpArg(arg Expr) (*regexp.Regexp, error) {
+	s, err := literal(arg)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile(s)
+}
+
+// depthArg returns the depth argument of deps and rdeps, or -1 for unlimited depth.
+func depthArg(args []Expr, i int) (int, error) {
+	if len(args) <= i {
+		return -1, nil
+	}
+	s, err := literal(args[i])
+	if err != nil {
+		return 0, err
+	}
+	depth, err := strconv.Atoi(s)
+	if err != nil || depth < 0 {
+		return 0, fmt.Errorf("invalid depth %q", s)
+	}
+	return depth, nil
+}
+
+// transitive returns the modules reachable from start within depth steps of next, including the
+// start modules themselves.
+func transitive(start Set, depth int, next func(*Module) []*Module) Set {
+	ret := make(Set)
+	var queue []*Module
+	for _, m := range start.Sorted() {
+		ret.add(m)
+		queue = append(queue, m)
+	}
+	for level := 0; len(queue) > 0 && (depth < 0 || level < depth); level++ {
+		var nextQueue []*Module
+		for _, m := range queue {
+			for _, n := range next(m) {
+				if !ret[n] {
+					ret.add(n)
+					nextQueue = append(nextQueue, n)
+				}
+			}
+		}
+		queue = nextQueue
+	}
+	return ret
+}
+
+func depsOf(m *Module) []*Module {
+	ret := make([]*Module, len(m.Deps))
+	for i, e := range m.Deps {
+		ret[i] = e.To
+	}
+	return ret
+}
+
+func rdepsOf(m *Module) []*Module {
+	ret := make([]*Module, len(m.Rdeps))
+	for i, e := range m.Rdeps {
+		ret[i] = e.From
+	}
+	return ret
+}
+
+func evalDeps(g *Graph, args []Expr) (Set, error) {
+	start, err := args[0].eval(g)
+	if err != nil {
+		return nil, err
+	}
+	depth, err := depthArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return transitive(start, depth, depsOf), nil
+}
+
+func evalRdeps(g *Graph, args []Expr) (Set, error) {
+	start, err := args[0].eval(g)
+	if err != nil {
+		return nil, err
+	}
+	depth, err := depthArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return transitive(start, depth, rdepsOf), nil
+}
+
+// evalSomepath returns the modules on one shortest dependency path from a module in the first
+// argument to a module in the second argument, or an empty set if there is no such path.
+func evalSomepath(g *Graph, args []Expr) (Set, error) {
+	from, err := args[0].eval(g)
+	if err != nil {
+		return nil, err
+	}
+	to, err := args[1].eval(g)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := make(map[*Module]*Module)
+	var queue []*Module
+	for _, m := range from.Sorted() {
+		parent[m] = nil
+		queue = append(queue, m)
+	}
+	for len(queue) > 0 {
+		m := queue[0]
+		queue = queue[1:]
+		if to[m] {
+			ret := make(Set)
+			for ; m != nil; m = parent[m] {
+				ret.add(m)
+			}
+			return ret, nil
+		}
+		for _, n := range depsOf(m) {
+			if _, seen := parent[n]; !seen {
+				parent[n] = m
+				queue = append(queue, n)
+			}
+		}
+	}
+	return make(Set), nil
+}
+
+// evalAllpaths returns the modules on any dependency path from a module in the first argument
+// to a module in the second argument.
+func evalAllpaths(g *Graph, args []Expr) (Set, error) {
+	from, err := args[0].eval(g)
+	if err != nil {
+		return nil, err
+	}
+	to, err := args[1].eval(g)
+	if err != nil {
+		return nil, err
+	}
+	forward := transitive(from, -1, depsOf)
+	backward := transitive(to, -1, rdepsOf)
+	ret := make(Set)
+	for m := range forward {
+		if backward[m] {
+			ret.add(m)
+		}
+	}
+	return ret, nil
+}
+
+// filterSet returns the modules of the last argument for which match returns true.
+func filterSet(g *Graph, arg Expr, match func(*Module) bool) (Set, error) {
+	input, err := arg.eval(g)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(Set)
+	for m := range input {
+		if match(m) {
+			ret.add(m)
+		}
+	}
+	return ret, nil
+}
+
+func evalKind(g *Graph, args []Expr) (Set, error) {
+	re, err := regexpArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return filterSet(g, args[1], func(m *Module) bool {
+		return re.MatchString(m.Type)
+	})
+}
+
+// evalAttr returns the modules whose entry in the module graph has a value matching a regexp at
+// a dotted path, e.g. attr(Module.Android.SrcPaths, "\.cpp$", deps(libfoo)).  Lists match if
+// any of their elements match.
+func evalAttr(g *Graph, args []Expr) (Set, error) {
+	name, err := literal(args[0])
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexpArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return filterSet(g, args[2], func(m *Module) bool {
+		var value interface{} = m.json
+		for _, key := range strings.Split(name, ".") {
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				return false
+			}
+			if value, ok = obj[key]; !ok {
+				return false
+			}
+		}
+		if list, ok := value.([]interface{}); ok {
+			for _, v := range list {
+				if re.MatchString(attrString(v)) {
+					return true
+				}
+			}
+			return false
+		}
+		return re.MatchString(attrString(value))
+	})
+}
+
+func attrString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// evalFilter returns the modules whose name, directory or variant matches a regexp.  Filtering
+// on tag returns the modules that are the target of a dependency with a matching dependency tag
+// from another module in the input, e.g. filter(tag, "sharedLib", deps(libfoo)).
+func evalFilter(g *Graph, args []Expr) (Set, error) {
+	field, err := literal(args[0])
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexpArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+	switch field {
+	case "name":
+		return filterSet(g, args[2], func(m *Module) bool { return re.MatchString(m.Name) })
+	case "dir":
+		return filterSet(g, args[2], func(m *Module) bool { return re.MatchString(m.Dir()) })
+	case "variant":
+		return filterSet(g, args[2], func(m *Module) bool { return re.MatchString(m.Variant) })
+	case "tag":
+		input, err := args[2].eval(g)
+		if err != nil {
+			return nil, err
+		}
+		ret := make(Set)
+		for m := range input {
+			for _, e := range m.Rdeps {
+				if input[e.From] && re.MatchString(e.Tag) {
+					ret.add(m)
+					break
+				}
+			}
+		}
+		return ret, nil
+	default:
+		return nil, fmt.Errorf("unknown field %q, expected name, dir, variant or tag", field)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package query implements a small query language over the module graph that soong_build dumps
+// with --module_graph_file.
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+)
+
+// Module is a single variant of a module in the module graph.
+type Module struct {
+	Name    string
+	Variant string
+	// Type is the module type, e.g. cc_library.
+	Type string
+	// Blueprint is the path of the Android.bp file that defines the module.
+	Blueprint string
+
+	// Deps are the direct dependencies of the module.
+	Deps []*Edge
+	// Rdeps are the direct reverse dependencies of the module.
+	Rdeps []*Edge
+
+	// json is the module's entry in the module graph, used to evaluate attr().
+	json map[string]interface{}
+	// index is the position of the module in the graph, used to sort results.
+	index int
+}
+
+// Label returns the name of the module variant in the form name{variant}.
+func (m *Module) Label() string {
+	if m.Variant == "" {
+		return m.Name
+	}
+	return m.Name + "{" + m.Variant + "}"
+}
+
+// Dir returns the directory the module is defined in.
+func (m *Module) Dir() string {
+	return path.Dir(m.Blueprint)
+}
+
+// Edge is a dependency from one module variant to another.
+type Edge struct {
+	From, To *Module
+	// Tag is the dependency tag of the dependency as printed by blueprint.
+	Tag string
+}
+
+// Graph is the module graph dumped by soong_build.
+type Graph struct {
+	Modules []*Module
+
+	byName map[string][]*Module
+}
+
+// jsonModule is the subset of blueprint's JSON module format that is used to build the graph.
+type jsonModule struct {
+	Name      string
+	Variant   string
+	Type      string
+	Blueprint string
+	Deps      []struct {
+		Name    string
+		Variant string
+		Tag     string
+	}
+}
+
+// LoadGraph reads a module graph written by soong_build --module_graph_file.
+func LoadGraph(r io.Reader) (*Graph, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse module graph: %w", err)
+	}
+
+	g := &Graph{byName: make(map[string][]*Module)}
+	type variantKey struct{ name, variant string }
+	byVariant := make(map[variantKey]*Module, len(raw))
+	parsed := make([]jsonModule, len(raw))
+
+	for i, data := range raw {
+		if err := json.Unmarshal(data, &parsed[i]); err != nil {
+			return nil, fmt.Errorf("failed to parse module %d of module graph: %w", i, err)
+		}
+		m := &Module{
+			Name:      parsed[i].Name,
+			Variant:   parsed[i].Variant,
+			Type:      parsed[i].Type,
+			Blueprint: parsed[i].Blueprint,
+			index:     i,
+		}
+		if err := json.Unmarshal(data, &m.json); err != nil {
+			return nil, fmt.Errorf("failed to parse module %s: %w", m.Label(), err)
+		}
+		g.Modules = append(g.Modules, m)
+		g.byName[m.Name] = append(g.byName[m.Name], m)
+		byVariant[variantKey{m.Name, m.Variant}] = m
+	}
+
+	for i, m := range g.Modules {
+		for _, dep := range parsed[i].Deps {
+			to := byVariant[variantKey{dep.Name, dep.Variant}]
+			if to == nil {
+				// Dependencies on modules that aren't in the graph, e.g. missing dependencies
+				// allowed by ALLOW_MISSING_DEPENDENCIES, can't be queried.
+				continue
+			}
+			e := &Edge{From: m, To: to, Tag: dep.Tag}
+			m.Deps = append(m.Deps, e)
+			to.Rdeps = append(to.Rdeps, e)
+		}
+	}
+	return g, nil
+}
+
+// Set is a set of module variants, the value of a query expression.
+type Set map[*Module]bool
+
+func (s Set) add(m *Module) {
+	s[m] = true
+}
+
+// Sorted returns the modules in the set sorted by label.
+func (s Set) Sorted() []*Module {
+	ret := make([]*Module, 0, len(s))
+	for m := range s {
+		ret = append(ret, m)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Name != ret[j].Name {
+			return ret[i].Name < ret[j].Name
+		}
+		if ret[i].Variant != ret[j].Variant {
+			return ret[i].Variant < ret[j].Variant
+		}
+		return ret[i].index < ret[j].index
+	})
+	return ret
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// OutputFormats are the formats supported by Write.
+var OutputFormats = []string{"label", "graph", "json"}
+
+// Write writes the result of a query in one of OutputFormats.
+func Write(w io.Writer, format string, s Set) error {
+	switch format {
+	case "label":
+		return WriteLabels(w, s)
+	case "graph":
+		return WriteGraphviz(w, s)
+	case "json":
+		return WriteJSON(w, s)
+	default:
+		return fmt.Errorf("unknown output format %q, expected one of %q", format, OutputFormats)
+	}
+}
+
+// WriteLabels writes the labels of the modules in the set, one per line.
+func WriteLabels(w io.Writer, s Set) error {
+	buf := bufio.NewWriter(w)
+	for _, m := range s.Sorted() {
+		fmt.Fprintln(buf, m.Label())
+	}
+	return buf.Flush()
+}
+
+// WriteGraphviz writes the modules in the set and the dependencies between them as a graphviz
+// dot graph.
+func WriteGraphviz(w io.Writer, s Set) error {
+	buf := bufio.NewWriter(w)
+	fmt.Fprintln(buf, "digraph query {")
+	fmt.Fprintln(buf, "  node [shape=box];")
+	modules := s.Sorted()
+	for _, m := range modules {
+		fmt.Fprintf(buf, "  %s [tooltip=%s];\n", strconv.Quote(m.Label()), strconv.Quote(m.Type))
+	}
+	for _, m := range modules {
+		for _, e := range m.Deps {
+			if !s[e.To] {
+				continue
+			}
+			fmt.Fprintf(buf, "  %s -> %s", strconv.Quote(m.Label()), strconv.Quote(e.To.Label()))
+			if e.Tag != "" {
+				fmt.Fprintf(buf, " [tooltip=%s]", strconv.Quote(e.Tag))
+			}
+			fmt.Fprintln(buf, ";")
+		}
+	}
+	fmt.Fprintln(buf, "}")
+	return buf.Flush()
+}
+
+type jsonDep struct {
+	Name    string
+	Variant string
+	Tag     string `json:",omitempty"`
+}
+
+type jsonResult struct {
+	Name    string
+	Variant string
+	Type    string
+	Dir     string
+	// Deps are the dependencies of the module that are also in the result.
+	Deps []jsonDep
+}
+
+// WriteJSON writes the modules in the set and the dependencies between them as a JSON array.
+func WriteJSON(w io.Writer, s Set) error {
+	results := []jsonResult{}
+	for _, m := range s.Sorted() {
+		r := jsonResult{
+			Name:    m.Name,
+			Variant: m.Variant,
+			Type:    m.Type,
+			Dir:     m.Dir(),
+			Deps:    []jsonDep{},
+		}
+		for _, e := range m.Deps {
+			if s[e.To] {
+				r.Deps = append(r.Deps, jsonDep{e.To.Name, e.To.Variant, e.Tag})
+			}
+		}
+		results = append(results, r)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
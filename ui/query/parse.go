@@ -0,0 +1,227 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expr is a parsed query expression.
+type Expr interface {
+	eval(g *Graph) (Set, error)
+	String() string
+}
+
+// patternExpr matches modules by name, see matchPattern.
+type patternExpr struct {
+	pattern string
+}
+
+func (e *patternExpr) String() string { return strconv.Quote(e.pattern) }
+
+// setExpr combines the results of two expressions.
+type setExpr struct {
+	op          string
+	left, right Expr
+}
+
+func (e *setExpr) String() string {
+	return "(" + e.left.String() + " " + e.op + " " + e.right.String() + ")"
+}
+
+// callExpr is a call to one of the query functions.
+type callExpr struct {
+	name string
+	args []Expr
+}
+
+func (e *callExpr) String() string {
+	var args []string
+	for _, arg := range e.args {
+		args = append(args, arg.String())
+	}
+	return e.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// setOperators maps the spellings of the set operators to their canonical names.
+var setOperators = map[string]string{
+	"+":         "union",
+	"union":     "union",
+	"-":         "except",
+	"except":    "except",
+	"^":         "intersect",
+	"intersect": "intersect",
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, token{tokenString, s[i+1 : i+1+end], i})
+			i += end + 2
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t\n\r(),\"'", rune(s[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokenWord, s[start:i], start})
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(s)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses a query expression.
+func Parse(query string) (Expr, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+	return e, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// parseExpr parses a sequence of terms separated by set operators, which all have the same
+// precedence and are left associative.
+func (p *parser) parseExpr() (Expr, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		op, ok := setOperators[t.text]
+		if t.kind != tokenWord || !ok {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &setExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseTerm() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ')' at offset %d, got %q", t.pos, t.text)
+		}
+		return e, nil
+	case tokenString:
+		return &patternExpr{t.text}, nil
+	case tokenWord:
+		if _, ok := setOperators[t.text]; ok {
+			return nil, fmt.Errorf("unexpected operator %q at offset %d", t.text, t.pos)
+		}
+		if p.peek().kind != tokenLParen {
+			return &patternExpr{t.text}, nil
+		}
+		if _, ok := functions[t.text]; !ok {
+			return nil, fmt.Errorf("unknown function %q at offset %d", t.text, t.pos)
+		}
+		p.next()
+		call := &callExpr{name: t.text}
+		if p.peek().kind == tokenRParen {
+			p.next()
+			return call, nil
+		}
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			switch t := p.next(); t.kind {
+			case tokenComma:
+				continue
+			case tokenRParen:
+				return call, nil
+			default:
+				return nil, fmt.Errorf("expected ',' or ')' at offset %d, got %q", t.pos, t.text)
+			}
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of query")
+	default:
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+}
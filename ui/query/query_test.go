@@ -0,0 +1,310 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testGraph = `[
+  {
+    "Name": "app",
+    "Variant": "android_common",
+    "Type": "android_app",
+    "Blueprint": "packages/apps/App/Android.bp",
+    "Module": {"Android": {"SrcPaths": ["packages/apps/App/src/Main.java"]}},
+    "Deps": [
+      {"Name": "libjava", "Variant": "android_common", "Tag": "java.dependencyTag{name:staticlib}"},
+      {"Name": "libjni", "Variant": "android_arm64_shared", "Tag": "java.dependencyTag{name:jnilib}"}
+    ]
+  },
+  {
+    "Name": "libjava",
+    "Variant": "android_common",
+    "Type": "java_library",
+    "Blueprint": "frameworks/lib/Android.bp",
+    "Module": {"Android": {"SrcPaths": ["frameworks/lib/Lib.java"]}},
+    "Deps": [
+      {"Name": "libjni", "Variant": "android_arm64_shared", "Tag": "java.dependencyTag{name:libs}"}
+    ]
+  },
+  {
+    "Name": "libjni",
+    "Variant": "android_arm64_shared",
+    "Type": "cc_library",
+    "Blueprint": "frameworks/lib/jni/Android.bp",
+    "Module": {"Android": {"SrcPaths": ["frameworks/lib/jni/jni.cpp"]}},
+    "Deps": [
+      {"Name": "libc", "Variant": "android_arm64_shared", "Tag": "cc.libraryDependencyTag{Kind:sharedLibraryDependency}"},
+      {"Name": "libmissing", "Variant": "android_arm64_shared", "Tag": ""}
+    ]
+  },
+  {
+    "Name": "libc",
+    "Variant": "android_arm64_shared",
+    "Type": "cc_library",
+    "Blueprint": "bionic/libc/Android.bp",
+    "Deps": []
+  },
+  {
+    "Name": "libc",
+    "Variant": "android_arm64_static",
+    "Type": "cc_library",
+    "Blueprint": "bionic/libc/Android.bp",
+    "Deps": []
+  }
+]`
+
+func loadTestGraph(t *testing.T) *Graph {
+	t.Helper()
+	g, err := LoadGraph(strings.NewReader(testGraph))
+	if err != nil {
+		t.Fatalf("failed to load graph: %s", err)
+	}
+	return g
+}
+
+func labels(s Set) []string {
+	ret := []string{}
+	for _, m := range s.Sorted() {
+		ret = append(ret, m.Label())
+	}
+	return ret
+}
+
+func TestEval(t *testing.T) {
+	g := loadTestGraph(t)
+
+	testCases := []struct {
+		query string
+		want  []string
+	}{
+		{
+			query: "libc",
+			want:  []string{"libc{android_arm64_shared}", "libc{android_arm64_static}"},
+		},
+		{
+			query: "lib*",
+			want: []string{"libc{android_arm64_shared}", "libc{android_arm64_static}",
+				"libjava{android_common}", "libjni{android_arm64_shared}"},
+		},
+		{
+			query: "libc{*_static}",
+			want:  []string{"libc{android_arm64_static}"},
+		},
+		{
+			query: "//frameworks/...",
+			want:  []string{"libjava{android_common}", "libjni{android_arm64_shared}"},
+		},
+		{
+			query: "//frameworks/lib",
+			want:  []string{"libjava{android_common}"},
+		},
+		{
+			query: "//frameworks/lib/jni:libjni",
+			want:  []string{"libjni{android_arm64_shared}"},
+		},
+		{
+			query: "deps(app)",
+			want: []string{"app{android_common}", "libc{android_arm64_shared}",
+				"libjava{android_common}", "libjni{android_arm64_shared}"},
+		},
+		{
+			query: "deps(app, 1)",
+			want:  []string{"app{android_common}", "libjava{android_common}", "libjni{android_arm64_shared}"},
+		},
+		{
+			query: "rdeps(libjni)",
+			want:  []string{"app{android_common}", "libjava{android_common}", "libjni{android_arm64_shared}"},
+		},
+		{
+			query: "somepath(app, libc)",
+			want:  []string{"app{android_common}", "libc{android_arm64_shared}", "libjni{android_arm64_shared}"},
+		},
+		{
+			query: "allpaths(app, libjni)",
+			want:  []string{"app{android_common}", "libjava{android_common}", "libjni{android_arm64_shared}"},
+		},
+		{
+			query: "somepath(libc, app)",
+			want:  []string{},
+		},
+		{
+			query: "kind(^cc_, deps(app))",
+			want:  []string{"libc{android_arm64_shared}", "libjni{android_arm64_shared}"},
+		},
+		{
+			query: `attr(Module.Android.SrcPaths, "\.java$", //...)`,
+			want:  []string{"app{android_common}", "libjava{android_common}"},
+		},
+		{
+			query: "filter(dir, ^bionic, //...)",
+			want:  []string{"libc{android_arm64_shared}", "libc{android_arm64_static}"},
+		},
+		{
+			query: "filter(variant, static, libc)",
+			want:  []string{"libc{android_arm64_static}"},
+		},
+		{
+			query: "filter(tag, jnilib, deps(app))",
+			want:  []string{"libjni{android_arm64_shared}"},
+		},
+		{
+			query: `deps(app) - kind("app|java", //...)`,
+			want:  []string{"libc{android_arm64_shared}", "libjni{android_arm64_shared}"},
+		},
+		{
+			query: "deps(libjava) ^ rdeps(libc) + app",
+			want: []string{"app{android_common}", "libc{android_arm64_shared}",
+				"libjava{android_common}", "libjni{android_arm64_shared}"},
+		},
+		{
+			query: "deps(libjava) intersect (rdeps(libc) union app)",
+			want: []string{"libc{android_arm64_shared}", "libjava{android_common}",
+				"libjni{android_arm64_shared}"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			s, err := g.Eval(tc.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := labels(s); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	g := loadTestGraph(t)
+
+	testCases := []struct {
+		query string
+		err   string
+	}{
+		{"libfoo", `no module named "libfoo"`},
+		{"deps(app", `expected ',' or ')'`},
+		{"depz(app)", `unknown function "depz"`},
+		{"deps(app, -1)", `invalid depth "-1"`},
+		{"kind(cc)", "wrong number of arguments to kind"},
+		{"kind(deps(app), app)", "expected a word or a string"},
+		{"filter(type, cc, app)", `unknown field "type"`},
+		{"app +", "unexpected end of query"},
+		{`"app`, "unterminated string"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			_, err := g.Eval(tc.query)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("want error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	g := loadTestGraph(t)
+	s, err := g.Eval("deps(libjava)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "label",
+			want: `libc{android_arm64_shared}
+libjava{android_common}
+libjni{android_arm64_shared}
+`,
+		},
+		{
+			format: "graph",
+			want: `digraph query {
+  node [shape=box];
+  "libc{android_arm64_shared}" [tooltip="cc_library"];
+  "libjava{android_common}" [tooltip="java_library"];
+  "libjni{android_arm64_shared}" [tooltip="cc_library"];
+  "libjava{android_common}" -> "libjni{android_arm64_shared}" [tooltip="java.dependencyTag{name:libs}"];
+  "libjni{android_arm64_shared}" -> "libc{android_arm64_shared}" [tooltip="cc.libraryDependencyTag{Kind:sharedLibraryDependency}"];
+}
+`,
+		},
+		{
+			format: "json",
+			want: `[
+  {
+    "Name": "libc",
+    "Variant": "android_arm64_shared",
+    "Type": "cc_library",
+    "Dir": "bionic/libc",
+    "Deps": []
+  },
+  {
+    "Name": "libjava",
+    "Variant": "android_common",
+    "Type": "java_library",
+    "Dir": "frameworks/lib",
+    "Deps": [
+      {
+        "Name": "libjni",
+        "Variant": "android_arm64_shared",
+        "Tag": "java.dependencyTag{name:libs}"
+      }
+    ]
+  },
+  {
+    "Name": "libjni",
+    "Variant": "android_arm64_shared",
+    "Type": "cc_library",
+    "Dir": "frameworks/lib/jni",
+    "Deps": [
+      {
+        "Name": "libc",
+        "Variant": "android_arm64_shared",
+        "Tag": "cc.libraryDependencyTag{Kind:sharedLibraryDependency}"
+      }
+    ]
+  }
+]
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := Write(buf, tc.format, s); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf("want:\n%s\ngot:\n%s", tc.want, got)
+			}
+		})
+	}
+
+	if err := Write(&bytes.Buffer{}, "xml", s); err == nil {
+		t.Error("expected error for unknown output format")
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"android/soong/ui/logger"
+)
+
+// unattributedModule is the name used in the report for actions that could not be mapped to a
+// module, for example actions generated by Kati or phony edges.
+const unattributedModule = "<unattributed>"
+
+// NewBuildAttributionLogger creates a StatusOutput that attributes the CPU time, wall time and
+// output size of every action to the module that owns it, and writes an aggregated report to
+// reportFile when flushed.
+//
+// Actions are attributed using the tags of their ninja edge, which soong_build sets to the module,
+// variant, type, directory and namespace of the owner when SOONG_BUILD_ATTRIBUTION is set.
+func NewBuildAttributionLogger(log logger.Logger, reportFile string) StatusOutput {
+	return &buildAttributionLogger{
+		log:        log,
+		reportFile: reportFile,
+		running:    make(map[*Action]time.Time),
+		clock:      osClock{},
+	}
+}
+
+type buildAttributionLogger struct {
+	log        logger.Logger
+	reportFile string
+
+	running map[*Action]time.Time
+	actions []attributedAction
+
+	clock clock
+}
+
+// attributedAction is the measured cost of a single finished action.
+type attributedAction struct {
+	owner       buildAttributionOwner
+	cpuTime     time.Duration
+	wallTime    time.Duration
+	outputBytes int64
+}
+
+// buildAttributionOwner is the module variant that owns an action.
+type buildAttributionOwner struct {
+	Module    string
+	Namespace string
+	Variant   string
+	Type      string
+	Dir       string
+}
+
+// parseBuildAttributionTags parses the ";key=value" tags of a ninja edge.
+func parseBuildAttributionTags(tags string) buildAttributionOwner {
+	var owner buildAttributionOwner
+	for _, pair := range strings.Split(tags, ";") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "module":
+			owner.Module = value
+		case "namespace":
+			owner.Namespace = value
+		case "variant":
+			owner.Variant = value
+		case "type":
+			owner.Type = value
+		case "dir":
+			owner.Dir = value
+		}
+	}
+	if owner.Module == "" {
+		return buildAttributionOwner{Module: unattributedModule}
+	}
+	return owner
+}
+
+// BuildAttributionCost is the aggregated cost of a set of actions.
+type BuildAttributionCost struct {
+	Actions     int   `json:"actions"`
+	CpuTimeMs   int64 `json:"cpu_time_ms"`
+	WallTimeMs  int64 `json:"wall_time_ms"`
+	OutputBytes int64 `json:"output_bytes"`
+}
+
+func (c *BuildAttributionCost) add(action attributedAction) {
+	c.Actions++
+	c.CpuTimeMs += action.cpuTime.Milliseconds()
+	c.WallTimeMs += action.wallTime.Milliseconds()
+	c.OutputBytes += action.outputBytes
+}
+
+// BuildAttributionModule is the cost of all the actions owned by the variants of a module.
+type BuildAttributionModule struct {
+	Module    string   `json:"module"`
+	Namespace string   `json:"namespace,omitempty"`
+	Type      string   `json:"type,omitempty"`
+	Dir       string   `json:"dir,omitempty"`
+	Variants  []string `json:"variants,omitempty"`
+	BuildAttributionCost
+}
+
+// BuildAttributionDir is the cost of all the actions owned by modules in a directory or any of its
+// subdirectories.
+type BuildAttributionDir struct {
+	Dir string `json:"dir"`
+	BuildAttributionCost
+}
+
+// BuildAttributionReport is the contents of the report file written by the build attribution
+// logger.  Modules and directories are sorted by decreasing CPU time.
+type BuildAttributionReport struct {
+	Total       BuildAttributionCost     `json:"total"`
+	Modules     []BuildAttributionModule `json:"modules"`
+	Directories []BuildAttributionDir    `json:"directories"`
+}
+
+func (b *buildAttributionLogger) StartAction(action *Action, counts Counts) {
+	b.running[action] = b.clock.Now()
+}
+
+func (b *buildAttributionLogger) FinishAction(result ActionResult, counts Counts) {
+	start, ok := b.running[result.Action]
+	if !ok {
+		return
+	}
+	delete(b.running, result.Action)
+
+	action := attributedAction{
+		owner:    parseBuildAttributionTags(result.Stats.Tags),
+		cpuTime:  time.Duration(result.Stats.UserTime+result.Stats.SystemTime) * time.Millisecond,
+		wallTime: b.clock.Now().Sub(start),
+	}
+	for _, output := range result.Action.Outputs {
+		if fi, err := os.Stat(output); err == nil && fi.Mode().IsRegular() {
+			action.outputBytes += fi.Size()
+		}
+	}
+	b.actions = append(b.actions, action)
+}
+
+func (b *buildAttributionLogger) Flush() {
+	if len(b.actions) == 0 {
+		return
+	}
+
+	report := buildAttributionReport(b.actions)
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		b.log.Println("Failed to marshal build attribution report:", err)
+		return
+	}
+	if err := os.WriteFile(b.reportFile, data, 0666); err != nil {
+		b.log.Println("Failed to write build attribution report:", err)
+		return
+	}
+
+	b.log.Verbosef("build attribution report written to %s", b.reportFile)
+	for i := 0; i < len(report.Modules) && i < 10; i++ {
+		m := report.Modules[i]
+		b.log.Verbosef("   %8dms cpu %8dms wall %12d bytes %s (%s)",
+			m.CpuTimeMs, m.WallTimeMs, m.OutputBytes, m.Module, m.Dir)
+	}
+}
+
+func (b *buildAttributionLogger) Message(level MsgLevel, msg string) {}
+
+func (b *buildAttributionLogger) Write(p []byte) (n int, err error) { return len(p), nil }
+
+func buildAttributionReport(actions []attributedAction) *BuildAttributionReport {
+	report := &BuildAttributionReport{}
+	modules := make(map[string]*BuildAttributionModule)
+	dirs := make(map[string]*BuildAttributionDir)
+
+	for _, action := range actions {
+		report.Total.add(action)
+
+		owner := action.owner
+		key := owner.Namespace + ":" + owner.Dir + ":" + owner.Module
+		module := modules[key]
+		if module == nil {
+			module = &BuildAttributionModule{
+				Module:    owner.Module,
+				Namespace: owner.Namespace,
+				Type:      owner.Type,
+				Dir:       owner.Dir,
+			}
+			modules[key] = module
+		}
+		module.add(action)
+		if owner.Variant != "" && !slices.Contains(module.Variants, owner.Variant) {
+			module.Variants = append(module.Variants, owner.Variant)
+		}
+
+		if owner.Dir == "" {
+			continue
+		}
+		// Attribute the action to the module's directory and every parent directory so that
+		// the report can be used to find expensive subtrees.
+		for dir := owner.Dir; ; dir = filepath.Dir(dir) {
+			d := dirs[dir]
+			if d == nil {
+				d = &BuildAttributionDir{Dir: dir}
+				dirs[dir] = d
+			}
+			d.add(action)
+			if dir == "." || dir == "/" || !strings.Contains(dir, "/") {
+				break
+			}
+		}
+	}
+
+	for _, module := range modules {
+		sort.Strings(module.Variants)
+		report.Modules = append(report.Modules, *module)
+	}
+	sort.Slice(report.Modules, func(i, j int) bool {
+		a, b := report.Modules[i], report.Modules[j]
+		if a.CpuTimeMs != b.CpuTimeMs {
+			return a.CpuTimeMs > b.CpuTimeMs
+		}
+		if a.Dir != b.Dir {
+			return a.Dir < b.Dir
+		}
+		if a.Module != b.Module {
+			return a.Module < b.Module
+		}
+		return a.Namespace < b.Namespace
+	})
+
+	for _, dir := range dirs {
+		report.Directories = append(report.Directories, *dir)
+	}
+	sort.Slice(report.Directories, func(i, j int) bool {
+		a, b := report.Directories[i], report.Directories[j]
+		if a.CpuTimeMs != b.CpuTimeMs {
+			return a.CpuTimeMs > b.CpuTimeMs
+		}
+		return a.Dir < b.Dir
+	})
+
+	return report
+}
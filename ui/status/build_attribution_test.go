@@ -0,0 +1,119 @@
+// Copyright 2026 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"android/soong/ui/logger"
+)
+
+func TestBuildAttribution(t *testing.T) {
+	dir := t.TempDir()
+
+	// Outputs are resolved relative to the working directory, so use absolute paths to make
+	// the output sizes visible to the logger.
+	libfooOut := filepath.Join(dir, "libfoo.so")
+	if err := os.WriteFile(libfooOut, make([]byte, 100), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	reportFile := filepath.Join(dir, "build_attribution.json")
+
+	b := NewBuildAttributionLogger(logger.New(io.Discard), reportFile).(*buildAttributionLogger)
+
+	run := func(outputs []string, tags string, start, end time.Duration, cpuMs uint32) {
+		action := &Action{Outputs: outputs}
+		b.clock = testClock(time.Unix(0, 0).Add(start))
+		b.StartAction(action, Counts{})
+		b.clock = testClock(time.Unix(0, 0).Add(end))
+		b.FinishAction(ActionResult{
+			Action: action,
+			Stats:  ActionResultStats{UserTime: cpuMs, SystemTime: cpuMs, Tags: tags},
+		}, Counts{})
+	}
+
+	run([]string{libfooOut}, ";module=libfoo;variant=android_arm64;type=cc_library;dir=external/foo;namespace=.",
+		0, 2*time.Second, 1000)
+	run([]string{"libfoo32.so"}, ";module=libfoo;variant=android_arm;type=cc_library;dir=external/foo;namespace=.",
+		0, time.Second, 500)
+	run([]string{"bar.jar"}, ";module=bar;variant=android_common;type=java_library;dir=external/bar/java;namespace=.",
+		time.Second, 4*time.Second, 3000)
+	run([]string{"out/target/product/system.img"}, "", 0, 5*time.Second, 100)
+	run([]string{"baz.txt"}, ";module=baz;type=genrule;dir=external/bar/baz;namespace=external/bar",
+		0, time.Second, 10)
+	run([]string{"singleton.txt"}, ";module=test_singleton;type=singleton", 0, time.Second, 5)
+
+	b.Flush()
+
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("report was not written: %s", err)
+	}
+	var report BuildAttributionReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("failed to parse report: %s", err)
+	}
+
+	wantTotal := BuildAttributionCost{Actions: 6, CpuTimeMs: 9230, WallTimeMs: 13000, OutputBytes: 100}
+	if report.Total != wantTotal {
+		t.Errorf("want total %+v, got %+v", wantTotal, report.Total)
+	}
+
+	wantModules := []BuildAttributionModule{
+		{
+			Module: "bar", Namespace: ".", Type: "java_library", Dir: "external/bar/java",
+			Variants:             []string{"android_common"},
+			BuildAttributionCost: BuildAttributionCost{Actions: 1, CpuTimeMs: 6000, WallTimeMs: 3000},
+		},
+		{
+			Module: "libfoo", Namespace: ".", Type: "cc_library", Dir: "external/foo",
+			Variants:             []string{"android_arm", "android_arm64"},
+			BuildAttributionCost: BuildAttributionCost{Actions: 2, CpuTimeMs: 3000, WallTimeMs: 3000, OutputBytes: 100},
+		},
+		{
+			Module:               unattributedModule,
+			BuildAttributionCost: BuildAttributionCost{Actions: 1, CpuTimeMs: 200, WallTimeMs: 5000},
+		},
+		{
+			Module: "baz", Namespace: "external/bar", Type: "genrule", Dir: "external/bar/baz",
+			BuildAttributionCost: BuildAttributionCost{Actions: 1, CpuTimeMs: 20, WallTimeMs: 1000},
+		},
+		{
+			Module: "test_singleton", Type: "singleton",
+			BuildAttributionCost: BuildAttributionCost{Actions: 1, CpuTimeMs: 10, WallTimeMs: 1000},
+		},
+	}
+	if !reflect.DeepEqual(report.Modules, wantModules) {
+		t.Errorf("want modules:\n%+v\ngot:\n%+v", wantModules, report.Modules)
+	}
+
+	wantDirs := []BuildAttributionDir{
+		{Dir: "external", BuildAttributionCost: BuildAttributionCost{Actions: 4, CpuTimeMs: 9020, WallTimeMs: 7000, OutputBytes: 100}},
+		{Dir: "external/bar", BuildAttributionCost: BuildAttributionCost{Actions: 2, CpuTimeMs: 6020, WallTimeMs: 4000}},
+		{Dir: "external/bar/java", BuildAttributionCost: BuildAttributionCost{Actions: 1, CpuTimeMs: 6000, WallTimeMs: 3000}},
+		{Dir: "external/foo", BuildAttributionCost: BuildAttributionCost{Actions: 2, CpuTimeMs: 3000, WallTimeMs: 3000, OutputBytes: 100}},
+		{Dir: "external/bar/baz", BuildAttributionCost: BuildAttributionCost{Actions: 1, CpuTimeMs: 20, WallTimeMs: 1000}},
+	}
+	if !reflect.DeepEqual(report.Directories, wantDirs) {
+		t.Errorf("want directories:\n%+v\ngot:\n%+v", wantDirs, report.Directories)
+	}
+}